@@ -0,0 +1,73 @@
+package main
+
+import "strings"
+
+// how words in name parts are cased and joined (-case)
+const (
+	caseTitle  = "title"  // Door_Slam, the default
+	caseCamel  = "camel"  // doorSlam
+	casePascal = "pascal" // DoorSlam
+	caseSnake  = "snake"  // door_slam
+	caseUpper  = "upper"  // DOOR_SLAM
+)
+
+// validCaseStyle reports whether s is one of the -case values
+func validCaseStyle(s string) bool {
+	switch s {
+	case caseTitle, caseCamel, casePascal, caseSnake, caseUpper:
+		return true
+	}
+	return false
+}
+
+// caseStyle returns the configured style, defaulting to title case
+func (ap *AudioProcessor) caseStyle() string {
+	if ap.config.Case == "" {
+		return caseTitle
+	}
+	return ap.config.Case
+}
+
+// formatWords cases and joins words in style. Title case is joined with
+// titleSep since the pack name and name parts join title-cased words differently.
+// Words starting with a digit keep their case, except in snake and upper case
+// which force one case on everything.
+func formatWords(words []string, style, titleSep string) string {
+	out := make([]string, 0, len(words))
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		switch style {
+		case caseSnake:
+			out = append(out, strings.ToLower(word))
+		case caseUpper:
+			out = append(out, strings.ToUpper(word))
+		case caseCamel:
+			if len(out) == 0 {
+				out = append(out, strings.ToLower(word))
+				break
+			}
+			out = append(out, capitalizeWord(word))
+		default:
+			out = append(out, capitalizeWord(word))
+		}
+	}
+
+	switch style {
+	case caseSnake, caseUpper:
+		return strings.Join(out, "_")
+	case caseCamel, casePascal:
+		return strings.Join(out, "")
+	}
+	return strings.Join(out, titleSep)
+}
+
+// capitalizeWord upper-cases the first letter and lower-cases the rest.
+// Numbers at the start are kept as-is.
+func capitalizeWord(word string) string {
+	if word[0] >= '0' && word[0] <= '9' {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+}
@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+func TestCleanNamePartCase(t *testing.T) {
+	tests := []struct {
+		style    string
+		input    string
+		expected string
+	}{
+		{caseTitle, "door-slam heavy", "Door_Slam_Heavy"},
+		{caseTitle, "123test_file", "123test_File"},
+		{caseCamel, "door-slam heavy", "doorSlamHeavy"},
+		{caseCamel, "DOOR_SLAM", "doorSlam"},
+		{caseCamel, "test_123_file", "test123File"},
+		{caseCamel, "2nd_door", "2ndDoor"},
+		{casePascal, "door-slam heavy", "DoorSlamHeavy"},
+		{casePascal, "test___multiple", "TestMultiple"},
+		{casePascal, "123test_file", "123testFile"},
+		{caseSnake, "Door-Slam Heavy", "door_slam_heavy"},
+		{caseSnake, "test___multiple__", "test_multiple"},
+		{caseSnake, "123TEST", "123test"},
+		{caseUpper, "door-slam heavy", "DOOR_SLAM_HEAVY"},
+		{caseUpper, "_test__123_", "TEST_123"},
+		{caseUpper, "@#$", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style+"/"+tt.input, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{Case: tt.style})
+			if got := ap.cleanNamePart(tt.input); got != tt.expected {
+				t.Errorf("cleanNamePart(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCleanNameWithCaseStyle(t *testing.T) {
+	tests := []struct {
+		style    string
+		input    string
+		expected string
+	}{
+		{caseTitle, "horror pack", "HorrorPack"},
+		{caseTitle, "HorrorPack", "HorrorPack"},
+		{caseCamel, "horror pack", "horrorPack"},
+		{caseCamel, "HorrorPack", "horrorPack"},
+		{casePascal, "horror_pack", "HorrorPack"},
+		{caseSnake, "HorrorPack", "horror_pack"},
+		{caseSnake, "horror-pack 2", "horror_pack_2"},
+		{caseUpper, "HorrorPack", "HORROR_PACK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style+"/"+tt.input, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{Case: tt.style})
+			if got := ap.cleanNameWithCase(tt.input); got != tt.expected {
+				t.Errorf("cleanNameWithCase(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateUE5NameCase(t *testing.T) {
+	af := AudioFile{OriginalName: "door_slam.wav", Category: "SFX_Impact", SubCategory: "door_slam"}
+
+	tests := []struct {
+		style    string
+		expected string
+	}{
+		{"", "A_HorrorPack_Impact_Door_Slam.wav"},
+		{caseTitle, "A_HorrorPack_Impact_Door_Slam.wav"},
+		{caseCamel, "A_horrorPack_impact_doorSlam.wav"},
+		{casePascal, "A_HorrorPack_Impact_DoorSlam.wav"},
+		{caseSnake, "A_horror_pack_impact_door_slam.wav"},
+		{caseUpper, "A_HORROR_PACK_IMPACT_DOOR_SLAM.wav"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: "HorrorPack", Case: tt.style})
+			if got := ap.generateUE5Name(&af); got != tt.expected {
+				t.Errorf("generateUE5Name() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+)
+
+// writeCatalog is the end of a -catalog run: files are analyzed and
+// categorized as usual, but never named, moved or touched. The manifest is
+// the whole point, so it's written even with -manifest=false.
+func (ap *AudioProcessor) writeCatalog() error {
+	for i := range ap.audioFiles {
+		ap.emitFile(&ap.audioFiles[i], statusCataloged)
+	}
+
+	if err := ap.mkdirAll(ap.config.OutputDir); err != nil {
+		return err
+	}
+	if err := ap.createManifest(); err != nil {
+		return fmt.Errorf("failed to create manifest: %w", err)
+	}
+	if err := ap.emitHTMLReport(); err != nil {
+		return err
+	}
+
+	ap.emitSummary(false)
+	ap.log.Printf("\n✓ Cataloged %d files, nothing was renamed or moved\n", len(ap.audioFiles))
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	names := []string{"door_creak.wav", "gun_shot.wav"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(src, name), buildTestWAV(48000, 1, 16, make([]byte, 9600)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: filepath.Join(out, "index"), Layout: layoutCategory, Catalog: true, Workers: 1})
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(src, name)); err != nil {
+			t.Errorf("%s should be left where it was: %v", name, err)
+		}
+	}
+
+	data, err := os.ReadFile(ap.manifestPath())
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var manifest struct {
+		Files   []AudioFile     `json:"files"`
+		Renames json.RawMessage `json:"renames"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != len(names) {
+		t.Fatalf("manifest lists %d files, want %d", len(manifest.Files), len(names))
+	}
+	for _, af := range manifest.Files {
+		if af.NewName != "" {
+			t.Errorf("%s got a new name %q in a catalog", af.OriginalName, af.NewName)
+		}
+		if af.Category == "" || af.AudioMeta == nil {
+			t.Errorf("%s should be categorized and analyzed, got category %q", af.OriginalName, af.Category)
+		}
+	}
+	if manifest.Renames != nil {
+		t.Error("a catalog manifest shouldn't count renames")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+const (
+	defaultCategorizerTimeout = 10 * time.Second
+
+	// how long to wait for the output pipes once the command is killed; a
+	// script's own children can keep them open long after it's gone
+	categorizerWaitDelay = time.Second
+)
+
+// categorizerInput is written to the -categorizer-cmd's stdin
+type categorizerInput struct {
+	Path       string              `json:"path"`
+	Name       string              `json:"name"`
+	Category   string              `json:"category,omitempty"` // what audio analysis made of it
+	Confidence float64             `json:"confidence,omitempty"`
+	Meta       *tidy.AudioMetadata `json:"meta,omitempty"`
+}
+
+// categorizerResult is what the command prints on stdout
+type categorizerResult struct {
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+}
+
+// runCategorizer asks the -categorizer-cmd about a file. The command is split
+// on spaces and gets the file's path as its last argument, and its metadata as
+// JSON on stdin along with what audio analysis made of it. Any failure,
+// including a timeout, is returned as an error so the caller can fall back to
+// the built-in inference.
+func (ap *AudioProcessor) runCategorizer(af *AudioFile, analyzedCategory string, analyzedConfidence float64) (categorizerResult, error) {
+	args := strings.Fields(ap.config.CategorizerCmd)
+	if len(args) == 0 {
+		return categorizerResult{}, fmt.Errorf("no command")
+	}
+
+	timeout := ap.config.CategorizerTimeout
+	if timeout <= 0 {
+		timeout = defaultCategorizerTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	input, err := json.Marshal(categorizerInput{
+		Path:       af.OriginalPath,
+		Name:       af.OriginalName,
+		Category:   analyzedCategory,
+		Confidence: analyzedConfidence,
+		Meta:       af.AudioMeta,
+	})
+	if err != nil {
+		return categorizerResult{}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], af.OriginalPath)...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = categorizerWaitDelay
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return categorizerResult{}, fmt.Errorf("timed out after %v", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return categorizerResult{}, fmt.Errorf("%w: %s", err, msg)
+		}
+		return categorizerResult{}, err
+	}
+
+	var result categorizerResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return categorizerResult{}, fmt.Errorf("invalid output: %w", err)
+	}
+	if strings.TrimSpace(result.Category) == "" {
+		return categorizerResult{}, fmt.Errorf("no category in the output")
+	}
+	if result.Confidence < 0 || result.Confidence > 1 {
+		return categorizerResult{}, fmt.Errorf("confidence %v isn't between 0 and 1", result.Confidence)
+	}
+	result.Category = tidy.NormalizeCategory(result.Category)
+	return result, nil
+}
+
+// externalCategory runs the categorizer for a file and reports whether its
+// answer is confident enough (-confidence-threshold) to beat the built-in one
+func (ap *AudioProcessor) externalCategory(af *AudioFile, analyzedCategory string, analyzedConfidence float64) (categorizerResult, bool) {
+	result, err := ap.runCategorizer(af, analyzedCategory, analyzedConfidence)
+	if err != nil {
+		ap.log.Warnf("⚠ %s: categorizer failed, using built-in inference: %v\n", af.OriginalName, err)
+		return result, false
+	}
+	if result.Confidence < ap.config.ConfidenceThreshold {
+		ap.log.Verbosef("%s: categorizer said %s at %.2f, below -confidence-threshold", af.OriginalName, result.Category, result.Confidence)
+		return result, false
+	}
+	return result, true
+}
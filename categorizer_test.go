@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCategorizerHelper isn't a real test: the tests below run the test binary
+// as their -categorizer-cmd, and this answers based on the file name.
+func TestCategorizerHelper(t *testing.T) {
+	if os.Getenv("TIDY_CATEGORIZER_HELPER") != "1" {
+		return
+	}
+	io.Copy(io.Discard, os.Stdin)
+
+	name := filepath.Base(os.Args[len(os.Args)-1])
+	switch {
+	case strings.HasPrefix(name, "confident"):
+		fmt.Println(`{"category": "creature", "confidence": 0.9}`)
+	case strings.HasPrefix(name, "unsure"):
+		fmt.Println(`{"category": "creature", "confidence": 0.2}`)
+	case strings.HasPrefix(name, "slow"):
+		time.Sleep(5 * time.Second)
+	case strings.HasPrefix(name, "garbage"):
+		fmt.Println("not json")
+	default:
+		fmt.Fprintln(os.Stderr, "model not loaded")
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+func TestExternalCategorizer(t *testing.T) {
+	t.Setenv("TIDY_CATEGORIZER_HELPER", "1")
+	cmd := os.Args[0] + " -test.run=^TestCategorizerHelper$ --"
+
+	tests := []struct {
+		file           string
+		wantCategory   string
+		wantConfidence float64
+	}{
+		{"confident_door_slam.wav", "SFX_Creature", 0.9},
+		{"unsure_door_slam.wav", "SFX_Impact", 0},  // below the threshold, the name wins
+		{"slow_door_slam.wav", "SFX_Impact", 0},    // timed out
+		{"garbage_door_slam.wav", "SFX_Impact", 0}, // unreadable output
+		{"failing_door_slam.wav", "SFX_Impact", 0}, // non-zero exit
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			// a race-built binary takes about a second to exit, so only the
+			// slow case gets a timeout short enough to hit
+			timeout := time.Minute
+			if strings.HasPrefix(tt.file, "slow") {
+				timeout = time.Second
+			}
+			ap := NewAudioProcessor(Config{
+				PackName:            "TestPack",
+				CategorizerCmd:      cmd,
+				CategorizerTimeout:  timeout,
+				ConfidenceThreshold: 0.5,
+			})
+			ap.log.out = io.Discard
+
+			af := &AudioFile{OriginalName: tt.file, OriginalPath: filepath.Join(t.TempDir(), tt.file)}
+			ap.parseFile(af)
+
+			if af.Category != tt.wantCategory {
+				t.Errorf("category = %s, want %s", af.Category, tt.wantCategory)
+			}
+			if tt.wantConfidence > 0 && af.Confidence != tt.wantConfidence {
+				t.Errorf("confidence = %.2f, want %.2f", af.Confidence, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestExternalCategorizerLosesToOverride(t *testing.T) {
+	t.Setenv("TIDY_CATEGORIZER_HELPER", "1")
+	ap := NewAudioProcessor(Config{
+		PackName:       "TestPack",
+		CategorizerCmd: os.Args[0] + " -test.run=^TestCategorizerHelper$ --",
+		Overrides:      []RenameOverride{{Pattern: "confident_*", Category: "SFX_Impact"}},
+	})
+	ap.log.out = io.Discard
+
+	af := &AudioFile{OriginalName: "confident_hit.wav", OriginalPath: "confident_hit.wav"}
+	ap.parseFile(af)
+	if af.Category != "SFX_Impact" {
+		t.Errorf("category = %s, the override should win", af.Category)
+	}
+}
+
+func TestExternalCategorizerOrphanedPipes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	// the script is killed at the timeout, the sleep it started holds on to stdout
+	script := filepath.Join(t.TempDir(), "categorize.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nsleep 30 &\nsleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ap := NewAudioProcessor(Config{PackName: "TestPack", CategorizerCmd: script, CategorizerTimeout: 200 * time.Millisecond})
+	ap.log.out = io.Discard
+
+	start := time.Now()
+	if _, err := ap.runCategorizer(&AudioFile{OriginalName: "door_slam.wav", OriginalPath: "door_slam.wav"}, "", 0); err == nil {
+		t.Error("runCategorizer() should time out")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("runCategorizer() took %v, it should give up on the pipes", elapsed)
+	}
+}
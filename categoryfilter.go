@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// filterCategories leaves files alone whose category is excluded by
+// -exclude-category or not listed by -only-category. It runs once the
+// categories are known, they come from the names and the audio analysis.
+func (ap *AudioProcessor) filterCategories() {
+	only, exclude := ap.config.OnlyCategories, ap.config.ExcludeCategories
+	if len(only) == 0 && len(exclude) == 0 {
+		return
+	}
+
+	filtered := 0
+	for i := range ap.audioFiles {
+		af := &ap.audioFiles[i]
+		if af.SkipReason != "" {
+			continue
+		}
+		switch {
+		case matchesCategory(af.Category, exclude):
+			af.SkipReason = fmt.Sprintf("category %s is excluded", af.Category)
+		case len(only) > 0 && !matchesCategory(af.Category, only):
+			af.SkipReason = fmt.Sprintf("category %s is not in -only-category", af.Category)
+		default:
+			continue
+		}
+		filtered++
+	}
+
+	if filtered > 0 {
+		ap.log.Infof("Leaving %d files alone because of their category\n", filtered)
+	}
+}
+
+// matchesCategory reports whether a category is one of the listed ones,
+// ignoring case. A listed top-level category covers its subcategories, so SFX
+// matches SFX_Impact, and the SFX_ prefix is optional: Impact matches it too.
+func matchesCategory(category string, list []string) bool {
+	category = strings.ToUpper(category)
+	for _, item := range list {
+		item = strings.ToUpper(item)
+		if category == item || strings.HasPrefix(category, item+"_") || strings.TrimPrefix(category, "SFX_") == item {
+			return true
+		}
+	}
+	return false
+}
+
+// skippedByCategory counts the files left where they are in each category
+func skippedByCategory(files []AudioFile) map[string]int {
+	counts := make(map[string]int)
+	for _, af := range files {
+		if af.SkipReason != "" && af.DuplicateStatus != duplicateDropped {
+			counts[af.Category]++
+		}
+	}
+	return counts
+}
+
+// describeCategoryCounts reads like "Music 8, Ambient 4", most files first
+func describeCategoryCounts(counts map[string]int) string {
+	categories := make([]string, 0, len(counts))
+	for category := range counts {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if counts[categories[i]] != counts[categories[j]] {
+			return counts[categories[i]] > counts[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+
+	parts := make([]string, len(categories))
+	for i, category := range categories {
+		parts[i] = fmt.Sprintf("%s %d", strings.TrimPrefix(category, "SFX_"), counts[category])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchesCategory(t *testing.T) {
+	tests := []struct {
+		category string
+		list     []string
+		want     bool
+	}{
+		{"Music", []string{"music"}, true},
+		{"SFX_Impact", []string{"SFX"}, true},
+		{"SFX_Impact", []string{"Impact"}, true},
+		{"SFX_Impact", []string{"sfx_impact"}, true},
+		{"SFX_Impact", []string{"Music", "Ambient"}, false},
+		{"SFX_ImpactMetal", []string{"Impact"}, false},
+		{"Ambient", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesCategory(tt.category, tt.list); got != tt.want {
+			t.Errorf("matchesCategory(%q, %v) = %v, want %v", tt.category, tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestCategoryFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		only    []string
+		exclude []string
+		renamed map[string]bool
+	}{
+		{"exclude", nil, []string{"Music", "Ambient"}, map[string]bool{"gun_shot.wav": true, "music_theme.wav": false, "ambient_wind.wav": false}},
+		{"only", []string{"SFX"}, nil, map[string]bool{"gun_shot.wav": true, "music_theme.wav": false, "ambient_wind.wav": false}},
+		{"both", []string{"SFX", "Music"}, []string{"Weapon"}, map[string]bool{"gun_shot.wav": false, "music_theme.wav": true, "ambient_wind.wav": false}},
+	}
+
+	for _, tt := range tests {
+		src := t.TempDir()
+		for name := range tt.renamed {
+			if err := os.WriteFile(filepath.Join(src, name), buildTestWAV(48000, 1, 16, make([]byte, 4800)), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		config := Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: src, PackName: "TestPack", Layout: layoutFlat, OnlyCategories: tt.only, ExcludeCategories: tt.exclude, Workers: 1}
+		ap := NewAudioProcessor(config)
+		ap.log.out = io.Discard
+		if err := ap.Process(context.Background()); err != nil {
+			t.Fatalf("%s: Process() error = %v", tt.name, err)
+		}
+
+		skipped := 0
+		for name, renamed := range tt.renamed {
+			_, err := os.Stat(filepath.Join(src, name))
+			if untouched := err == nil; untouched == renamed {
+				t.Errorf("%s: %s still in place = %v, want %v", tt.name, name, untouched, !renamed)
+			}
+			if !renamed {
+				skipped++
+			}
+		}
+
+		total := 0
+		for _, n := range skippedByCategory(ap.audioFiles) {
+			total += n
+		}
+		if total != skipped {
+			t.Errorf("%s: skippedByCategory() counts %d files, want %d", tt.name, total, skipped)
+		}
+	}
+}
+
+func TestDescribeCategoryCounts(t *testing.T) {
+	got := describeCategoryCounts(map[string]int{"Ambient": 4, "Music": 8, "SFX_Impact": 4})
+	if want := "Music 8, Ambient 4, Impact 4"; got != want {
+		t.Errorf("describeCategoryCounts() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// createCategoryManifests writes a manifest.json into each category folder with
+// only the files that ended up in it. Folders shared through -folder-map get
+// one manifest covering all their categories. Dropped duplicates and skipped
+// files aren't in any category folder, so they're only in the top-level manifest.
+func (ap *AudioProcessor) createCategoryManifests() error {
+	files := ap.audioFiles
+	if len(ap.priorFiles) > 0 {
+		files = append(append([]AudioFile{}, ap.priorFiles...), ap.audioFiles...)
+	}
+
+	byFolder := make(map[string][]AudioFile)
+	for _, af := range files {
+		if af.DuplicateStatus == duplicateDropped || af.SkipReason != "" {
+			continue
+		}
+		folder := ap.categoryDir(af.Category)
+		byFolder[folder] = append(byFolder[folder], af)
+	}
+
+	folders := make([]string, 0, len(byFolder))
+	for folder := range byFolder {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+
+	for _, folder := range folders {
+		dir := filepath.Join(ap.config.OutputDir, folder)
+		if err := ap.mkdirAll(dir); err != nil {
+			return err
+		}
+		if err := ap.writeManifest(filepath.Join(dir, ap.manifestName()), byFolder[folder], nil); err != nil {
+			return fmt.Errorf("%s: %w", folder, err)
+		}
+	}
+
+	ap.log.Infof("\n✓ Created %d category manifests\n", len(folders))
+	return nil
+}
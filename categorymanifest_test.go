@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCreateCategoryManifests(t *testing.T) {
+	out := t.TempDir()
+	ap := NewAudioProcessor(Config{
+		OutputDir:    out,
+		Layout:       layoutCategory,
+		FolderMap:    map[string]string{"AMBIENT": "Ambience", "MUSIC": "Ambience"},
+		DedupeAction: dedupeMove,
+	})
+	ap.log.out = io.Discard
+	ap.priorFiles = []AudioFile{
+		{OriginalName: "old_scream.wav", NewName: "A_Pack_Voice_Old_Scream.wav", Category: "SFX_Voice"},
+	}
+	ap.audioFiles = []AudioFile{
+		{OriginalName: "scream.wav", NewName: "A_Pack_Voice_Scream.wav", Category: "SFX_Voice"},
+		{OriginalName: "scream_copy.wav", NewName: "scream_copy.wav", Category: "SFX_Voice", DuplicateStatus: duplicateDropped},
+		{OriginalName: "wind.wav", NewName: "A_Pack_Ambient_Wind.wav", Category: "Ambient"},
+		{OriginalName: "theme.wav", NewName: "A_Pack_Music_Theme.wav", Category: "Music"},
+		{OriginalName: "taken.wav", NewName: "A_Pack_Music_Theme.wav", Category: "Music", SkipReason: skipNameTaken},
+	}
+
+	if err := ap.createCategoryManifests(); err != nil {
+		t.Fatalf("createCategoryManifests() error = %v", err)
+	}
+
+	tests := []struct {
+		folder     string
+		total      int
+		categories map[string]int
+		names      []string
+	}{
+		{"Sfx_Voice", 2, map[string]int{"SFX_Voice": 2}, []string{"old_scream.wav", "scream.wav"}},
+		{"Ambience", 2, map[string]int{"Ambient": 1, "Music": 1}, []string{"theme.wav", "wind.wav"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.folder, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(out, tt.folder, manifestFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var manifest struct {
+				TotalFiles int            `json:"total_files"`
+				Categories map[string]int `json:"categories"`
+				Files      []AudioFile    `json:"files"`
+			}
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				t.Fatal(err)
+			}
+
+			if manifest.TotalFiles != tt.total {
+				t.Errorf("total_files = %d, want %d", manifest.TotalFiles, tt.total)
+			}
+			if !reflect.DeepEqual(manifest.Categories, tt.categories) {
+				t.Errorf("categories = %v, want %v", manifest.Categories, tt.categories)
+			}
+			var names []string
+			for _, af := range manifest.Files {
+				names = append(names, af.OriginalName)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("files = %v, want %v", names, tt.names)
+			}
+		})
+	}
+
+	// no manifests for folders nothing went into
+	entries, _ := os.ReadDir(out)
+	if len(entries) != 2 {
+		t.Errorf("output has %d entries, want just the 2 category folders", len(entries))
+	}
+}
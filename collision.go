@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// what to do when two files (or a file and something already on disk) end up
+// with the same output name
+const (
+	collisionNumber    = "number"    // add _01, _02, ... (-dup-suffix) until the name is free
+	collisionSkip      = "skip"      // leave the later file where it is
+	collisionOverwrite = "overwrite" // replace whatever is there
+	collisionFail      = "fail"      // stop before touching anything
+)
+
+const skipNameTaken = "name collision"
+
+// defaultDupSuffix is the -dup-suffix numbered names get: _01, _02, ...
+const defaultDupSuffix = "_%02d"
+
+var (
+	dupSuffixVerb    = regexp.MustCompile(`%(?:0[1-9])?d`)
+	dupSuffixLiteral = regexp.MustCompile(`^[A-Za-z0-9_.\-]*$`)
+)
+
+// validateDupSuffix checks a -dup-suffix has exactly one number in it, like
+// _%02d, _v%02d, .%02d or _%03d, and nothing that doesn't belong in a filename
+func validateDupSuffix(format string) error {
+	if n := len(dupSuffixVerb.FindAllString(format, -1)); n != 1 {
+		return fmt.Errorf("%q needs exactly one number like %%02d, found %d", format, n)
+	}
+	literal := dupSuffixVerb.ReplaceAllString(format, "")
+	if !dupSuffixLiteral.MatchString(literal) {
+		return fmt.Errorf("%q can only have letters, digits, _, . and - around the number", format)
+	}
+	return nil
+}
+
+// validCollisionStrategy reports whether s is one of the -on-collision values
+func validCollisionStrategy(s string) bool {
+	switch s {
+	case collisionNumber, collisionSkip, collisionOverwrite, collisionFail:
+		return true
+	}
+	return false
+}
+
+// collisionStrategy returns the configured strategy, defaulting to numbering
+func (ap *AudioProcessor) collisionStrategy() string {
+	if ap.config.OnCollision == "" {
+		return collisionNumber
+	}
+	return ap.config.OnCollision
+}
+
+// destinationTaken reports whether something other than the file itself
+// already exists at its output path, or with -merge-output an asset of the
+// same name in the library
+func (ap *AudioProcessor) destinationTaken(af *AudioFile) bool {
+	if af.DuplicateStatus == duplicateDropped && ap.config.DedupeAction == dedupeDelete {
+		return false // deleted, never lands anywhere
+	}
+	if ap.alreadyInPlace(af) {
+		return false
+	}
+	if ap.clashesWithLibrary(af) {
+		return true
+	}
+	existing, err := os.Stat(ap.outputPath(af))
+	if err != nil {
+		return false
+	}
+	// on a case-insensitive filesystem FILE.WAV is found as file.wav, it's
+	// still only the file itself
+	current, err := os.Stat(af.OriginalPath)
+	return err != nil || !os.SameFile(existing, current)
+}
+
+// dupSuffix returns the suffix the n-th numbered copy of a name gets
+func (ap *AudioProcessor) dupSuffix(n int) string {
+	format := ap.config.DupSuffix
+	if format == "" {
+		format = defaultDupSuffix
+	}
+	return fmt.Sprintf(format, n)
+}
+
+// resolveCollision handles a file whose base name was already handed out
+// count times in this run (or a prior one), or whose destination exists on disk.
+// It may rename the file, mark it skipped, or fail the run.
+func (ap *AudioProcessor) resolveCollision(af *AudioFile, baseName string, count int) (int, error) {
+	strategy := ap.collisionStrategy()
+	if af.DuplicateStatus == duplicateDropped {
+		strategy = collisionNumber // dropped copies only need somewhere to go
+	}
+
+	switch strategy {
+	case collisionSkip:
+		af.SkipReason = skipNameTaken
+		return count, nil
+	case collisionOverwrite:
+		return count, nil
+	case collisionFail:
+		return count, fmt.Errorf("%s would be renamed to %s, which is already taken (use -on-collision to choose another strategy)", af.OriginalName, af.NewName)
+	}
+
+	ext := filepath.Ext(af.NewName)
+	if count == 0 {
+		count = 1 // the plain name is on disk, start numbering
+	}
+	for {
+		suffix := ap.dupSuffix(count) // _01, _02, etc.
+		base := baseName
+		if maxLength := ap.config.MaxNameLength; maxLength > 0 && len(base)+len(suffix) > maxLength {
+			base = truncateName(base, maxLength-len(suffix))
+			ap.markTruncated(af, "to make room for "+suffix)
+		}
+		af.NewName = base + suffix + ext
+		count++
+		if !ap.destinationTaken(af) {
+			return count, nil
+		}
+	}
+}
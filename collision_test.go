@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOnCollision(t *testing.T) {
+	tests := []struct {
+		strategy  string
+		onDisk    bool // a file from an earlier run already sits at the first output path
+		wantErr   bool
+		wantNames []string
+		wantSkip  []bool
+	}{
+		{collisionNumber, false, false, []string{"A_TestPack_Voice_Scream_Male.wav", "A_TestPack_Voice_Scream_Male_01.wav"}, []bool{false, false}},
+		{collisionNumber, true, false, []string{"A_TestPack_Voice_Scream_Male_01.wav", "A_TestPack_Voice_Scream_Male_02.wav"}, []bool{false, false}},
+		{collisionSkip, false, false, []string{"A_TestPack_Voice_Scream_Male.wav", "A_TestPack_Voice_Scream_Male.wav"}, []bool{false, true}},
+		{collisionSkip, true, false, []string{"A_TestPack_Voice_Scream_Male.wav", "A_TestPack_Voice_Scream_Male.wav"}, []bool{true, true}},
+		{collisionOverwrite, true, false, []string{"A_TestPack_Voice_Scream_Male.wav", "A_TestPack_Voice_Scream_Male.wav"}, []bool{false, false}},
+		{collisionFail, false, true, nil, nil},
+	}
+
+	for _, tt := range tests {
+		name := tt.strategy
+		if tt.onDisk {
+			name += " on disk"
+		}
+		t.Run(name, func(t *testing.T) {
+			src := t.TempDir()
+			out := t.TempDir()
+
+			var files []AudioFile
+			for _, dir := range []string{"a", "b"} {
+				p := filepath.Join(src, dir, "scream_male.wav")
+				if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(dir), 0644); err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, AudioFile{OriginalPath: p, OriginalName: "scream_male.wav"})
+			}
+
+			ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, OnCollision: tt.strategy})
+			ap.audioFiles = files
+			ap.parseFiles()
+
+			existing := filepath.Join(out, ap.categoryDir("SFX_Voice"), "A_TestPack_Voice_Scream_Male.wav")
+			if tt.onDisk {
+				if err := os.MkdirAll(filepath.Dir(existing), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(existing, []byte("earlier run"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := ap.generateNewNames()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("generateNewNames() should fail on a collision")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("generateNewNames() error = %v", err)
+			}
+
+			for i, af := range ap.audioFiles {
+				if af.NewName != tt.wantNames[i] {
+					t.Errorf("file %d NewName = %q, want %q", i, af.NewName, tt.wantNames[i])
+				}
+				if (af.SkipReason != "") != tt.wantSkip[i] {
+					t.Errorf("file %d skipped = %v, want %v", i, af.SkipReason != "", tt.wantSkip[i])
+				}
+			}
+
+			if err := ap.applyChanges(context.Background()); err != nil {
+				t.Fatalf("applyChanges() error = %v", err)
+			}
+
+			for i, af := range ap.audioFiles {
+				_, err := os.Stat(af.OriginalPath)
+				if stayed := err == nil; stayed != tt.wantSkip[i] {
+					t.Errorf("file %d left at source = %v, want %v", i, stayed, tt.wantSkip[i])
+				}
+			}
+
+			data, err := os.ReadFile(existing)
+			if err != nil {
+				t.Fatalf("reading %s: %v", existing, err)
+			}
+			want := "a"
+			switch {
+			case tt.strategy == collisionOverwrite:
+				want = "b" // last one in wins
+			case tt.onDisk:
+				want = "earlier run"
+			}
+			if string(data) != want {
+				t.Errorf("%s contains %q, want %q", filepath.Base(existing), data, want)
+			}
+		})
+	}
+}
+
+func TestValidateDupSuffix(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{"_%02d", false},
+		{"_v%02d", false},
+		{".%02d", false},
+		{"_%03d", false},
+		{"-%d", false},
+		{"_01", true},        // no number
+		{"_%02d_%02d", true}, // two numbers
+		{"_%3d", true},       // pads with spaces
+		{"_%s", true},
+		{"/%02d", true},
+	}
+
+	for _, tt := range tests {
+		if err := validateDupSuffix(tt.format); (err != nil) != tt.wantErr {
+			t.Errorf("validateDupSuffix(%q) error = %v, want error %v", tt.format, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDupSuffix(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"", []string{"A_TestPack_Voice_Scream_Male.wav", "A_TestPack_Voice_Scream_Male_01.wav", "A_TestPack_Voice_Scream_Male_02.wav"}},
+		{"_v%02d", []string{"A_TestPack_Voice_Scream_Male.wav", "A_TestPack_Voice_Scream_Male_v01.wav", "A_TestPack_Voice_Scream_Male_v02.wav"}},
+		{".%02d", []string{"A_TestPack_Voice_Scream_Male.wav", "A_TestPack_Voice_Scream_Male.01.wav", "A_TestPack_Voice_Scream_Male.02.wav"}},
+		{"_%03d", []string{"A_TestPack_Voice_Scream_Male.wav", "A_TestPack_Voice_Scream_Male_001.wav", "A_TestPack_Voice_Scream_Male_002.wav"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			src := t.TempDir()
+			ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: t.TempDir(), PackName: "TestPack", Layout: layoutFlat, DupSuffix: tt.format})
+			for _, dir := range []string{"a", "b", "c"} {
+				ap.audioFiles = append(ap.audioFiles, AudioFile{OriginalPath: filepath.Join(src, dir, "scream_male.wav"), OriginalName: "scream_male.wav"})
+			}
+			ap.parseFiles()
+			if err := ap.generateNewNames(); err != nil {
+				t.Fatalf("generateNewNames() error = %v", err)
+			}
+
+			for i, af := range ap.audioFiles {
+				if af.NewName != tt.want[i] {
+					t.Errorf("file %d NewName = %q, want %q", i, af.NewName, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+const (
+	defaultConfidenceThreshold = 0.5
+
+	// where -min-confidence puts files we can't place
+	defaultFallbackCategory = "SFX_Uncategorized"
+
+	// confidence for a category spelled out in the filename ("PE-Horror")
+	explicitCategoryConfidence = 0.9
+)
+
+// mergeTags appends the tags of b missing from a, keeping order
+func mergeTags(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	merged := make([]string, 0, len(a)+len(b))
+	for _, tag := range append(append([]string{}, a...), b...) {
+		if !seen[tag] {
+			seen[tag] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFileConfidence(t *testing.T) {
+	tests := []struct {
+		name         string
+		analyzedCat  string
+		analyzedConf float64
+		wantCategory string
+		wantConf     float64
+		wantLow      bool
+	}{
+		{"T003.wav", "SFX_Creature", 0.7, "SFX_Creature", 0.7, false},
+		{"creature_roar_ABC.wav", "SFX_Creature", 0.9, "SFX_Creature", 0.9, false},
+		{"scream_male_ABC.wav", "SFX_UI", 0.6, "SFX_Voice", 0.8 / 1.5, false},
+		{"PE-Horror_BW.wav", "SFX_Impact", 0.4, "SFX_Percussion", explicitCategoryConfidence, false},
+		{"T004.wav", "SFX_UI", 0.3, "SFX_UI", 0.3, true},
+		{"T005.wav", "", 0, "SFX", 0, true}, // analysis failed, nothing to go on
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: "TestPack", ConfidenceThreshold: defaultConfidenceThreshold})
+			af := &AudioFile{
+				OriginalName: tt.name,
+				Category:     tt.analyzedCat,
+				Confidence:   tt.analyzedConf,
+				Tags:         []string{"short", "duplicate"},
+			}
+			ap.parseFile(af)
+
+			if af.Category != tt.wantCategory || af.Confidence != tt.wantConf {
+				t.Errorf("parseFile() = %s (%.2f), want %s (%.2f)", af.Category, af.Confidence, tt.wantCategory, tt.wantConf)
+			}
+			if got := contains(af.Tags, "low-confidence"); got != tt.wantLow {
+				t.Errorf("low-confidence tag = %v, want %v (tags %v)", got, tt.wantLow, af.Tags)
+			}
+			// tags from analysis and duplicate detection survive parsing
+			if !contains(af.Tags, "short") || !contains(af.Tags, "duplicate") {
+				t.Errorf("parseFile() dropped earlier tags: %v", af.Tags)
+			}
+		})
+	}
+}
+
+func TestParseFileMinConfidence(t *testing.T) {
+	tests := []struct {
+		name           string
+		analyzedCat    string
+		analyzedConf   float64
+		wantCategory   string
+		wantUnresolved bool
+		wantGuess      string
+	}{
+		{"T004.wav", "SFX_UI", 0.3, defaultFallbackCategory, true, "SFX_UI"},
+		{"T005.wav", "", 0, defaultFallbackCategory, true, "SFX"},
+		{"T003.wav", "SFX_Creature", 0.7, "SFX_Creature", false, ""},
+		{"PE-Horror_BW.wav", "", 0, "SFX_Percussion", false, ""}, // spelled out, never a guess
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: "TestPack", MinConfidence: 0.4, FallbackCategory: defaultFallbackCategory})
+			af := &AudioFile{OriginalName: tt.name, Category: tt.analyzedCat, Confidence: tt.analyzedConf}
+			ap.parseFile(af)
+
+			if af.Category != tt.wantCategory || af.Unresolved != tt.wantUnresolved || af.GuessedCategory != tt.wantGuess {
+				t.Errorf("parseFile() = %s (unresolved %v, guess %q), want %s (unresolved %v, guess %q)",
+					af.Category, af.Unresolved, af.GuessedCategory, tt.wantCategory, tt.wantUnresolved, tt.wantGuess)
+			}
+			if got := contains(af.Tags, "unresolved"); got != tt.wantUnresolved {
+				t.Errorf("unresolved tag = %v, want %v (tags %v)", got, tt.wantUnresolved, af.Tags)
+			}
+		})
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	got := mergeTags([]string{"a", "hq", "b"}, []string{"hq", "c", "a", "hq"})
+	want := []string{"a", "hq", "b", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeTags() = %v, want %v", got, want)
+	}
+}
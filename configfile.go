@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultConfigFile = ".tidyrc.yaml"       // looked for in the current directory
+	configFileEnv     = "TIDY_RENAME_CONFIG" // path of a config file to use without -config
+)
+
+// findConfigFile picks the config file for a run: -config, then
+// $TIDY_RENAME_CONFIG, then .tidyrc.yaml in the current directory. It returns
+// an empty path when there's none. A file named by -config or the environment
+// has to exist, a missing .tidyrc.yaml is fine.
+func findConfigFile(explicit string) (path, source string, err error) {
+	if explicit != "" {
+		return explicit, "-config", nil
+	}
+	if env := os.Getenv(configFileEnv); env != "" {
+		if _, err := os.Stat(env); err != nil {
+			return "", "", fmt.Errorf("$%s: %w", configFileEnv, err)
+		}
+		return env, "$" + configFileEnv, nil
+	}
+	if _, err := os.Stat(defaultConfigFile); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+	return defaultConfigFile, "current directory", nil
+}
+
+// loadConfigFile reads a YAML file of option names (as on the command line,
+// without the dash) and values
+func loadConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// repeatedFlag is a flag that takes one value per use, like -id-pattern,
+// and can't have a list joined with commas
+type repeatedFlag interface {
+	flag.Value
+	repeated()
+}
+
+// applyConfigFile sets every flag in values that wasn't given on the command
+// line, so flags always win over the file. Lists are joined with commas, or
+// set item by item for repeated flags, and maps (for -folder-map and
+// -prefix-map) become Key=Value pairs.
+func applyConfigFile(flags *flag.FlagSet, values map[string]any) error {
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names) // report the same bad option first every time
+
+	for _, name := range names {
+		if name == "config" || flags.Lookup(name) == nil {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if explicit[name] {
+			continue
+		}
+		if items, ok := values[name].([]any); ok {
+			if _, ok := flags.Lookup(name).Value.(repeatedFlag); ok {
+				for _, item := range items {
+					value, err := configValue(item)
+					if err != nil {
+						return fmt.Errorf("%s: %w", name, err)
+					}
+					if err := flags.Set(name, value); err != nil {
+						return fmt.Errorf("%s: %w", name, err)
+					}
+				}
+				continue
+			}
+		}
+		value, err := configValue(values[name])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// configValue turns a YAML value into the string the flag would get
+func configValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any:
+		pairs := make([]string, 0, len(v))
+		for key, item := range v {
+			s, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, key+"="+s)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	case string, bool, int, float64:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "env.yaml")
+	for _, name := range []string{envFile, filepath.Join(dir, defaultConfigFile)} {
+		if err := os.WriteFile(name, []byte("pack: Pack\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		name       string
+		explicit   string
+		env        string
+		removeRC   bool
+		wantPath   string
+		wantSource string
+		wantErr    bool
+	}{
+		{name: "flag wins", explicit: "other.yaml", env: envFile, wantPath: "other.yaml", wantSource: "-config"},
+		{name: "env over rc", env: envFile, wantPath: envFile, wantSource: "$" + configFileEnv},
+		{name: "missing env file", env: filepath.Join(dir, "missing.yaml"), wantErr: true},
+		{name: "rc file", wantPath: defaultConfigFile, wantSource: "current directory"},
+		{name: "nothing", removeRC: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(configFileEnv, tt.env)
+			if tt.removeRC {
+				os.Remove(defaultConfigFile)
+			}
+
+			path, source, err := findConfigFile(tt.explicit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findConfigFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if path != tt.wantPath || source != tt.wantSource {
+				t.Errorf("findConfigFile() = %q, %q, want %q, %q", path, source, tt.wantPath, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "tidy.yaml")
+	content := `pack: FilePack
+dry-run: true
+workers: 3
+source:
+  - ./a
+  - ./b
+folder-map:
+  SFX_Voice: Dialogue
+  Ambient: Ambience
+id-pattern:
+  - '#(\d{3,6})$'
+  - '_v(\d+)$'
+`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	var sources sourceList
+	flags.Var(&sources, "source", "")
+	pack := flags.String("pack", "", "")
+	dryRun := flags.Bool("dry-run", false, "")
+	workers := flags.Int("workers", 1, "")
+	folderMap := flags.String("folder-map", "", "")
+	var idPatterns idPatternList
+	flags.Var(&idPatterns, "id-pattern", "")
+	if err := flags.Parse([]string{"-pack", "FlagPack"}); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := loadConfigFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfigFile(flags, values); err != nil {
+		t.Fatal(err)
+	}
+
+	if *pack != "FlagPack" {
+		t.Errorf("pack = %q, the command line should win", *pack)
+	}
+	if !*dryRun || *workers != 3 {
+		t.Errorf("dry-run = %v, workers = %d, want true, 3", *dryRun, *workers)
+	}
+	if len(sources) != 2 || sources[1] != "./b" {
+		t.Errorf("sources = %v, want [./a ./b]", sources)
+	}
+	if *folderMap != "Ambient=Ambience,SFX_Voice=Dialogue" {
+		t.Errorf("folder-map = %q", *folderMap)
+	}
+	if len(idPatterns) != 2 || idPatterns[0] != `#(\d{3,6})$` {
+		t.Errorf("id-pattern = %q, want one value per list item", idPatterns)
+	}
+
+	// a fresh set, flags the file already set count as given
+	flags = flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Int("workers", 1, "")
+	if err := applyConfigFile(flags, map[string]any{"no-such-option": true}); err == nil {
+		t.Error("unknown options should be an error")
+	}
+	if err := applyConfigFile(flags, map[string]any{"workers": "many"}); err == nil {
+		t.Error("bad values should be an error")
+	}
+}
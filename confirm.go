@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// confirmChanges asks the user whether to go ahead after the preview.
+// In per-category mode, files in skipped categories are dropped from the run.
+// Returns false if there's nothing left to apply.
+func (ap *AudioProcessor) confirmChanges() bool {
+	reader := bufio.NewReader(ap.input)
+
+	if !ap.config.InteractivePerCategory {
+		return ap.askYesNo(reader, fmt.Sprintf("\nApply these %d changes? [y/N] ", len(ap.audioFiles)))
+	}
+
+	categoryGroups, categories := ap.groupByCategory()
+
+	accepted := make(map[*AudioFile]bool)
+	ap.log.Println()
+	for _, cat := range categories {
+		files := categoryGroups[cat]
+		if ap.askYesNo(reader, fmt.Sprintf("Apply [%s] (%d files)? [y/N] ", cat, len(files))) {
+			for _, af := range files {
+				accepted[af] = true
+			}
+		}
+	}
+
+	kept := make([]AudioFile, 0, len(accepted))
+	for i := range ap.audioFiles {
+		if accepted[&ap.audioFiles[i]] {
+			kept = append(kept, ap.audioFiles[i])
+		}
+	}
+
+	if skipped := len(ap.audioFiles) - len(kept); skipped > 0 {
+		ap.log.Printf("Skipping %d files\n", skipped)
+	}
+	ap.audioFiles = kept
+
+	return len(kept) > 0
+}
+
+// askYesNo prints the prompt and reads one line, anything other than y/yes is a no
+func (ap *AudioProcessor) askYesNo(reader *bufio.Reader, prompt string) bool {
+	ap.log.Printf("%s", prompt)
+
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		ap.log.Println()
+		return false // EOF or closed stdin counts as no
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
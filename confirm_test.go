@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfirmChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{"yes", "y\n", true},
+		{"yes_full", "YES\n", true},
+		{"no", "n\n", false},
+		{"empty", "\n", false},
+		{"eof", "", false},
+		{"garbage", "sure\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: "TestPack", Interactive: true})
+			ap.audioFiles = []AudioFile{{OriginalName: "a.wav"}, {OriginalName: "b.wav"}}
+			ap.input = strings.NewReader(tt.input)
+
+			if result := ap.confirmChanges(); result != tt.expected {
+				t.Errorf("confirmChanges() with input %q = %v, want %v", tt.input, result, tt.expected)
+			}
+			if len(ap.audioFiles) != 2 {
+				t.Errorf("confirmChanges() should not drop files in single-prompt mode, got %d", len(ap.audioFiles))
+			}
+		})
+	}
+}
+
+func TestConfirmChangesPerCategory(t *testing.T) {
+	ap := NewAudioProcessor(Config{PackName: "TestPack", InteractivePerCategory: true})
+	ap.audioFiles = []AudioFile{
+		{OriginalName: "wind.wav", Category: "Ambient"},
+		{OriginalName: "roar.wav", Category: "SFX_Creature"},
+		{OriginalName: "rain.wav", Category: "Ambient"},
+		{OriginalName: "mystery.wav"},
+	}
+	// categories are asked in sorted order: Ambient, SFX_Creature, Uncategorized
+	ap.input = strings.NewReader("y\nn\ny\n")
+
+	if !ap.confirmChanges() {
+		t.Fatal("confirmChanges() = false, want true when some categories are accepted")
+	}
+
+	if len(ap.audioFiles) != 3 {
+		t.Fatalf("confirmChanges() kept %d files, want 3", len(ap.audioFiles))
+	}
+	for _, af := range ap.audioFiles {
+		if af.Category == "SFX_Creature" {
+			t.Errorf("confirmChanges() kept %s from a skipped category", af.OriginalName)
+		}
+	}
+
+	// rejecting everything means nothing to apply
+	ap.input = strings.NewReader("n\nn\n")
+	if ap.confirmChanges() {
+		t.Error("confirmChanges() = true, want false when every category is skipped")
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// contentPath is where a file ends up in the UE5 content browser once the
+// output directory is imported at -content-root: the same folders as on disk,
+// then the asset name, which is the new name without its extension. Files
+// that stay where they are or are dropped as duplicates don't get one.
+func (ap *AudioProcessor) contentPath(af *AudioFile) string {
+	if ap.config.ContentRoot == "" || ap.config.Catalog || af.NewRelativePath == "" ||
+		af.SkipReason != "" || af.DuplicateStatus == duplicateDropped {
+		return ""
+	}
+	rel := strings.TrimSuffix(af.NewRelativePath, path.Ext(af.NewRelativePath))
+	return path.Join(ap.config.ContentRoot, rel)
+}
+
+// parseContentRoot checks a content browser path like /Game/Audio and drops
+// any trailing slash
+func parseContentRoot(root string) (string, error) {
+	if root == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(root, "/") || strings.Contains(root, `\`) {
+		return "", fmt.Errorf("%q is not a content browser path, expected something like /Game/Audio", root)
+	}
+	return path.Clean(root), nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// header of the -datatable CSV. UE5 takes the first column as the row name
+// whatever it's called, "---" is what its own CSV export writes. The rest
+// match the fields of a row struct like:
+//
+//	USTRUCT(BlueprintType)
+//	struct FSoundRow : public FTableRowBase
+//	{
+//		FString Category;
+//		float Duration;  // seconds
+//		float LoopStart; // seconds, 0 without a loop
+//		float LoopEnd;
+//		TArray<FString> Tags;
+//	};
+var dataTableHeader = []string{"---", "Category", "Duration", "LoopStart", "LoopEnd", "Tags"}
+
+// dataTableRows returns a row for every analyzed file that ends up in the
+// output, named after its new name without the extension
+func (ap *AudioProcessor) dataTableRows() [][]string {
+	rows := [][]string{dataTableHeader}
+	index := make(map[string]int) // row name -> position in rows
+	for i := range ap.audioFiles {
+		af := &ap.audioFiles[i]
+		meta := af.AudioMeta
+		if meta == nil || af.SkipReason != "" || af.DuplicateStatus == duplicateDropped {
+			continue
+		}
+		name := strings.TrimSuffix(af.NewName, filepath.Ext(af.NewName))
+
+		loopStart, loopEnd := 0.0, 0.0
+		if meta.Loop != nil && meta.SampleRate > 0 {
+			loopStart = float64(meta.Loop.Start) / float64(meta.SampleRate)
+			loopEnd = float64(meta.Loop.End) / float64(meta.SampleRate)
+		}
+
+		row := []string{
+			name,
+			af.Category,
+			formatSeconds(meta.Duration.Seconds()),
+			formatSeconds(loopStart),
+			formatSeconds(loopEnd),
+			dataTableArray(af.Tags),
+		}
+
+		// -on-collision overwrite can leave two files with one name, the last one wins on disk too
+		if r, ok := index[name]; ok {
+			rows[r] = row
+			continue
+		}
+		index[name] = len(rows)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+var dataTableEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// dataTableArray formats a TArray<FString> the way UE5's CSV import reads it: ("a","b")
+func dataTableArray(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = `"` + dataTableEscaper.Replace(item) + `"`
+	}
+	return "(" + strings.Join(quoted, ",") + ")"
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 6, 64)
+}
+
+// writeDataTable writes the -datatable CSV
+func (ap *AudioProcessor) writeDataTable(path string) error {
+	f, err := ap.createFile(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	w.WriteAll(ap.dataTableRows())
+	if err := w.Error(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write data table: %w", err)
+	}
+	return f.Close()
+}
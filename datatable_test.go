@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestWriteDataTable(t *testing.T) {
+	ap := NewAudioProcessor(Config{})
+	ap.audioFiles = []AudioFile{
+		{
+			NewName:   "A_Pack_Music_Chase_Loop.wav",
+			Category:  "Music",
+			Tags:      []string{"loop", "stereo"},
+			AudioMeta: &tidy.AudioMetadata{Duration: 4 * time.Second, SampleRate: 48000, Loop: &tidy.LoopInfo{Start: 24000, End: 192000}},
+		},
+		{
+			NewName:   "A_Pack_Voice_Scream.wav",
+			Category:  "SFX_Voice",
+			Tags:      []string{`say "hi"`},
+			AudioMeta: &tidy.AudioMetadata{Duration: 1500 * time.Millisecond, SampleRate: 44100},
+		},
+		{NewName: "A_Pack_Broken.wav", Category: "SFX"}, // analysis failed
+		{NewName: "A_Pack_Copy.wav", DuplicateStatus: duplicateDropped, AudioMeta: &tidy.AudioMetadata{}},
+		{NewName: "A_Pack_Taken.wav", SkipReason: skipNameTaken, AudioMeta: &tidy.AudioMetadata{}},
+	}
+
+	path := filepath.Join(t.TempDir(), "sounds.csv")
+	if err := ap.writeDataTable(path); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"---", "Category", "Duration", "LoopStart", "LoopEnd", "Tags"},
+		{"A_Pack_Music_Chase_Loop", "Music", "4.000000", "0.500000", "4.000000", `("loop","stereo")`},
+		{"A_Pack_Voice_Scream", "SFX_Voice", "1.500000", "0.000000", "0.000000", `("say \"hi\"")`},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("data table =\n%q\nwant\n%q", rows, want)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	duplicateKept    = "kept"
+	duplicateDropped = "dropped"
+
+	dedupeMove   = "move"
+	dedupeDelete = "delete"
+
+	duplicatesDir = "_duplicates"
+)
+
+// how the copy to keep is picked from a duplicate group (-keep-policy)
+const (
+	keepQuality = "quality" // highest sample rate, then bit depth, then duration
+	keepNewest  = "newest"  // most recently modified, quality breaks ties
+	keepOldest  = "oldest"  // least recently modified, quality breaks ties
+)
+
+// DuplicateGroup lists the files with the same audio, split into the copy
+// worth keeping and the redundant rest. It's only a suggestion unless -dedupe
+// acts on it.
+type DuplicateGroup struct {
+	Keep      string   `json:"keep"`      // original path
+	Redundant []string `json:"redundant"` // original paths
+}
+
+func validKeepPolicy(s string) bool {
+	return s == keepQuality || s == keepNewest || s == keepOldest
+}
+
+// pickKeeper returns the index of the file to keep from a duplicate group
+func (ap *AudioProcessor) pickKeeper(indices []int) int {
+	better := ap.betterDuplicate
+	if policy := ap.config.KeepPolicy; policy == keepNewest || policy == keepOldest {
+		modTimes := make(map[int]time.Time, len(indices))
+		for _, idx := range indices {
+			if info, err := os.Stat(ap.audioFiles[idx].OriginalPath); err == nil {
+				modTimes[idx] = info.ModTime()
+			}
+		}
+		better = func(a, b int) bool {
+			ta, tb := modTimes[a], modTimes[b]
+			switch {
+			case ta.IsZero() != tb.IsZero():
+				return !ta.IsZero() // a file we couldn't stat never wins on age
+			case !ta.Equal(tb):
+				return ta.After(tb) == (policy == keepNewest)
+			}
+			return ap.betterDuplicate(a, b)
+		}
+	}
+
+	best := indices[0]
+	for _, idx := range indices[1:] {
+		if better(idx, best) {
+			best = idx
+		}
+	}
+	return best
+}
+
+// groupByContent splits files that share a fingerprint into groups of
+// byte-for-byte identical files, in the order of indices. Files identical to
+// none of the others, or that can't be read, come back as unconfirmed.
+func groupByContent(files []AudioFile, indices []int) (groups [][]int, unconfirmed []int) {
+	byHash := make(map[string][]int)
+	var order []string
+	for _, idx := range indices {
+		hash, err := contentHash(files[idx].OriginalPath)
+		if err != nil {
+			unconfirmed = append(unconfirmed, idx)
+			continue
+		}
+		if byHash[hash] == nil {
+			order = append(order, hash)
+		}
+		byHash[hash] = append(byHash[hash], idx)
+	}
+
+	for _, hash := range order {
+		if group := byHash[hash]; len(group) > 1 {
+			groups = append(groups, group)
+		} else {
+			unconfirmed = append(unconfirmed, group[0])
+		}
+	}
+	sort.Ints(unconfirmed)
+	return groups, unconfirmed
+}
+
+// contentHash returns the SHA-256 of a file's bytes
+func contentHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resolveDuplicates keeps one file from each duplicate group and marks the
+// rest as dropped. The keeper is picked by -keep-policy, by default the best
+// quality copy: highest sample rate, then highest bit depth, then longest
+// duration. If those all tie, the file with the lexically first original path
+// wins so runs are repeatable.
+func (ap *AudioProcessor) resolveDuplicates() {
+	dropped := 0
+	for _, indices := range ap.duplicateGroups {
+		best := ap.pickKeeper(indices)
+
+		keeper := &ap.audioFiles[best]
+		keeper.DuplicateStatus = duplicateKept
+		for _, idx := range indices {
+			if idx == best || ap.audioFiles[idx].SkipReason != "" {
+				continue // left alone, like files outside -only-category
+			}
+			ap.audioFiles[idx].DuplicateStatus = duplicateDropped
+			ap.audioFiles[idx].DuplicateOf = keeper.OriginalPath
+			dropped++
+		}
+	}
+
+	if dropped > 0 {
+		ap.log.Infof("Keeping 1 file per duplicate group, dropping %d copies\n", dropped)
+	}
+}
+
+// duplicateGroupsIn returns the groups with at least one file in files, so a
+// per-category manifest only lists the groups it has a part in
+func duplicateGroupsIn(groups []DuplicateGroup, files []AudioFile) []DuplicateGroup {
+	paths := make(map[string]bool, len(files))
+	for _, af := range files {
+		paths[af.OriginalPath] = true
+	}
+
+	var in []DuplicateGroup
+	for _, g := range groups {
+		listed := paths[g.Keep]
+		for _, p := range g.Redundant {
+			listed = listed || paths[p]
+		}
+		if listed {
+			in = append(in, g)
+		}
+	}
+	return in
+}
+
+// betterDuplicate reports whether file a should be kept over file b
+func (ap *AudioProcessor) betterDuplicate(a, b int) bool {
+	fa, fb := &ap.audioFiles[a], &ap.audioFiles[b]
+	ma, mb := fa.AudioMeta, fb.AudioMeta
+	if ma == nil || mb == nil {
+		if ma != mb {
+			return ma != nil // analyzed beats unanalyzed
+		}
+		return fa.OriginalPath < fb.OriginalPath
+	}
+
+	if ma.SampleRate != mb.SampleRate {
+		return ma.SampleRate > mb.SampleRate
+	}
+	if ma.BitDepth != mb.BitDepth {
+		return ma.BitDepth > mb.BitDepth
+	}
+	if ma.Duration != mb.Duration {
+		return ma.Duration > mb.Duration
+	}
+	return fa.OriginalPath < fb.OriginalPath
+}
+
+// dropDuplicate deletes a dropped duplicate or moves it into the _duplicates folder
+func (ap *AudioProcessor) dropDuplicate(af *AudioFile) error {
+	if ap.config.DedupeAction == dedupeDelete {
+		if ap.sandbox {
+			return nil // nothing of the library gets deleted in the sandbox
+		}
+		return os.Remove(af.OriginalPath)
+	}
+
+	outputPath := ap.outputPath(af)
+	if err := ap.mkdirAll(filepath.Dir(outputPath)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	times, timesOK := captureTimes(af.OriginalPath)
+	if err := ap.relocate(af.OriginalPath, outputPath); err != nil {
+		return err
+	}
+	if err := ap.applyFileMode(outputPath); err != nil {
+		ap.log.Warnf("Warning: could not set the permissions of %s: %v\n", af.NewName, err)
+	}
+	if ap.config.PreserveTimes && timesOK && !isSymlink(outputPath) {
+		return times.restore(outputPath)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestResolveDuplicates(t *testing.T) {
+	ap := NewAudioProcessor(Config{PackName: "TestPack", Dedupe: true})
+	ap.audioFiles = []AudioFile{
+		{OriginalPath: "a/boom.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 44100, BitDepth: 24, Duration: 2 * time.Second}},
+		{OriginalPath: "b/boom.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 48000, BitDepth: 16, Duration: 2 * time.Second}},
+		{OriginalPath: "c/boom.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 48000, BitDepth: 24, Duration: 1 * time.Second}},
+		{OriginalPath: "d/boom.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 48000, BitDepth: 24, Duration: 2 * time.Second}},
+		{OriginalPath: "e/unique.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 96000}},
+		{OriginalPath: "z/tie.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 48000}},
+		{OriginalPath: "y/tie.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 48000}},
+	}
+	ap.duplicateGroups = [][]int{{0, 1, 2, 3}, {5, 6}}
+
+	ap.resolveDuplicates()
+
+	expected := []string{duplicateDropped, duplicateDropped, duplicateDropped, duplicateKept, "", duplicateDropped, duplicateKept}
+	for i, status := range expected {
+		if ap.audioFiles[i].DuplicateStatus != status {
+			t.Errorf("%s status = %q, want %q", ap.audioFiles[i].OriginalPath, ap.audioFiles[i].DuplicateStatus, status)
+		}
+	}
+	if ap.audioFiles[0].DuplicateOf != "d/boom.wav" {
+		t.Errorf("DuplicateOf = %q, want d/boom.wav", ap.audioFiles[0].DuplicateOf)
+	}
+	if ap.audioFiles[5].DuplicateOf != "y/tie.wav" {
+		t.Errorf("tie should go to the first path, DuplicateOf = %q", ap.audioFiles[5].DuplicateOf)
+	}
+}
+
+func TestApplyChangesDedupe(t *testing.T) {
+	for _, action := range []string{dedupeMove, dedupeDelete} {
+		t.Run(action, func(t *testing.T) {
+			src := t.TempDir()
+			out := t.TempDir()
+
+			keep := filepath.Join(src, "boom_hi.wav")
+			drop := filepath.Join(src, "boom_lo.wav")
+			for _, p := range []string{keep, drop} {
+				if err := os.WriteFile(p, []byte("audio"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, Dedupe: true, DedupeAction: action})
+			ap.audioFiles = []AudioFile{
+				{OriginalPath: keep, OriginalName: "boom_hi.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 96000}},
+				{OriginalPath: drop, OriginalName: "boom_lo.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 44100}},
+			}
+			ap.duplicateGroups = [][]int{{0, 1}}
+
+			ap.parseFiles()
+			ap.resolveDuplicates()
+			if err := ap.generateNewNames(); err != nil {
+				t.Fatalf("generateNewNames() error = %v", err)
+			}
+			if err := ap.applyChanges(context.Background()); err != nil {
+				t.Fatalf("applyChanges() error = %v", err)
+			}
+
+			if _, err := os.Stat(drop); !os.IsNotExist(err) {
+				t.Error("dropped duplicate should no longer be at its original path")
+			}
+
+			movedPath := filepath.Join(out, duplicatesDir, "boom_lo.wav")
+			_, err := os.Stat(movedPath)
+			if action == dedupeMove && err != nil {
+				t.Errorf("dropped duplicate should be moved to %s", movedPath)
+			}
+			if action == dedupeDelete && err == nil {
+				t.Errorf("dropped duplicate should be deleted, found it at %s", movedPath)
+			}
+
+			kept := filepath.Join(out, ap.categoryDir(ap.audioFiles[0].Category), ap.audioFiles[0].NewName)
+			if _, err := os.Stat(kept); err != nil {
+				t.Errorf("kept file should be renamed to %s", kept)
+			}
+		})
+	}
+}
+
+func TestDetectDuplicatesKeeper(t *testing.T) {
+	ap := NewAudioProcessor(Config{PackName: "TestPack"})
+	ap.log.out = io.Discard
+	dir := t.TempDir()
+	ap.audioFiles = []AudioFile{
+		{OriginalPath: filepath.Join(dir, "a_boom.wav"), AudioMeta: &tidy.AudioMetadata{Fingerprint: "fp", SampleRate: 44100, BitDepth: 24}},
+		{OriginalPath: filepath.Join(dir, "b_boom.wav"), AudioMeta: &tidy.AudioMetadata{Fingerprint: "fp", SampleRate: 48000, BitDepth: 16}},
+		{OriginalPath: filepath.Join(dir, "c_boom.wav"), AudioMeta: &tidy.AudioMetadata{Fingerprint: "fp", SampleRate: 48000, BitDepth: 24}},
+		{OriginalPath: filepath.Join(dir, "d_unique.wav"), AudioMeta: &tidy.AudioMetadata{Fingerprint: "other"}},
+	}
+	for _, af := range ap.audioFiles {
+		if err := os.WriteFile(af.OriginalPath, []byte(af.AudioMeta.Fingerprint), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ap.indexFingerprints()
+	ap.detectDuplicates()
+
+	wantTags := []string{"duplicate-redundant", "duplicate-redundant", "duplicate-keep", ""}
+	for i, want := range wantTags {
+		tags := ap.audioFiles[i].Tags
+		for _, tag := range []string{"duplicate-keep", "duplicate-redundant"} {
+			if contains(tags, tag) != (tag == want) {
+				t.Errorf("%s tags = %v, want %q", ap.audioFiles[i].OriginalPath, tags, want)
+			}
+		}
+	}
+
+	want := []DuplicateGroup{{Keep: ap.audioFiles[2].OriginalPath, Redundant: []string{ap.audioFiles[0].OriginalPath, ap.audioFiles[1].OriginalPath}}}
+	if !reflect.DeepEqual(ap.duplicateReport, want) {
+		t.Errorf("duplicateReport = %+v, want %+v", ap.duplicateReport, want)
+	}
+
+	// a category manifest only lists the groups it has files from
+	if got := duplicateGroupsIn(ap.duplicateReport, ap.audioFiles[1:2]); !reflect.DeepEqual(got, want) {
+		t.Errorf("duplicateGroupsIn() = %+v, want %+v", got, want)
+	}
+	if got := duplicateGroupsIn(ap.duplicateReport, ap.audioFiles[3:]); got != nil {
+		t.Errorf("duplicateGroupsIn() = %+v, want none", got)
+	}
+}
+
+func TestPickKeeperPolicy(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.wav")
+	mid := filepath.Join(dir, "mid.wav")
+	newer := filepath.Join(dir, "new.wav")
+	now := time.Now()
+	for i, p := range []string{old, mid, newer} {
+		if err := os.WriteFile(p, []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(time.Duration(i-2) * time.Hour)
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		policy   string
+		expected string
+	}{
+		{keepQuality, mid},
+		{keepNewest, newer},
+		{keepOldest, old},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: "TestPack", KeepPolicy: tt.policy})
+			ap.audioFiles = []AudioFile{
+				{OriginalPath: old, AudioMeta: &tidy.AudioMetadata{SampleRate: 44100}},
+				{OriginalPath: mid, AudioMeta: &tidy.AudioMetadata{SampleRate: 96000}},
+				{OriginalPath: newer, AudioMeta: &tidy.AudioMetadata{SampleRate: 48000}},
+				{OriginalPath: filepath.Join(dir, "missing.wav"), AudioMeta: &tidy.AudioMetadata{SampleRate: 192000}},
+			}
+			if tt.policy == keepQuality {
+				ap.audioFiles = ap.audioFiles[:3] // the missing file would win on quality
+			}
+			indices := make([]int, len(ap.audioFiles))
+			for i := range indices {
+				indices[i] = i
+			}
+
+			got := ap.audioFiles[ap.pickKeeper(indices)].OriginalPath
+			if got != tt.expected {
+				t.Errorf("pickKeeper() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDedupeNeedsIdenticalContent(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	glass := make([]byte, 48000*2*2)
+	metal := make([]byte, 48000*2*2)
+	for i := range metal {
+		metal[i] = byte(i % 251)
+	}
+	files := map[string][]byte{
+		"glass_break.wav":      buildTestWAV(48000, 1, 16, glass),
+		"glass_break_copy.wav": buildTestWAV(48000, 1, 16, glass),
+		"metal_impact.wav":     buildTestWAV(48000, 1, 16, metal), // same format and length, different sound
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(src, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutFlat, Dedupe: true, DedupeAction: dedupeDelete, Workers: 1})
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	dropped := 0
+	for _, af := range ap.audioFiles {
+		switch af.OriginalName {
+		case "metal_impact.wav":
+			if af.DuplicateStatus != "" || !contains(af.Tags, "possible-duplicate") {
+				t.Errorf("metal_impact.wav status = %q, tags = %v, want no duplicate status and possible-duplicate", af.DuplicateStatus, af.Tags)
+			}
+			if _, err := os.Stat(ap.outputPath(&af)); err != nil {
+				t.Errorf("metal_impact.wav should be renamed, not deleted: %v", err)
+			}
+		default:
+			if af.DuplicateStatus == duplicateDropped {
+				dropped++
+			}
+		}
+	}
+	if dropped != 1 {
+		t.Errorf("%d copies of glass_break.wav dropped, want 1", dropped)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// the platform calls, swapped out in tests
+var (
+	diskFree     = freeSpace
+	onSameVolume = sameVolume
+)
+
+// checkDiskSpace makes sure the output volume has room for the files that
+// will be copied there. Moves within a volume are renames and need no space,
+// but a move to another drive is a copy followed by a delete, and running out
+// halfway leaves the library split across two places.
+func (ap *AudioProcessor) checkDiskSpace() error {
+	outputDir := existingParent(ap.config.OutputDir)
+
+	var needed uint64
+	copies := 0
+	for i := range ap.audioFiles {
+		af := &ap.audioFiles[i]
+		switch ap.fileStatus(af) {
+		case statusRenamed:
+		case statusDropped:
+			if ap.config.DedupeAction == dedupeDelete {
+				continue
+			}
+		default:
+			continue
+		}
+		if onSameVolume(af.OriginalPath, outputDir) {
+			continue
+		}
+		info, err := os.Stat(af.OriginalPath)
+		if err != nil {
+			continue // it'll fail with a proper error when it's moved
+		}
+		needed += uint64(info.Size())
+		copies++
+	}
+	if copies == 0 {
+		return nil
+	}
+
+	available, err := diskFree(outputDir)
+	if err != nil {
+		ap.log.Warnf("⚠ Could not check free space on %s: %v\n", outputDir, err)
+		return nil
+	}
+
+	ap.log.Infof("\n%d files are on another volume and will be copied: %s needed, %s free on %s\n", copies, formatSize(needed), formatSize(available), outputDir)
+	if needed > available {
+		return fmt.Errorf("not enough space on %s: copying needs %s but only %s is free (use -skip-space-check to try anyway)", outputDir, formatSize(needed), formatSize(available))
+	}
+	return nil
+}
+
+// existingParent returns dir, or the closest folder above it that exists,
+// since the output directory is only created when the first file is moved
+func existingParent(dir string) string {
+	dir = absPath(dir)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// formatSize prints a byte count the way file managers do, like 1.5 GB
+func formatSize(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package main
+
+import "errors"
+
+// freeSpace isn't available here, the check is skipped with a warning
+func freeSpace(dir string) (uint64, error) {
+	return 0, errors.New("not supported on this platform")
+}
+
+// sameVolume can't tell volumes apart here, so nothing counts as a copy
+func sameVolume(a, b string) bool {
+	return true
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		bytes uint64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536 * 1024, "1.5 MB"},
+		{5 << 30, "5.0 GB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatSize(tt.bytes); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	tests := []struct {
+		name       string
+		sameVolume bool
+		free       uint64
+		skip       bool
+		wantErr    bool
+	}{
+		{"same volume needs nothing", true, 0, false, false},
+		{"other volume with room", false, 1 << 20, false, false},
+		{"other volume full", false, 100, false, true},
+		{"skipped", false, 100, true, false},
+	}
+
+	defer func(free func(string) (uint64, error), same func(string, string) bool) {
+		diskFree, onSameVolume = free, same
+	}(diskFree, onSameVolume)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diskFree = func(string) (uint64, error) { return tt.free, nil }
+			onSameVolume = func(string, string) bool { return tt.sameVolume }
+
+			src, out := t.TempDir(), filepath.Join(t.TempDir(), "not", "created", "yet")
+			for _, name := range []string{"scream_male.wav", "door_creak.wav"} {
+				if err := os.WriteFile(filepath.Join(src, name), make([]byte, 1000), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, PackName: "TestPack", Workers: 1, SkipSpaceCheck: tt.skip})
+			ap.log.out = io.Discard
+			err := ap.Process(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Process() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "-skip-space-check") {
+				t.Errorf("error %q should mention -skip-space-check", err)
+			}
+
+			// nothing may be moved when the check fails
+			left, _ := filepath.Glob(filepath.Join(src, "*.wav"))
+			if tt.wantErr && len(left) != 2 {
+				t.Errorf("%d files left in the source, want both", len(left))
+			}
+		})
+	}
+}
+
+func TestExistingParent(t *testing.T) {
+	dir := t.TempDir()
+	if got := existingParent(filepath.Join(dir, "a", "b")); got != dir {
+		t.Errorf("existingParent() = %q, want %q", got, dir)
+	}
+}
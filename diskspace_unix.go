@@ -0,0 +1,24 @@
+//go:build unix
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// freeSpace returns the bytes available to this user on the volume holding dir
+func freeSpace(dir string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+// sameVolume reports whether two paths are on the same device, so moving from
+// one to the other is a rename rather than a copy
+func sameVolume(a, b string) bool {
+	var statA, statB unix.Stat_t
+	if unix.Stat(a, &statA) != nil || unix.Stat(b, &statB) != nil {
+		return true // can't tell, don't count it
+	}
+	return statA.Dev == statB.Dev
+}
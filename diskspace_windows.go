@@ -0,0 +1,29 @@
+//go:build windows
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// freeSpace returns the bytes available to this user on the volume holding dir
+func freeSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
+
+// sameVolume reports whether two paths are on the same drive, so moving from
+// one to the other is a rename rather than a copy
+func sameVolume(a, b string) bool {
+	return strings.EqualFold(filepath.VolumeName(absPath(a)), filepath.VolumeName(absPath(b)))
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+)
+
+// no supported format fits a header and any audio worth keeping in fewer
+// bytes; a WAV header alone is 44
+const minAudioSize = 128
+
+const (
+	skipEmpty     = "empty file"
+	skipCorrupt   = "too small to hold audio"
+	skipTruncated = "truncated, the header promises more audio than the file holds"
+)
+
+// checkSize tags a zero-byte file empty, an implausibly small one corrupt and
+// a WAV or AIFF cut off before the end of its audio truncated-audio, and records
+// which in SizeProblem. They're left where they are unless -include-empty, a
+// broken file in the organized library is worse than one left behind in the
+// source.
+func (ap *AudioProcessor) checkSize(af *AudioFile) {
+	info, err := os.Stat(af.OriginalPath)
+	if err != nil {
+		return // one that can't be read fails analysis with a proper error
+	}
+	af.Size = info.Size()
+
+	var tag string
+	switch {
+	case info.Size() == 0:
+		tag, af.SizeProblem = "empty", skipEmpty
+	case info.Size() < minAudioSize:
+		tag, af.SizeProblem = "corrupt", skipCorrupt
+	case truncatedAudio(af.OriginalPath, info.Size()):
+		tag, af.SizeProblem = "truncated-audio", skipTruncated // plain truncated is a shortened name
+	default:
+		return
+	}
+	af.Tags = append(af.Tags, tag)
+	if !ap.config.IncludeEmpty {
+		af.SkipReason = af.SizeProblem
+	}
+}
+
+// truncatedAudio reports whether the sample data chunk of a WAV (data) or
+// AIFF (SSND) file ends past the end of the file, the sign of a copy or
+// download that was cut short. Other formats and files whose chunks can't be
+// walked aren't judged. A data size of 0 or 0xFFFFFFFF is what recorders
+// write while still recording, that's not a claim about the length.
+func truncatedAudio(path string, size int64) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	header := make([]byte, 12)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return false
+	}
+	var order binary.ByteOrder
+	var dataID string
+	switch {
+	case string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE":
+		order, dataID = binary.LittleEndian, "data"
+	case string(header[0:4]) == "FORM" && (string(header[8:12]) == "AIFF" || string(header[8:12]) == "AIFC"):
+		order, dataID = binary.BigEndian, "SSND"
+	default:
+		return false
+	}
+
+	chunk := make([]byte, 8)
+	for pos := int64(12); pos+8 <= size; {
+		if _, err := f.ReadAt(chunk, pos); err != nil {
+			return false
+		}
+		chunkSize := int64(order.Uint32(chunk[4:8]))
+		if string(chunk[0:4]) == dataID {
+			return chunkSize != 0 && chunkSize != 0xFFFFFFFF && pos+8+chunkSize > size
+		}
+		pos += 8 + chunkSize + chunkSize%2
+	}
+	return false
+}
+
+// emptyOrCorrupt reports whether checkSize found nothing in a file to
+// analyze. A truncated file still has audio up to where it was cut off.
+func emptyOrCorrupt(af *AudioFile) bool {
+	return af.SizeProblem == skipEmpty || af.SizeProblem == skipCorrupt
+}
+
+// reportEmptyFiles lists the empty, corrupt and truncated files found by the
+// scan, so they can be deleted or fetched again at the source
+func (ap *AudioProcessor) reportEmptyFiles() {
+	var junk []*AudioFile
+	for i := range ap.audioFiles {
+		if ap.audioFiles[i].SizeProblem != "" {
+			junk = append(junk, &ap.audioFiles[i])
+		}
+	}
+	if len(junk) == 0 {
+		return
+	}
+
+	ap.log.Warnf("⚠ %d files are empty, truncated or too small to hold audio:\n", len(junk))
+	for _, af := range junk {
+		ap.log.Warnf("  %s: %s\n", ap.relPath(af.OriginalPath), af.SizeProblem)
+	}
+	if ap.config.IncludeEmpty {
+		ap.log.Warnf("  They will be moved with the rest (-include-empty).\n")
+	} else {
+		ap.log.Warnf("  They will be left where they are (use -include-empty to move them anyway).\n")
+	}
+}
+
+// emptyFileCount is how many files checkSize found a problem with, for the
+// JSON summary
+func emptyFileCount(files []AudioFile) int {
+	n := 0
+	for i := range files {
+		if files[i].SizeProblem != "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmptyFiles(t *testing.T) {
+	for _, include := range []bool{false, true} {
+		src, out := t.TempDir(), t.TempDir()
+		files := map[string][]byte{
+			"door_creak.wav":      buildTestWAV(48000, 1, 16, make([]byte, 4800)),
+			"empty_room_tone.wav": buildTestWAV(48000, 1, 16, make([]byte, 4800)), // "empty" only in the name
+			"glass_break.wav":     nil,                                            // zero bytes
+			"wind_howl.wav":       buildTestWAV(48000, 1, 16, nil)[:40],           // cut off inside the header
+			"rain_loop.wav":       buildTestWAV(48000, 1, 16, make([]byte, 9600))[:4000],
+		}
+		for name, data := range files {
+			if err := os.WriteFile(filepath.Join(src, name), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutFlat, IncludeEmpty: include, Workers: 1})
+		ap.log.out = io.Discard
+		if err := ap.Process(context.Background()); err != nil {
+			t.Fatalf("include=%v: Process() error = %v", include, err)
+		}
+
+		wantTags := map[string]string{"glass_break.wav": "empty", "wind_howl.wav": "corrupt", "rain_loop.wav": "truncated-audio"}
+		for _, af := range ap.audioFiles {
+			tag, junk := wantTags[af.OriginalName]
+			if junk && !contains(af.Tags, tag) {
+				t.Errorf("include=%v: %s tags %v, want %s", include, af.OriginalName, af.Tags, tag)
+			}
+			_, err := os.Stat(af.OriginalPath)
+			if stayed := err == nil; stayed != (junk && !include) {
+				t.Errorf("include=%v: %s left in place = %v", include, af.OriginalName, stayed)
+			}
+		}
+		if n := emptyFileCount(ap.audioFiles); n != 3 {
+			t.Errorf("include=%v: emptyFileCount() = %d, want 3", include, n)
+		}
+	}
+}
+
+func TestTruncatedAudio(t *testing.T) {
+	wav := buildTestWAV(48000, 1, 16, make([]byte, 9600))
+	streaming := append([]byte{}, wav[:4000]...)
+	binary.LittleEndian.PutUint32(streaming[40:], 0xFFFFFFFF) // data size of a recording in progress
+
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"whole.wav", wav, false},
+		{"cut.wav", wav[:4000], true},
+		{"streaming.wav", streaming, false},
+		{"not_riff.wav", fakeAudio, false},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, tt.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := truncatedAudio(path, int64(len(tt.data))); got != tt.want {
+			t.Errorf("truncatedAudio(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
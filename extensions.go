@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// defaultExtensions are the audio formats picked up when -extensions isn't given
+var defaultExtensions = []string{".wav", ".mp3", ".ogg", ".flac", ".aac", ".m4a", ".wma", ".aiff", ".aif"}
+
+// parseExtensions reads the -extensions value: a comma-separated list that
+// replaces the defaults, or adds to them if it starts with "+". Every entry has
+// to start with a dot and is matched case-insensitively.
+func parseExtensions(value string) ([]string, error) {
+	if value == "" {
+		return defaultExtensions, nil
+	}
+
+	var extensions []string
+	if strings.HasPrefix(value, "+") {
+		extensions = append(extensions, defaultExtensions...)
+		value = value[1:]
+	}
+
+	list := splitList(value)
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no extensions given")
+	}
+	for _, ext := range list {
+		if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+			return nil, fmt.Errorf("extension %q must start with a dot, like .wav", ext)
+		}
+		extensions = append(extensions, strings.ToLower(ext))
+	}
+
+	return extensions, nil
+}
+
+// fileExt returns the extension a file's new name gets: the one its content
+// calls for when analysis found it missing or wrong, its own otherwise.
+// Always lowercase, so FILE.WAV and file.wav end up named alike.
+func fileExt(af *AudioFile) string {
+	if af.AudioMeta != nil && af.AudioMeta.Extension != "" {
+		return af.AudioMeta.Extension
+	}
+	return strings.ToLower(filepath.Ext(af.OriginalName))
+}
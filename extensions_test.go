@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseExtensions(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{"", defaultExtensions, false},
+		{".wav,.AIFF", []string{".wav", ".aiff"}, false},
+		{"+.caf", append(append([]string{}, defaultExtensions...), ".caf"), false},
+		{".wav,mp3", nil, true},
+		{".", nil, true},
+		{"+", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseExtensions(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseExtensions(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseExtensions(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestScanFilesExtensions(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.wav", "b.AIF", "c.aiff", "d.caf", "e.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		extensions []string
+		want       []string
+	}{
+		{nil, []string{"a.wav", "b.AIF", "c.aiff"}},
+		{[]string{".caf", ".wav"}, []string{"a.wav", "d.caf"}},
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(Config{SourceDir: root, OutputDir: root, Extensions: tt.extensions})
+		if err := ap.scanFiles(); err != nil {
+			t.Fatalf("scanFiles() error = %v", err)
+		}
+		var got []string
+		for _, af := range ap.audioFiles {
+			got = append(got, af.OriginalName)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("scanFiles() with %v = %v, want %v", tt.extensions, got, tt.want)
+		}
+	}
+}
+
+func TestMixedCaseExtensions(t *testing.T) {
+	src := t.TempDir()
+	for _, name := range []string{"a/gun_shot.WAV", "b/gun_shot.wav", "wind_howl.Wav"} {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, fakeAudio, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: t.TempDir(), PackName: "TestPack", Layout: layoutCategory, NoAnalyze: true})
+	if err := ap.scanFiles(); err != nil {
+		t.Fatal(err)
+	}
+	ap.parseFiles()
+	if err := ap.generateNewNames(); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+	for _, af := range ap.audioFiles {
+		if filepath.Ext(af.NewName) != ".wav" {
+			t.Errorf("%s -> %s, want a lowercase .wav", af.OriginalName, af.NewName)
+		}
+		names[af.NewName] = true
+	}
+	// the two gun shots only differ in the case of their extension, they're
+	// numbered like any other pair of files with the same name
+	if len(names) != 3 || !names["A_TestPack_Weapon_Gun_Shot.wav"] || !names["A_TestPack_Weapon_Gun_Shot_01.wav"] {
+		t.Errorf("new names = %v, want Gun_Shot.wav, Gun_Shot_01.wav and the wind", names)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "sort"
+
+const skipUnreadable = "could not be analyzed"
+
+// fileError is a file that couldn't be read or analyzed, and why
+type fileError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// reportFileErrors lists the files analysis failed on, sorted by path
+func (ap *AudioProcessor) reportFileErrors() {
+	if len(ap.fileErrors) == 0 {
+		return
+	}
+
+	sort.Slice(ap.fileErrors, func(i, j int) bool { return ap.fileErrors[i].Path < ap.fileErrors[j].Path })
+
+	ap.log.Warnf("⚠ %d files could not be analyzed:\n", len(ap.fileErrors))
+	for _, fe := range ap.fileErrors {
+		ap.log.Warnf("  %s: %s\n", ap.relPath(fe.Path), fe.Error)
+	}
+	if ap.config.SkipUnreadable {
+		ap.log.Warnf("  They will be left where they are.\n")
+	} else {
+		ap.log.Warnf("  They will be named from the filename only.\n")
+	}
+}
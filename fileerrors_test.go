@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeAudioFilesCollectsErrors(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		dir := t.TempDir()
+		good := filepath.Join(dir, "door_slam.wav")
+		bad := filepath.Join(dir, "glass_break.wav")
+		if err := os.WriteFile(good, buildTestWAV(48000, 1, 16, make([]byte, 960)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(bad, fakeAudio, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		ap := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir, PackName: "TestPack", Workers: 2, SkipUnreadable: skip})
+		ap.log.out = io.Discard
+		if err := ap.scanFiles(); err != nil {
+			t.Fatal(err)
+		}
+		if err := ap.analyzeAudioFiles(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		ap.parseFiles()
+
+		if len(ap.fileErrors) != 1 || ap.fileErrors[0].Path != bad || ap.fileErrors[0].Error == "" {
+			t.Fatalf("skip=%v: fileErrors = %+v, want just %s with a reason", skip, ap.fileErrors, bad)
+		}
+
+		for _, af := range ap.audioFiles {
+			wantSkipped := skip && af.OriginalPath == bad
+			if skipped := af.SkipReason == skipUnreadable; skipped != wantSkipped {
+				t.Errorf("skip=%v: %s skipped = %v, want %v", skip, af.OriginalName, skipped, wantSkipped)
+			}
+			// categorized from the filename all the same
+			if af.Category == "" {
+				t.Errorf("skip=%v: %s has no category", skip, af.OriginalName)
+			}
+		}
+
+		if err := ap.createManifest(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(ap.manifestPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var manifest struct {
+			FailedFiles []fileError `json:"failed_files"`
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatal(err)
+		}
+		// relative to the source, like every path in the manifest
+		if len(manifest.FailedFiles) != 1 || manifest.FailedFiles[0].Path != "glass_break.wav" {
+			t.Errorf("skip=%v: failed_files = %+v, want glass_break.wav", skip, manifest.FailedFiles)
+		}
+	}
+}
+
+func TestAnalyzeAudioFilesRecoversPanics(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"door_slam.wav", "glass_break.wav"} {
+		if err := os.WriteFile(filepath.Join(dir, name), buildTestWAV(48000, 1, 16, make([]byte, 960)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir, PackName: "TestPack", Workers: 2})
+	ap.log.out = io.Discard
+	// stands in for a parser that chokes on one file
+	ap.audioAnalyzer.Verbosef = func(format string, args ...any) {
+		if args[0] == "glass_break.wav" {
+			panic("boom")
+		}
+	}
+	if err := ap.scanFiles(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ap.analyzeAudioFiles(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := filepath.Join(dir, "glass_break.wav")
+	if len(ap.fileErrors) != 1 || ap.fileErrors[0].Path != bad || ap.fileErrors[0].Error != "panic: boom" {
+		t.Fatalf("fileErrors = %+v, want just %s with panic: boom", ap.fileErrors, bad)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// fileTimes are the access and modification times of a file before it moves
+type fileTimes struct {
+	atime, mtime time.Time
+}
+
+// captureTimes reads a file's times, or returns false when it can't
+func captureTimes(path string) (fileTimes, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileTimes{}, false
+	}
+	return fileTimes{atime: accessTime(path, info), mtime: info.ModTime()}, true
+}
+
+// restore sets the times back on the file at path. A rename keeps them
+// already, but a copy across volumes or a rewrite for -write-tags doesn't.
+func (t fileTimes) restore(path string) error {
+	return os.Chtimes(path, t.atime, t.mtime)
+}
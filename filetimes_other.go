@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime isn't available here, the modification time stands in for it
+func accessTime(path string, info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestApplyChangesPreserveTimes(t *testing.T) {
+	old := time.Date(2019, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	for _, preserve := range []bool{false, true} {
+		src := t.TempDir()
+		out := t.TempDir()
+
+		// -write-tags rewrites the file, which gives it a fresh modification time
+		original := filepath.Join(src, "door_creak_ABC.wav")
+		if err := os.WriteFile(original, buildTestWAV(48000, 1, 16, make([]byte, 480)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(original, old, old); err != nil {
+			t.Fatal(err)
+		}
+
+		ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, PackName: "TestPack", Layout: layoutFlat, WriteTags: true, PreserveTimes: preserve})
+		ap.log.out = io.Discard
+		ap.audioFiles = []AudioFile{
+			{OriginalPath: original, OriginalName: "door_creak_ABC.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 48000}},
+		}
+		ap.parseFiles()
+		if err := ap.generateNewNames(); err != nil {
+			t.Fatal(err)
+		}
+		if err := ap.applyChanges(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(filepath.Join(out, ap.audioFiles[0].NewName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		kept := info.ModTime().Sub(old).Abs() < 2*time.Second
+		if kept != preserve {
+			t.Errorf("preserve-times %v: modification time = %v, original was %v", preserve, info.ModTime(), old)
+		}
+	}
+}
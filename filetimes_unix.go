@@ -0,0 +1,19 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// accessTime returns when the file at path was last read
+func accessTime(path string, info os.FileInfo) time.Time {
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return info.ModTime()
+	}
+	return time.Unix(stat.Atim.Unix())
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// splitList turns a comma-separated flag value into a trimmed list, dropping empties
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// relPath returns p relative to its source dir with forward slashes, so glob
+// patterns behave the same on every platform
+func (ap *AudioProcessor) relPath(p string) string {
+	rel, err := filepath.Rel(ap.sourceRoot(p), p)
+	if err != nil {
+		rel = p
+	}
+	return filepath.ToSlash(rel)
+}
+
+// matchGlob reports whether a relative path matches a pattern. Patterns without
+// a slash match any single path element (so "_rejects" or "*.tmp.wav" work at
+// any depth). Patterns with a slash match the whole path or any leading
+// directory of it, so "drafts/old" also covers everything below drafts/old/.
+func matchGlob(pattern, rel string) bool {
+	pattern = filepath.ToSlash(pattern)
+
+	if ok, _ := path.Match(pattern, rel); ok {
+		return true
+	}
+
+	elements := strings.Split(rel, "/")
+
+	if !strings.Contains(pattern, "/") {
+		for _, elem := range elements {
+			if ok, _ := path.Match(pattern, elem); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 1; i < len(elements); i++ {
+		prefix := strings.Join(elements[:i], "/")
+		if ok, _ := path.Match(pattern, prefix); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcluded checks the exclude patterns against a path relative to SourceDir
+func (ap *AudioProcessor) isExcluded(rel string) bool {
+	return matchAnyGlob(ap.config.Exclude, rel)
+}
+
+// isIncluded checks the include patterns, an empty include list means everything
+func (ap *AudioProcessor) isIncluded(rel string) bool {
+	if len(ap.config.Include) == 0 {
+		return true
+	}
+	return matchAnyGlob(ap.config.Include, rel)
+}
+
+// tooDeep reports whether dir is below -max-depth. The source itself is depth 0,
+// so with -max-depth 0 only files directly in it are scanned.
+func (ap *AudioProcessor) tooDeep(root, dir string) bool {
+	if ap.config.MaxDepth == nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+	depth := len(strings.Split(filepath.ToSlash(rel), "/"))
+	return depth > *ap.config.MaxDepth
+}
+
+// isHidden reports whether a file or folder name is a dotfile like .cache or .DS_Store
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// isAppleDouble reports whether name is a ._ file, where macOS keeps resource
+// forks and extended attributes on filesystems that can't store them
+func isAppleDouble(name string) bool {
+	return strings.HasPrefix(name, "._")
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{"a, b ,c", []string{"a", "b", "c"}},
+		{",a,,b,", []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := splitList(tt.input)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("splitList(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		rel      string
+		expected bool
+	}{
+		{"*.wav", "boom.wav", true},
+		{"*.wav", "impacts/boom.wav", true}, // no slash = any element
+		{"_rejects", "_rejects/boom.wav", true},
+		{"_rejects", "impacts/_rejects/boom.wav", true},
+		{"_rejects/*", "_rejects/boom.wav", true},
+		{"_rejects/*", "_rejects/old/boom.wav", true}, // leading directory matches
+		{"impacts/*.wav", "impacts/boom.wav", true},
+		{"impacts/*.wav", "impacts/metal/boom.wav", false},
+		{"impacts/metal", "impacts/metal/heavy/boom.wav", true},
+		{"impacts/metal", "impacts/wood/boom.wav", false},
+		{"*_draft*", "voices/scream_draft.wav", true},
+		{"*_draft*", "voices/scream.wav", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"|"+tt.rel, func(t *testing.T) {
+			if result := matchGlob(tt.pattern, tt.rel); result != tt.expected {
+				t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.rel, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanFilesIncludeExclude(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"boom.wav",
+		"impacts/metal_hit.wav",
+		"impacts/wood_hit.mp3",
+		"impacts/_rejects/bad_hit.wav",
+		"_rejects/old.wav",
+		"voices/scream.wav",
+		"voices/deep/growl.wav",
+		"notes.txt",
+	}
+	for _, f := range files {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "everything",
+			expected: []string{"_rejects/old.wav", "boom.wav", "impacts/_rejects/bad_hit.wav", "impacts/metal_hit.wav", "impacts/wood_hit.mp3", "voices/deep/growl.wav", "voices/scream.wav"},
+		},
+		{
+			name:     "exclude_nested_rejects",
+			exclude:  []string{"_rejects"},
+			expected: []string{"boom.wav", "impacts/metal_hit.wav", "impacts/wood_hit.mp3", "voices/deep/growl.wav", "voices/scream.wav"},
+		},
+		{
+			name:     "include_folder",
+			include:  []string{"voices/*"},
+			expected: []string{"voices/deep/growl.wav", "voices/scream.wav"},
+		},
+		{
+			name:     "exclude_wins",
+			include:  []string{"impacts/*"},
+			exclude:  []string{"*.mp3", "_rejects"},
+			expected: []string{"impacts/metal_hit.wav"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{SourceDir: root, OutputDir: root, Include: tt.include, Exclude: tt.exclude})
+			if err := ap.scanFiles(); err != nil {
+				t.Fatalf("scanFiles() error = %v", err)
+			}
+
+			var found []string
+			for _, af := range ap.audioFiles {
+				found = append(found, ap.relPath(af.OriginalPath))
+			}
+			sort.Strings(found)
+
+			if !reflect.DeepEqual(found, tt.expected) {
+				t.Errorf("scanFiles() found %v, want %v", found, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanFilesMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		"top.wav",
+		"vendor/one.wav",
+		"vendor/pack/two.wav",
+		"vendor/pack/extras/three.wav",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	depth := func(n int) *int { return &n }
+
+	tests := []struct {
+		name     string
+		maxDepth *int
+		expected []string
+	}{
+		{"no limit", nil, []string{"top.wav", "vendor/one.wav", "vendor/pack/extras/three.wav", "vendor/pack/two.wav"}},
+		{"depth 0", depth(0), []string{"top.wav"}},
+		{"depth 1", depth(1), []string{"top.wav", "vendor/one.wav"}},
+		{"depth 2", depth(2), []string{"top.wav", "vendor/one.wav", "vendor/pack/two.wav"}},
+		{"deeper than the tree", depth(10), []string{"top.wav", "vendor/one.wav", "vendor/pack/extras/three.wav", "vendor/pack/two.wav"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{SourceDir: root, OutputDir: root, MaxDepth: tt.maxDepth})
+			if err := ap.scanFiles(); err != nil {
+				t.Fatalf("scanFiles() error = %v", err)
+			}
+
+			var found []string
+			for _, af := range ap.audioFiles {
+				found = append(found, ap.relPath(af.OriginalPath))
+			}
+			sort.Strings(found)
+
+			if !reflect.DeepEqual(found, tt.expected) {
+				t.Errorf("scanFiles() found %v, want %v", found, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanFilesHidden(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		"boom.wav",
+		"._boom.wav",
+		".hidden.wav",
+		".cache/cached.wav",
+		"impacts/hit.wav",
+		"impacts/._hit.wav",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		skipHidden bool
+		expected   []string
+	}{
+		{"skip hidden", true, []string{"boom.wav", "impacts/hit.wav"}},
+		{"keep hidden", false, []string{".cache/cached.wav", ".hidden.wav", "boom.wav", "impacts/hit.wav"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{SourceDir: root, OutputDir: root, SkipHidden: tt.skipHidden})
+			if err := ap.scanFiles(); err != nil {
+				t.Fatalf("scanFiles() error = %v", err)
+			}
+
+			var found []string
+			for _, af := range ap.audioFiles {
+				found = append(found, ap.relPath(af.OriginalPath))
+			}
+			sort.Strings(found)
+
+			if !reflect.DeepEqual(found, tt.expected) {
+				t.Errorf("scanFiles() found %v, want %v", found, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path"
+	"strings"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+// folderCategory infers a category from the folders between the source
+// directory and the file, nearest folder first, since packs are often sorted
+// already (Weapons/Guns/ak47.wav). Returns the folder that matched and its
+// category, or "" when no folder has a category keyword.
+func (ap *AudioProcessor) folderCategory(af *AudioFile) (string, string) {
+	folders := sourceFolders(ap.relPath(af.OriginalPath))
+	for i := len(folders) - 1; i >= 0; i-- {
+		if category := tidy.InferCategory(folders[i]); category != "SFX" {
+			return folders[i], category
+		}
+	}
+	return "", ""
+}
+
+// sourceFolders splits the directory part of a slash-separated relative path
+func sourceFolders(rel string) []string {
+	dir := path.Dir(rel)
+	if dir == "." || dir == "/" {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
+
+// parentFolder returns the name of the folder a file is in, "" for files
+// directly in a source directory
+func (ap *AudioProcessor) parentFolder(af *AudioFile) string {
+	folders := sourceFolders(ap.relPath(af.OriginalPath))
+	if len(folders) == 0 {
+		return ""
+	}
+	return folders[len(folders)-1]
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestParseFileUseFolders(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		useFolders      bool
+		wantCategory    string
+		wantSubCategory string
+	}{
+		{"nearest folder names the category", "/src/Weapons/Guns/ak47.wav", true, "SFX_Weapon", "ak47"},
+		{"parent folder added to subcategory", "/src/Weapons/AK/ak47.wav", true, "SFX_Weapon", "AK_ak47"},
+		{"filename keyword wins", "/src/Weapons/door_slam.wav", true, tidy.InferCategory("door_slam"), "door_slam"},
+		{"no folder keyword", "/src/Misc/take_12.wav", true, "SFX", "take_12"},
+		{"file in the source root", "/src/ak47.wav", true, "SFX", "ak47"},
+		{"off by default", "/src/Weapons/Guns/ak47.wav", false, "SFX", "ak47"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{SourceDir: "/src", PackName: "TestPack", UseFolders: tt.useFolders})
+			ap.log.out = io.Discard
+
+			af := &AudioFile{OriginalPath: tt.path, OriginalName: filepath.Base(tt.path)}
+			ap.parseFile(af)
+
+			if af.Category != tt.wantCategory || af.SubCategory != tt.wantSubCategory {
+				t.Errorf("parseFile(%s) = %s / %s, want %s / %s", tt.path, af.Category, af.SubCategory, tt.wantCategory, tt.wantSubCategory)
+			}
+		})
+	}
+}
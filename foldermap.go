@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// parseFolderMap reads a category -> folder mapping. The value is either a path
+// to a file with one "Category=Folder" pair per line (blank lines and # comments
+// are ignored), or the pairs inline separated by commas.
+func parseFolderMap(value string) (map[string]string, error) {
+	return parseCategoryMap(value, "Folder")
+}
+
+// parseCategoryMap reads Category=Value pairs the way parseFolderMap describes.
+// what names the value in errors.
+func parseCategoryMap(value, what string) (map[string]string, error) {
+	categoryMap := make(map[string]string)
+	if value == "" {
+		return categoryMap, nil
+	}
+
+	var entries []string
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		file, err := os.Open(value)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			entries = append(entries, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		entries = splitList(value)
+	}
+
+	for _, entry := range entries {
+		category, mapped, ok := strings.Cut(entry, "=")
+		category = strings.TrimSpace(category)
+		mapped = strings.TrimSpace(mapped)
+		if !ok || category == "" || mapped == "" {
+			return nil, fmt.Errorf("invalid %s mapping %q, expected Category=%s", strings.ToLower(what), entry, what)
+		}
+		// keys are matched case-insensitively against the normalized category
+		categoryMap[strings.ToUpper(category)] = mapped
+	}
+
+	return categoryMap, nil
+}
+
+// categoryDir returns the output folder for a category, using the folder map
+// if the category is in it and the cleaned category name otherwise
+func (ap *AudioProcessor) categoryDir(category string) string {
+	if folder, ok := ap.config.FolderMap[strings.ToUpper(category)]; ok {
+		return folder
+	}
+
+	dir := ap.cleanName(category)
+	if dir == "" {
+		dir = "Uncategorized"
+	}
+	return dir
+}
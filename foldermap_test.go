@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFolderMap(t *testing.T) {
+	mapFile := filepath.Join(t.TempDir(), "folders.txt")
+	content := "# unreal layout\nSFX_Voice = Dialogue\n\nSFX_Footstep=Foley\nambient=Ambience\n"
+	if err := os.WriteFile(mapFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{name: "empty", value: "", expected: map[string]string{}},
+		{
+			name:     "inline",
+			value:    "SFX_Voice=Dialogue, Ambient=Ambience",
+			expected: map[string]string{"SFX_VOICE": "Dialogue", "AMBIENT": "Ambience"},
+		},
+		{
+			name:     "file",
+			value:    mapFile,
+			expected: map[string]string{"SFX_VOICE": "Dialogue", "SFX_FOOTSTEP": "Foley", "AMBIENT": "Ambience"},
+		},
+		{name: "missing_folder", value: "SFX_Voice=", wantErr: true},
+		{name: "no_equals", value: "SFX_Voice", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseFolderMap(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseFolderMap(%q) should fail", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFolderMap(%q) error = %v", tt.value, err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseFolderMap(%q) = %v, want %v", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCategoryDir(t *testing.T) {
+	ap := NewAudioProcessor(Config{FolderMap: map[string]string{"SFX_VOICE": "Dialogue", "AMBIENT": "Ambience"}})
+
+	tests := []struct {
+		category string
+		expected string
+	}{
+		{"SFX_Voice", "Dialogue"},
+		{"Ambient", "Ambience"},
+		{"SFX_Creature", "Sfx_Creature"}, // unmapped keeps the cleaned name
+		{"", "Uncategorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.category, func(t *testing.T) {
+			if result := ap.categoryDir(tt.category); result != tt.expected {
+				t.Errorf("categoryDir(%q) = %q, want %q", tt.category, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// GroupMode is what the folders of the category layout are named after
+type GroupMode string
+
+const (
+	groupCategory   GroupMode = "category"   // SFX_Impact/, Ambient/...
+	groupSource     GroupMode = "source"     // src_BW/, from the source code in the filename
+	groupSampleRate GroupMode = "samplerate" // 48kHz/, 44.1kHz/
+	groupDuration   GroupMode = "duration"   // 0-1s/, 5-30s/
+)
+
+// folder for files whose group can't be worked out, like a sample rate
+// without audio analysis
+const unknownGroup = "Unknown"
+
+func parseGroupBy(value string) (GroupMode, error) {
+	switch GroupMode(value) {
+	case groupCategory, groupSource, groupSampleRate, groupDuration:
+		return GroupMode(value), nil
+	case "":
+		return groupCategory, nil
+	}
+	return "", fmt.Errorf("unknown grouping %q, expected %s, %s, %s or %s", value, groupCategory, groupSource, groupSampleRate, groupDuration)
+}
+
+// groupDir returns the folder a file goes into under -layout category
+func (ap *AudioProcessor) groupDir(af *AudioFile) string {
+	switch ap.config.GroupBy {
+	case groupSource:
+		return sourceFolder(af.Source)
+	case groupSampleRate:
+		if af.AudioMeta == nil {
+			return unknownGroup
+		}
+		return sampleRateFolder(af.AudioMeta.SampleRate)
+	case groupDuration:
+		if af.AudioMeta == nil {
+			return unknownGroup
+		}
+		return durationFolder(af.AudioMeta.Duration)
+	}
+	return ap.categoryDir(af.Category)
+}
+
+var notFolderSafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// sourceFolder names the folder of a source code, like src_BW. Codes keep
+// their case, they're usually initials.
+func sourceFolder(source string) string {
+	source = notFolderSafe.ReplaceAllString(source, "")
+	if source == "" {
+		return "src_" + unknownGroup
+	}
+	return "src_" + source
+}
+
+// sampleRateFolder names the folder of a sample rate, like 48kHz or 44.1kHz
+func sampleRateFolder(rate int) string {
+	if rate <= 0 {
+		return unknownGroup
+	}
+	return strconv.FormatFloat(float64(rate)/1000, 'f', -1, 64) + "kHz"
+}
+
+// durationFolder puts a duration in the same buckets as the duration tags
+func durationFolder(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return unknownGroup
+	case d < time.Second:
+		return "0-1s"
+	case d < 5*time.Second:
+		return "1-5s"
+	case d < 30*time.Second:
+		return "5-30s"
+	}
+	return "30s+"
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    GroupMode
+		wantErr bool
+	}{
+		{"", groupCategory, false},
+		{"category", groupCategory, false},
+		{"source", groupSource, false},
+		{"samplerate", groupSampleRate, false},
+		{"duration", groupDuration, false},
+		{"bitdepth", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseGroupBy(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseGroupBy(%q) = %q, %v, want %q (error: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGroupDir(t *testing.T) {
+	af := AudioFile{
+		Category:  "SFX_Impact",
+		Source:    "BW",
+		AudioMeta: &tidy.AudioMetadata{SampleRate: 44100, Duration: 12 * time.Second},
+	}
+	bare := AudioFile{Category: "SFX_Impact"}
+
+	tests := []struct {
+		group GroupMode
+		file  AudioFile
+		want  string
+	}{
+		{groupCategory, af, "Sfx_Impact"},
+		{groupSource, af, "src_BW"},
+		{groupSampleRate, af, "44.1kHz"},
+		{groupDuration, af, "5-30s"},
+		{groupSource, bare, "src_Unknown"},
+		{groupSampleRate, bare, "Unknown"},
+		{groupDuration, bare, "Unknown"},
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(Config{GroupBy: tt.group})
+		if got := ap.groupDir(&tt.file); got != tt.want {
+			t.Errorf("groupDir() by %s = %q, want %q", tt.group, got, tt.want)
+		}
+	}
+}
+
+func TestFolderNames(t *testing.T) {
+	rates := map[int]string{48000: "48kHz", 44100: "44.1kHz", 96000: "96kHz", 22050: "22.05kHz", 0: "Unknown"}
+	for rate, want := range rates {
+		if got := sampleRateFolder(rate); got != want {
+			t.Errorf("sampleRateFolder(%d) = %q, want %q", rate, got, want)
+		}
+	}
+
+	durations := map[time.Duration]string{
+		500 * time.Millisecond: "0-1s",
+		time.Second:            "1-5s",
+		5 * time.Second:        "5-30s",
+		30 * time.Second:       "30s+",
+		0:                      "Unknown",
+	}
+	for d, want := range durations {
+		if got := durationFolder(d); got != want {
+			t.Errorf("durationFolder(%v) = %q, want %q", d, got, want)
+		}
+	}
+
+	if got := sourceFolder("B/W"); got != "src_BW" {
+		t.Errorf("sourceFolder(%q) = %q, want src_BW", "B/W", got)
+	}
+}
+
+func TestProcessGroupBySampleRate(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	files := map[string]int{"door_creak.wav": 48000, "wind_howl.wav": 44100}
+	for name, rate := range files {
+		if err := os.WriteFile(filepath.Join(src, name), buildTestWAV(rate, 1, 16, make([]byte, rate*2)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, GroupBy: groupSampleRate, Workers: 1})
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	for _, af := range ap.audioFiles {
+		want := filepath.Join(out, sampleRateFolder(files[af.OriginalName]), af.NewName)
+		if _, err := os.Stat(want); err != nil {
+			t.Errorf("%s: not at %s: %v", af.OriginalName, want, err)
+		}
+	}
+}
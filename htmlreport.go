@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// htmlReport is everything the -html page shows, built from the same files
+// the preview and the manifest are
+type htmlReport struct {
+	Pack       string
+	DryRun     bool
+	Summary    string
+	Categories []htmlCategory
+	Duplicates []DuplicateGroup
+}
+
+type htmlCategory struct {
+	Name  string
+	Files []htmlFile
+}
+
+type htmlFile struct {
+	Original   string // relative to its source
+	New        string // empty when the file keeps its name, like in a catalog
+	Status     string
+	Duration   string
+	Confidence float64
+	Unresolved bool
+	Tags       []string
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{if .Pack}}{{.Pack}} - {{end}}tidy-rename report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { text-align: left; padding: 0.25em 0.75em; border-bottom: 1px solid #ddd; vertical-align: top; }
+summary { cursor: pointer; font-weight: bold; margin: 0.5em 0; }
+.status { color: #666; font-size: 0.9em; }
+.unresolved { color: #b35900; }
+.tag { display: inline-block; background: #eef; border-radius: 3px; padding: 0 0.3em; margin: 0 0.2em 0.2em 0; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>{{if .Pack}}{{.Pack}}: {{end}}tidy-rename report</h1>
+<p>{{.Summary}}{{if .DryRun}} (dry run, nothing was changed){{end}}</p>
+
+<h2>Categories</h2>
+<table>
+<tr><th>Category</th><th>Files</th></tr>
+{{- range .Categories}}
+<tr><td>{{.Name}}</td><td>{{len .Files}}</td></tr>
+{{- end}}
+</table>
+
+{{- if .Duplicates}}
+<h2>Duplicate groups</h2>
+<table>
+<tr><th>Keep</th><th>Redundant</th></tr>
+{{- range .Duplicates}}
+<tr><td>{{.Keep}}</td><td>{{range .Redundant}}{{.}}<br>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2>Files</h2>
+{{- range .Categories}}
+<details>
+<summary>{{.Name}} ({{len .Files}})</summary>
+<table>
+<tr><th>Original</th><th>New name</th><th>Duration</th><th>Confidence</th><th>Tags</th></tr>
+{{- range .Files}}
+<tr>
+<td>{{.Original}}</td>
+<td>{{if .New}}{{.New}}{{else}}-{{end}}{{if ne .Status "renamed"}} <span class="status">({{.Status}})</span>{{end}}</td>
+<td>{{.Duration}}</td>
+<td{{if .Unresolved}} class="unresolved" title="unresolved"{{end}}>{{printf "%.2f" .Confidence}}</td>
+<td>{{range .Tags}}<span class="tag">{{.}}</span>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+</details>
+{{- end}}
+</body>
+</html>
+`))
+
+// buildHTMLReport groups the files by category like the preview does
+func (ap *AudioProcessor) buildHTMLReport() htmlReport {
+	report := htmlReport{
+		Pack:    ap.config.PackName,
+		DryRun:  ap.config.DryRun,
+		Summary: countRenames(ap.audioFiles).describe(ap.config.DryRun),
+	}
+	if ap.config.Catalog {
+		report.Summary = fmt.Sprintf("%d files cataloged", len(ap.audioFiles))
+	}
+
+	// paths relative to the source, like the file list
+	for _, group := range duplicateGroupsIn(ap.duplicateReport, ap.audioFiles) {
+		rel := DuplicateGroup{Keep: ap.relPath(group.Keep)}
+		for _, path := range group.Redundant {
+			rel.Redundant = append(rel.Redundant, ap.relPath(path))
+		}
+		report.Duplicates = append(report.Duplicates, rel)
+	}
+
+	groups, categories := ap.groupByCategory()
+	for _, cat := range categories {
+		category := htmlCategory{Name: cat}
+		for _, af := range groups[cat] {
+			file := htmlFile{
+				Original:   ap.relPath(af.OriginalPath),
+				New:        af.NewName,
+				Status:     ap.fileStatus(af),
+				Confidence: af.Confidence,
+				Unresolved: af.Unresolved,
+				Tags:       af.Tags,
+			}
+			if ap.config.Catalog {
+				file.Status = statusCataloged
+			}
+			if af.AudioMeta != nil && af.AudioMeta.Duration > 0 {
+				file.Duration = af.AudioMeta.Duration.Round(time.Millisecond).String()
+			}
+			category.Files = append(category.Files, file)
+		}
+		report.Categories = append(report.Categories, category)
+	}
+	return report
+}
+
+// writeHTMLReport writes the -html page, a run's results for people who'd
+// rather not read the manifest
+func (ap *AudioProcessor) writeHTMLReport(path string) error {
+	var b strings.Builder
+	if err := htmlReportTemplate.Execute(&b, ap.buildHTMLReport()); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	if err := ap.writeFile(path, []byte(b.String())); err != nil {
+		return fmt.Errorf("failed to write HTML report: %w", err)
+	}
+	return nil
+}
+
+// emitHTMLReport writes the -html page when one was asked for
+func (ap *AudioProcessor) emitHTMLReport() error {
+	if ap.config.HTMLReport == "" {
+		return nil
+	}
+	if err := ap.writeHTMLReport(ap.config.HTMLReport); err != nil {
+		return err
+	}
+	ap.log.Infof("\n✓ Wrote HTML report: %s\n", ap.config.HTMLReport)
+	return nil
+}
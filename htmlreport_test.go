@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	src := t.TempDir()
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: src, PackName: "HorrorPack", Layout: layoutCategory, DryRun: true})
+	ap.log.out = io.Discard
+	ap.audioFiles = []AudioFile{
+		{OriginalPath: filepath.Join(src, "door_creak.wav"), OriginalName: "door_creak.wav", AudioMeta: &tidy.AudioMetadata{Duration: 1500 * time.Millisecond}},
+		{OriginalPath: filepath.Join(src, "door_creak_copy.wav"), OriginalName: "door_creak_copy.wav"},
+		{OriginalPath: filepath.Join(src, "<b>gun</b>.wav"), OriginalName: "<b>gun</b>.wav"},
+	}
+	ap.parseFiles()
+	if err := ap.generateNewNames(); err != nil {
+		t.Fatal(err)
+	}
+	ap.duplicateReport = []DuplicateGroup{{Keep: ap.audioFiles[0].OriginalPath, Redundant: []string{ap.audioFiles[1].OriginalPath}}}
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := ap.writeHTMLReport(path); err != nil {
+		t.Fatalf("writeHTMLReport() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+
+	for _, want := range []string{
+		"<summary>SFX_Object (2)</summary>",
+		"<td>" + ap.audioFiles[0].NewName + "</td>",
+		"<td>1.5s</td>",
+		"<tr><td>door_creak.wav</td><td>door_creak_copy.wav<br></td></tr>", // duplicate group, relative paths
+		"&lt;b&gt;gun&lt;/b&gt;.wav",
+		"3 files, 3 would be renamed",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("report is missing %q", want)
+		}
+	}
+	if strings.Contains(page, "<b>gun") {
+		t.Error("file names should be escaped")
+	}
+}
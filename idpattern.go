@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// the IDs vendors put at the end of names: .12345 of any length, or at least
+// five digits after an underscore, space or dash. Variation numbers like the
+// 01 in step_01 are shorter, and stay part of the name for detectRounds.
+var defaultIDPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\.(\d+)$`),
+	regexp.MustCompile(`[_ -](\d{5,})$`),
+}
+
+// idPatternList collects -id-pattern flags. Each value is one regexp, they
+// aren't split on commas since a regexp like \d{4,6} has them.
+type idPatternList []string
+
+func (l *idPatternList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *idPatternList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// repeated marks it for applyConfigFile, which sets it once per list item
+func (l *idPatternList) repeated() {}
+
+// compileIDPatterns compiles -id-pattern regexps, which need a group around
+// the ID, and puts them in front of the built-in ones
+func compileIDPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns)+len(defaultIDPatterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if re.NumSubexp() == 0 {
+			return nil, fmt.Errorf("%q has no group around the ID, like _(\\d+)$", pattern)
+		}
+		compiled = append(compiled, re)
+	}
+	return append(compiled, defaultIDPatterns...), nil
+}
+
+// splitID takes the ID out of a name with the first pattern that matches, its
+// first group being the ID. The rest of the match (a separator) goes too.
+func splitID(name string, patterns []*regexp.Regexp) (rest, id string) {
+	for _, re := range patterns {
+		if m := re.FindStringSubmatchIndex(name); m != nil && m[2] >= 0 {
+			return name[:m[0]] + name[m[1]:], name[m[2]:m[3]]
+		}
+	}
+	return name, ""
+}
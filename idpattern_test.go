@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitID(t *testing.T) {
+	custom, err := compileIDPatterns([]string{`_(\d{3,})$`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		custom   bool
+		wantRest string
+		wantID   string
+	}{
+		{"thunder_crash.9876", false, "thunder_crash", "9876"},
+		{"thunder_crash_98765", false, "thunder_crash", "98765"},
+		{"thunder crash 98765", false, "thunder crash", "98765"},
+		{"thunder_crash-98765", false, "thunder_crash", "98765"},
+		{"step_01", false, "step_01", ""},     // a variation, left for detectRounds
+		{"rain_2019", false, "rain_2019", ""}, // too short to be a catalog ID
+		{"gun_shot_123", false, "gun_shot_123", ""},
+		{"gun_shot_123", true, "gun_shot", "123"},
+		{"step_01", true, "step_01", ""},
+		{"gun_shot.123", true, "gun_shot", "123"}, // the built-in ones still apply
+	}
+
+	for _, tt := range tests {
+		patterns := defaultIDPatterns
+		if tt.custom {
+			patterns = custom
+		}
+		rest, id := splitID(tt.name, patterns)
+		if rest != tt.wantRest || id != tt.wantID {
+			t.Errorf("splitID(%q) custom=%v = %q, %q, want %q, %q", tt.name, tt.custom, rest, id, tt.wantRest, tt.wantID)
+		}
+	}
+}
+
+func TestCompileIDPatterns(t *testing.T) {
+	for _, pattern := range []string{`_\d+$`, `_(\d+$`} {
+		if _, err := compileIDPatterns([]string{pattern}); err == nil {
+			t.Errorf("compileIDPatterns(%q) should fail", pattern)
+		}
+	}
+}
+
+func TestIDsInNames(t *testing.T) {
+	src := t.TempDir()
+	for _, name := range []string{"door_creak_48213.wav", "step_01.wav", "step_02.wav"} {
+		if err := os.WriteFile(filepath.Join(src, name), buildTestWAV(48000, 1, 16, make([]byte, 4800)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: src, PackName: "TestPack", Layout: layoutFlat, Template: "A_{pack}_{category}_{subcategory}_{id}", DryRun: true, Workers: 1})
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	want := map[string]string{
+		"door_creak_48213.wav": "A_TestPack_Object_Door_Creak_48213.wav",
+		"step_01.wav":          "A_TestPack_Footstep_Step_01.wav",
+		"step_02.wav":          "A_TestPack_Footstep_Step_02.wav",
+	}
+	for _, af := range ap.audioFiles {
+		if af.NewName != want[af.OriginalName] {
+			t.Errorf("%s -> %s, want %s", af.OriginalName, af.NewName, want[af.OriginalName])
+		}
+	}
+	if len(ap.roundGroups) != 1 {
+		t.Errorf("found %d rounds, want the steps", len(ap.roundGroups))
+	}
+}
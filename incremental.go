@@ -0,0 +1,147 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	manifestFileName = "manifest.json"
+	gzipExt          = ".gz" // a manifest path ending in this is gzip-compressed
+)
+
+// manifestName is the file name of the manifests, with .gz for -manifest-gzip
+func (ap *AudioProcessor) manifestName() string {
+	if ap.config.ManifestGzip {
+		return manifestFileName + gzipExt
+	}
+	return manifestFileName
+}
+
+func (ap *AudioProcessor) manifestPath() string {
+	return filepath.Join(ap.config.OutputDir, ap.manifestName())
+}
+
+// loadPriorManifest reads the files recorded by a previous run. A missing
+// manifest just means this is the first run.
+func (ap *AudioProcessor) loadPriorManifest() error {
+	files, err := readManifestFiles(ap.manifestPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	ap.priorFiles = files
+	return nil
+}
+
+// readManifestFiles returns the files listed in a manifest, gunzipping it if
+// the path ends in .gz
+func readManifestFiles(path string) ([]AudioFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, gzipExt) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var manifest struct {
+		Files []AudioFile `json:"files"`
+	}
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return manifest.Files, nil
+}
+
+// skipKnownByName drops scanned files whose name matches a file the previous
+// run processed, either by its original name or the name it was renamed to.
+// This runs before analysis so known files cost nothing.
+func (ap *AudioProcessor) skipKnownByName() int {
+	known := make(map[string]bool, len(ap.priorFiles)*2)
+	for _, prior := range ap.priorFiles {
+		known[prior.OriginalName] = true
+		if prior.NewName != "" {
+			known[prior.NewName] = true
+		}
+	}
+
+	return ap.filterFiles(func(af *AudioFile) bool {
+		return !known[af.OriginalName]
+	})
+}
+
+// skipKnownByFingerprint drops analyzed files that are byte-for-byte the same
+// as a file the previous run processed, catching files that were renamed
+// since. The fingerprint only narrows down which library files to compare
+// with, one-shots rendered at the same length share it without being the
+// same sound.
+func (ap *AudioProcessor) skipKnownByFingerprint() int {
+	known := make(map[string][]string) // fingerprint -> where those files are in the library
+	for i, prior := range ap.priorFiles {
+		if prior.AudioMeta != nil && prior.AudioMeta.Fingerprint != "" {
+			known[prior.AudioMeta.Fingerprint] = append(known[prior.AudioMeta.Fingerprint], ap.recordedPath(&ap.priorFiles[i]))
+		}
+	}
+	if len(known) == 0 {
+		return 0
+	}
+
+	hashes := make(map[string]string) // library path -> content hash, "" if unreadable
+	libraryHash := func(path string) string {
+		if hash, ok := hashes[path]; ok {
+			return hash
+		}
+		hash, _ := contentHash(path)
+		hashes[path] = hash
+		return hash
+	}
+
+	return ap.filterFiles(func(af *AudioFile) bool {
+		if af.AudioMeta == nil || len(known[af.AudioMeta.Fingerprint]) == 0 {
+			return true
+		}
+		hash, err := contentHash(af.OriginalPath)
+		if err != nil {
+			return true
+		}
+		for _, path := range known[af.AudioMeta.Fingerprint] {
+			if libraryHash(path) == hash {
+				ap.log.Infof("  %s is already in the library as %s\n", af.OriginalName, path)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// filterFiles keeps only the files for which keep returns true and returns
+// how many were removed
+func (ap *AudioProcessor) filterFiles(keep func(*AudioFile) bool) int {
+	kept := ap.audioFiles[:0]
+	for i := range ap.audioFiles {
+		if keep(&ap.audioFiles[i]) {
+			kept = append(kept, ap.audioFiles[i])
+		}
+	}
+
+	removed := len(ap.audioFiles) - len(kept)
+	ap.audioFiles = kept
+	return removed
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func writePriorManifest(t *testing.T, dir string, files []AudioFile) {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"total_files": len(files), "files": files})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/"+manifestFileName, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIncrementalSkipsKnownFiles(t *testing.T) {
+	dir := t.TempDir()
+	writePriorManifest(t, dir, []AudioFile{
+		{OriginalName: "scream_male.wav", NewName: "A_TestPack_Voice_Scream_Male.wav", Category: "SFX_Voice"},
+		{OriginalName: "door_creak.wav", NewName: "A_TestPack_Object_Door_Creak.wav", NewRelativePath: "A_TestPack_Object_Door_Creak.wav", AudioMeta: &tidy.AudioMetadata{Fingerprint: "fp-door"}},
+	})
+	for name, content := range map[string]string{
+		"A_TestPack_Object_Door_Creak.wav": "creak",
+		"door_creak_v2.wav":                "creak",
+		"door_slam.wav":                    "slam",
+		"growl_new.wav":                    "growl",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir, PackName: "TestPack", Incremental: true})
+	if err := ap.loadPriorManifest(); err != nil {
+		t.Fatalf("loadPriorManifest() error = %v", err)
+	}
+	if len(ap.priorFiles) != 2 {
+		t.Fatalf("loadPriorManifest() loaded %d files, want 2", len(ap.priorFiles))
+	}
+
+	ap.audioFiles = []AudioFile{
+		{OriginalName: "scream_male.wav"},                  // same original name
+		{OriginalName: "A_TestPack_Voice_Scream_Male.wav"}, // output of the previous run
+		{OriginalName: "door_creak_v2.wav"},                // renamed copy, caught by fingerprint and content
+		{OriginalName: "door_slam.wav"},                    // same fingerprint, different sound
+		{OriginalName: "growl_new.wav"},
+	}
+	for i := range ap.audioFiles {
+		ap.audioFiles[i].OriginalPath = filepath.Join(dir, ap.audioFiles[i].OriginalName)
+	}
+
+	if skipped := ap.skipKnownByName(); skipped != 2 {
+		t.Errorf("skipKnownByName() = %d, want 2", skipped)
+	}
+
+	ap.audioFiles[0].AudioMeta = &tidy.AudioMetadata{Fingerprint: "fp-door"}
+	ap.audioFiles[1].AudioMeta = &tidy.AudioMetadata{Fingerprint: "fp-door"}
+	ap.audioFiles[2].AudioMeta = &tidy.AudioMetadata{Fingerprint: "fp-growl"}
+	var log bytes.Buffer
+	ap.log.out = &log
+	if skipped := ap.skipKnownByFingerprint(); skipped != 1 {
+		t.Errorf("skipKnownByFingerprint() = %d, want 1", skipped)
+	}
+	if !strings.Contains(log.String(), "door_creak_v2.wav") {
+		t.Errorf("skipped file should be named in the log, got %q", log.String())
+	}
+
+	if len(ap.audioFiles) != 2 || ap.audioFiles[0].OriginalName != "door_slam.wav" || ap.audioFiles[1].OriginalName != "growl_new.wav" {
+		t.Errorf("remaining files = %v, want door_slam.wav and growl_new.wav", ap.audioFiles)
+	}
+}
+
+func TestIncrementalNamingAndManifestMerge(t *testing.T) {
+	dir := t.TempDir()
+	writePriorManifest(t, dir, []AudioFile{
+		{OriginalName: "scream_male_OLD.wav", NewName: "A_TestPack_Voice_Scream_Male.wav", Category: "SFX_Voice"},
+	})
+
+	ap := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir, PackName: "TestPack", Incremental: true})
+	if err := ap.loadPriorManifest(); err != nil {
+		t.Fatal(err)
+	}
+	ap.audioFiles = []AudioFile{{OriginalName: "scream_male_NEW.wav"}}
+	ap.parseFiles()
+	if err := ap.generateNewNames(); err != nil {
+		t.Fatalf("generateNewNames() error = %v", err)
+	}
+
+	// the prior run already used the base name
+	if ap.audioFiles[0].NewName != "A_TestPack_Voice_Scream_Male_01.wav" {
+		t.Errorf("NewName = %q, want A_TestPack_Voice_Scream_Male_01.wav", ap.audioFiles[0].NewName)
+	}
+
+	if err := ap.createManifest(); err != nil {
+		t.Fatalf("createManifest() error = %v", err)
+	}
+
+	data, err := os.ReadFile(ap.manifestPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest struct {
+		TotalFiles int            `json:"total_files"`
+		Categories map[string]int `json:"categories"`
+		Files      []AudioFile    `json:"files"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.TotalFiles != 2 || len(manifest.Files) != 2 {
+		t.Errorf("merged manifest has %d/%d files, want 2", manifest.TotalFiles, len(manifest.Files))
+	}
+	if manifest.Categories["SFX_Voice"] != 2 {
+		t.Errorf("merged category stats = %v, want 2 SFX_Voice", manifest.Categories)
+	}
+}
+
+func TestManifestGzipRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	ap := NewAudioProcessor(Config{OutputDir: dir, PackName: "TestPack", ManifestGzip: true})
+	ap.log.out = io.Discard
+	ap.audioFiles = []AudioFile{
+		{OriginalName: "door_creak.wav", NewName: "A_TestPack_Object_Door_Creak.wav", Category: "SFX_Object"},
+	}
+	if err := ap.createManifest(); err != nil {
+		t.Fatalf("createManifest() error = %v", err)
+	}
+
+	if filepath.Base(ap.manifestPath()) != "manifest.json.gz" {
+		t.Errorf("manifestPath() = %s, want manifest.json.gz", ap.manifestPath())
+	}
+	f, err := os.Open(ap.manifestPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := gzip.NewReader(f); err != nil {
+		t.Fatalf("manifest isn't gzip-compressed: %v", err)
+	}
+
+	next := NewAudioProcessor(Config{OutputDir: dir, PackName: "TestPack", ManifestGzip: true, Incremental: true})
+	if err := next.loadPriorManifest(); err != nil {
+		t.Fatalf("loadPriorManifest() error = %v", err)
+	}
+	if len(next.priorFiles) != 1 || next.priorFiles[0].NewName != "A_TestPack_Object_Door_Creak.wav" {
+		t.Errorf("loadPriorManifest() = %+v, want the one file written", next.priorFiles)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// exit status of a run stopped by Ctrl-C, like shells use for SIGINT
+const exitInterrupted = 130
+
+// interruptContext is cancelled by the first Ctrl-C (or SIGTERM), so the run
+// can stop between files. A second one kills the process as usual, for when
+// it's stuck somewhere that doesn't check, like the -interactive prompt.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		fmt.Fprintln(os.Stderr, "\nInterrupted, finishing the file in progress (press Ctrl-C again to quit right away)")
+		cancel()
+	}()
+	return ctx
+}
+
+// finishInterrupted wraps up a run that was cancelled while moving files.
+// The files it got to go into the manifest, so -incremental can do the rest.
+func (ap *AudioProcessor) finishInterrupted(err error) error {
+	ap.emitSummary(true)
+	if !ap.config.CreateManifest || len(ap.audioFiles) == 0 {
+		return err
+	}
+	if merr := ap.createManifest(); merr != nil {
+		ap.log.Warnf("⚠ Could not write a manifest of the files moved so far: %v\n", merr)
+		return err
+	}
+	ap.log.Infoln("Run again with -incremental to process the rest.")
+	return err
+}
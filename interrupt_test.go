@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// cancelAfter is a context that's cancelled once Err has been checked n
+// times, to interrupt a run at a known point
+type cancelAfter struct {
+	context.Context
+	mu sync.Mutex
+	n  int
+}
+
+func (c *cancelAfter) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestAnalyzeInterrupted(t *testing.T) {
+	src := t.TempDir()
+	for _, name := range []string{"door_creak.wav", "wind_howl.wav"} {
+		if err := os.WriteFile(filepath.Join(src, name), buildTestWAV(48000, 1, 16, make([]byte, 9600)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: src, PackName: "TestPack", Workers: 2})
+	ap.log.out = io.Discard
+	if err := ap.scanFiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ap.analyzeAudioFiles(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("analyzeAudioFiles() error = %v, want context.Canceled", err)
+	}
+	for _, af := range ap.audioFiles {
+		if af.AudioMeta != nil {
+			t.Errorf("%s analyzed after the run was cancelled", af.OriginalName)
+		}
+	}
+}
+
+func TestAnalyzeInterruptedResumeAdvice(t *testing.T) {
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "door_creak.wav"), buildTestWAV(48000, 1, 16, make([]byte, 9600)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		// without a state directory, a dry run keeps its hands off the output and saves nothing
+		ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: t.TempDir(), PackName: "TestPack", DryRun: dryRun, Workers: 1})
+		var log bytes.Buffer
+		ap.log.out = &log
+		if err := ap.scanFiles(); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := ap.analyzeAudioFiles(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("analyzeAudioFiles() error = %v, want context.Canceled", err)
+		}
+		if got := strings.Contains(log.String(), "-resume"); got == dryRun {
+			t.Errorf("dry run %v: suggests -resume = %v in %q", dryRun, got, log.String())
+		}
+	}
+}
+
+func TestProcessInterruptedWhileMoving(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	names := []string{"door_creak.wav", "gun_shot.wav", "wind_howl.wav"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(src, name), fakeAudio, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutFlat, CreateManifest: true, NoAnalyze: true, Sort: sortOriginal, Workers: 1})
+	ap.log.out = io.Discard
+	err := ap.Process(&cancelAfter{Context: context.Background(), n: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Process() error = %v, want context.Canceled", err)
+	}
+
+	// the first file was moved, the others weren't touched
+	for i, name := range names {
+		_, err := os.Stat(filepath.Join(src, name))
+		if moved := os.IsNotExist(err); moved != (i == 0) {
+			t.Errorf("%s moved = %v, want %v", name, moved, i == 0)
+		}
+	}
+
+	data, err := os.ReadFile(ap.manifestPath())
+	if err != nil {
+		t.Fatalf("no manifest of the moved files: %v", err)
+	}
+	var manifest struct {
+		Files []AudioFile `json:"files"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 1 || manifest.Files[0].OriginalName != names[0] {
+		t.Errorf("manifest lists %+v, want just %s", manifest.Files, names[0])
+	}
+}
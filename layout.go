@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// LayoutMode is how renamed files are arranged in the output directory
+type LayoutMode string
+
+const (
+	layoutCategory LayoutMode = "category" // one folder per category, or per -group-by group
+	layoutPreserve LayoutMode = "preserve" // same subfolders as the source
+	layoutFlat     LayoutMode = "flat"     // everything directly in the output directory
+)
+
+// parseLayout checks a -layout value. Without one, -organize picks between
+// category folders (the default) and the source structure, like it always has.
+func parseLayout(value string, organize bool) (LayoutMode, error) {
+	switch LayoutMode(value) {
+	case layoutCategory, layoutPreserve, layoutFlat:
+		return LayoutMode(value), nil
+	case "":
+		if organize {
+			return layoutCategory, nil
+		}
+		return layoutPreserve, nil
+	}
+	return "", fmt.Errorf("unknown layout %q, expected %s, %s or %s", value, layoutCategory, layoutPreserve, layoutFlat)
+}
+
+// layoutDir returns the folder, relative to the output directory, that a file
+// goes into under the given layout
+func (ap *AudioProcessor) layoutDir(af *AudioFile, layout LayoutMode) string {
+	switch layout {
+	case layoutCategory:
+		return ap.groupDir(af)
+	case layoutFlat:
+		return ""
+	}
+
+	// Keep in same structure (files from several sources share the folders)
+	relPath, err := filepath.Rel(ap.sourceRoot(af.OriginalPath), af.OriginalPath)
+	if err != nil {
+		return ""
+	}
+	return filepath.Dir(relPath)
+}
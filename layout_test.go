@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLayout(t *testing.T) {
+	tests := []struct {
+		value    string
+		organize bool
+		want     LayoutMode
+		wantErr  bool
+	}{
+		{"", true, layoutCategory, false},
+		{"", false, layoutPreserve, false},
+		{"flat", true, layoutFlat, false},
+		{"preserve", true, layoutPreserve, false},
+		{"category", false, layoutCategory, false},
+		{"folders", true, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseLayout(tt.value, tt.organize)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseLayout(%q, %v) = %q, %v; want %q, error %v", tt.value, tt.organize, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestOutputPathLayout(t *testing.T) {
+	src := filepath.Join("lib", "raw")
+	out := filepath.Join("lib", "clean")
+	af := &AudioFile{
+		OriginalPath: filepath.Join(src, "voices", "take2", "scream_male.wav"),
+		Category:     "SFX_Voice",
+		NewName:      "A_Pack_Voice_Scream_Male.wav",
+	}
+
+	tests := []struct {
+		layout LayoutMode
+		want   string
+	}{
+		{layoutCategory, filepath.Join(out, "Sfx_Voice", af.NewName)},
+		{layoutPreserve, filepath.Join(out, "voices", "take2", af.NewName)},
+		{layoutFlat, filepath.Join(out, af.NewName)},
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, Layout: tt.layout})
+		if got := ap.outputPath(af); got != tt.want {
+			t.Errorf("outputPath() with %s layout = %q, want %q", tt.layout, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// how much human-readable output a run prints (-quiet, -verbose)
+type logLevel int
+
+const (
+	levelQuiet   logLevel = iota // warnings, errors and the final summary
+	levelNormal                  // progress, preview and stats
+	levelVerbose                 // plus why each file got its category
+)
+
+// logger writes human-readable output at or below its level. Analysis workers
+// log concurrently, so writes are serialized.
+type logger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level logLevel
+}
+
+func newLogger(out io.Writer, level logLevel) *logger {
+	return &logger{out: out, level: level}
+}
+
+// logLevelFor picks the level from the -quiet and -verbose flags
+func logLevelFor(config Config) logLevel {
+	switch {
+	case config.Quiet:
+		return levelQuiet
+	case config.Verbose:
+		return levelVerbose
+	}
+	return levelNormal
+}
+
+func (l *logger) write(level logLevel, s string) {
+	if l == nil || level > l.level {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.out, s)
+}
+
+// Printf always prints, for the final summary and prompts
+func (l *logger) Printf(format string, args ...any) {
+	l.write(levelQuiet, fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Println(args ...any) {
+	l.write(levelQuiet, fmt.Sprintln(args...))
+}
+
+// Warnf prints problems the user should know about, even with -quiet
+func (l *logger) Warnf(format string, args ...any) {
+	l.write(levelQuiet, fmt.Sprintf(format, args...))
+}
+
+// Infof prints regular progress output, hidden by -quiet
+func (l *logger) Infof(format string, args ...any) {
+	l.write(levelNormal, fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Infoln(args ...any) {
+	l.write(levelNormal, fmt.Sprintln(args...))
+}
+
+// Verbosef prints per-file decisions, only with -verbose. A newline is added.
+func (l *logger) Verbosef(format string, args ...any) {
+	l.write(levelVerbose, fmt.Sprintf(format, args...)+"\n")
+}
+
+// enabled reports whether output at level is printed
+func (l *logger) enabled(level logLevel) bool {
+	return l != nil && level <= l.level
+}
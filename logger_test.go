@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	tests := []struct {
+		level logLevel
+		want  string
+	}{
+		{levelQuiet, "warn\nsummary\n"},
+		{levelNormal, "info\nwarn\nsummary\n"},
+		{levelVerbose, "info\nwhy\nwarn\nsummary\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		l := newLogger(&buf, tt.level)
+		l.Infoln("info")
+		l.Verbosef("why")
+		l.Warnf("warn\n")
+		l.Println("summary")
+
+		if got := buf.String(); got != tt.want {
+			t.Errorf("level %d: got %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestLogLevelFor(t *testing.T) {
+	tests := []struct {
+		config Config
+		want   logLevel
+	}{
+		{Config{}, levelNormal},
+		{Config{Quiet: true}, levelQuiet},
+		{Config{Verbose: true}, levelVerbose},
+	}
+
+	for _, tt := range tests {
+		if got := logLevelFor(tt.config); got != tt.want {
+			t.Errorf("logLevelFor(%+v) = %d, want %d", tt.config, got, tt.want)
+		}
+	}
+}
+
+func TestParseFileVerbose(t *testing.T) {
+	ap := NewAudioProcessor(Config{Verbose: true})
+	var buf bytes.Buffer
+	ap.log.out = &buf
+
+	af := &AudioFile{OriginalName: "Big_Explosion_Distant.wav"}
+	ap.parseFile(af)
+
+	got := buf.String()
+	if !strings.Contains(got, "Big_Explosion_Distant.wav: "+af.Category) || !strings.Contains(got, `keyword "explosion"`) {
+		t.Errorf("verbose output = %q, want the category and the keyword that matched", got)
+	}
+}
@@ -1,31 +1,195 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
 )
 
 type AudioFile struct {
-	OriginalPath string
-	OriginalName string
-	Category     string
-	SubCategory  string
-	Source       string
-	ID           string
-	NewName      string
-	Tags         []string
-	AudioMeta    *AudioMetadata `json:"audio_metadata,omitempty"`
+	OriginalPath    string `json:",omitempty"` // left out of the manifest without -absolute-paths
+	OriginalName    string
+	SourceDir       string `json:"source_dir,omitempty"`        // the -source directory the file was found in
+	RelativePath    string `json:"relative_path,omitempty"`     // original path relative to SourceDir
+	NewRelativePath string `json:"new_relative_path,omitempty"` // where the run put it, relative to the output directory
+	ContentPath     string `json:"content_path,omitempty"`      // UE5 content browser path of the asset (-content-root)
+	Category        string
+	SubCategory     string
+	Source          string
+	ID              string
+	NewName         string
+	Tags            []string
+	Confidence      float64             `json:"confidence,omitempty"`       // how sure we are about Category, 0-1
+	Unresolved      bool                `json:"unresolved,omitempty"`       // confidence was below -min-confidence, Category is the fallback
+	GuessedCategory string              `json:"guessed_category,omitempty"` // the category we'd have picked for an unresolved file
+	AlreadyNamed    bool                `json:"already_named,omitempty"`    // named by an earlier run, keeps its name
+	Override        string              `json:"override,omitempty"`         // the -overrides pattern that set its name or category
+	AudioMeta       *tidy.AudioMetadata `json:"audio_metadata,omitempty"`
+
+	// set when -dedupe resolves a duplicate group
+	DuplicateStatus string `json:"duplicate_status,omitempty"` // "kept" or "dropped"
+	DuplicateOf     string `json:"duplicate_of,omitempty"`     // original path of the kept file
+
+	// numbered variations of one sound (step_01, step_02...) share a round group
+	RoundGroup int `json:"round_group,omitempty"`
+
+	// set when the file was left in place instead of renamed (-on-collision skip)
+	SkipReason string `json:"skip_reason,omitempty"`
+
+	// set by the scan when the file is empty, too small to hold audio or truncated
+	SizeProblem string `json:"size_problem,omitempty"`
+
+	// size in bytes, as scanned and again once it's in the output
+	Size int64 `json:"size,omitempty"`
 }
 
 type Config struct {
-	SourceDir      string
+	SourceDir      string   // first source directory, the default output directory
+	SourceDirs     []string // every source directory to scan, SourceDir included
 	OutputDir      string
 	PackName       string
 	DryRun         bool
-	Organize       bool
+	Sandbox        bool       // apply the changes into a temp directory with copies, then remove it
+	Layout         LayoutMode // category folders, source structure, or flat
+	GroupBy        GroupMode  // what the folders of the category layout are named after
 	CreateManifest bool
+
+	ManifestPerCategory bool   // also write a manifest.json into each category folder
+	ManifestGzip        bool   // write manifest.json.gz instead of manifest.json
+	AbsolutePaths       bool   // keep this machine's absolute paths in manifests next to the relative ones
+	ContentRoot         string // content browser path the output directory is imported to, like /Game/Audio
+
+	Interactive            bool // ask before applying changes
+	InteractivePerCategory bool // ask once per category group instead of once overall
+
+	Include []string // glob patterns matched against the path relative to SourceDir
+	Exclude []string // glob patterns that skip files/folders, checked before Include
+
+	MaxDepth *int // folder levels below each source to scan into, nil for no limit
+
+	SkipHidden bool // leave out dotfiles and everything in dot-folders
+	UseFolders bool // infer the category from folder names when the filename has no keywords
+
+	Sniff bool // also scan files without an extension and keep the ones that look like audio
+
+	SkipUnreadable bool // leave files that can't be analyzed in place instead of naming them from the filename
+	IncludeEmpty   bool // move zero-byte and implausibly small files too, instead of leaving them in place
+
+	Workers   int  // number of parallel analysis workers
+	NoAnalyze bool // skip audio analysis, name and categorize from filenames alone
+
+	Resume   bool   // reuse analysis results from the state file of a previous run
+	StateDir string // where state files are kept, one per output directory; "" puts it in the output directory
+
+	Dedupe       bool   // keep one file per duplicate group and drop the rest
+	DedupeAction string // what to do with dropped duplicates: "move" or "delete"
+	KeepPolicy   string // which copy of a duplicate group to keep: quality, newest or oldest
+
+	DupThreshold int // most bits perceptual hashes may differ in for near-duplicates, 0 for off
+
+	RenumberRounds bool // give numbered variations (step_01, step_02...) a gapless _NN sequence
+
+	FolderMap map[string]string // upper-cased category -> output folder name
+
+	PrefixMap   map[string]string // upper-cased category -> asset prefix, AssetPrefix for the rest
+	AssetPrefix string            // prefix of categories PrefixMap doesn't name, "" for A
+	NoPrefix    bool              // no prefix for those categories at all (-prefix "")
+
+	Overrides []RenameOverride // forced names and categories from -overrides, first match wins
+
+	Incremental bool // skip files already in an existing manifest and append to it
+	MergeOutput bool // new names also steer clear of assets already in the output, ignoring case and extension
+
+	SilenceThreshold float64 // dBFS level below which WAV samples count as silence
+
+	LoudnessTarget string // peak or loudness to suggest gains towards, like -1dBFS or -23LUFS
+
+	FingerprintPrecision time.Duration // durations are rounded to this for duplicate fingerprints
+	AnalysisWindow       time.Duration // how much of the start of each WAV and AIFF file the spectral analysis reads
+
+	Scoring *tidy.ScoringConfig // analysis thresholds from -scoring, nil for the defaults
+
+	OnCollision string // "number", "skip", "overwrite" or "fail" when output names clash
+	DupSuffix   string // fmt format of the number added to clashing names, like _%02d
+
+	Sort string // order of the preview and manifest: "category", "name", "duration" or "original"
+
+	MaxNameLength int // longest new name without the extension, longer ones are shortened; 0 for no limit
+
+	SkipSpaceCheck bool // don't check the output volume has room for files copied across volumes
+
+	WriteTags bool // write category and tags into the output file's own metadata
+	Sidecar   bool // write a <file>.meta.json with the file's record next to each output file
+
+	PreserveTimes bool // give moved files back their original access and modification times
+
+	DirMode  os.FileMode // permissions of created directories, 0 for 0755
+	FileMode os.FileMode // permissions of files put in the output, 0 to leave moved files as they are and write new ones 0644
+
+	Format     string // "text" for the preview and progress bar, "json" for JSON lines on stdout
+	Quiet      bool   // only warnings, errors and the final summary
+	Verbose    bool   // also log why each file got its category
+	NoProgress bool   // no progress bar or progress lines
+
+	ConfidenceThreshold float64 // files categorized below this confidence get a low-confidence tag
+	MinConfidence       float64 // files categorized below this confidence go to FallbackCategory, 0 to keep every guess
+	FallbackCategory    string  // category for files below MinConfidence
+	Strict              bool    // fail before renaming when too many files are uncategorized
+	StrictRatio         float64 // fraction of uncategorized files Strict tolerates
+
+	OnlyCategories    []string // only rename files in these categories, leave the rest alone
+	ExcludeCategories []string // leave files in these categories alone, checked before OnlyCategories
+
+	Extensions []string // lower-cased file extensions (with the dot) to pick up
+
+	Verify  bool // check the output directory against its manifest instead of renaming
+	Catalog bool // only analyze and categorize, and write the manifest without renaming anything
+
+	FollowSymlinks bool // scan linked files and folders instead of skipping them, links are moved, not their targets
+
+	PreferTags bool // name files after their embedded title tag when they have one
+	TagArtist  bool // with PreferTags, put the artist tag before the title
+
+	ReportPath  string // dry-run report file, JSON if it ends in .json
+	HTMLReport  string // HTML page of the run's results, grouped by category
+	RulesReport bool   // print which category rules and keywords matched the library
+	TagReport   bool   // print every tag with the number of files carrying it, and add it to the manifest
+
+	DataTable string // UE5 DataTable CSV to write after renaming, empty for none
+
+	RequireSampleRate int    // files at other sample rates get tagged or skipped, 0 to allow any
+	SampleRateAction  string // "tag" or "skip" for files not at RequireSampleRate
+
+	BPMInName bool // append the detected tempo (e.g. _120BPM) to new names
+
+	Transliterate bool // fold accented letters to ASCII and keep non-Latin words as placeholders
+
+	Case string // how name parts are cased: title, camel, pascal, snake or upper
+
+	Template    string // how new names are put together from {tokens}, empty for the default
+	PackVersion string // value of the {version} token
+	DateFormat  string // Go time layout of the {date} token
+
+	Profile string // vendor filename schema to parse names with, empty for the default heuristic
+
+	SourcePattern string   // regexp for trailing name segments that are source codes, on top of the built-in rules
+	IDPatterns    []string // regexps for trailing catalog IDs, tried before the built-in ones
+
+	CategorizerCmd     string        // external command asked for each file's category, empty for none
+	CategorizerTimeout time.Duration // how long to wait for it per file
+
+	ConfigFile string // config file options were read from and how it was found, for -verbose
 }
 
 var (
@@ -35,13 +199,109 @@ var (
 func main() {
 	var config Config
 	var showVersion bool
+	var include, exclude string
+	var onlyCategory, excludeCategory string
+	var folderMap string
+	var prefix, prefixMap string
+	var scoringFile string
+	var overridesFile string
+	var organize bool
+	var layout, groupBy string
+	var dirMode, fileMode string
+	var extensions string
+	var sources sourceList
+	var idPatterns idPatternList
+	var maxDepth int
+	var configFile string
 
-	flag.StringVar(&config.SourceDir, "source", "", "Source directory containing audio files (required)")
+	flag.Var(&sources, "source", "Source directory containing audio files (required); repeat the flag or separate with commas to combine several")
 	flag.StringVar(&config.OutputDir, "output", "", "Output directory for cleaned files (default: source directory)")
 	flag.StringVar(&config.PackName, "pack", "", "Pack name identifier for UE5 naming (required)")
 	flag.BoolVar(&config.DryRun, "dry-run", false, "Preview changes without modifying files")
-	flag.BoolVar(&config.Organize, "organize", true, "Organize files into category folders")
+	flag.BoolVar(&config.Sandbox, "sandbox", false, "Apply changes to copies in a temp directory to catch real errors, then remove it; nothing is modified")
+	flag.BoolVar(&organize, "organize", true, "Organize files into category folders (same as -layout category, or -layout preserve when false)")
+	flag.StringVar(&layout, "layout", "", "Output layout: category (folder per category), preserve (keep source subfolders), or flat (no subfolders); overrides -organize")
+	flag.StringVar(&groupBy, "group-by", "", "Folders of the category layout: category (default), source (src_BW), samplerate (48kHz), or duration (5-30s)")
 	flag.BoolVar(&config.CreateManifest, "manifest", true, "Create manifest.json with file metadata")
+	flag.BoolVar(&config.ManifestGzip, "manifest-gzip", false, "Write manifests gzip-compressed, as manifest.json.gz")
+	flag.BoolVar(&config.AbsolutePaths, "absolute-paths", false, "Also record absolute original paths and source directories in manifests, not just paths relative to the source and output")
+	flag.StringVar(&config.ContentRoot, "content-root", "", "UE5 content browser path the output directory gets imported to, like /Game/Audio; the manifest then records each file's content_path")
+	flag.BoolVar(&config.ManifestPerCategory, "manifest-per-category", false, "Write a manifest.json into each category folder with just that folder's files (needs -layout category; combine with -manifest=false to skip the top-level one)")
+	flag.BoolVar(&config.Interactive, "interactive", false, "Ask for confirmation before applying changes")
+	flag.BoolVar(&config.InteractivePerCategory, "interactive-category", false, "Ask for confirmation for each category group before applying changes")
+	flag.StringVar(&include, "include", "", "Comma-separated glob patterns of files to include, relative to source (default: everything)")
+	flag.StringVar(&exclude, "exclude", "", "Comma-separated glob patterns of files or folders to skip, relative to source")
+	flag.IntVar(&maxDepth, "max-depth", -1, "How many folder levels below the source to scan: 0 for only files directly in it (default: no limit)")
+	flag.BoolVar(&config.SkipHidden, "skip-hidden", true, "Skip hidden files and folders (names starting with a dot); macOS ._ files are always skipped")
+	flag.BoolVar(&config.UseFolders, "use-folders", false, "When a filename has no category keywords, infer the category from the folders it's in (nearest first)")
+	flag.BoolVar(&config.Sniff, "sniff", false, "Also scan files without an extension, keeping those whose first bytes look like WAV, AIFF, MP3, AAC, OGG, FLAC, M4A or WMA")
+	flag.BoolVar(&config.SkipUnreadable, "skip-unreadable", false, "Leave files that can't be read or analyzed where they are, instead of renaming them based on the filename alone")
+	flag.BoolVar(&config.IncludeEmpty, "include-empty", false, "Rename and move zero-byte files and files too small to hold audio too; by default they're tagged empty or corrupt and left where they are")
+	flag.BoolVar(&config.NoAnalyze, "no-analyze", false, "Skip audio analysis and name and categorize files from their filenames alone; much faster, but without duration, format or channel tags and without duplicate detection")
+	flag.IntVar(&config.Workers, "workers", runtime.NumCPU(), "Number of parallel workers for audio analysis")
+	flag.BoolVar(&config.Resume, "resume", false, "Reuse analysis of unchanged files from a previous (possibly interrupted) run")
+	flag.BoolVar(&config.Dedupe, "dedupe", false, "Keep only the best file of each duplicate group")
+	flag.StringVar(&config.KeepPolicy, "keep-policy", keepQuality, "Which copy of a duplicate group to suggest keeping (and keep with -dedupe): quality (sample rate, bit depth, duration), newest or oldest")
+	flag.StringVar(&config.DedupeAction, "dedupe-action", dedupeMove, "What to do with dropped duplicates: move (into _duplicates/) or delete")
+	flag.IntVar(&config.DupThreshold, "dup-threshold", 0, "Group WAV and AIFF files whose perceptual hashes differ in at most this many of 64 bits as near-duplicates, like 6 (default: 0, off)")
+	flag.BoolVar(&config.RenumberRounds, "renumber-rounds", false, "Renumber each round of numbered variations (step_1, step_2, step_10) into a gapless _01, _02, _03 sequence")
+	flag.StringVar(&folderMap, "folder-map", "", "Category to output folder mapping: a file of Category=Folder lines, or inline pairs like \"SFX_Voice=Dialogue,Ambient=Ambience\"")
+	flag.StringVar(&prefix, "prefix", defaultAssetPrefix, "Asset prefix every new name starts with, like SW; -prefix \"\" for none, for engines other than UE5")
+	flag.StringVar(&prefixMap, "prefix-map", "", "Category to asset prefix mapping (other categories get -prefix): a file of Category=Prefix lines, or inline pairs like \"SFX_Voice=DLG,Music=MUS\"")
+	flag.StringVar(&overridesFile, "overrides", "", "CSV file of pattern,new_name,category rows that force the name and/or category of matching files")
+	flag.BoolVar(&config.Incremental, "incremental", false, "Only process files not already in the output's manifest.json, and append them to it")
+	flag.BoolVar(&config.MergeOutput, "merge-output", false, "Merge into an organized library: scan the output directory first so new names never clash with assets already there, even ones that only differ in case or extension")
+	flag.DurationVar(&config.FingerprintPrecision, "fingerprint-precision", tidy.DefaultFingerprintPrecision, "Step durations are rounded to when fingerprinting files for duplicate detection, like 10ms or 1s; coarser steps also group copies that were trimmed or padded slightly, but risk grouping different sounds of similar length")
+	flag.DurationVar(&config.AnalysisWindow, "analysis-window", tidy.DefaultAnalysisWindow, "How much of the start of each WAV and AIFF file spectral analysis reads, like 500ms or 5s; for WAV, memory use stays the same whatever the sample rate or channel count")
+	flag.Float64Var(&config.SilenceThreshold, "silence-threshold", tidy.DefaultSilenceThreshold, "Level in dBFS below which leading/trailing audio counts as silence for trim suggestions")
+	flag.StringVar(&config.LoudnessTarget, "loudness-target", "", "Suggest the gain that would bring each WAV file to this sample peak or integrated loudness, like -1dBFS or -23LUFS; files more than 6 dB off are tagged loudness-off-target (the audio is never changed)")
+	flag.StringVar(&config.CategorizerCmd, "categorizer-cmd", "", "External command to categorize each file: gets the path as its last argument and metadata JSON on stdin, prints {\"category\": ..., \"confidence\": ...}; used when at least -confidence-threshold")
+	flag.DurationVar(&config.CategorizerTimeout, "categorizer-timeout", defaultCategorizerTimeout, "How long -categorizer-cmd gets per file before the built-in inference is used instead")
+	flag.StringVar(&scoringFile, "scoring", "", "JSON file of analysis thresholds (hq tags, spectral scoring) to tune categorization; fields left out keep their defaults")
+	flag.StringVar(&config.OnCollision, "on-collision", collisionNumber, "What to do when an output name is already taken: number, skip, overwrite, or fail")
+	flag.StringVar(&config.DupSuffix, "dup-suffix", defaultDupSuffix, "Format of the number -on-collision number adds to a taken name, with one %d verb: _%02d (_01), _v%02d (_v01), .%02d (.01) or _%03d (_001)")
+	flag.StringVar(&config.Sort, "sort", sortCategory, "Order of the preview and manifest: category (then name), name, duration, or original (source path)")
+	flag.IntVar(&config.MaxNameLength, "max-name-length", 0, "Longest new name (without extension), like 64; longer names lose subcategory words from the end, then get cut off, and are tagged truncated (default: no limit)")
+	flag.BoolVar(&config.SkipSpaceCheck, "skip-space-check", false, "Don't check that the output volume has room for files that have to be copied there from another drive")
+	flag.BoolVar(&config.WriteTags, "write-tags", false, "Write the category (genre) and tags (comment) into each renamed WAV or MP3 file's metadata")
+	flag.BoolVar(&config.Sidecar, "sidecar", false, "Write a <file>.meta.json next to each output file with its category, tags, confidence, original path and audio metadata")
+	flag.StringVar(&dirMode, "dir-mode", "", "Octal permissions of the directories created in the output, like 0775 for group-writable folders on a shared drive (default 0755)")
+	flag.StringVar(&fileMode, "file-mode", "", "Octal permissions of the files put in the output, moved audio files included, like 0664 (default: moved files keep theirs, new ones are 0644)")
+	flag.BoolVar(&config.PreserveTimes, "preserve-times", true, "Keep each file's original modification and access times when it's copied across volumes or rewritten by -write-tags")
+	flag.StringVar(&config.Format, "format", formatText, "Output format: text, or json for one JSON object per file on stdout (no preview or progress bar)")
+	flag.BoolVar(&config.Quiet, "quiet", false, "Only print warnings, errors and the final summary (no progress bar or preview)")
+	flag.BoolVar(&config.Verbose, "verbose", false, "Also print how each filename was parsed and why its category was chosen")
+	flag.BoolVar(&config.NoProgress, "no-progress", false, "Don't show progress; without a terminal (CI, redirected output) progress is printed as a plain line every 10% instead of a bar")
+	flag.Float64Var(&config.ConfidenceThreshold, "confidence-threshold", defaultConfidenceThreshold, "Tag files categorized with less than this confidence (0-1) as low-confidence")
+	flag.Float64Var(&config.MinConfidence, "min-confidence", 0, "Put files categorized with less than this confidence (0-1) in -fallback-category instead, marked unresolved")
+	flag.StringVar(&config.FallbackCategory, "fallback-category", defaultFallbackCategory, "Category for files below -min-confidence")
+	flag.BoolVar(&config.Strict, "strict", false, "Fail before renaming anything (with exit status 1) when more than -strict-ratio of the files are below -min-confidence or left in the generic SFX category, and list them")
+	flag.Float64Var(&config.StrictRatio, "strict-ratio", 0, "Fraction of uncategorized files -strict tolerates, from 0 (none) to 1")
+	flag.StringVar(&onlyCategory, "only-category", "", "Comma-separated categories to process, like SFX or Impact,Voice; files in other categories are left where they are")
+	flag.StringVar(&excludeCategory, "exclude-category", "", "Comma-separated categories to leave where they are, like Music,Ambient")
+	flag.StringVar(&extensions, "extensions", "", "Comma-separated file extensions to process, like \".wav,.aiff\"; start with + to add to the defaults instead of replacing them")
+	flag.StringVar(&config.DataTable, "datatable", "", "Write a UE5 DataTable CSV (row name, Category, Duration, LoopStart, LoopEnd, Tags) of the analyzed files to this file after renaming")
+	flag.BoolVar(&config.PreferTags, "prefer-tags", false, "Name files with an embedded title tag after the title instead of the filename, for libraries of track01.wav style names")
+	flag.BoolVar(&config.TagArtist, "tag-artist", false, "With -prefer-tags, put the artist tag before the title")
+	flag.BoolVar(&config.FollowSymlinks, "follow-symlinks", false, "Scan symlinked files and folders instead of skipping them; a linked file is renamed by moving the link, its target is never touched")
+	flag.BoolVar(&config.Catalog, "catalog", false, "Only index the library: analyze and categorize every file and write the manifest, without renaming or moving anything")
+	flag.BoolVar(&config.Verify, "verify", false, "Check an organized library against its manifest without changing anything: report missing, moved, changed and added files, and exit with status 1 if there are any (use the -output, -layout and naming options of the run that wrote it)")
+	flag.BoolVar(&config.RulesReport, "rules-report", false, "Print how many files each category rule and keyword matched, how many fell back to SFX, and the keywords that never matched")
+	flag.BoolVar(&config.TagReport, "tag-report", false, "Print every distinct tag with the number of files carrying it, most used first, and add it to the manifest as tag_histogram")
+	flag.StringVar(&config.ReportPath, "report", "", "With -dry-run, write the planned renames with category and confidence to this file (JSON if it ends in .json, text otherwise)")
+	flag.StringVar(&config.HTMLReport, "html", "", "Write an HTML page of the run's results to this file: files grouped by category with old and new names, durations, confidence and tags, plus category counts and duplicate groups")
+	flag.IntVar(&config.RequireSampleRate, "require-sample-rate", 0, "Sample rate in Hz every file should have, like 48000; others are tagged or skipped (see -sample-rate-action)")
+	flag.StringVar(&config.SampleRateAction, "sample-rate-action", sampleRateTag, "What to do with files not at -require-sample-rate: tag (sample-rate-mismatch) or skip (leave in place)")
+	flag.BoolVar(&config.BPMInName, "bpm-in-name", false, "Append the detected tempo of music and loops to their new names, like A_Pack_Music_Theme_120BPM.wav")
+	flag.BoolVar(&config.Transliterate, "transliterate", false, "Turn accented letters in names into plain ASCII (é to e, ß to ss) instead of dropping them, and replace words with no ASCII equivalent (like CJK) with a placeholder")
+	flag.StringVar(&config.Case, "case", caseTitle, "Case style for the pack name and name parts: title (Door_Slam), camel (doorSlam), pascal (DoorSlam), snake (door_slam), or upper (DOOR_SLAM)")
+	flag.StringVar(&config.Template, "template", defaultNameTemplate, "How new names are built: {pack}, {category}, {subcategory}, {source}, {id}, {bpm}, {key}, {scene}, {take}, {version} and {date} joined by underscores; parts whose tokens are empty are left out")
+	flag.StringVar(&config.PackVersion, "pack-version", "", "Pack version for the {version} token, like 2 in a template with v{version}")
+	flag.StringVar(&config.DateFormat, "date-format", defaultDateFormat, "Go time layout for the {date} token, like 20060102 or 2006_01")
+	flag.StringVar(&config.Profile, "profile", "", "Vendor filename schema to parse names with: ucs or boom; names that don't fit it fall back to the default parsing")
+	flag.StringVar(&config.SourcePattern, "source-pattern", "", "Regular expression for the last underscore segment to count as a source/library code, in addition to all-caps or short alphanumeric codes")
+	flag.Var(&idPatterns, "id-pattern", "Regular expression for a trailing catalog ID to take out of names, with a group around the ID, like _(\\d{3,})$; repeat the flag for several, tried in order before the built-in .12345 and _12345 (5+ digits)")
+	flag.StringVar(&configFile, "config", "", "YAML file of option defaults, keyed by flag name; flags given on the command line win (default: $"+configFileEnv+", then "+defaultConfigFile+")")
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
 	flag.BoolVar(&showVersion, "v", false, "Show version information (shorthand)")
 	flag.Parse()
@@ -51,28 +311,364 @@ func main() {
 		os.Exit(0)
 	}
 
+	configPath, configSource, err := findConfigFile(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: config file: %v\n", err)
+		os.Exit(1)
+	}
+	if configPath != "" {
+		values, err := loadConfigFile(configPath)
+		if err == nil {
+			err = applyConfigFile(flag.CommandLine, values)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: config file %s: %v\n", configPath, err)
+			os.Exit(1)
+		}
+		config.ConfigFile = fmt.Sprintf("%s (from %s)", configPath, configSource)
+	}
+
+	config.SourceDirs = sources
+	if len(sources) > 0 {
+		config.SourceDir = sources[0]
+	}
+
 	if config.SourceDir == "" {
 		fmt.Fprintf(os.Stderr, "Error: -source flag is required\n")
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	if config.PackName == "" {
+	if config.PackName == "" && !config.Verify && !config.Catalog {
 		fmt.Fprintf(os.Stderr, "Error: -pack flag is required\n")
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	if config.Workers < 1 {
+		fmt.Fprintf(os.Stderr, "Error: -workers must be at least 1\n")
+		os.Exit(1)
+	}
+
+	if config.DupThreshold < 0 || config.DupThreshold > 64 {
+		fmt.Fprintf(os.Stderr, "Error: -dup-threshold must be between 0 and 64 bits\n")
+		os.Exit(1)
+	}
+
+	if !validKeepPolicy(config.KeepPolicy) {
+		fmt.Fprintf(os.Stderr, "Error: -keep-policy must be %q, %q or %q\n", keepQuality, keepNewest, keepOldest)
+		os.Exit(1)
+	}
+
+	contentRoot, err := parseContentRoot(config.ContentRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -content-root: %v\n", err)
+		os.Exit(1)
+	}
+	config.ContentRoot = contentRoot
+
+	if config.DirMode, err = parseMode(dirMode); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -dir-mode: %v\n", err)
+		os.Exit(1)
+	}
+	if config.FileMode, err = parseMode(fileMode); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -file-mode: %v\n", err)
+		os.Exit(1)
+	}
+
+	if config.DedupeAction != dedupeMove && config.DedupeAction != dedupeDelete {
+		fmt.Fprintf(os.Stderr, "Error: -dedupe-action must be %q or %q\n", dedupeMove, dedupeDelete)
+		os.Exit(1)
+	}
+
+	if config.Format != formatText && config.Format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Error: -format must be %q or %q\n", formatText, formatJSON)
+		os.Exit(1)
+	}
+
+	if config.Quiet && config.Verbose {
+		fmt.Fprintf(os.Stderr, "Error: -quiet and -verbose can't be used together\n")
+		os.Exit(1)
+	}
+
+	if config.ConfidenceThreshold < 0 || config.ConfidenceThreshold > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -confidence-threshold must be between 0 and 1\n")
+		os.Exit(1)
+	}
+
+	if config.MinConfidence < 0 || config.MinConfidence > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -min-confidence must be between 0 and 1\n")
+		os.Exit(1)
+	}
+
+	if config.StrictRatio < 0 || config.StrictRatio > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -strict-ratio must be between 0 and 1\n")
+		os.Exit(1)
+	}
+
+	if config.MinConfidence > 0 && strings.TrimSpace(config.FallbackCategory) == "" {
+		fmt.Fprintf(os.Stderr, "Error: -fallback-category can't be empty\n")
+		os.Exit(1)
+	}
+
+	if config.Catalog {
+		conflicts := []struct {
+			flag string
+			set  bool
+		}{
+			{"-dry-run", config.DryRun},
+			{"-dedupe", config.Dedupe},
+			{"-interactive", config.Interactive || config.InteractivePerCategory},
+			{"-manifest-per-category", config.ManifestPerCategory},
+			{"-datatable", config.DataTable != ""},
+			{"-verify", config.Verify},
+		}
+		for _, c := range conflicts {
+			if c.set {
+				fmt.Fprintf(os.Stderr, "Error: -catalog doesn't rename anything, so it can't be used with %s\n", c.flag)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if config.NoAnalyze {
+		conflicts := []struct {
+			flag string
+			set  bool
+		}{
+			{"-resume", config.Resume},
+			{"-dedupe", config.Dedupe},
+			{"-dup-threshold", config.DupThreshold > 0},
+			{"-prefer-tags", config.PreferTags},
+			{"-require-sample-rate", config.RequireSampleRate > 0},
+			{"-bpm-in-name", config.BPMInName},
+			{"-datatable", config.DataTable != ""},
+			{"-verify", config.Verify},
+		}
+		for _, c := range conflicts {
+			if c.set {
+				fmt.Fprintf(os.Stderr, "Error: %s needs audio analysis, so it can't be used with -no-analyze\n", c.flag)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if config.Sandbox {
+		conflicts := []struct {
+			flag string
+			set  bool
+		}{
+			{"-dry-run", config.DryRun},
+			{"-catalog", config.Catalog},
+			{"-interactive", config.Interactive || config.InteractivePerCategory},
+		}
+		for _, c := range conflicts {
+			if c.set {
+				fmt.Fprintf(os.Stderr, "Error: -sandbox can't be used with %s\n", c.flag)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if config.ReportPath != "" && !config.DryRun {
+		fmt.Fprintf(os.Stderr, "Error: -report only works with -dry-run\n")
+		os.Exit(1)
+	}
+
+	config.CategorizerCmd = strings.TrimSpace(config.CategorizerCmd)
+	if config.CategorizerCmd != "" {
+		if _, err := exec.LookPath(strings.Fields(config.CategorizerCmd)[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -categorizer-cmd: %v\n", err)
+			os.Exit(1)
+		}
+		if config.CategorizerTimeout <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: -categorizer-timeout must be positive\n")
+			os.Exit(1)
+		}
+	}
+
+	if config.RequireSampleRate < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -require-sample-rate must be a positive number of Hz\n")
+		os.Exit(1)
+	}
+
+	if config.SampleRateAction != sampleRateTag && config.SampleRateAction != sampleRateSkip {
+		fmt.Fprintf(os.Stderr, "Error: -sample-rate-action must be %q or %q\n", sampleRateTag, sampleRateSkip)
+		os.Exit(1)
+	}
+
+	if !validCaseStyle(config.Case) {
+		fmt.Fprintf(os.Stderr, "Error: -case must be one of %s, %s, %s, %s, %s\n", caseTitle, caseCamel, casePascal, caseSnake, caseUpper)
+		os.Exit(1)
+	}
+
+	if err := validateTemplate(config.Template); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -template: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !validCollisionStrategy(config.OnCollision) {
+		fmt.Fprintf(os.Stderr, "Error: -on-collision must be one of %s, %s, %s, %s\n", collisionNumber, collisionSkip, collisionOverwrite, collisionFail)
+		os.Exit(1)
+	}
+
+	if err := validateDupSuffix(config.DupSuffix); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -dup-suffix: %v\n", err)
+		os.Exit(1)
+	}
+
+	if config.MaxNameLength != 0 && config.MaxNameLength < 16 {
+		fmt.Fprintf(os.Stderr, "Error: -max-name-length must be at least 16\n")
+		os.Exit(1)
+	}
+
+	if !validSortOrder(config.Sort) {
+		fmt.Fprintf(os.Stderr, "Error: -sort must be one of %s, %s, %s, %s\n", sortCategory, sortName, sortDuration, sortOriginal)
+		os.Exit(1)
+	}
+
+	if config.FingerprintPrecision <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -fingerprint-precision must be positive\n")
+		os.Exit(1)
+	}
+
+	if config.AnalysisWindow <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -analysis-window must be positive\n")
+		os.Exit(1)
+	}
+
+	if config.LoudnessTarget != "" {
+		if _, err := tidy.ParseLoudnessTarget(config.LoudnessTarget); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -loudness-target: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if config.SilenceThreshold >= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -silence-threshold must be below 0 dBFS\n")
+		os.Exit(1)
+	}
+
+	if config.Layout, err = parseLayout(layout, organize); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -layout: %v\n", err)
+		os.Exit(1)
+	}
+
+	if config.GroupBy, err = parseGroupBy(groupBy); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -group-by: %v\n", err)
+		os.Exit(1)
+	}
+	if config.GroupBy != groupCategory && config.Layout != layoutCategory {
+		fmt.Fprintf(os.Stderr, "Error: -group-by needs -layout category\n")
+		os.Exit(1)
+	}
+
+	if config.Profile != "" {
+		if _, err := lookupProfile(config.Profile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -profile: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if config.SourcePattern != "" {
+		if _, err := regexp.Compile(config.SourcePattern); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -source-pattern: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	config.IDPatterns = idPatterns
+	if _, err := compileIDPatterns(config.IDPatterns); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -id-pattern: %v\n", err)
+		os.Exit(1)
+	}
+
+	if config.ManifestPerCategory && (config.Layout != layoutCategory || config.GroupBy != groupCategory) {
+		fmt.Fprintf(os.Stderr, "Error: -manifest-per-category needs -layout category and -group-by category\n")
+		os.Exit(1)
+	}
+
+	if config.Extensions, err = parseExtensions(extensions); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -extensions: %v\n", err)
+		os.Exit(1)
+	}
+
+	if config.FolderMap, err = parseFolderMap(folderMap); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -folder-map: %v\n", err)
+		os.Exit(1)
+	}
+	if len(config.FolderMap) > 0 && config.GroupBy != groupCategory {
+		fmt.Fprintf(os.Stderr, "Error: -folder-map only applies with -group-by category\n")
+		os.Exit(1)
+	}
+
+	config.AssetPrefix = strings.TrimSuffix(prefix, "_")
+	config.NoPrefix = config.AssetPrefix == ""
+	if !config.NoPrefix && !assetPrefixPattern.MatchString(config.AssetPrefix) {
+		fmt.Fprintf(os.Stderr, "Error: -prefix %q: use letters and digits starting with a letter\n", prefix)
+		os.Exit(1)
+	}
+
+	if config.PrefixMap, err = parsePrefixMap(prefixMap); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -prefix-map: %v\n", err)
+		os.Exit(1)
+	}
+
+	if overridesFile != "" {
+		if config.Overrides, err = loadOverrides(overridesFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -overrides: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if scoringFile != "" {
+		scoring, err := tidy.LoadScoringConfig(scoringFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -scoring: %v\n", err)
+			os.Exit(1)
+		}
+		config.Scoring = &scoring
+	}
+
+	if maxDepth >= 0 {
+		config.MaxDepth = &maxDepth
+	}
+
+	config.Include = splitList(include)
+	config.Exclude = splitList(exclude)
+	config.OnlyCategories = splitList(onlyCategory)
+	config.ExcludeCategories = splitList(excludeCategory)
+
 	if config.OutputDir == "" {
 		config.OutputDir = config.SourceDir // default to same as source
 	}
 
-	if _, err := os.Stat(config.SourceDir); os.IsNotExist(err) {
-		log.Fatalf("Error: Source directory does not exist: %s", config.SourceDir)
+	// the state is a cache, it doesn't belong in the library and dry runs can write it too
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		config.StateDir = filepath.Join(cacheDir, "tidy-rename")
+	}
+
+	for _, dir := range config.SourceDirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			log.Fatalf("Error: Source directory does not exist: %s", dir)
+		}
 	}
 
 	processor := NewAudioProcessor(config)
-	if err := processor.Process(); err != nil {
+	if config.Verify {
+		problems, err := processor.Verify()
+		if err != nil {
+			log.Fatalf("Error verifying library: %v", err)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if err := processor.Process(interruptContext()); err != nil {
+		if errors.Is(err, context.Canceled) {
+			os.Exit(exitInterrupted) // what was done is already reported
+		}
 		log.Fatalf("Error processing files: %v", err)
 	}
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// encodeManifest writes fields plus a "files" array as indented JSON, exactly
+// as json.MarshalIndent with two spaces would (plus a trailing newline), but
+// encodes the files one at a time so a 50k-file manifest is never in memory
+// as a whole
+func encodeManifest(w io.Writer, fields map[string]interface{}, files []AudioFile) error {
+	keys := make([]string, 0, len(fields)+1)
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	keys = append(keys, "files")
+	sort.Strings(keys) // same order a marshalled map gets
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("{\n")
+	for i, key := range keys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		bw.WriteString("  ")
+		bw.Write(name)
+		bw.WriteString(": ")
+
+		if key == "files" {
+			if err := encodeFiles(bw, files); err != nil {
+				return err
+			}
+		} else {
+			value, err := json.MarshalIndent(fields[key], "  ", "  ")
+			if err != nil {
+				return err
+			}
+			bw.Write(value)
+		}
+
+		if i < len(keys)-1 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// encodeFiles writes the files array as the second level of an indented manifest
+func encodeFiles(bw *bufio.Writer, files []AudioFile) error {
+	if files == nil {
+		bw.WriteString("null")
+		return nil
+	}
+	if len(files) == 0 {
+		bw.WriteString("[]")
+		return nil
+	}
+
+	bw.WriteString("[\n")
+	for i := range files {
+		data, err := json.MarshalIndent(&files[i], "    ", "  ")
+		if err != nil {
+			return err
+		}
+		bw.WriteString("    ")
+		bw.Write(data)
+		if i < len(files)-1 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString("  ]")
+	return nil
+}
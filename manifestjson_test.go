@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestEncodeManifestMatchesMarshalIndent(t *testing.T) {
+	files := []AudioFile{
+		{OriginalName: "door_creak.wav", NewName: "A_TestPack_Object_Door_Creak.wav", Category: "SFX_Object", Tags: []string{"door", "<creak>"}},
+		{OriginalName: "boom.wav", NewName: "A_TestPack_Impact_Boom.wav", Category: "SFX_Impact", AudioMeta: &tidy.AudioMetadata{SampleRate: 48000, Duration: time.Second}},
+	}
+
+	tests := []struct {
+		name   string
+		fields map[string]interface{}
+		files  []AudioFile
+	}{
+		{"files", map[string]interface{}{"total_files": 2, "categories": getCategoryStats(files), "renames": countRenames(files)}, files},
+		{"no files", map[string]interface{}{"total_files": 0}, []AudioFile{}},
+		{"nil files", map[string]interface{}{"total_files": 0}, nil},
+		{"only files", map[string]interface{}{}, files[:1]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whole := map[string]interface{}{"files": tt.files}
+			for key, value := range tt.fields {
+				whole[key] = value
+			}
+			want, err := json.MarshalIndent(whole, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, '\n')
+
+			var got bytes.Buffer
+			if err := encodeManifest(&got, tt.fields, tt.files); err != nil {
+				t.Fatalf("encodeManifest() error = %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("encodeManifest() =\n%s\nwant\n%s", got.Bytes(), want)
+			}
+		})
+	}
+}
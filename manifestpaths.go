@@ -0,0 +1,92 @@
+package main
+
+import "path/filepath"
+
+// portableFiles returns files the way a manifest records them: with paths
+// relative to their source and to the output directory, so the manifest is
+// the same on every machine and -verify works wherever the library is
+// mounted. The absolute original paths and source directories are only kept
+// with -absolute-paths, otherwise source_dir is relative to the output too.
+func (ap *AudioProcessor) portableFiles(files []AudioFile) []AudioFile {
+	portable := make([]AudioFile, len(files))
+	for i, af := range files {
+		// files from an earlier manifest without absolute paths already have theirs
+		if af.OriginalPath != "" {
+			if af.RelativePath == "" {
+				af.RelativePath = ap.relPath(af.OriginalPath)
+			}
+			if af.NewRelativePath == "" {
+				af.NewRelativePath = ap.newRelativePath(&af)
+			}
+			if !ap.config.AbsolutePaths {
+				af.OriginalPath = ""
+				if af.SourceDir != "" {
+					af.SourceDir = ap.outputRelative(af.SourceDir)
+				}
+				if af.DuplicateOf != "" {
+					af.DuplicateOf = ap.relPath(af.DuplicateOf)
+				}
+			}
+		}
+		if af.ContentPath == "" {
+			af.ContentPath = ap.contentPath(&af)
+		}
+		portable[i] = af
+	}
+	return portable
+}
+
+// newRelativePath is where the run left a file, relative to the output
+// directory, or "" for a duplicate it deleted
+func (ap *AudioProcessor) newRelativePath(af *AudioFile) string {
+	if af.DuplicateStatus == duplicateDropped && ap.config.DedupeAction == dedupeDelete {
+		return ""
+	}
+	return ap.outputRelative(ap.recordedPath(af))
+}
+
+// outputRelative returns path relative to the output directory, with forward
+// slashes, or "" if there's no way there
+func (ap *AudioProcessor) outputRelative(path string) string {
+	// walk paths and -output may be relative to different places
+	out, err := filepath.Abs(ap.config.OutputDir)
+	if err != nil {
+		return ""
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(out, path)
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// portableGroups makes the paths in duplicate groups relative to their source
+func (ap *AudioProcessor) portableGroups(groups []DuplicateGroup) []DuplicateGroup {
+	if ap.config.AbsolutePaths {
+		return groups
+	}
+	portable := make([]DuplicateGroup, len(groups))
+	for i, group := range groups {
+		portable[i] = DuplicateGroup{Keep: ap.relPath(group.Keep)}
+		for _, path := range group.Redundant {
+			portable[i].Redundant = append(portable[i].Redundant, ap.relPath(path))
+		}
+	}
+	return portable
+}
+
+// portableErrors makes the paths of failed files relative to their source
+func (ap *AudioProcessor) portableErrors(failed []fileError) []fileError {
+	if ap.config.AbsolutePaths {
+		return failed
+	}
+	portable := make([]fileError, len(failed))
+	for i, fe := range failed {
+		portable[i] = fileError{Path: ap.relPath(fe.Path), Error: fe.Error}
+	}
+	return portable
+}
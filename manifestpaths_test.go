@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestRelativePaths(t *testing.T) {
+	for _, absolute := range []bool{false, true} {
+		src := t.TempDir()
+		out := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(src, "doors"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		original := filepath.Join(src, "doors", "door_creak.wav")
+		if err := os.WriteFile(original, buildTestWAV(48000, 1, 16, make([]byte, 48000*2)), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		config := Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, CreateManifest: true, AbsolutePaths: absolute, Workers: 1}
+		ap := NewAudioProcessor(config)
+		ap.log.out = io.Discard
+		if err := ap.Process(context.Background()); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+
+		data, err := os.ReadFile(ap.manifestPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var manifest struct {
+			Files []AudioFile `json:"files"`
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatal(err)
+		}
+		if len(manifest.Files) != 1 {
+			t.Fatalf("absolute=%v: manifest has %d files, want 1", absolute, len(manifest.Files))
+		}
+		got := manifest.Files[0]
+
+		if got.RelativePath != "doors/door_creak.wav" {
+			t.Errorf("absolute=%v: relative_path = %q, want doors/door_creak.wav", absolute, got.RelativePath)
+		}
+		if want := filepath.ToSlash(filepath.Join(ap.layoutDir(&ap.audioFiles[0], layoutCategory), ap.audioFiles[0].NewName)); got.NewRelativePath != want {
+			t.Errorf("absolute=%v: new_relative_path = %q, want %q", absolute, got.NewRelativePath, want)
+		}
+
+		if absolute {
+			if got.OriginalPath != original || got.SourceDir != src {
+				t.Errorf("absolute=true: OriginalPath = %q, source_dir = %q, want %q and %q", got.OriginalPath, got.SourceDir, original, src)
+			}
+			continue
+		}
+		if got.OriginalPath != "" {
+			t.Errorf("absolute=false: OriginalPath = %q, want none", got.OriginalPath)
+		}
+		if strings.Contains(string(data), src) || strings.Contains(string(data), out) {
+			t.Errorf("absolute=false: manifest mentions an absolute path:\n%s", data)
+		}
+	}
+}
+
+func TestVerifyMovedLibrary(t *testing.T) {
+	src := t.TempDir()
+	out := filepath.Join(t.TempDir(), "library")
+	for _, name := range []string{"door_creak.wav", "wind_howl.wav"} {
+		if err := os.WriteFile(filepath.Join(src, name), buildTestWAV(48000, 1, 16, make([]byte, 48000*2)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, CreateManifest: true, Workers: 2}
+	ap := NewAudioProcessor(config)
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	// the library is mounted somewhere else now, and the paths come from the
+	// manifest rather than from -layout
+	moved := filepath.Join(t.TempDir(), "mounted")
+	if err := os.Rename(out, moved); err != nil {
+		t.Fatal(err)
+	}
+	config.OutputDir = moved
+	config.Layout = layoutFlat
+	v := NewAudioProcessor(config)
+	v.log.out = io.Discard
+	problems, err := v.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("moved library has discrepancies: %+v", problems)
+	}
+}
+
+func TestManifestContentPath(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, ContentRoot: "/Game/Audio"})
+	ap.audioFiles = []AudioFile{
+		{OriginalPath: filepath.Join(src, "door_creak.wav"), OriginalName: "door_creak.wav", Category: "SFX_Object", NewName: "A_TestPack_Object_Door_Creak.wav"},
+		{OriginalPath: filepath.Join(src, "taken.wav"), OriginalName: "taken.wav", Category: "SFX_Object", NewName: "A_TestPack_Object_Taken.wav", SkipReason: skipNameTaken},
+	}
+
+	files := ap.portableFiles(ap.audioFiles)
+	if want := "/Game/Audio/Sfx_Object/A_TestPack_Object_Door_Creak"; files[0].ContentPath != want {
+		t.Errorf("content_path = %q, want %q", files[0].ContentPath, want)
+	}
+	if files[1].ContentPath != "" {
+		t.Errorf("skipped file has content_path %q, want none", files[1].ContentPath)
+	}
+}
+
+func TestParseContentRoot(t *testing.T) {
+	tests := []struct {
+		root    string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"/Game/Audio", "/Game/Audio", false},
+		{"/Game/Audio/", "/Game/Audio", false},
+		{"/MyPlugin", "/MyPlugin", false},
+		{"Game/Audio", "", true},
+		{`\Game\Audio`, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseContentRoot(tt.root)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseContentRoot(%q) = %q, %v, want %q (error: %v)", tt.root, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// indexOutput lists the audio already in the output directory for
+// -merge-output, by asset key. Files of this batch that are in there (when the
+// source is the output) don't count, they're about to get new names anyway.
+func (ap *AudioProcessor) indexOutput() error {
+	found, err := ap.scanLibrary()
+	if errors.Is(err, fs.ErrNotExist) {
+		found, err = nil, nil // nothing there yet
+	}
+	if err != nil {
+		return err
+	}
+
+	batch := make(map[string]bool, len(ap.audioFiles))
+	for _, af := range ap.audioFiles {
+		batch[absPath(af.OriginalPath)] = true
+	}
+
+	ap.existingAssets = make(map[string]bool, len(found))
+	for path := range found {
+		if !batch[absPath(path)] {
+			ap.existingAssets[ap.assetKey(path)] = true
+		}
+	}
+	if len(ap.existingAssets) > 0 {
+		ap.log.Infof("Merging into %d files already in %s\n", len(ap.existingAssets), ap.config.OutputDir)
+	}
+	return nil
+}
+
+// assetKey is a path in the output directory the way UE5 sees the asset:
+// relative, without the extension and ignoring case, so Door.wav clashes
+// with door.WAV and with Door.mp3
+func (ap *AudioProcessor) assetKey(path string) string {
+	rel, err := filepath.Rel(absPath(ap.config.OutputDir), absPath(path))
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	return strings.ToLower(strings.TrimSuffix(rel, filepath.Ext(rel)))
+}
+
+// clashesWithLibrary reports whether a file's new name is taken by an asset
+// that was in the output directory before the run, with -merge-output
+func (ap *AudioProcessor) clashesWithLibrary(af *AudioFile) bool {
+	return ap.existingAssets[ap.assetKey(ap.outputPath(af))]
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeOutput(t *testing.T) {
+	for _, merge := range []bool{false, true} {
+		src, out := t.TempDir(), t.TempDir()
+		for _, name := range []string{"door_creak.wav", "wind_howl.wav", "glass_break.wav"} {
+			if err := os.WriteFile(filepath.Join(src, name), buildTestWAV(48000, 1, 16, make([]byte, 4800*len(name))), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// the library already has these assets, under another extension and case
+		for _, name := range []string{"A_TestPack_Object_Door_Creak.mp3", "a_testpack_ambient_wind_howl.wav"} {
+			if err := os.WriteFile(filepath.Join(out, name), []byte("existing"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutFlat, MergeOutput: merge, DryRun: true, Workers: 1})
+		ap.log.out = io.Discard
+		if err := ap.Process(context.Background()); err != nil {
+			t.Fatalf("merge=%v: Process() error = %v", merge, err)
+		}
+
+		numbered := map[string]bool{}
+		for _, af := range ap.audioFiles {
+			numbered[af.OriginalName] = strings.HasSuffix(af.NewName, "_01.wav")
+		}
+		for name, clashes := range map[string]bool{"door_creak.wav": true, "wind_howl.wav": true, "glass_break.wav": false} {
+			if want := merge && clashes; numbered[name] != want {
+				t.Errorf("merge=%v: %s numbered = %v, want %v", merge, name, numbered[name], want)
+			}
+		}
+	}
+}
+
+func TestMergeIntoMissingOutput(t *testing.T) {
+	ap := NewAudioProcessor(Config{OutputDir: filepath.Join(t.TempDir(), "library"), MergeOutput: true})
+	ap.log.out = io.Discard
+	if err := ap.indexOutput(); err != nil {
+		t.Fatalf("indexOutput() error = %v", err)
+	}
+	if len(ap.existingAssets) != 0 {
+		t.Errorf("indexed %d assets in a directory that doesn't exist", len(ap.existingAssets))
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+// detectNearDuplicates groups files whose perceptual hashes are at most
+// -dup-threshold bits apart and tags them near-duplicate. Files that are
+// exact duplicates of each other don't count, detectDuplicates already found
+// those; files that only share a fingerprint do. Groups are numbered in file order, which is the scan order.
+// Only WAV and AIFF files are decoded and hashed, so MP3, OGG and the other
+// compressed formats are never near-duplicate candidates.
+func (ap *AudioProcessor) detectNearDuplicates() {
+	threshold := ap.config.DupThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	// parsed once up front, there are n²/2 pairs to compare
+	var hashed []int
+	var hashes []uint64 // hashes[x] belongs to hashed[x]
+	for i, af := range ap.audioFiles {
+		if af.AudioMeta == nil {
+			continue
+		}
+		if hash, ok := tidy.ParseHash(af.AudioMeta.PerceptualHash); ok {
+			hashed = append(hashed, i)
+			hashes = append(hashes, hash)
+		}
+	}
+
+	duplicateGroup := make(map[int]int)
+	for n, group := range ap.duplicateGroups {
+		for _, idx := range group {
+			duplicateGroup[idx] = n + 1
+		}
+	}
+
+	// union-find over every close pair
+	parent := make(map[int]int, len(hashed))
+	var find func(int) int
+	find = func(i int) int {
+		if p, ok := parent[i]; ok && p != i {
+			parent[i] = find(p)
+			return parent[i]
+		}
+		return i
+	}
+	for x, i := range hashed {
+		for y := x + 1; y < len(hashed); y++ {
+			j := hashed[y]
+			if duplicateGroup[i] != 0 && duplicateGroup[i] == duplicateGroup[j] || ap.sameRound(i, j) {
+				continue
+			}
+			if bits.OnesCount64(hashes[x]^hashes[y]) <= threshold {
+				if ri, rj := find(i), find(j); ri != rj {
+					parent[max(ri, rj)] = min(ri, rj)
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for _, i := range hashed {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	roots := make([]int, 0, len(members))
+	for root, group := range members {
+		if len(group) > 1 {
+			roots = append(roots, root)
+		}
+	}
+	sort.Ints(roots)
+
+	for n, root := range roots {
+		group := members[root]
+		ap.nearDuplicateGroups = append(ap.nearDuplicateGroups, group)
+		for _, idx := range group {
+			ap.audioFiles[idx].Tags = append(ap.audioFiles[idx].Tags, "near-duplicate", fmt.Sprintf("near-duplicate-group-%d", n+1))
+		}
+	}
+	if len(roots) > 0 {
+		ap.log.Warnf("⚠ Found %d near-duplicate file groups (similar audio)\n", len(roots))
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestDetectNearDuplicates(t *testing.T) {
+	files := []AudioFile{
+		{OriginalName: "hit.wav", AudioMeta: &tidy.AudioMetadata{PerceptualHash: "00000000000000ff", Fingerprint: "a"}},
+		{OriginalName: "hit_mp3ish.wav", AudioMeta: &tidy.AudioMetadata{PerceptualHash: "00000000000000fc", Fingerprint: "b"}}, // 2 bits off
+		{OriginalName: "whoosh.wav", AudioMeta: &tidy.AudioMetadata{PerceptualHash: "ff00ff00ff00ff00", Fingerprint: "c"}},
+		{OriginalName: "whoosh_copy.wav", AudioMeta: &tidy.AudioMetadata{PerceptualHash: "ff00ff00ff00ff00", Fingerprint: "c"}}, // exact duplicate
+		{OriginalName: "unhashed.wav", AudioMeta: &tidy.AudioMetadata{Fingerprint: "d"}},
+		{OriginalName: "garbled.wav", AudioMeta: &tidy.AudioMetadata{PerceptualHash: "not hex", Fingerprint: "e"}}, // never pairs up
+	}
+
+	tests := []struct {
+		threshold  int
+		wantGroups int
+	}{
+		{0, 0}, // off
+		{1, 0},
+		{2, 1},
+		{64, 1}, // everything hashed is close, but exact duplicates don't pair up
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(Config{DupThreshold: tt.threshold})
+		ap.log.out = io.Discard
+		ap.audioFiles = append([]AudioFile{}, files...)
+		ap.duplicateGroups = [][]int{{2, 3}} // what detectDuplicates makes of the copy
+		for i := range ap.audioFiles {
+			ap.audioFiles[i].Tags = nil
+		}
+
+		ap.detectNearDuplicates()
+		if len(ap.nearDuplicateGroups) != tt.wantGroups {
+			t.Errorf("threshold %d: %d groups, want %d", tt.threshold, len(ap.nearDuplicateGroups), tt.wantGroups)
+		}
+		if tt.wantGroups > 0 && !contains(ap.audioFiles[1].Tags, "near-duplicate") {
+			t.Errorf("threshold %d: tags of %s = %v, want near-duplicate", tt.threshold, ap.audioFiles[1].OriginalName, ap.audioFiles[1].Tags)
+		}
+		if contains(ap.audioFiles[4].Tags, "near-duplicate") {
+			t.Errorf("threshold %d: a file without a hash was grouped", tt.threshold)
+		}
+	}
+}
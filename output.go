@@ -0,0 +1,123 @@
+package main
+
+import "github.com/kemaswara/tidy-rename/pkg/tidy"
+
+const (
+	formatText = "text"
+	formatJSON = "json" // one JSON object per line on stdout, human output goes to stderr
+)
+
+// per-file statuses in JSON output
+const (
+	statusRenamed   = "renamed"
+	statusUnchanged = "unchanged" // already had the right name and place
+	statusSkipped   = "skipped"
+	statusDropped   = "dropped"   // duplicate moved to _duplicates/ or deleted
+	statusCataloged = "cataloged" // indexed by -catalog, left as it is
+)
+
+// fileEvent is the JSON line written for each processed file
+type fileEvent struct {
+	Type       string              `json:"type"` // always "file"
+	Original   string              `json:"original"`
+	New        string              `json:"new"`
+	Status     string              `json:"status"`
+	Category   string              `json:"category"`
+	Confidence float64             `json:"confidence,omitempty"`
+	Tags       []string            `json:"tags"`
+	Meta       *tidy.AudioMetadata `json:"meta,omitempty"`
+	Reason     string              `json:"reason,omitempty"` // why a file was skipped or dropped
+}
+
+// summaryEvent is the last JSON line of a run
+type summaryEvent struct {
+	Type       string         `json:"type"` // always "summary"
+	Total      int            `json:"total"`
+	Statuses   map[string]int `json:"statuses"`
+	Categories map[string]int `json:"categories"`
+	DualMono   int            `json:"dual_mono,omitempty"`   // stereo files with identical channels
+	Empty      int            `json:"empty_files,omitempty"` // zero-byte or too small to hold audio
+	Duplicates int            `json:"duplicate_groups,omitempty"`
+	NearDups   int            `json:"near_duplicate_groups,omitempty"` // similar but not identical audio (-dup-threshold)
+	Rounds     int            `json:"round_groups,omitempty"`          // numbered variations, not counted as duplicates
+	Renames    renameCounts   `json:"renames"`                         // new names compared with the current ones
+	Skipped    map[string]int `json:"skipped_categories,omitempty"`    // files left where they are, per category
+	Symlinks   int            `json:"skipped_symlinks,omitempty"`      // left out without -follow-symlinks
+	DryRun     bool           `json:"dry_run"`
+	Aborted    bool           `json:"aborted,omitempty"`
+}
+
+// jsonOutput reports whether results are streamed as JSON lines
+func (ap *AudioProcessor) jsonOutput() bool {
+	return ap.config.Format == formatJSON
+}
+
+// emitFile writes the JSON line for a file. Does nothing in text mode.
+func (ap *AudioProcessor) emitFile(af *AudioFile, status string) {
+	if !ap.jsonOutput() {
+		return
+	}
+
+	event := fileEvent{
+		Type:       "file",
+		Original:   af.OriginalPath,
+		New:        ap.outputPath(af),
+		Status:     status,
+		Category:   af.Category,
+		Confidence: af.Confidence,
+		Tags:       af.Tags,
+		Meta:       af.AudioMeta,
+	}
+	switch status {
+	case statusSkipped:
+		event.Reason = af.SkipReason
+		event.New = af.OriginalPath
+	case statusDropped:
+		event.Reason = "duplicate of " + af.DuplicateOf
+		if ap.config.DedupeAction == dedupeDelete {
+			event.New = ""
+		}
+	case statusUnchanged, statusCataloged:
+		event.New = af.OriginalPath
+	}
+
+	ap.statusCounts[status]++
+	ap.json.Encode(event)
+}
+
+// emitSummary writes the final JSON line. Does nothing in text mode.
+func (ap *AudioProcessor) emitSummary(aborted bool) {
+	if !ap.jsonOutput() {
+		return
+	}
+
+	ap.json.Encode(summaryEvent{
+		Type:       "summary",
+		Total:      len(ap.audioFiles),
+		Statuses:   ap.statusCounts,
+		Categories: getCategoryStats(ap.audioFiles),
+		DualMono:   computePackStats(ap.audioFiles).DualMono,
+		Empty:      emptyFileCount(ap.audioFiles),
+		Renames:    countRenames(ap.audioFiles),
+		Skipped:    skippedByCategory(ap.audioFiles),
+		Duplicates: len(ap.duplicateGroups),
+		NearDups:   len(ap.nearDuplicateGroups),
+		Rounds:     len(ap.roundGroups),
+		Symlinks:   ap.skippedSymlinks,
+		DryRun:     ap.config.DryRun,
+		Aborted:    aborted,
+	})
+}
+
+// fileStatus is what happens (or would happen, in a dry run) to a file
+func (ap *AudioProcessor) fileStatus(af *AudioFile) string {
+	switch {
+	case af.DuplicateStatus == duplicateDropped:
+		return statusDropped
+	case af.SkipReason != "":
+		return statusSkipped
+	case ap.alreadyInPlace(af):
+		return statusUnchanged
+	}
+	return statusRenamed
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONOutput(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		src := t.TempDir()
+		out := t.TempDir()
+		wav := buildTestWAV(8000, 1, 16, buildTestPCM(0, 8000, 0, 5000))
+		for _, name := range []string{"creature_roar.wav", "door_slam.wav"} {
+			if err := os.WriteFile(filepath.Join(src, name), wav, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, DryRun: dryRun, Format: formatJSON, Workers: 1})
+		var stdout bytes.Buffer
+		ap.json = json.NewEncoder(&stdout)
+		ap.log.out = &bytes.Buffer{}
+
+		if err := ap.Process(context.Background()); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("got %d JSON lines, want 2 files and a summary:\n%s", len(lines), stdout.String())
+		}
+
+		for _, line := range lines[:2] {
+			var event fileEvent
+			if err := json.Unmarshal([]byte(line), &event); err != nil {
+				t.Fatalf("bad file line %q: %v", line, err)
+			}
+			if event.Type != "file" || event.Status != statusRenamed || event.Category == "" || event.Meta == nil || event.Confidence == 0 {
+				t.Errorf("file event = %+v", event)
+			}
+			if _, err := os.Stat(event.New); (err == nil) == dryRun {
+				t.Errorf("dry run %v: new path %s exists = %v", dryRun, event.New, err == nil)
+			}
+		}
+
+		var summary summaryEvent
+		if err := json.Unmarshal([]byte(lines[2]), &summary); err != nil {
+			t.Fatalf("bad summary line %q: %v", lines[2], err)
+		}
+		if summary.Type != "summary" || summary.Total != 2 || summary.Statuses[statusRenamed] != 2 || summary.DryRun != dryRun {
+			t.Errorf("summary = %+v", summary)
+		}
+	}
+}
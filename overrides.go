@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+// RenameOverride forces the name and/or category of the files a pattern
+// matches, for when inference gets a file wrong (-overrides)
+type RenameOverride struct {
+	Pattern  string // glob like -exclude, matched against the path relative to the source
+	NewName  string // output name, the file's extension is added when it's missing
+	Category string // normalized category
+}
+
+// loadOverrides reads a CSV file of pattern,new_name,category rows. Either of
+// the last two can be left empty, lines starting with # are skipped, and a
+// first row starting with "pattern" is taken as a header.
+func loadOverrides(file string) ([]RenameOverride, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1 // the category column is optional
+	reader.TrimLeadingSpace = true
+
+	var overrides []RenameOverride
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if row == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "pattern") {
+			continue
+		}
+
+		override, err := parseOverride(record)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("%s line %d: %w", file, line, err)
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, nil
+}
+
+func parseOverride(record []string) (RenameOverride, error) {
+	if len(record) < 2 || len(record) > 3 {
+		return RenameOverride{}, fmt.Errorf("expected pattern,new_name[,category], got %d fields", len(record))
+	}
+	override := RenameOverride{
+		Pattern: filepath.ToSlash(strings.TrimSpace(record[0])),
+		NewName: strings.TrimSpace(record[1]),
+	}
+	if len(record) == 3 {
+		if category := strings.TrimSpace(record[2]); category != "" {
+			override.Category = tidy.NormalizeCategory(category)
+		}
+	}
+
+	if override.Pattern == "" {
+		return override, fmt.Errorf("missing pattern")
+	}
+	if _, err := path.Match(override.Pattern, ""); err != nil {
+		return override, fmt.Errorf("invalid pattern %q: %w", override.Pattern, err)
+	}
+	if override.NewName == "" && override.Category == "" {
+		return override, fmt.Errorf("%q sets neither a name nor a category", override.Pattern)
+	}
+	if base := strings.TrimSuffix(override.NewName, filepath.Ext(override.NewName)); override.NewName != "" &&
+		(base == "" || !templateLiteral.MatchString(base)) {
+		return override, fmt.Errorf("invalid name %q, only letters, digits and underscores are allowed", override.NewName)
+	}
+	return override, nil
+}
+
+// overrideFor returns the first override whose pattern matches the file, or nil
+func (ap *AudioProcessor) overrideFor(af *AudioFile) *RenameOverride {
+	rel := ap.relPath(af.OriginalPath)
+	for i := range ap.config.Overrides {
+		if matchGlob(ap.config.Overrides[i].Pattern, rel) {
+			return &ap.config.Overrides[i]
+		}
+	}
+	return nil
+}
+
+// overrideName returns the override's name with the file's own extension
+func overrideName(override *RenameOverride, af *AudioFile) string {
+	ext := fileExt(af)
+	if strings.EqualFold(filepath.Ext(override.NewName), ext) {
+		return strings.TrimSuffix(override.NewName, filepath.Ext(override.NewName)) + ext
+	}
+	return override.NewName + ext
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []RenameOverride
+		wantErr bool
+	}{
+		{
+			"header, comment and optional category",
+			"pattern,new_name,category\n# fix the odd ones\ntake_07.wav,A_Pack_Door_Slam\nrain_*.wav,,ambient\n",
+			[]RenameOverride{{Pattern: "take_07.wav", NewName: "A_Pack_Door_Slam"}, {Pattern: "rain_*.wav", Category: "Ambient"}},
+			false,
+		},
+		{"spaces after commas", "foley/*, A_Pack_Foley, voice\n", []RenameOverride{{Pattern: "foley/*", NewName: "A_Pack_Foley", Category: "SFX_Voice"}}, false},
+		{"name with extension", "x.wav,A_Pack_X.wav\n", []RenameOverride{{Pattern: "x.wav", NewName: "A_Pack_X.wav"}}, false},
+		{"nothing to set", "x.wav,,\n", nil, true},
+		{"bad name", "x.wav,A Pack/X\n", nil, true},
+		{"bad pattern", "[x.wav,A_X\n", nil, true},
+		{"too many fields", "x.wav,A_X,Voice,extra\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "overrides.csv")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadOverrides(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadOverrides() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("loadOverrides() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("override %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOverridesApplied(t *testing.T) {
+	src := t.TempDir()
+	for _, name := range []string{"take_07.wav", "scream_male.wav", "rain_heavy.wav"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{
+		SourceDir: src,
+		OutputDir: t.TempDir(),
+		PackName:  "TestPack",
+		Layout:    layoutCategory,
+		Workers:   1,
+		DryRun:    true,
+		Overrides: []RenameOverride{
+			{Pattern: "take_07.wav", NewName: "A_TestPack_Door_Slam", Category: "SFX_Door"},
+			{Pattern: "rain_*", Category: "SFX_Voice"}, // deliberately wrong, overrides still win
+		},
+	})
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct{ newName, category, override string }{
+		"take_07.wav":     {"A_TestPack_Door_Slam.wav", "SFX_Door", "take_07.wav"},
+		"rain_heavy.wav":  {"A_TestPack_Voice_Rain_Heavy.wav", "SFX_Voice", "rain_*"},
+		"scream_male.wav": {"A_TestPack_Voice_Scream_Male.wav", "SFX_Voice", ""},
+	}
+	for _, af := range ap.audioFiles {
+		w := want[af.OriginalName]
+		if af.NewName != w.newName || af.Category != w.category || af.Override != w.override {
+			t.Errorf("%s: got %s/%s override %q, want %s/%s override %q", af.OriginalName, af.NewName, af.Category, af.Override, w.newName, w.category, w.override)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// what -sample-rate-action does with files that don't match -require-sample-rate
+const (
+	sampleRateTag  = "tag"  // add a sample-rate-mismatch tag
+	sampleRateSkip = "skip" // leave the file where it is
+)
+
+// formatGroup counts the files sharing one sample rate, bit depth and channel count
+type formatGroup struct {
+	SampleRate int `json:"sample_rate"`
+	BitDepth   int `json:"bit_depth"`
+	Channels   int `json:"channels"`
+	Count      int `json:"count"`
+}
+
+// packStats describes how consistent the audio format of a pack is. Unreal
+// resamples anything that doesn't match the project rate, so a mix of 44.1k
+// and 48k is usually a mistake worth knowing about before the files go in.
+type packStats struct {
+	Formats     []formatGroup `json:"formats"`
+	SampleRates map[int]int   `json:"sample_rates"`
+	BitDepths   map[int]int   `json:"bit_depths"`
+	Channels    map[int]int   `json:"channels"`
+	Unknown     int           `json:"unknown,omitempty"`   // files we couldn't read the format of
+	DualMono    int           `json:"dual_mono,omitempty"` // stereo files that could be downmixed to mono
+	Warnings    []string      `json:"warnings,omitempty"`
+}
+
+// computePackStats groups files by format. Dropped duplicates are left out since
+// they don't end up in the pack.
+func computePackStats(files []AudioFile) *packStats {
+	stats := &packStats{
+		SampleRates: make(map[int]int),
+		BitDepths:   make(map[int]int),
+		Channels:    make(map[int]int),
+	}
+
+	groups := make(map[formatGroup]int)
+	for _, af := range files {
+		if af.DuplicateStatus == duplicateDropped {
+			continue
+		}
+		meta := af.AudioMeta
+		if meta == nil || meta.SampleRate == 0 {
+			stats.Unknown++
+			continue
+		}
+
+		if meta.DualMono {
+			stats.DualMono++
+		}
+		stats.SampleRates[meta.SampleRate]++
+		if meta.BitDepth > 0 { // compressed formats don't have one
+			stats.BitDepths[meta.BitDepth]++
+		}
+		if meta.Channels > 0 {
+			stats.Channels[meta.Channels]++
+		}
+		groups[formatGroup{SampleRate: meta.SampleRate, BitDepth: meta.BitDepth, Channels: meta.Channels}]++
+	}
+
+	for g, count := range groups {
+		g.Count = count
+		stats.Formats = append(stats.Formats, g)
+	}
+	// most common format first
+	sort.Slice(stats.Formats, func(i, j int) bool {
+		a, b := stats.Formats[i], stats.Formats[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.SampleRate != b.SampleRate {
+			return a.SampleRate < b.SampleRate
+		}
+		if a.BitDepth != b.BitDepth {
+			return a.BitDepth < b.BitDepth
+		}
+		return a.Channels < b.Channels
+	})
+
+	for _, mix := range []struct {
+		what   string
+		counts map[int]int
+		unit   string
+	}{
+		{"sample rates", stats.SampleRates, "Hz"},
+		{"bit depths", stats.BitDepths, "bit"},
+		{"channel counts", stats.Channels, "ch"},
+	} {
+		if len(mix.counts) > 1 {
+			stats.Warnings = append(stats.Warnings, fmt.Sprintf("Mixed %s: %s", mix.what, describeCounts(mix.counts, mix.unit)))
+		}
+	}
+
+	return stats
+}
+
+// describeCounts formats counts like "3 files at 44100Hz, 997 at 48000Hz"
+func describeCounts(counts map[int]int, unit string) string {
+	values := make([]int, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+
+	parts := make([]string, len(values))
+	for i, v := range values {
+		switch {
+		case i > 0:
+			parts[i] = fmt.Sprintf("%d at %d%s", counts[v], v, unit)
+		case counts[v] == 1:
+			parts[i] = fmt.Sprintf("1 file at %d%s", v, unit)
+		default:
+			parts[i] = fmt.Sprintf("%d files at %d%s", counts[v], v, unit)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// displayPackStats prints the formats in the pack and warns about mixes
+func (ap *AudioProcessor) displayPackStats() {
+	stats := computePackStats(ap.audioFiles)
+	if len(stats.Formats) == 0 {
+		return
+	}
+
+	ap.log.Infoln("\n=== Pack Format ===")
+	for _, g := range stats.Formats {
+		ap.log.Infof("  %d × %dHz", g.Count, g.SampleRate)
+		if g.BitDepth > 0 {
+			ap.log.Infof(" | %dbit", g.BitDepth)
+		}
+		if g.Channels > 0 {
+			ap.log.Infof(" | %dch", g.Channels)
+		}
+		ap.log.Infoln()
+	}
+	if stats.Unknown > 0 {
+		ap.log.Infof("  %d × unknown format\n", stats.Unknown)
+	}
+	if stats.DualMono > 0 {
+		ap.log.Infof("  %d stereo files are dual mono (identical channels) and could be downmixed\n", stats.DualMono)
+	}
+	for _, w := range stats.Warnings {
+		ap.log.Warnf("⚠ %s\n", w)
+	}
+}
+
+// checkSampleRates tags or skips files whose sample rate isn't -require-sample-rate.
+// Files we couldn't read the sample rate of are left alone.
+func (ap *AudioProcessor) checkSampleRates() {
+	required := ap.config.RequireSampleRate
+	if required == 0 {
+		return
+	}
+
+	mismatched := 0
+	for i := range ap.audioFiles {
+		af := &ap.audioFiles[i]
+		if af.DuplicateStatus == duplicateDropped || af.AudioMeta == nil {
+			continue
+		}
+		rate := af.AudioMeta.SampleRate
+		if rate == 0 || rate == required {
+			continue
+		}
+
+		mismatched++
+		if ap.config.SampleRateAction == sampleRateSkip {
+			af.SkipReason = fmt.Sprintf("sample rate is %dHz, not %dHz", rate, required)
+		} else {
+			af.Tags = mergeTags(af.Tags, []string{"sample-rate-mismatch"})
+		}
+	}
+
+	if mismatched > 0 {
+		ap.log.Warnf("⚠ %d files are not at the required %dHz\n", mismatched, required)
+	}
+}
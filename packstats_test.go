@@ -0,0 +1,134 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestComputePackStats(t *testing.T) {
+	file := func(rate, depth, channels int) AudioFile {
+		return AudioFile{AudioMeta: &tidy.AudioMetadata{SampleRate: rate, BitDepth: depth, Channels: channels}}
+	}
+
+	tests := []struct {
+		name         string
+		files        []AudioFile
+		wantFormats  []formatGroup
+		wantUnknown  int
+		wantWarnings []string
+	}{
+		{
+			name:        "consistent pack",
+			files:       []AudioFile{file(48000, 24, 2), file(48000, 24, 2)},
+			wantFormats: []formatGroup{{48000, 24, 2, 2}},
+		},
+		{
+			name:        "mixed sample rates",
+			files:       []AudioFile{file(48000, 24, 2), file(44100, 24, 2), file(48000, 24, 2), file(48000, 24, 2)},
+			wantFormats: []formatGroup{{48000, 24, 2, 3}, {44100, 24, 2, 1}},
+			wantWarnings: []string{
+				"Mixed sample rates: 1 file at 44100Hz, 3 at 48000Hz",
+			},
+		},
+		{
+			name:        "mixed everything",
+			files:       []AudioFile{file(44100, 16, 1), file(44100, 16, 1), file(48000, 24, 2)},
+			wantFormats: []formatGroup{{44100, 16, 1, 2}, {48000, 24, 2, 1}},
+			wantWarnings: []string{
+				"Mixed sample rates: 2 files at 44100Hz, 1 at 48000Hz",
+				"Mixed bit depths: 2 files at 16bit, 1 at 24bit",
+				"Mixed channel counts: 2 files at 1ch, 1 at 2ch",
+			},
+		},
+		{
+			name: "unknown and dropped files are not mixed in",
+			files: []AudioFile{
+				file(48000, 24, 2),
+				{OriginalName: "unreadable.wav"},
+				{AudioMeta: &tidy.AudioMetadata{SampleRate: 44100, BitDepth: 16, Channels: 2}, DuplicateStatus: duplicateDropped},
+			},
+			wantFormats: []formatGroup{{48000, 24, 2, 1}},
+			wantUnknown: 1,
+		},
+		{
+			name:        "compressed files have no bit depth",
+			files:       []AudioFile{file(44100, 0, 2), file(44100, 16, 2)},
+			wantFormats: []formatGroup{{44100, 0, 2, 1}, {44100, 16, 2, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := computePackStats(tt.files)
+			if !reflect.DeepEqual(stats.Formats, tt.wantFormats) {
+				t.Errorf("Formats = %v, want %v", stats.Formats, tt.wantFormats)
+			}
+			if stats.Unknown != tt.wantUnknown {
+				t.Errorf("Unknown = %d, want %d", stats.Unknown, tt.wantUnknown)
+			}
+			if !reflect.DeepEqual(stats.Warnings, tt.wantWarnings) {
+				t.Errorf("Warnings = %q, want %q", stats.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestComputePackStatsDualMono(t *testing.T) {
+	files := []AudioFile{
+		{AudioMeta: &tidy.AudioMetadata{SampleRate: 48000, Channels: 2, DualMono: true}},
+		{AudioMeta: &tidy.AudioMetadata{SampleRate: 48000, Channels: 2}},
+		{AudioMeta: &tidy.AudioMetadata{SampleRate: 48000, Channels: 2, DualMono: true}, DuplicateStatus: duplicateDropped},
+	}
+	if got := computePackStats(files).DualMono; got != 1 {
+		t.Errorf("DualMono = %d, want 1 (dropped duplicates left out)", got)
+	}
+}
+
+func TestCheckSampleRates(t *testing.T) {
+	newFiles := func() []AudioFile {
+		return []AudioFile{
+			{OriginalName: "at_48k.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 48000}},
+			{OriginalName: "at_44k.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 44100}},
+			{OriginalName: "unknown.ogg"},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		required   int
+		action     string
+		wantTagged []bool
+		wantSkip   []bool
+	}{
+		{"not required", 0, sampleRateTag, []bool{false, false, false}, []bool{false, false, false}},
+		{"tag", 48000, sampleRateTag, []bool{false, true, false}, []bool{false, false, false}},
+		{"skip", 48000, sampleRateSkip, []bool{false, false, false}, []bool{false, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{RequireSampleRate: tt.required, SampleRateAction: tt.action})
+			ap.log.out = io.Discard
+			ap.audioFiles = newFiles()
+			ap.checkSampleRates()
+
+			for i, af := range ap.audioFiles {
+				tagged := false
+				for _, tag := range af.Tags {
+					if tag == "sample-rate-mismatch" {
+						tagged = true
+					}
+				}
+				if tagged != tt.wantTagged[i] {
+					t.Errorf("%s tagged = %v, want %v", af.OriginalName, tagged, tt.wantTagged[i])
+				}
+				if skipped := af.SkipReason != ""; skipped != tt.wantSkip[i] {
+					t.Errorf("%s skipped = %v (%q), want %v", af.OriginalName, skipped, af.SkipReason, tt.wantSkip[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// permissions of what the tool creates when -dir-mode and -file-mode aren't given
+const (
+	defaultDirMode  os.FileMode = 0755
+	defaultFileMode os.FileMode = 0644
+)
+
+// parseMode reads a -dir-mode or -file-mode value, an octal mode like 0775 or
+// 664 (an 0o prefix is fine too). "" is 0, meaning the default.
+func parseMode(value string) (os.FileMode, error) {
+	if value == "" {
+		return 0, nil
+	}
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "0o"), "0O")
+	n, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil || digits == "" {
+		return 0, fmt.Errorf("%q isn't an octal mode like 0775", value)
+	}
+	if n > 0777 {
+		return 0, fmt.Errorf("%q has more than permission bits, use a mode up to 0777", value)
+	}
+	return os.FileMode(n), nil
+}
+
+func (ap *AudioProcessor) dirMode() os.FileMode {
+	if ap.config.DirMode != 0 {
+		return ap.config.DirMode
+	}
+	return defaultDirMode
+}
+
+func (ap *AudioProcessor) fileMode() os.FileMode {
+	if ap.config.FileMode != 0 {
+		return ap.config.FileMode
+	}
+	return defaultFileMode
+}
+
+// mkdirAll creates dir and its missing parents with the -dir-mode
+// permissions. The umask would take bits like group write off a mode given on
+// the command line, so the directories this creates are chmodded to it too;
+// ones that already existed are left as they are.
+func (ap *AudioProcessor) mkdirAll(dir string) error {
+	if ap.config.DirMode == 0 {
+		return os.MkdirAll(dir, defaultDirMode)
+	}
+
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Lstat(d); err == nil || filepath.Dir(d) == d {
+			break
+		}
+		missing = append(missing, d)
+	}
+	if err := os.MkdirAll(dir, ap.config.DirMode); err != nil {
+		return err
+	}
+	for _, d := range missing {
+		if err := os.Chmod(d, ap.config.DirMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile writes data to path with the -file-mode permissions, chmodding
+// it for the same reason as mkdirAll and because an existing file keeps its
+// mode otherwise
+func (ap *AudioProcessor) writeFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, ap.fileMode()); err != nil {
+		return err
+	}
+	return ap.applyFileMode(path)
+}
+
+// createFile is os.Create with the -file-mode permissions
+func (ap *AudioProcessor) createFile(path string) (*os.File, error) {
+	if ap.config.FileMode == 0 {
+		return os.Create(path)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, ap.config.FileMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(ap.config.FileMode); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// applyFileMode gives a file put in the output the -file-mode permissions,
+// moved audio files keep their own otherwise. Links are left alone, a chmod
+// would change the file they point to.
+func (ap *AudioProcessor) applyFileMode(path string) error {
+	if ap.config.FileMode == 0 || isSymlink(path) {
+		return nil
+	}
+	return os.Chmod(path, ap.config.FileMode)
+}
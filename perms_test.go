@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0775", 0775, false},
+		{"664", 0664, false},
+		{"0o2775", 0, true}, // setgid isn't a permission bit
+		{"0o770", 0770, false},
+		{"0789", 0, true},
+		{"rwxrwxr-x", 0, true},
+		{"0o", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMode(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseMode(%q) = %o, %v, want %o (error: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestOutputModes(t *testing.T) {
+	tests := []struct {
+		name              string
+		dirMode, fileMode os.FileMode
+		wantDir, wantFile os.FileMode
+		wantManifest      os.FileMode
+	}{
+		{"default", 0, 0, 0, 0600, 0}, // moved files keep their mode, the rest is up to the umask
+		{"group-writable", 0775, 0664, 0775, 0664, 0664},
+	}
+
+	for _, tt := range tests {
+		src, out := t.TempDir(), filepath.Join(t.TempDir(), "library")
+		if err := os.WriteFile(filepath.Join(src, "door_creak.wav"), buildTestWAV(48000, 1, 16, make([]byte, 4800)), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, CreateManifest: true, DirMode: tt.dirMode, FileMode: tt.fileMode, Workers: 1})
+		ap.log.out = io.Discard
+		if err := ap.Process(context.Background()); err != nil {
+			t.Fatalf("%s: Process() error = %v", tt.name, err)
+		}
+
+		af := ap.audioFiles[0]
+		checks := []struct {
+			path string
+			want os.FileMode
+		}{
+			{out, tt.wantDir},
+			{filepath.Dir(ap.outputPath(&af)), tt.wantDir},
+			{ap.outputPath(&af), tt.wantFile},
+			{ap.manifestPath(), tt.wantManifest},
+		}
+		for _, c := range checks {
+			if c.want == 0 {
+				continue
+			}
+			info, err := os.Stat(c.path)
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			if got := info.Mode().Perm(); got != c.want {
+				t.Errorf("%s: %s has mode %o, want %o", tt.name, c.path, got, c.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// aiffSound describes where the sample data of an AIFF file is and how it's stored
+type aiffSound struct {
+	offset       int64 // file offset of the first sample frame
+	size         int64 // bytes of sample data
+	channels     int
+	bitDepth     int
+	littleEndian bool // AIFF-C "sowt"
+}
+
+// analyzeAIFF reads the COMM chunk of an AIFF/AIFF-C file for channels, sample
+// frames, bit depth and sample rate, then decodes the start of the SSND chunk for
+// spectral analysis like we do for WAV. Chunks are big-endian, unlike WAV.
+func (aa *AudioAnalyzer) analyzeAIFF(file *os.File, meta *AudioMetadata) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return fmt.Errorf("failed to read FORM header: %w", err)
+	}
+	form := string(header[8:12])
+	if string(header[0:4]) != "FORM" || (form != "AIFF" && form != "AIFC") {
+		return fmt.Errorf("invalid AIFF file")
+	}
+
+	var comm []byte
+	var sound *aiffSound
+	pos := int64(12)
+	chunkHeader := make([]byte, 8)
+	for {
+		if _, err := file.ReadAt(chunkHeader, pos); err != nil {
+			break // end of file, we're done
+		}
+		size := int64(binary.BigEndian.Uint32(chunkHeader[4:8]))
+		body := pos + 8
+
+		switch string(chunkHeader[0:4]) {
+		case "COMM":
+			// channels(2) sample frames(4) sample size(2) sample rate(10, 80-bit float),
+			// then for AIFF-C the compression type(4)
+			if size < 18 || size > maxRIFFChunkSize {
+				return fmt.Errorf("invalid COMM chunk size %d", size)
+			}
+			comm = make([]byte, size)
+			if _, err := file.ReadAt(comm, body); err != nil {
+				return fmt.Errorf("failed to read COMM chunk: %w", err)
+			}
+		case "SSND":
+			// offset(4) and block size(4) come before the samples
+			if size >= 8 {
+				offset := make([]byte, 4)
+				// the offset is the file's word, one past the chunk leaves no samples
+				if _, err := file.ReadAt(offset, body); err == nil {
+					if skip := 8 + int64(binary.BigEndian.Uint32(offset)); skip <= size {
+						sound = &aiffSound{offset: body + skip, size: size - skip}
+					}
+				}
+			}
+		}
+
+		pos = body + size + size%2
+	}
+
+	if comm == nil {
+		return fmt.Errorf("no COMM chunk found")
+	}
+
+	channels := int(binary.BigEndian.Uint16(comm[0:2]))
+	frames := int64(binary.BigEndian.Uint32(comm[2:6]))
+	bitDepth := int(binary.BigEndian.Uint16(comm[6:8]))
+	rate := extendedToFloat(comm[8:18])
+	if err := checkFormat(rate, channels); err != nil {
+		return fmt.Errorf("invalid AIFF COMM chunk: %w", err)
+	}
+	sampleRate := int(rate)
+
+	meta.Format = "AIFF"
+	meta.SampleRate = sampleRate
+	meta.Channels = channels
+	meta.BitDepth = bitDepth
+	meta.Duration = time.Duration(float64(frames) / float64(sampleRate) * float64(time.Second))
+	meta.Bitrate = sampleRate * channels * bitDepth
+	meta.Fingerprint = aa.GenerateFingerprint(meta)
+
+	// only uncompressed PCM can be decoded for spectral analysis
+	compression := "NONE"
+	if form == "AIFC" && len(comm) >= 22 {
+		compression = string(comm[18:22])
+	}
+	if sound != nil && (compression == "NONE" || compression == "twos" || compression == "sowt") {
+		sound.channels = channels
+		sound.bitDepth = bitDepth
+		sound.littleEndian = compression == "sowt"
+		if err := aa.analyzeAIFFSpectral(file, sound, meta); err != nil {
+			// spectral analysis failed, but that's okay - continue without it
+		}
+	}
+
+	return nil
+}
+
+// analyzeAIFFSpectral decodes the start of the PCM data (channels averaged),
+// computes the same spectral features as for WAV from the analysis window,
+// counts the onsets in up to the first 10 seconds and hashes them
+func (aa *AudioAnalyzer) analyzeAIFFSpectral(file *os.File, sound *aiffSound, meta *AudioMetadata) error {
+	bytesPerSample := (sound.bitDepth + 7) / 8
+	if bytesPerSample < 1 || bytesPerSample > 4 {
+		return fmt.Errorf("unsupported bit depth %d", sound.bitDepth)
+	}
+	frameSize := bytesPerSample * sound.channels
+
+	window := aa.analysisFrames(meta)
+	maxFrames := int64(onsetMaxDuration.Seconds() * float64(meta.SampleRate))
+	if maxFrames < int64(window) {
+		maxFrames = int64(window)
+	}
+	// the chunk size is only what the header claims, the file may be shorter
+	available := sound.size
+	if info, err := file.Stat(); err == nil && info.Size()-sound.offset < available {
+		available = info.Size() - sound.offset
+	}
+	if frames := available / int64(frameSize); frames < maxFrames {
+		maxFrames = frames
+	}
+	if maxFrames <= 0 {
+		return fmt.Errorf("no sound data")
+	}
+
+	data := make([]byte, maxFrames*int64(frameSize))
+	n, err := file.ReadAt(data, sound.offset)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	data = data[:n-n%frameSize]
+
+	// samples are left-justified, so scale by the container size
+	fullScale := float64(int64(1) << (bytesPerSample*8 - 1))
+	samples := make([]float64, 0, len(data)/frameSize)
+	for off := 0; off < len(data); off += frameSize {
+		sum := 0.0
+		for ch := 0; ch < sound.channels; ch++ {
+			start := off + ch*bytesPerSample
+			sum += float64(decodeAIFFSample(data[start:start+bytesPerSample], sound.littleEndian)) / fullScale
+		}
+		samples = append(samples, sum/float64(sound.channels))
+	}
+
+	if len(samples) < 100 {
+		return fmt.Errorf("not enough samples for analysis")
+	}
+
+	spectral := samples
+	if len(spectral) > window {
+		spectral = spectral[:window]
+	}
+	features := &SpectralFeatures{}
+	aa.calculateSpectralFeatures(spectral, meta.SampleRate, features)
+	meta.SpectralFeatures = features
+	meta.OnsetCount = countOnsets(samples, meta.SampleRate)
+	meta.PerceptualHash = perceptualHash(samples, meta.SampleRate)
+
+	return nil
+}
+
+// decodeAIFFSample reads one signed integer sample of 1-4 bytes
+func decodeAIFFSample(b []byte, littleEndian bool) int64 {
+	var v int64
+	for i := range b {
+		idx := i
+		if littleEndian {
+			idx = len(b) - 1 - i
+		}
+		v = v<<8 | int64(b[idx])
+	}
+	// sign-extend from the sample's width
+	shift := 64 - 8*uint(len(b))
+	return v << shift >> shift
+}
+
+// extendedToFloat decodes an 80-bit IEEE 754 extended precision number, which
+// AIFF uses for the sample rate: sign and 15-bit exponent, then a 64-bit mantissa
+// with an explicit integer bit
+func extendedToFloat(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exponent == 0 && mantissa == 0 {
+		return 0
+	}
+
+	value := math.Ldexp(float64(mantissa), exponent-16383-63)
+	if b[0]&0x80 != 0 {
+		value = -value
+	}
+	return value
+}
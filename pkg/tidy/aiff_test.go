@@ -0,0 +1,155 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// buildTestAIFF makes an AIFF file with a COMM chunk and silent sound data
+func buildTestAIFF(sampleRate uint16, channels, bitDepth int, frames uint32) []byte {
+	return buildTestAIFFWithSamples(sampleRate, channels, bitDepth, frames, make([]byte, int(frames)*channels*bitDepth/8))
+}
+
+// buildTestAIFFWithSamples makes an AIFF file with the given big-endian sample data
+func buildTestAIFFWithSamples(sampleRate uint16, channels, bitDepth int, frames uint32, samples []byte) []byte {
+	comm := binary.BigEndian.AppendUint16(nil, uint16(channels))
+	comm = binary.BigEndian.AppendUint32(comm, frames)
+	comm = binary.BigEndian.AppendUint16(comm, uint16(bitDepth))
+	// 80-bit extended: biased exponent, then the rate shifted up to the top of the mantissa
+	exponent, mantissa := uint16(16383+15), uint64(sampleRate)<<48
+	for mantissa&(1<<63) == 0 {
+		mantissa <<= 1
+		exponent--
+	}
+	comm = binary.BigEndian.AppendUint16(comm, exponent)
+	comm = binary.BigEndian.AppendUint64(comm, mantissa)
+
+	body := []byte("AIFF")
+	body = append(body, "COMM"...)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(comm)))
+	body = append(body, comm...)
+
+	ssnd := append(make([]byte, 8), samples...) // offset, block size, samples
+	body = append(body, "SSND"...)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(ssnd)))
+	body = append(body, ssnd...)
+
+	out := []byte("FORM")
+	out = binary.BigEndian.AppendUint32(out, uint32(len(body)))
+	return append(out, body...)
+}
+
+func TestAnalyzeAIFF(t *testing.T) {
+	tests := []struct {
+		name       string
+		sampleRate uint16
+		channels   int
+		bitDepth   int
+		frames     uint32
+		wantDur    time.Duration
+	}{
+		{"48k_stereo.aiff", 48000, 2, 24, 96000, 2 * time.Second},
+		{"44k_mono.aif", 44100, 1, 16, 22050, 500 * time.Millisecond},
+	}
+
+	aa := NewAudioAnalyzer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, tt.name, buildTestAIFF(tt.sampleRate, tt.channels, tt.bitDepth, tt.frames))
+			meta, err := aa.AnalyzeFile(path)
+			if err != nil {
+				t.Fatalf("AnalyzeFile() error = %v", err)
+			}
+			if meta.Format != "AIFF" || meta.SampleRate != int(tt.sampleRate) || meta.Channels != tt.channels ||
+				meta.BitDepth != tt.bitDepth || meta.Duration != tt.wantDur {
+				t.Errorf("AnalyzeFile() = %s %dHz %dch %dbit %v, want AIFF %dHz %dch %dbit %v",
+					meta.Format, meta.SampleRate, meta.Channels, meta.BitDepth, meta.Duration,
+					tt.sampleRate, tt.channels, tt.bitDepth, tt.wantDur)
+			}
+		})
+	}
+
+	// garbage still gets a format from the extension
+	meta, err := aa.AnalyzeFile(writeTestFile(t, "broken.aiff", []byte("FORMxxxx")))
+	if err != nil || meta.Format != "AIFF" {
+		t.Errorf("AnalyzeFile() on a broken AIFF = %+v, %v", meta, err)
+	}
+}
+
+func TestAnalyzeAIFFSpectral(t *testing.T) {
+	// 16-bit mono square wave at 1kHz: toggles every 24 samples at 48kHz
+	var samples []byte
+	for i := 0; i < 4800; i++ {
+		v := int16(16000)
+		if (i/24)%2 == 1 {
+			v = -16000
+		}
+		samples = binary.BigEndian.AppendUint16(samples, uint16(v))
+	}
+
+	aa := NewAudioAnalyzer()
+	meta, err := aa.AnalyzeFile(writeTestFile(t, "tone.aif", buildTestAIFFWithSamples(48000, 1, 16, 4800, samples)))
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	if meta.SpectralFeatures == nil {
+		t.Fatal("AnalyzeFile() should run spectral analysis on AIFF samples")
+	}
+	// one crossing per 24 samples
+	if zcr := meta.SpectralFeatures.ZeroCrossing; zcr < 0.035 || zcr > 0.05 {
+		t.Errorf("ZeroCrossing = %.3f, want about 1/24", zcr)
+	}
+	if meta.Fingerprint == "" {
+		t.Error("AnalyzeFile() should fingerprint AIFF files")
+	}
+}
+
+// TestAnalyzeAIFFCraftedHeaders feeds headers that claim more than the file
+// holds, they must not crash the analysis or size buffers from the header
+func TestAnalyzeAIFFCraftedHeaders(t *testing.T) {
+	patch := func(at int, value []byte) []byte {
+		data := buildTestAIFF(48000, 1, 16, 4800)
+		copy(data[at:], value)
+		return data
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		// the COMM chunk is 20-37 with the sample rate at 28, the SSND header at 38
+		{"ssnd_offset.aiff", patch(46, []byte{0xFF, 0xFF, 0xFF, 0x00})},
+		{"ssnd_size.aiff", patch(42, []byte{0xFF, 0xFF, 0xFF, 0xF0})},
+		{"sample_rate.aiff", patch(28, []byte{0x40, 0x3E})}, // about 2^63 Hz
+		{"channels.aiff", patch(20, []byte{0xFF, 0xFF})},
+	}
+
+	aa := NewAudioAnalyzer()
+	for _, tt := range tests {
+		meta, err := aa.AnalyzeFile(writeTestFile(t, tt.name, tt.data))
+		if err != nil || meta.Format != "AIFF" {
+			t.Errorf("%s: AnalyzeFile() = %+v, %v", tt.name, meta, err)
+		}
+	}
+}
+
+func TestDecodeAIFFSample(t *testing.T) {
+	tests := []struct {
+		data         []byte
+		littleEndian bool
+		want         int64
+	}{
+		{[]byte{0x7F}, false, 127},
+		{[]byte{0x80}, false, -128},
+		{[]byte{0xFF, 0xFE}, false, -2},
+		{[]byte{0xFE, 0xFF}, true, -2},
+		{[]byte{0x80, 0x00, 0x00}, false, -8388608},
+		{[]byte{0x00, 0x00, 0x01, 0x00}, false, 256},
+	}
+
+	for _, tt := range tests {
+		if got := decodeAIFFSample(tt.data, tt.littleEndian); got != tt.want {
+			t.Errorf("decodeAIFFSample(%x, %v) = %d, want %d", tt.data, tt.littleEndian, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package tidy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ASF object GUIDs as they appear on disk (little-endian fields first)
+var (
+	asfHeaderObject     = []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11, 0xA6, 0xD9, 0x00, 0xAA, 0x00, 0x62, 0xCE, 0x6C} // 75B22630-668E-11CF-A6D9-00AA0062CE6C
+	asfFileProperties   = []byte{0xA1, 0xDC, 0xAB, 0x8C, 0x47, 0xA9, 0xCF, 0x11, 0x8E, 0xE4, 0x00, 0xC0, 0x0C, 0x20, 0x53, 0x65} // 8CABDCA1-A947-11CF-8EE4-00C00C205365
+	asfStreamProperties = []byte{0x91, 0x07, 0xDC, 0xB7, 0xB7, 0xA9, 0xCF, 0x11, 0x8E, 0xE6, 0x00, 0xC0, 0x0C, 0x20, 0x53, 0x65} // B7DC0791-A9B7-11CF-8EE6-00C00C205365
+	asfAudioMedia       = []byte{0x40, 0x9E, 0x69, 0xF8, 0x4D, 0x5B, 0xCF, 0x11, 0xA8, 0xFD, 0x00, 0x80, 0x5F, 0x5C, 0x44, 0x2B} // F8699E40-5B4D-11CF-A8FD-00805F5C442B
+)
+
+const (
+	asfObjectHeaderSize = 24 // GUID(16), size(8)
+	asfMaxHeaderSize    = 16 << 20
+)
+
+// analyzeWMA reads the ASF header object of a WMA file: the file properties
+// object for the length and the audio stream's WAVEFORMATEX for sample rate,
+// channels and bitrate
+func (aa *AudioAnalyzer) analyzeWMA(file *os.File, meta *AudioMetadata) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// header object: GUID(16), size(8), object count(4), reserved(2)
+	top := make([]byte, asfObjectHeaderSize+6)
+	if _, err := io.ReadFull(file, top); err != nil {
+		return fmt.Errorf("failed to read ASF header: %w", err)
+	}
+	if !bytes.Equal(top[0:16], asfHeaderObject) {
+		return fmt.Errorf("invalid ASF file")
+	}
+	size := binary.LittleEndian.Uint64(top[16:24])
+	if size < uint64(len(top)) || size > asfMaxHeaderSize {
+		return fmt.Errorf("invalid ASF header size %d", size)
+	}
+
+	objects := make([]byte, size-uint64(len(top)))
+	if _, err := io.ReadFull(file, objects); err != nil {
+		return fmt.Errorf("failed to read ASF header objects: %w", err)
+	}
+
+	foundAudio := false
+	for len(objects) >= asfObjectHeaderSize {
+		objectSize := binary.LittleEndian.Uint64(objects[16:24])
+		if objectSize < asfObjectHeaderSize || objectSize > uint64(len(objects)) {
+			return fmt.Errorf("invalid ASF object size %d", objectSize)
+		}
+		object := objects[:objectSize]
+		objects = objects[objectSize:]
+
+		switch {
+		case bytes.Equal(object[0:16], asfFileProperties):
+			parseASFFileProperties(object[asfObjectHeaderSize:], meta)
+		case bytes.Equal(object[0:16], asfStreamProperties) && !foundAudio:
+			foundAudio = parseASFAudioStream(object[asfObjectHeaderSize:], meta)
+		}
+	}
+
+	if !foundAudio {
+		return fmt.Errorf("no audio stream")
+	}
+	meta.Format = "WMA"
+
+	meta.Fingerprint = aa.GenerateFingerprint(meta)
+
+	return nil
+}
+
+// parseASFFileProperties reads the play duration. Layout: file ID(16), file
+// size(8), creation date(8), packet count(8), play duration(8, 100ns units),
+// send duration(8), preroll(8, ms)
+func parseASFFileProperties(data []byte, meta *AudioMetadata) {
+	if len(data) < 64 {
+		return
+	}
+	play := time.Duration(binary.LittleEndian.Uint64(data[40:48])) * 100
+	preroll := time.Duration(binary.LittleEndian.Uint64(data[56:64])) * time.Millisecond
+
+	// the play duration includes the preroll buffer
+	if play > preroll {
+		meta.Duration = play - preroll
+	}
+}
+
+// parseASFAudioStream reads the WAVEFORMATEX of an audio stream properties
+// object and reports whether it was one. Layout: stream type(16), error
+// correction type(16), time offset(8), type-specific length(4), error
+// correction length(4), flags(2), reserved(4), then the type-specific data.
+func parseASFAudioStream(data []byte, meta *AudioMetadata) bool {
+	if len(data) < 54 || !bytes.Equal(data[0:16], asfAudioMedia) {
+		return false
+	}
+	format := data[54:]
+	// WAVEFORMATEX: format tag(2), channels(2), sample rate(4), bytes/sec(4),
+	// block align(2), bits per sample(2)
+	if length := binary.LittleEndian.Uint32(data[40:44]); length < 16 || len(format) < 16 {
+		return false
+	}
+
+	meta.Channels = int(binary.LittleEndian.Uint16(format[2:4]))
+	meta.SampleRate = int(binary.LittleEndian.Uint32(format[4:8]))
+	meta.Bitrate = int(binary.LittleEndian.Uint32(format[8:12])) * 8
+	return meta.SampleRate > 0 && meta.Channels > 0
+}
@@ -0,0 +1,91 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func asfTestObject(guid []byte, payload []byte) []byte {
+	object := append([]byte{}, guid...)
+	object = binary.LittleEndian.AppendUint64(object, uint64(asfObjectHeaderSize+len(payload)))
+	return append(object, payload...)
+}
+
+// writeTestWMA builds a WMA header with a file properties object and an audio
+// stream properties object, followed by some fake packet data
+func writeTestWMA(t *testing.T, sampleRate, channels, bitrate int, duration time.Duration) string {
+	t.Helper()
+
+	props := make([]byte, 80)
+	preroll := 3000 * time.Millisecond
+	binary.LittleEndian.PutUint64(props[40:], uint64((duration+preroll)/100))
+	binary.LittleEndian.PutUint64(props[56:], uint64(preroll/time.Millisecond))
+
+	stream := make([]byte, 54+18)
+	copy(stream, asfAudioMedia)
+	binary.LittleEndian.PutUint32(stream[40:], 18)
+	format := stream[54:]
+	binary.LittleEndian.PutUint16(format[0:], 0x0161) // WMA v2
+	binary.LittleEndian.PutUint16(format[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(format[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(format[8:], uint32(bitrate/8))
+
+	// an object we don't know, to check it gets skipped
+	other := asfTestObject(make([]byte, 16), make([]byte, 10))
+
+	objects := append(append(other, asfTestObject(asfFileProperties, props)...), asfTestObject(asfStreamProperties, stream)...)
+	header := append([]byte{}, asfHeaderObject...)
+	header = binary.LittleEndian.AppendUint64(header, uint64(30+len(objects)))
+	header = binary.LittleEndian.AppendUint32(header, 3)
+	header = append(header, 1, 2)
+	data := append(append(header, objects...), make([]byte, 2048)...)
+
+	path := filepath.Join(t.TempDir(), "fixture.wma")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestAnalyzeWMA(t *testing.T) {
+	meta, err := NewAudioAnalyzer().AnalyzeFile(writeTestWMA(t, 44100, 2, 192000, 95*time.Second))
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+
+	if meta.Format != "WMA" {
+		t.Errorf("Format = %q, want WMA", meta.Format)
+	}
+	if meta.SampleRate != 44100 || meta.Channels != 2 || meta.Bitrate != 192000 {
+		t.Errorf("got %d Hz, %d ch, %d bps, want 44100 Hz, 2 ch, 192000 bps", meta.SampleRate, meta.Channels, meta.Bitrate)
+	}
+	if meta.Duration != 95*time.Second {
+		t.Errorf("Duration = %v, want 1m35s without the preroll", meta.Duration)
+	}
+	if meta.Fingerprint == "" {
+		t.Error("Fingerprint should be set")
+	}
+
+	// long stereo file with nothing in the name: categorized like a WAV would be
+	if got := NewAudioAnalyzer().InferCategoryWithConfidence(meta, "take_03.wma").Category; got != "Ambient" {
+		t.Errorf("InferCategoryWithConfidence() = %q, want Ambient", got)
+	}
+}
+
+func TestAnalyzeWMANotASF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.wma")
+	if err := os.WriteFile(path, make([]byte, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v, should fall back", err)
+	}
+	if meta.Format != "wma" {
+		t.Errorf("Format = %q, want the wma fallback", meta.Format)
+	}
+}
@@ -0,0 +1,825 @@
+package tidy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dhowden/tag"
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+// AudioMetadata is what AnalyzeFile reads from a file
+type AudioMetadata struct {
+	Duration        time.Duration
+	SampleRate      int
+	Channels        int
+	BitDepth        int
+	Bitrate         int
+	Format          string
+	Title           string
+	Artist          string
+	Album           string
+	Genre           string
+	Year            int
+	Comment         string
+	HasEmbeddedTags bool
+
+	// Spectral analysis features
+	SpectralFeatures *SpectralFeatures `json:"spectral_features,omitempty"`
+
+	// Audio fingerprint for duplicate detection
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Broadcast WAV (bext chunk) info, WAV only
+	BWF *BroadcastInfo `json:"bwf,omitempty"`
+
+	// field recorder production info (iXML chunk), WAV only
+	IXML *IXMLInfo `json:"ixml,omitempty"`
+
+	// loop region and cue markers in sample frames (smpl/cue chunks), WAV only
+	Loop      *LoopInfo `json:"loop,omitempty"`
+	CuePoints []uint32  `json:"cue_points,omitempty"`
+
+	// dead air before the first and after the last sample above the silence threshold, WAV only
+	SilenceLeadMs int64 `json:"silence_lead_ms,omitempty"`
+	SilenceTailMs int64 `json:"silence_tail_ms,omitempty"`
+
+	// sample peak and integrated loudness (BS.1770), and the gain that would
+	// bring the file to the analyzer's LoudnessTarget, WAV only
+	PeakDBFS        float64 `json:"peak_dbfs,omitempty"`
+	LoudnessLUFS    float64 `json:"loudness_lufs,omitempty"`
+	SuggestedGainDB float64 `json:"suggested_gain_db,omitempty"`
+
+	// the peak a PEAK chunk claims, and whether it's more than 1 dB off the
+	// one measured from the samples, WAV only
+	EmbeddedPeak *PeakInfo `json:"embedded_peak,omitempty"`
+	PeakMismatch bool      `json:"peak_mismatch,omitempty"`
+
+	// tempo of music and loops, from a TBPM or BPM tag of any format, or
+	// else estimated from the audio, WAV only
+	BPM float64 `json:"bpm,omitempty"`
+
+	// musical key from a TKEY or INITIALKEY tag, like Am or F#
+	Key string `json:"key,omitempty"`
+
+	// transients in the first 10 seconds, WAV and AIFF only
+	OnsetCount int `json:"onset_count,omitempty"`
+
+	// coarse spectrogram of the first 10 seconds as 16 hex digits, for finding
+	// near-duplicates by Hamming distance, WAV and AIFF only
+	PerceptualHash string `json:"perceptual_hash,omitempty"`
+
+	// speaker layout from a WAVE_FORMAT_EXTENSIBLE channel mask (quad, 5.1,
+	// 7.1...) and whether it has a low-frequency channel, WAV only
+	ChannelLayout string `json:"channel_layout,omitempty"`
+	LFE           bool   `json:"lfe,omitempty"`
+
+	// stereo with identical channels, could be downmixed to mono, WAV only
+	DualMono bool `json:"dual_mono,omitempty"`
+
+	// the extension the content calls for, set when the file's own is missing or wrong
+	Extension string `json:"extension,omitempty"`
+}
+
+type SpectralFeatures struct {
+	LowEnergy    float64 // 0-200 Hz
+	MidEnergy    float64 // 200-2000 Hz
+	HighEnergy   float64 // 2000+ Hz
+	ZeroCrossing float64 // zero crossing rate
+	Centroid     float64 // spectral centroid (Hz)
+	Energy       float64 // total energy
+}
+
+// the widest formats a header is believed about. Anything outside is a
+// corrupt or crafted header, and sizing buffers from it could take all memory.
+const (
+	maxSampleRate = 768000
+	maxChannels   = 64
+)
+
+// checkFormat rejects sample rates and channel counts no real file has
+func checkFormat(sampleRate float64, channels int) error {
+	if sampleRate < 1 || sampleRate > maxSampleRate {
+		return fmt.Errorf("implausible sample rate %.0f", sampleRate)
+	}
+	if channels < 1 || channels > maxChannels {
+		return fmt.Errorf("implausible channel count %d", channels)
+	}
+	return nil
+}
+
+// how far apart (as a fraction of full scale) the two channels of a stereo file
+// may be and still count as dual mono, about -80 dB
+const dualMonoEpsilon = 1e-4
+
+// AudioAnalyzer reads audio files and categorizes and tags them from what it
+// finds. NewAudioAnalyzer sets the defaults, change the fields before use.
+type AudioAnalyzer struct {
+	SilenceThreshold     float64         // dBFS, samples at or below this count as silence
+	FingerprintPrecision time.Duration   // durations are rounded to this in fingerprints
+	AnalysisWindow       time.Duration   // how much of the start of a WAV or AIFF file the spectral features cover
+	Scoring              ScoringConfig   // thresholds for tags and spectral scoring
+	LoudnessTarget       *LoudnessTarget // reference for suggested gains, nil for none
+
+	// Verbosef gets why each file got its category, nil stays silent
+	Verbosef func(format string, args ...any)
+}
+
+// DefaultFingerprintPrecision is fine enough that clips of clearly different
+// length never share a fingerprint, and coarse enough to forgive the odd
+// padding sample a re-export adds
+const DefaultFingerprintPrecision = 10 * time.Millisecond
+
+// DefaultAnalysisWindow covers the attack and some of the body of most
+// sounds, which is what tells an impact from a drone
+const DefaultAnalysisWindow = 2 * time.Second
+
+// frames decoded at a time for spectral analysis, whatever the window
+const spectralChunkFrames = 4096
+
+// NewAudioAnalyzer returns an analyzer with the default thresholds
+func NewAudioAnalyzer() *AudioAnalyzer {
+	return &AudioAnalyzer{
+		SilenceThreshold:     DefaultSilenceThreshold,
+		FingerprintPrecision: DefaultFingerprintPrecision,
+		AnalysisWindow:       DefaultAnalysisWindow,
+		Scoring:              DefaultScoringConfig(),
+	}
+}
+
+// ErrUnsupportedFormat means AnalyzeFile couldn't tell what kind of audio a
+// file without an extension is
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// AnalyzeFile reads the format, tags and audio of the file at filePath.
+// Files without an extension have to be recognizable from their content, or
+// it returns ErrUnsupportedFormat.
+func (aa *AudioAnalyzer) AnalyzeFile(filePath string) (*AudioMetadata, error) {
+	meta := &AudioMetadata{}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	if err := aa.readEmbeddedTags(file, meta); err != nil {
+		// no embedded tags, that's fine
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("failed to seek file: %w", err)
+	}
+
+	// the content decides the format, the extension may be missing or lie
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if sniffed := sniffFormat(file); sniffed != "" && !sameFormat(sniffed, ext) {
+		meta.Extension = sniffed
+		ext = sniffed
+	}
+
+	switch ext {
+	case ".wav":
+		if err := aa.analyzeWAV(file, meta); err != nil {
+			return nil, fmt.Errorf("failed to analyze WAV: %w", err)
+		}
+		// the decode passes size their buffers from the header's rate and
+		// channels, a corrupt or crafted one only gets the header read
+		if checkFormat(float64(meta.SampleRate), meta.Channels) == nil {
+			// perform spectral analysis on WAV files
+			if _, err := file.Seek(0, 0); err == nil {
+				if err := aa.analyzeSpectral(file, meta); err != nil {
+					// spectral analysis failed, but that's okay - continue without it
+				}
+			}
+			// count transients to tell one-shots from sustained sounds
+			if _, err := file.Seek(0, 0); err == nil {
+				if err := aa.analyzeOnsets(file, meta); err != nil {
+					// no PCM data to scan, leave the count at zero
+				}
+			}
+			// find dead air at the start and end for trim suggestions
+			if _, err := file.Seek(0, 0); err == nil {
+				if err := aa.analyzeSilence(file, meta); err != nil {
+					// no PCM data to scan, leave silence unset
+				}
+			}
+			// measure peak and loudness for gain suggestions
+			if _, err := file.Seek(0, 0); err == nil {
+				if err := aa.analyzeLoudness(file, meta); err != nil {
+					// no PCM data to scan, leave loudness unset
+				}
+			}
+		}
+		// pick up BWF and other metadata chunks the decoder doesn't expose
+		if err := aa.readWAVChunks(file, meta); err != nil {
+			// no extra chunks, nothing to add
+		}
+	case ".mp3":
+		if err := aa.analyzeMP3(file, meta); err != nil {
+			// couldn't find frames, fall back to whatever the tags give us
+			if _, err := file.Seek(0, 0); err == nil {
+				if err := aa.analyzeCompressed(file, meta); err != nil {
+					meta.Format = ext[1:]
+				}
+			}
+		}
+	case ".flac":
+		if err := aa.analyzeFLAC(file, meta); err != nil {
+			return nil, fmt.Errorf("failed to analyze FLAC: %w", err)
+		}
+	case ".ogg":
+		if err := aa.analyzeOGG(file, meta); err != nil {
+			// not Vorbis (or broken), fall back to whatever the tags give us
+			if _, err := file.Seek(0, 0); err == nil {
+				if err := aa.analyzeCompressed(file, meta); err != nil {
+					meta.Format = ext[1:]
+				}
+			}
+		}
+	case ".aiff", ".aif":
+		if err := aa.analyzeAIFF(file, meta); err != nil {
+			// not a readable AIFF, fall back to whatever the tags give us
+			meta.Format = "AIFF"
+		}
+	case ".m4a":
+		if err := aa.analyzeMP4(file, meta); err != nil {
+			// no readable moov, fall back to whatever the tags give us
+			if _, err := file.Seek(0, 0); err == nil {
+				if err := aa.analyzeCompressed(file, meta); err != nil {
+					meta.Format = ext[1:]
+				}
+			}
+		}
+	case ".wma":
+		if err := aa.analyzeWMA(file, meta); err != nil {
+			// not a readable ASF header, fall back to whatever the tags give us
+			if _, err := file.Seek(0, 0); err == nil {
+				if err := aa.analyzeCompressed(file, meta); err != nil {
+					meta.Format = ext[1:]
+				}
+			}
+		}
+	case ".aac":
+		if err := aa.analyzeCompressed(file, meta); err != nil {
+			meta.Format = ext[1:]
+		}
+	case "":
+		// no extension and not something sniffFormat recognizes
+		return nil, ErrUnsupportedFormat
+	default:
+		meta.Format = ext[1:]
+	}
+
+	return meta, nil
+}
+
+func (aa *AudioAnalyzer) readEmbeddedTags(file *os.File, meta *AudioMetadata) error {
+	m, err := tag.ReadFrom(file)
+	if err != nil {
+		return err
+	}
+
+	meta.HasEmbeddedTags = true
+	meta.Title = m.Title()
+	meta.Artist = m.Artist()
+	meta.Album = m.Album()
+	meta.Genre = m.Genre()
+	meta.Year = m.Year()
+	meta.Comment = m.Comment()
+	readTagTempo(m.Raw(), meta)
+
+	format := m.Format()
+	meta.Format = string(format)
+
+	return nil
+}
+
+func (aa *AudioAnalyzer) analyzeWAV(file *os.File, meta *AudioMetadata) error {
+	decoder := wav.NewDecoder(file)
+	if !decoder.IsValidFile() {
+		return fmt.Errorf("invalid WAV file")
+	}
+
+	meta.Format = "WAV"
+
+	format := decoder.Format()
+	if format != nil {
+		meta.SampleRate = int(format.SampleRate)
+		meta.Channels = int(format.NumChannels)
+		meta.BitDepth = int(decoder.BitDepth) // read from the fmt chunk along with the format
+		if meta.BitDepth == 0 {
+			meta.BitDepth = 16
+		}
+	}
+
+	if format != nil && format.SampleRate > 0 {
+		duration, err := decoder.Duration()
+		if err == nil && duration > 0 {
+			meta.Duration = duration
+		} else {
+			// fallback: estimate from file size (44 bytes is typical WAV header)
+			fileInfo, err := file.Stat()
+			if err == nil {
+				bytesPerSample := int64(meta.BitDepth / 8)
+				if bytesPerSample > 0 {
+					dataSize := fileInfo.Size() - 44
+					if dataSize > 0 {
+						totalSamples := dataSize / (int64(format.NumChannels) * bytesPerSample)
+						if totalSamples > 0 {
+							durationSeconds := float64(totalSamples) / float64(format.SampleRate)
+							meta.Duration = time.Duration(durationSeconds * float64(time.Second))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if meta.SampleRate > 0 && meta.Channels > 0 && meta.BitDepth > 0 {
+		meta.Bitrate = meta.SampleRate * meta.Channels * meta.BitDepth
+	}
+
+	// generate fingerprint after we have all metadata
+	meta.Fingerprint = aa.GenerateFingerprint(meta)
+
+	return nil
+}
+
+func (aa *AudioAnalyzer) analyzeCompressed(file *os.File, meta *AudioMetadata) error {
+	m, err := tag.ReadFrom(file)
+	if err != nil {
+		return err
+	}
+
+	format := m.Format()
+	if format != "" {
+		meta.Format = string(format)
+	}
+
+	// rough duration estimate for compressed formats
+	if meta.Bitrate > 0 {
+		fileInfo, err := file.Stat()
+		if err == nil {
+			fileSizeBits := fileInfo.Size() * 8
+			durationSeconds := float64(fileSizeBits) / float64(meta.Bitrate)
+			meta.Duration = time.Duration(durationSeconds * float64(time.Second))
+		}
+	}
+
+	return nil
+}
+
+func (aa *AudioAnalyzer) InferCategoryFromAudio(meta *AudioMetadata, filename string) string {
+	// use duration as a hint
+	if meta.Duration > 0 {
+		if meta.Duration < 2*time.Second {
+			return "SFX_UI" // very short = probably UI sound
+		} else if meta.Duration < 5*time.Second {
+			return "SFX"
+		} else if meta.Duration > 30*time.Second {
+			// long file, check genre tag if available
+			if meta.HasEmbeddedTags && meta.Genre != "" {
+				genreLower := strings.ToLower(meta.Genre)
+				if strings.Contains(genreLower, "music") || strings.Contains(genreLower, "song") {
+					return "Music"
+				}
+			}
+			return "Ambient"
+		}
+	}
+
+	// channel count can also be a hint
+	if meta.Channels == 1 {
+		return "SFX" // mono = usually focused SFX
+	} else if meta.Channels >= 5 {
+		return "Ambient" // surround = probably ambient
+	}
+
+	if meta.HasEmbeddedTags {
+		if meta.Genre != "" {
+			genreLower := strings.ToLower(meta.Genre)
+			if strings.Contains(genreLower, "voice") || strings.Contains(genreLower, "dialogue") {
+				return "SFX_Voice"
+			}
+			if strings.Contains(genreLower, "music") {
+				return "Music"
+			}
+			if strings.Contains(genreLower, "ambient") {
+				return "Ambient"
+			}
+		}
+	}
+
+	return ""
+}
+
+func (aa *AudioAnalyzer) GenerateAudioTags(meta *AudioMetadata) []string {
+	tags := []string{}
+
+	if meta.Duration > 0 {
+		if meta.Duration < 1*time.Second {
+			tags = append(tags, "short", "<1s")
+		} else if meta.Duration < 5*time.Second {
+			tags = append(tags, "short", "1-5s")
+		} else if meta.Duration < 30*time.Second {
+			tags = append(tags, "medium", "5-30s")
+		} else {
+			tags = append(tags, "long", ">30s")
+		}
+	}
+
+	if meta.Channels == 1 {
+		tags = append(tags, "mono")
+	} else if meta.Channels == 2 {
+		tags = append(tags, "stereo")
+		if meta.DualMono {
+			tags = append(tags, "dual-mono")
+		}
+	} else if meta.Channels > 2 {
+		tags = append(tags, "multichannel", fmt.Sprintf("%dch", meta.Channels))
+		if meta.ChannelLayout != "" {
+			tags = append(tags, meta.ChannelLayout)
+		}
+		if meta.LFE {
+			tags = append(tags, "lfe")
+		}
+	}
+
+	if meta.SampleRate > 0 {
+		if meta.SampleRate >= aa.Scoring.HQSampleRate {
+			tags = append(tags, "hq", fmt.Sprintf("%dkHz", meta.SampleRate/1000)) // 48kHz+ is high quality
+		} else {
+			tags = append(tags, fmt.Sprintf("%dkHz", meta.SampleRate/1000))
+		}
+	}
+
+	if meta.BitDepth >= aa.Scoring.HQBitDepth {
+		tags = append(tags, "hq", fmt.Sprintf("%dbit", meta.BitDepth))
+	}
+
+	if meta.Bitrate > 0 {
+		if meta.Bitrate >= aa.Scoring.HighBitrate {
+			tags = append(tags, "hq", "high-bitrate")
+		}
+	}
+
+	if meta.Loop != nil {
+		tags = append(tags, "loop")
+	}
+
+	if meta.Extension != "" {
+		tags = append(tags, "extension-fixed")
+	}
+
+	if NeedsTrim(meta) {
+		tags = append(tags, "needs-trim")
+	}
+
+	if offTarget(meta) {
+		tags = append(tags, "loudness-off-target")
+	}
+
+	if meta.PeakMismatch {
+		tags = append(tags, "peak-mismatch")
+	}
+
+	if meta.BPM > 0 {
+		tags = append(tags, fmt.Sprintf("bpm:%.0f", meta.BPM))
+	}
+
+	if meta.Key != "" {
+		tags = append(tags, "key:"+meta.Key)
+	}
+
+	if meta.HasEmbeddedTags {
+		tags = append(tags, "tagged")
+		if meta.Genre != "" {
+			tags = append(tags, "genre:"+strings.ToLower(meta.Genre))
+		}
+	}
+
+	return tags
+}
+
+// analyzeSpectral performs basic spectral analysis on WAV files
+// extracts frequency characteristics to help with categorization
+func (aa *AudioAnalyzer) analyzeSpectral(file *os.File, meta *AudioMetadata) error {
+	if err := checkFormat(float64(meta.SampleRate), meta.Channels); err != nil {
+		return err
+	}
+
+	decoder := wav.NewDecoder(file)
+	if !decoder.IsValidFile() {
+		return fmt.Errorf("invalid WAV file")
+	}
+	if err := decoder.FwdToPCM(); err != nil {
+		return err
+	}
+
+	// the window is mixed down to one channel as it's decoded, a chunk at a
+	// time. Nothing is sized from the header: samples grow with what's
+	// actually read, up to the window or the data chunk, whichever is shorter.
+	frames := aa.analysisFrames(meta)
+	if frameSize := int64(decoder.BitDepth+7) / 8 * int64(meta.Channels); frameSize > 0 {
+		if dataFrames := decoder.PCMLen() / frameSize; dataFrames < int64(frames) {
+			frames = int(dataFrames)
+		}
+	}
+	var samples []float64
+	buf := &audio.IntBuffer{
+		Format: &audio.Format{
+			NumChannels: meta.Channels,
+			SampleRate:  meta.SampleRate,
+		},
+		Data: make([]int, spectralChunkFrames*meta.Channels),
+	}
+
+	// stereo files whose channels never differ by more than dualMonoEpsilon are
+	// dual mono; silence doesn't count since it proves nothing
+	fullScale := 32768.0
+	if meta.BitDepth > 0 {
+		fullScale = float64(int64(1) << (meta.BitDepth - 1))
+	}
+	dualMono, heard := meta.Channels == 2, false
+
+	for len(samples) < frames {
+		n, err := decoder.PCMBuffer(buf)
+		if err != nil || n == 0 {
+			break
+		}
+
+		// n is the number of samples read, each frame has Channels of them.
+		// Mono files use their one channel, everything else the average of
+		// the first two (the front pair of a surround file).
+		for i := 0; (i+1)*meta.Channels <= n && len(samples) < frames; i++ {
+			idx := i * meta.Channels
+			if meta.Channels == 1 {
+				samples = append(samples, float64(buf.Data[idx])/32768.0)
+				continue
+			}
+
+			left, right := float64(buf.Data[idx]), float64(buf.Data[idx+1])
+			if math.Abs(left-right)/fullScale > dualMonoEpsilon {
+				dualMono = false
+			}
+			if left != 0 || right != 0 {
+				heard = true
+			}
+			samples = append(samples, (left+right)/2.0/32768.0)
+		}
+	}
+
+	if len(samples) < 100 {
+		return fmt.Errorf("not enough samples for analysis")
+	}
+
+	features := &SpectralFeatures{}
+	aa.calculateSpectralFeatures(samples, meta.SampleRate, features)
+	meta.SpectralFeatures = features
+	meta.DualMono = dualMono && heard
+
+	return nil
+}
+
+// analysisFrames is how many frames from the start of a file go into the
+// spectral features: AnalysisWindow's worth, or the whole file if it's shorter
+func (aa *AudioAnalyzer) analysisFrames(meta *AudioMetadata) int {
+	window := aa.AnalysisWindow
+	if window <= 0 {
+		window = DefaultAnalysisWindow
+	}
+	frames := int(math.Ceil(window.Seconds() * float64(meta.SampleRate)))
+	if meta.Duration > 0 {
+		if total := int(math.Ceil(meta.Duration.Seconds() * float64(meta.SampleRate))); total < frames {
+			frames = total
+		}
+	}
+	return frames
+}
+
+// calculateSpectralFeatures computes frequency band energies, zero crossing rate, and spectral centroid
+func (aa *AudioAnalyzer) calculateSpectralFeatures(samples []float64, sampleRate int, features *SpectralFeatures) {
+	// calculate zero crossing rate
+	zeroCrossings := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1] >= 0 && samples[i] < 0) || (samples[i-1] < 0 && samples[i] >= 0) {
+			zeroCrossings++
+		}
+	}
+	features.ZeroCrossing = float64(zeroCrossings) / float64(len(samples))
+
+	// simple frequency band analysis using a basic FFT approximation
+	// we'll use a simplified approach: calculate energy in different frequency ranges
+	// by looking at sample variations and using a simple high-pass/low-pass concept
+
+	// calculate total energy
+	totalEnergy := 0.0
+	for _, s := range samples {
+		totalEnergy += s * s
+	}
+	features.Energy = totalEnergy / float64(len(samples))
+
+	// frequency band analysis using simple differentiation
+	// high frequencies = rapid changes, low frequencies = slow changes
+	lowFreqEnergy := 0.0
+	midFreqEnergy := 0.0
+	highFreqEnergy := 0.0
+
+	// use different window sizes to approximate frequency bands
+	// low: large window (slow changes)
+	// high: small window (fast changes)
+	windowLow := 100
+	windowMid := 20
+	windowHigh := 5
+
+	if len(samples) > windowLow {
+		// low frequency energy (0-200 Hz approximation)
+		for i := windowLow; i < len(samples); i++ {
+			diff := samples[i] - samples[i-windowLow]
+			lowFreqEnergy += diff * diff
+		}
+		lowFreqEnergy /= float64(len(samples) - windowLow)
+	}
+
+	if len(samples) > windowMid {
+		// mid frequency energy (200-2000 Hz approximation)
+		for i := windowMid; i < len(samples); i++ {
+			diff := samples[i] - samples[i-windowMid]
+			midFreqEnergy += diff * diff
+		}
+		midFreqEnergy /= float64(len(samples) - windowMid)
+	}
+
+	if len(samples) > windowHigh {
+		// high frequency energy (2000+ Hz approximation)
+		for i := windowHigh; i < len(samples); i++ {
+			diff := samples[i] - samples[i-windowHigh]
+			highFreqEnergy += diff * diff
+		}
+		highFreqEnergy /= float64(len(samples) - windowHigh)
+	}
+
+	features.LowEnergy = lowFreqEnergy
+	features.MidEnergy = midFreqEnergy
+	features.HighEnergy = highFreqEnergy
+
+	// spectral centroid approximation
+	// weighted average frequency - higher = brighter sound
+	totalWeighted := 0.0
+	totalWeight := 0.0
+	for i := 1; i < len(samples); i++ {
+		// use sample index as frequency proxy
+		freq := float64(i) * float64(sampleRate) / float64(len(samples))
+		magnitude := math.Abs(samples[i] - samples[i-1])
+		totalWeighted += freq * magnitude
+		totalWeight += magnitude
+	}
+	if totalWeight > 0 {
+		features.Centroid = totalWeighted / totalWeight
+	} else {
+		features.Centroid = float64(sampleRate) / 4 // default to mid-range
+	}
+}
+
+// describeMetadata collects the free-text descriptions recorders and libraries
+// embed (BWF description, iXML scene/note/track names) into one string
+func describeMetadata(meta *AudioMetadata) string {
+	var parts []string
+	if meta.BWF != nil && meta.BWF.Description != "" {
+		parts = append(parts, meta.BWF.Description)
+	}
+	if meta.IXML != nil {
+		if meta.IXML.Scene != "" {
+			parts = append(parts, meta.IXML.Scene)
+		}
+		if meta.IXML.Note != "" {
+			parts = append(parts, meta.IXML.Note)
+		}
+		parts = append(parts, meta.IXML.TrackNames...)
+	}
+	return strings.Join(parts, " ")
+}
+
+// GenerateFingerprint creates a hash-based fingerprint for duplicate detection
+func (aa *AudioAnalyzer) GenerateFingerprint(meta *AudioMetadata) string {
+	precision := aa.FingerprintPrecision
+	if precision <= 0 {
+		precision = DefaultFingerprintPrecision
+	}
+
+	// combine key characteristics into a fingerprint, the duration in steps
+	// of the precision
+	fpData := fmt.Sprintf("%d|%d|%d|%d|%s|%s",
+		meta.SampleRate,
+		meta.Channels,
+		meta.BitDepth,
+		int64(meta.Duration.Round(precision)/precision),
+		meta.Format,
+		meta.Title, // include title if available
+	)
+
+	hash := sha256.Sum256([]byte(fpData))
+	return hex.EncodeToString(hash[:16]) // use first 16 bytes (32 hex chars)
+}
+
+// CategoryResult is a category with a confidence score (0.0-1.0)
+type CategoryResult struct {
+	Category   string
+	Confidence float64
+}
+
+// InferCategoryWithConfidence returns category with confidence score (0.0-1.0)
+// combines filename patterns, metadata, and spectral features. Categories
+// with the same score are settled by rule Priority and then by name, the
+// same way everywhere, so a file gets the same category on every run.
+func (aa *AudioAnalyzer) InferCategoryWithConfidence(meta *AudioMetadata, filename string) CategoryResult {
+	filenameLower := strings.ToLower(filename)
+
+	// Start with filename-based category matching
+	scores := InferCategoryWithConfidenceScores(filename)
+
+	// filename didn't match anything, embedded descriptions are the next best text to go on
+	if len(scores) == 0 {
+		if text := describeMetadata(meta); text != "" {
+			scores = InferCategoryWithConfidenceScores(text)
+		}
+	}
+
+	// Apply metadata-based scoring
+	ApplyMetadataScoring(scores, meta, filenameLower)
+
+	// spectral analysis scoring (low-medium confidence)
+	if meta.SpectralFeatures != nil {
+		sf := meta.SpectralFeatures
+		sc := aa.Scoring
+
+		// high zero crossing rate = noisy/percussive sounds (impacts, weapons)
+		if sf.ZeroCrossing > sc.NoisyZeroCrossing {
+			scores["SFX_Impact"] += 0.3
+			scores["SFX_Weapon"] += 0.3
+		}
+
+		// high energy in low frequencies = impacts, explosions, bass
+		if sf.LowEnergy > sc.LowEnergy && sf.LowEnergy > sf.MidEnergy && sf.LowEnergy > sf.HighEnergy {
+			scores["SFX_Impact"] += 0.4
+		}
+
+		// high energy in high frequencies = UI sounds, clicks, sharp impacts
+		if sf.HighEnergy > sc.HighEnergy && sf.HighEnergy > sf.MidEnergy {
+			scores["SFX_UI"] += 0.3
+			scores["SFX_Impact"] += 0.2
+		}
+
+		// balanced energy across bands = ambient/music
+		if sf.LowEnergy > sc.BandEnergyFloor && sf.MidEnergy > sc.BandEnergyFloor && sf.HighEnergy > sc.BandEnergyFloor {
+			balance := math.Min(sf.LowEnergy, math.Min(sf.MidEnergy, sf.HighEnergy)) /
+				math.Max(sf.LowEnergy, math.Max(sf.MidEnergy, sf.HighEnergy))
+			if balance > sc.BalancedBands {
+				scores["Ambient"] += 0.3
+				scores["Music"] += 0.2
+			}
+		}
+
+		// low spectral centroid = dark/ambient, high = bright/UI
+		if sf.Centroid < sc.DarkCentroid {
+			scores["Ambient"] += 0.2
+		} else if sf.Centroid > sc.BrightCentroid {
+			scores["SFX_UI"] += 0.2
+		}
+
+		// onsets are counted alongside the spectral features
+		ApplyOnsetScoring(scores, meta)
+	}
+
+	// find best category
+	bestCategory := "SFX"
+	bestScore := 0.0
+	for cat, score := range scores {
+		if score > bestScore || (score == bestScore && score > 0 && tieBreakCategory(cat, bestCategory)) {
+			bestScore = score
+			bestCategory = cat
+		}
+	}
+
+	if aa.Verbosef != nil {
+		if len(scores) == 0 {
+			aa.Verbosef("%s: analysis found nothing to go on, using %s", filename, bestCategory)
+		} else {
+			aa.Verbosef("%s: analysis picked %s (scores: %s)", filename, bestCategory, DescribeScores(scores))
+		}
+	}
+
+	return CategoryResult{
+		Category:   bestCategory,
+		Confidence: NormalizeConfidence(bestScore),
+	}
+}
@@ -0,0 +1,503 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestGenerateFingerprint(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	tests := []struct {
+		name     string
+		meta     *AudioMetadata
+		expected string // we'll just check it's not empty and consistent
+	}{
+		{
+			name: "basic_metadata",
+			meta: &AudioMetadata{
+				SampleRate: 44100,
+				Channels:   2,
+				BitDepth:   16,
+				Duration:   5 * time.Second,
+				Format:     "WAV",
+				Title:      "Test",
+			},
+		},
+		{
+			name: "different_metadata",
+			meta: &AudioMetadata{
+				SampleRate: 48000,
+				Channels:   1,
+				BitDepth:   24,
+				Duration:   10 * time.Second,
+				Format:     "MP3",
+				Title:      "Different",
+			},
+		},
+		{
+			name: "no_title",
+			meta: &AudioMetadata{
+				SampleRate: 44100,
+				Channels:   2,
+				BitDepth:   16,
+				Duration:   5 * time.Second,
+				Format:     "WAV",
+				Title:      "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp1 := aa.GenerateFingerprint(tt.meta)
+			fp2 := aa.GenerateFingerprint(tt.meta)
+
+			// fingerprint should be consistent
+			if fp1 != fp2 {
+				t.Errorf("GenerateFingerprint() inconsistent: %q != %q", fp1, fp2)
+			}
+
+			// fingerprint should be 32 hex characters (16 bytes)
+			if len(fp1) != 32 {
+				t.Errorf("GenerateFingerprint() length = %d, want 32", len(fp1))
+			}
+
+			// fingerprint should not be empty
+			if fp1 == "" {
+				t.Error("GenerateFingerprint() returned empty string")
+			}
+		})
+	}
+
+	// test that different metadata produces different fingerprints
+	fp1 := aa.GenerateFingerprint(tests[0].meta)
+	fp2 := aa.GenerateFingerprint(tests[1].meta)
+	if fp1 == fp2 {
+		t.Error("GenerateFingerprint() should produce different fingerprints for different metadata")
+	}
+}
+
+func TestFingerprintDurationPrecision(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name      string
+		precision time.Duration
+		a, b      time.Duration
+		same      bool
+	}{
+		{"within a second", 0, 4400 * ms, 4600 * ms, false},
+		{"across a second", 0, 4900 * ms, 5100 * ms, false},
+		{"a frame of padding", 0, 4400 * ms, 4402 * ms, true},
+		{"coarse", time.Second, 4600 * ms, 5400 * ms, true},
+		{"coarse, far apart", time.Second, 4400 * ms, 5600 * ms, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aa := NewAudioAnalyzer()
+			if tt.precision > 0 {
+				aa.FingerprintPrecision = tt.precision
+			}
+			fpA := aa.GenerateFingerprint(&AudioMetadata{SampleRate: 48000, Channels: 2, BitDepth: 24, Duration: tt.a, Format: "WAV"})
+			fpB := aa.GenerateFingerprint(&AudioMetadata{SampleRate: 48000, Channels: 2, BitDepth: 24, Duration: tt.b, Format: "WAV"})
+			if (fpA == fpB) != tt.same {
+				t.Errorf("%v and %v at %v: same fingerprint = %v, want %v", tt.a, tt.b, aa.FingerprintPrecision, fpA == fpB, tt.same)
+			}
+		})
+	}
+}
+
+func TestCalculateSpectralFeatures(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	tests := []struct {
+		name     string
+		samples  []float64
+		sampleRate int
+		checkFunc func(*SpectralFeatures) bool
+	}{
+		{
+			name: "sine_wave_like",
+			samples: generateSineWave(1000, 44100),
+			sampleRate: 44100,
+			checkFunc: func(f *SpectralFeatures) bool {
+				return f.Energy > 0 && f.ZeroCrossing >= 0 && f.ZeroCrossing <= 1
+			},
+		},
+		{
+			name: "noisy_signal",
+			samples: generateNoisySignal(1000),
+			sampleRate: 44100,
+			checkFunc: func(f *SpectralFeatures) bool {
+				return f.Energy > 0 && f.ZeroCrossing >= 0 // just check it's valid
+			},
+		},
+		{
+			name: "silence",
+			samples: make([]float64, 1000),
+			sampleRate: 44100,
+			checkFunc: func(f *SpectralFeatures) bool {
+				return f.Energy == 0 && f.ZeroCrossing == 0
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features := &SpectralFeatures{}
+			aa.calculateSpectralFeatures(tt.samples, tt.sampleRate, features)
+
+			if !tt.checkFunc(features) {
+				t.Errorf("calculateSpectralFeatures() failed validation for %s", tt.name)
+			}
+
+			// basic sanity checks
+			if features.ZeroCrossing < 0 || features.ZeroCrossing > 1 {
+				t.Errorf("ZeroCrossing = %f, should be between 0 and 1", features.ZeroCrossing)
+			}
+			if features.Energy < 0 {
+				t.Errorf("Energy = %f, should be non-negative", features.Energy)
+			}
+			if features.Centroid < 0 {
+				t.Errorf("Centroid = %f, should be non-negative", features.Centroid)
+			}
+		})
+	}
+}
+
+func TestInferCategoryWithConfidence(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	tests := []struct {
+		name     string
+		filename string
+		meta     *AudioMetadata
+		expectedCategory string
+		minConfidence    float64
+	}{
+		{
+			name:     "scream_voice",
+			filename:  "scream_male.wav",
+			meta:      &AudioMetadata{Duration: 2 * time.Second, Channels: 1},
+			expectedCategory: "SFX_Voice",
+			minConfidence:    0.5,
+		},
+		{
+			name:     "creature_roar",
+			filename:  "creature_roar.wav",
+			meta:      &AudioMetadata{Duration: 3 * time.Second, Channels: 2},
+			expectedCategory: "SFX_Creature",
+			minConfidence:    0.5,
+		},
+		{
+			name:     "short_ui",
+			filename:  "button_click.wav",
+			meta:      &AudioMetadata{Duration: 500 * time.Millisecond, Channels: 1},
+			expectedCategory: "SFX_UI",
+			minConfidence:    0.5,
+		},
+		{
+			name:     "long_ambient",
+			filename:  "wind_ambient.wav",
+			meta:      &AudioMetadata{Duration: 60 * time.Second, Channels: 2},
+			expectedCategory: "Ambient",
+			minConfidence:    0.4,
+		},
+		{
+			name:     "weapon_gun",
+			filename:  "gun_shot.wav",
+			meta:      &AudioMetadata{Duration: 1 * time.Second, Channels: 1},
+			expectedCategory: "SFX_Weapon",
+			minConfidence:    0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := aa.InferCategoryWithConfidence(tt.meta, tt.filename)
+
+			if result.Category != tt.expectedCategory {
+				t.Errorf("InferCategoryWithConfidence() Category = %q, want %q", result.Category, tt.expectedCategory)
+			}
+
+			if result.Confidence < tt.minConfidence {
+				t.Errorf("InferCategoryWithConfidence() Confidence = %f, want >= %f", result.Confidence, tt.minConfidence)
+			}
+
+			if result.Confidence < 0 || result.Confidence > 1 {
+				t.Errorf("InferCategoryWithConfidence() Confidence = %f, should be between 0 and 1", result.Confidence)
+			}
+		})
+	}
+}
+
+func TestInferCategoryWithConfidenceTies(t *testing.T) {
+	aa := NewAudioAnalyzer()
+	meta := &AudioMetadata{Duration: 3 * time.Second, Channels: 2}
+
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"door_slam.wav", "SFX_Impact"}, // Object and Impact tie, Impact has the higher priority
+		{"gun_hit.wav", "SFX_Impact"},   // Weapon and Impact tie on priority too, so by name
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 200; i++ {
+			if got := aa.InferCategoryWithConfidence(meta, tt.filename).Category; got != tt.want {
+				t.Fatalf("InferCategoryWithConfidence(%q) run %d = %q, want %q", tt.filename, i, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestGenerateAudioTags(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	tests := []struct {
+		name     string
+		meta     *AudioMetadata
+		expectedTags []string
+	}{
+		{
+			name: "short_mono",
+			meta: &AudioMetadata{
+				Duration: 500 * time.Millisecond,
+				Channels: 1,
+				SampleRate: 44100,
+			},
+			expectedTags: []string{"short", "<1s", "mono"},
+		},
+		{
+			name: "long_stereo",
+			meta: &AudioMetadata{
+				Duration: 60 * time.Second,
+				Channels: 2,
+				SampleRate: 48000,
+			},
+			expectedTags: []string{"long", ">30s", "stereo", "hq", "48kHz"},
+		},
+		{
+			name: "high_quality",
+			meta: &AudioMetadata{
+				Duration: 5 * time.Second,
+				Channels: 2,
+				SampleRate: 96000,
+				BitDepth: 24,
+			},
+			expectedTags: []string{"medium", "5-30s", "stereo", "hq", "96kHz", "hq", "24bit"}, // 5 seconds is medium, not short
+		},
+		{
+			name: "with_genre",
+			meta: &AudioMetadata{
+				Duration: 10 * time.Second,
+				Channels: 2,
+				HasEmbeddedTags: true,
+				Genre: "Horror",
+			},
+			expectedTags: []string{"medium", "5-30s", "stereo", "tagged", "genre:horror"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := aa.GenerateAudioTags(tt.meta)
+
+			for _, expectedTag := range tt.expectedTags {
+				if !containsTag(tags, expectedTag) {
+					t.Errorf("GenerateAudioTags() missing tag %q, got %v", expectedTag, tags)
+				}
+			}
+		})
+	}
+}
+
+// Helper functions for generating test data
+
+func generateSineWave(length int, sampleRate int) []float64 {
+	samples := make([]float64, length)
+	freq := 440.0 // A4 note
+	for i := 0; i < length; i++ {
+		samples[i] = math.Sin(2 * math.Pi * freq * float64(i) / float64(sampleRate))
+	}
+	return samples
+}
+
+func generateNoisySignal(length int) []float64 {
+	samples := make([]float64, length)
+	for i := 0; i < length; i++ {
+		// generate random-like signal
+		samples[i] = math.Sin(float64(i)*0.1) * 0.5 + math.Sin(float64(i)*0.3)*0.3 + math.Sin(float64(i)*0.7)*0.2
+	}
+	return samples
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAnalyzeDualMono(t *testing.T) {
+	// 16-bit stereo frames from a left and right signal
+	stereo := func(left, right func(i int) float64) []byte {
+		pcm := make([]byte, 4*4800)
+		for i := 0; i < 4800; i++ {
+			binary.LittleEndian.PutUint16(pcm[4*i:], uint16(int16(left(i)*32767)))
+			binary.LittleEndian.PutUint16(pcm[4*i+2:], uint16(int16(right(i)*32767)))
+		}
+		return pcm
+	}
+	sine := func(freq float64) func(int) float64 {
+		return func(i int) float64 { return 0.5 * math.Sin(2*math.Pi*freq*float64(i)/48000) }
+	}
+	silent := func(int) float64 { return 0 }
+
+	tests := []struct {
+		name     string
+		channels int
+		pcm      []byte
+		want     bool
+	}{
+		{"identical channels", 2, stereo(sine(440), sine(440)), true},
+		{"different channels", 2, stereo(sine(440), sine(660)), false},
+		{"one channel silent", 2, stereo(sine(440), silent), false},
+		{"all silence", 2, stereo(silent, silent), false},
+		{"mono", 1, make([]byte, 2*4800), false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "test.wav")
+		if err := os.WriteFile(path, buildTestWAV(48000, tt.channels, 16, tt.pcm), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.DualMono != tt.want {
+			t.Errorf("%s: DualMono = %v, want %v", tt.name, meta.DualMono, tt.want)
+		}
+		tags := NewAudioAnalyzer().GenerateAudioTags(meta)
+		if got := containsTag(tags, "dual-mono"); got != tt.want {
+			t.Errorf("%s: dual-mono tag = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// surroundSquareWAV is 192kHz 5.1, a 1kHz square wave on the front pair and
+// silence on the other four channels
+func surroundSquareWAV(seconds int) []byte {
+	const rate, channels = 192000, 6
+	pcm := make([]byte, 0, rate*seconds*channels*2)
+	for i := 0; i < rate*seconds; i++ {
+		v := int16(16000)
+		if (i/96)%2 == 1 {
+			v = -16000
+		}
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+		pcm = append(pcm, make([]byte, (channels-2)*2)...)
+	}
+	return buildTestWAV(rate, channels, 16, pcm)
+}
+
+// spectralAllocs runs the spectral analysis of the file at path with the
+// given window and returns the metadata and the bytes allocated doing it
+func spectralAllocs(t *testing.T, path string, window time.Duration) (*AudioMetadata, uint64) {
+	t.Helper()
+	aa := NewAudioAnalyzer()
+	aa.AnalysisWindow = window
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	meta := &AudioMetadata{}
+	if err := aa.analyzeWAV(file, meta); err != nil {
+		t.Fatalf("analyzeWAV() error = %v", err)
+	}
+	file.Seek(0, 0)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	err = aa.analyzeSpectral(file, meta)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatalf("analyzeSpectral() error = %v", err)
+	}
+	return meta, after.TotalAlloc - before.TotalAlloc
+}
+
+func TestAnalyzeSpectralHighSampleRate(t *testing.T) {
+	short := writeTestFile(t, "short.wav", surroundSquareWAV(1))
+	long := writeTestFile(t, "long.wav", surroundSquareWAV(4))
+
+	for _, window := range []time.Duration{250 * time.Millisecond, time.Second} {
+		meta, shortAlloc := spectralAllocs(t, short, window)
+		_, longAlloc := spectralAllocs(t, long, window)
+
+		// one crossing per 96 frames, from the front pair alone
+		if zcr := meta.SpectralFeatures.ZeroCrossing; zcr < 0.009 || zcr > 0.0115 {
+			t.Errorf("window %v: ZeroCrossing = %.4f, want about 1/96", window, zcr)
+		}
+
+		// only the window is read, however much more file there is
+		if longAlloc > shortAlloc+64*1024 {
+			t.Errorf("window %v: a 4s file took %d bytes, a 1s one %d", window, longAlloc, shortAlloc)
+		}
+	}
+
+	// a header claiming 4 GHz is refused before anything is sized from it
+	crafted := surroundSquareWAV(1)
+	binary.LittleEndian.PutUint32(crafted[24:], 0xFFFFFFFF) // fmt sample rate
+	file, err := os.Open(writeTestFile(t, "crafted.wav", crafted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := NewAudioAnalyzer().analyzeSpectral(file, &AudioMetadata{SampleRate: 0xFFFFFFFF, Channels: 6}); err == nil {
+		t.Error("analyzeSpectral() accepted a 4 GHz sample rate")
+	}
+
+	// a data chunk that claims more than the file holds is read for what's there
+	truncated := surroundSquareWAV(1)[:44+192000*6*2/4]
+	meta, _ := spectralAllocs(t, writeTestFile(t, "truncated.wav", truncated), time.Second)
+	if meta.SpectralFeatures == nil {
+		t.Error("a truncated file should still get spectral features")
+	}
+
+	// a window longer than the file stops at its end
+	aa := NewAudioAnalyzer()
+	aa.AnalysisWindow = time.Minute
+	if frames := aa.analysisFrames(&AudioMetadata{SampleRate: 192000, Duration: 4 * time.Second}); frames != 4*192000 {
+		t.Errorf("analysisFrames() = %d, want the whole 4s file", frames)
+	}
+}
+
+func TestAnalyzeWAVBitDepth(t *testing.T) {
+	aa := NewAudioAnalyzer()
+	for _, bits := range []int{8, 16, 24, 32} {
+		path := writeTestFile(t, "tone.wav", buildTestWAV(48000, 1, bits, make([]byte, 48000*bits/8)))
+		meta, err := aa.AnalyzeFile(path)
+		if err != nil {
+			t.Fatalf("AnalyzeFile() %d-bit error = %v", bits, err)
+		}
+		if meta.BitDepth != bits {
+			t.Errorf("AnalyzeFile() BitDepth = %d, want %d from the fmt chunk", meta.BitDepth, bits)
+		}
+	}
+}
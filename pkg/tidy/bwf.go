@@ -0,0 +1,36 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// BroadcastInfo holds the fields we use from a Broadcast WAV "bext" chunk
+type BroadcastInfo struct {
+	Description     string
+	Originator      string
+	OriginationDate string // yyyy-mm-dd as written by the recorder
+	TimeReference   uint64 // sample offset since midnight
+}
+
+// bext layout: 256 description, 32 originator, 32 originator reference,
+// 10 origination date, 8 origination time, 8 time reference (low/high uint32)
+const bextMinSize = 256 + 32 + 32 + 10 + 8 + 8
+
+// parseBext decodes the fixed-size leading fields of a bext chunk
+func parseBext(data []byte) (*BroadcastInfo, error) {
+	if len(data) < bextMinSize {
+		return nil, fmt.Errorf("bext chunk too short")
+	}
+
+	info := &BroadcastInfo{
+		Description:     fixedString(data[0:256]),
+		Originator:      fixedString(data[256:288]),
+		OriginationDate: fixedString(data[320:330]),
+	}
+	low := uint64(binary.LittleEndian.Uint32(data[338:342]))
+	high := uint64(binary.LittleEndian.Uint32(data[342:346]))
+	info.TimeReference = high<<32 | low
+
+	return info, nil
+}
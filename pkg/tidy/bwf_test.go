@@ -0,0 +1,65 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func buildTestBext(description, originator, date string, timeRef uint64) []byte {
+	data := make([]byte, bextMinSize+256) // room for the trailing fields we don't read
+	copy(data[0:256], description)
+	copy(data[256:288], originator)
+	copy(data[320:330], date)
+	binary.LittleEndian.PutUint32(data[338:], uint32(timeRef))
+	binary.LittleEndian.PutUint32(data[342:], uint32(timeRef>>32))
+	return data
+}
+
+func TestParseBext(t *testing.T) {
+	info, err := parseBext(buildTestBext("Heavy metal door slam", "Sound Devices", "2024-03-15", 5<<32|1234))
+	if err != nil {
+		t.Fatalf("parseBext() error = %v", err)
+	}
+
+	if info.Description != "Heavy metal door slam" {
+		t.Errorf("Description = %q", info.Description)
+	}
+	if info.Originator != "Sound Devices" {
+		t.Errorf("Originator = %q", info.Originator)
+	}
+	if info.OriginationDate != "2024-03-15" {
+		t.Errorf("OriginationDate = %q", info.OriginationDate)
+	}
+	if info.TimeReference != 5<<32|1234 {
+		t.Errorf("TimeReference = %d", info.TimeReference)
+	}
+
+	if _, err := parseBext(make([]byte, 100)); err == nil {
+		t.Error("parseBext() should fail on a short chunk")
+	}
+}
+
+func TestAnalyzeWAVWithBext(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	bext := buildTestBext("Monster roar, deep and wet", "Zaxcom", "2023-11-02", 0)
+	data := buildTestWAV(48000, 2, 16, make([]byte, 48000*4*3), riffChunk{ID: "bext", Data: bext})
+	path := writeTestFile(t, "take_001.wav", data)
+
+	meta, err := aa.AnalyzeFile(path)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	if meta.BWF == nil {
+		t.Fatal("AnalyzeFile() did not pick up the bext chunk")
+	}
+	if meta.BWF.Originator != "Zaxcom" {
+		t.Errorf("BWF.Originator = %q, want Zaxcom", meta.BWF.Originator)
+	}
+
+	// the filename says nothing, so the description should drive the category
+	result := aa.InferCategoryWithConfidence(meta, "take_001.wav")
+	if result.Category != "SFX_Creature" {
+		t.Errorf("InferCategoryWithConfidence() = %q, want SFX_Creature from the bext description", result.Category)
+	}
+}
@@ -1,6 +1,20 @@
-package main
+// Package tidy is the categorizer behind tidy-rename. InferCategory and
+// InferCategoryWithConfidenceScores match a filename against CategoryRules;
+// an AudioAnalyzer reads WAV, AIFF, FLAC, MP3, OGG, M4A and WMA files and
+// refines the category and tags from the audio itself:
+//
+//	aa := tidy.NewAudioAnalyzer()
+//	meta, err := aa.AnalyzeFile(path)
+//	if err != nil {
+//		return err
+//	}
+//	result := aa.InferCategoryWithConfidence(meta, filepath.Base(path))
+//	tags := aa.GenerateAudioTags(meta)
+package tidy
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -10,49 +24,50 @@ type CategoryRule struct {
 	Category   string   // The category name (e.g., "SFX_Voice", "Ambient")
 	Keywords   []string // Keywords that match this category
 	Exclusions []string // Keywords that exclude this category (e.g., "atmos" excludes vehicles)
-	Priority   int      // Higher priority = checked first (important for ambiguous cases)
+	Priority   int      // Breaks ties between categories with the same score and keyword length, higher wins
 	Confidence float64  // Default confidence score when matched
 }
 
-// CategoryRules defines all category matching rules
-// Order matters - rules are checked in order, so put more specific rules first
+// CategoryRules defines all category matching rules. Every rule that matches
+// a name adds its Confidence to its category, see InferCategory. Their order
+// doesn't matter, ties are settled by keyword length and then Priority
 var CategoryRules = []CategoryRule{
-	// Drones (check early, specific)
+	// Drones (specific, high priority)
 	{
 		Category:   "SFX_Drone",
 		Keywords:   []string{"drone"},
 		Priority:   10,
 		Confidence: 0.8,
 	},
-	// Loops (check early, specific)
+	// Loops (specific, high priority)
 	{
 		Category:   "Music",
 		Keywords:   []string{"loop"},
 		Priority:   10,
 		Confidence: 0.8,
 	},
-	// Risers (check early, specific)
+	// Risers (specific, high priority)
 	{
 		Category:   "SFX_Riser",
 		Keywords:   []string{"riser"},
 		Priority:   10,
 		Confidence: 0.8,
 	},
-	// Slowmotion/Timelapse (check early, specific)
+	// Slowmotion/Timelapse (specific, high priority)
 	{
 		Category:   "SFX_Time",
 		Keywords:   []string{"slowmotion", "slow motion", "slow-motion", "timelapse", "time lapse", "time-lapse"},
 		Priority:   10,
 		Confidence: 0.8,
 	},
-	// Transitions (check early, specific)
+	// Transitions (specific, high priority)
 	{
 		Category:   "SFX_Transition",
 		Keywords:   []string{"transition"},
 		Priority:   10,
 		Confidence: 0.7,
 	},
-	// Whooshes (check early, specific)
+	// Whooshes (specific, high priority)
 	{
 		Category:   "SFX_Whoosh",
 		Keywords:   []string{"whoosh"},
@@ -73,7 +88,7 @@ var CategoryRules = []CategoryRule{
 		Priority:   8,
 		Confidence: 0.8,
 	},
-	// Ambient/Environment (check before vehicles to catch "atmos")
+	// Ambient/Environment (vehicles exclude "atmos" and friends)
 	{
 		Category:   "Ambient",
 		Keywords:   []string{"wind", "rain", "thunder", "storm", "water", "ocean", "forest", "nature", "atmos", "atmosphere", "ambient", "ambience", "flame", "flames", "burning", "ember", "campfire", "bonfire", "jungle", "rainforest", "insect", "cicada", "cricket", "frog", "waterfall", "river", "stream", "wave", "beach", "underwater", "monsoon", "downpour", "raindrop", "lightning", "wind chime", "windchime", "city", "urban", "traffic", "crowd", "market", "construction", "airport", "station", "restaurant", "kitchen", "street", "highway", "freeway", "intersection", "walla", "room tone", "roomtone"},
@@ -244,22 +259,54 @@ func matchCategoryRule(nameLower string, rule CategoryRule) bool {
 	return false
 }
 
-// InferCategory matches filename against category rules and returns the best match
+// InferCategory scores every rule that matches filename, the way
+// InferCategoryWithConfidenceScores does, and returns the category with the
+// highest total. A tie goes to the category with the longest matching
+// keyword, the most specific signal in the name, so "rain_gunfire_ambience"
+// is Ambient rather than whichever rule comes first, and then to the higher
+// Priority. Nothing matching is SFX.
 func InferCategory(filename string) string {
 	nameLower := strings.ToLower(filename)
+	scores := make(map[string]float64)
+	specificity := make(map[string]int) // length of the longest keyword matched per category
 
-	// Sort rules by priority (higher first)
-	rules := make([]CategoryRule, len(CategoryRules))
-	copy(rules, CategoryRules)
+	for _, rule := range CategoryRules {
+		if !matchCategoryRule(nameLower, rule) {
+			continue
+		}
+		scores[rule.Category] += rule.Confidence
+		specificity[rule.Category] = max(specificity[rule.Category], len(ruleKeyword(nameLower, rule)))
+	}
 
-	// Check rules in priority order
-	for _, rule := range rules {
-		if matchCategoryRule(nameLower, rule) {
-			return rule.Category
+	best := "SFX" // default fallback
+	bestScore := 0.0
+	for cat, score := range scores {
+		switch {
+		case score > bestScore:
+		case score < bestScore:
+			continue
+		case specificity[cat] > specificity[best]:
+		case specificity[cat] < specificity[best] || !tieBreakCategory(cat, best):
+			continue
 		}
+		best, bestScore = cat, score
 	}
+	return best
+}
 
-	return "SFX" // default fallback
+// ruleKeyword returns the longest keyword of a rule that matches nameLower,
+// or "fire" for the standalone fire rule of Ambient
+func ruleKeyword(nameLower string, rule CategoryRule) string {
+	longest := ""
+	for _, keyword := range rule.Keywords {
+		if strings.Contains(nameLower, keyword) && len(keyword) > len(longest) {
+			longest = keyword
+		}
+	}
+	if longest == "" {
+		return "fire"
+	}
+	return longest
 }
 
 // InferCategoryWithConfidenceScores matches filename and returns confidence scores for all matching categories
@@ -277,6 +324,74 @@ func InferCategoryWithConfidenceScores(filename string) map[string]float64 {
 	return scores
 }
 
+// DescribeScores lists category scores highest first, like "SFX_Impact 0.90, Ambient 0.30"
+func DescribeScores(scores map[string]float64) string {
+	categories := make([]string, 0, len(scores))
+	for cat := range scores {
+		categories = append(categories, cat)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := categories[i], categories[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return tieBreakCategory(a, b)
+	})
+
+	parts := make([]string, len(categories))
+	for i, cat := range categories {
+		parts[i] = fmt.Sprintf("%s %.2f", cat, scores[cat])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// MatchedKeyword returns the keyword that decided the category InferCategory
+// picks for name, or "" when it falls back to SFX
+func MatchedKeyword(name string) string {
+	_, keyword := MatchedRule(name)
+	return keyword
+}
+
+// MatchedRule returns the index in CategoryRules of the rule that decided the
+// category InferCategory picks for name, the one of that category with the
+// longest matching keyword, along with that keyword. Returns -1 and "" when
+// it falls back to SFX.
+func MatchedRule(name string) (int, string) {
+	nameLower := strings.ToLower(name)
+	category := InferCategory(name)
+	index, keyword := -1, ""
+	for i, rule := range CategoryRules {
+		if rule.Category != category || !matchCategoryRule(nameLower, rule) {
+			continue
+		}
+		if k := ruleKeyword(nameLower, rule); len(k) > len(keyword) {
+			index, keyword = i, k
+		}
+	}
+	return index, keyword
+}
+
+// tieBreakCategory reports whether a should win over b when both score the same:
+// the higher rule priority wins, then the name, so results don't depend on map order
+func tieBreakCategory(a, b string) bool {
+	pa, pb := categoryPriority(a), categoryPriority(b)
+	if pa != pb {
+		return pa > pb
+	}
+	return a < b
+}
+
+// categoryPriority returns the highest priority of the rules for a category
+func categoryPriority(category string) int {
+	priority := 0
+	for _, rule := range CategoryRules {
+		if rule.Category == category && rule.Priority > priority {
+			priority = rule.Priority
+		}
+	}
+	return priority
+}
+
 // NormalizeCategory converts various category name formats to standardized names
 func NormalizeCategory(cat string) string {
 	catUpper := strings.ToUpper(cat)
@@ -332,6 +447,12 @@ func ApplyMetadataScoring(scores map[string]float64, meta *AudioMetadata, filena
 		scores["Ambient"] += 0.4 // surround = probably ambient
 	}
 
+	// Embedded loop points = made to loop, so music beds or drones
+	if meta.Loop != nil {
+		scores["Music"] += 0.4
+		scores["SFX_Drone"] += 0.3
+	}
+
 	// Genre-based scoring
 	if meta.HasEmbeddedTags && meta.Genre != "" {
 		genreLower := strings.ToLower(meta.Genre)
@@ -346,3 +467,27 @@ func ApplyMetadataScoring(scores map[string]float64, meta *AudioMetadata, filena
 		}
 	}
 }
+
+// ApplyOnsetScoring adds confidence scores based on how many transients a file
+// has: lots of hits in a short file are percussion or impacts, a long file with
+// hardly any is a drone or ambience
+func ApplyOnsetScoring(scores map[string]float64, meta *AudioMetadata) {
+	if meta == nil || meta.Duration <= 0 {
+		return
+	}
+
+	analyzed := meta.Duration
+	if analyzed > onsetMaxDuration {
+		analyzed = onsetMaxDuration
+	}
+	density := float64(meta.OnsetCount) / analyzed.Seconds()
+
+	switch {
+	case meta.Duration < 5*time.Second && meta.OnsetCount >= 2 && density >= 2:
+		scores["SFX_Impact"] += 0.4
+		scores["SFX_Percussion"] += 0.3
+	case meta.Duration >= 10*time.Second && meta.OnsetCount <= 1:
+		scores["Ambient"] += 0.3
+		scores["SFX_Drone"] += 0.3
+	}
+}
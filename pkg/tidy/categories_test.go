@@ -0,0 +1,142 @@
+package tidy
+
+import "testing"
+
+func TestInferCategory(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"scream_male", "SFX_Voice"},
+		{"voice_dialogue", "SFX_Voice"},
+		{"creature_roar", "SFX_Creature"},
+		{"monster_growl", "SFX_Creature"},
+		{"gun_shot", "SFX_Weapon"},
+		{"weapon_fire", "SFX_Weapon"},
+		{"explosion_impact", "SFX_Impact"},
+		{"footstep_walk", "SFX_Footstep"},
+		{"car_engine", "SFX_Vehicle"},
+		{"door_creak", "SFX_Object"},
+		{"button_click", "SFX_UI"},
+		{"wind_ambient", "Ambient"},
+		{"music_track", "Music"}, // music and track keywords now supported
+		{"siren_alarm", "SFX_Alarm"},
+		{"random_sound", "SFX"}, // default fallback
+		{"", "SFX"},
+		{"drone_sustained", "SFX_Drone"},
+		{"loop_music", "Music"},
+		{"riser_tension", "SFX_Riser"},
+		{"whoosh_wind", "SFX_Whoosh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := InferCategory(tt.input)
+			if result != tt.expected {
+				t.Errorf("InferCategory(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeCategory(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"SFX_Voice", "SFX_Voice"}, // NormalizeCategory uses map lookup, preserves case
+		{"SFX_Creature", "SFX_Creature"},
+		{"SFX_Weapon", "SFX_Weapon"},
+		{"SFX_Impact", "SFX_Impact"},
+		{"SFX_Footstep", "SFX_Footstep"},
+		{"SFX_Vehicle", "SFX_Vehicle"},
+		{"SFX_Mechanical", "SFX_Mechanical"},
+		{"SFX_Object", "SFX_Object"},
+		{"SFX_UI", "SFX_UI"}, // SFX_UI not in map, already has "_", so returned as-is
+		{"UI", "UI"},         // UI maps to "UI" in CategoryNormalization
+		{"SFX_Alarm", "SFX_Alarm"},
+		{"Ambient", "Ambient"},
+		{"Music", "Music"},
+		{"SFX", "SFX"},
+		{"PE", "SFX_Percussion"}, // PE maps to SFX_Percussion
+		{"DRONE", "SFX_Drone"},
+		{"LOOP", "Music"},
+		{"unknown", "SFX_UNKNOWN"}, // unknown gets SFX_ prefix and uppercased
+		{"", "SFX_"},               // empty: "" -> "" -> not in map -> !contains("_") -> "SFX_" + "" = "SFX_"
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := NormalizeCategory(tt.input)
+			if result != tt.expected {
+				t.Errorf("NormalizeCategory(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInferCategoryMixedKeywords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"rain_gunfire_ambience", "Ambient"}, // same score, "ambience" is longer than "gunfire"
+		{"gunfire_rain", "SFX_Weapon"},       // "gunfire" is longer than "rain"
+		{"explosion_loop_music", "Music"},    // two Music rules outscore one Impact rule
+		{"door_button_click", "SFX_UI"},      // UI rules are more confident
+		{"whoosh_wind", "SFX_Whoosh"},
+		{"nothing_here", "SFX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if result := InferCategory(tt.input); result != tt.expected {
+				t.Errorf("InferCategory(%q) = %q, want %q (scores: %s)", tt.input, result, tt.expected, DescribeScores(InferCategoryWithConfidenceScores(tt.input)))
+			}
+		})
+	}
+}
+
+func TestInferCategoryPriority(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"door_wind", "Ambient"},             // "door" and "wind" tie, Ambient has priority 9 over 6
+		{"creak_drone", "SFX_Drone"},         // "creak" and "drone" tie, Drone has priority 10 over 6
+		{"step_ember", "Ambient"},            // "step" is shorter than "ember", priority doesn't come into it
+		{"gun_hit", "SFX_Impact"},            // same length and priority, the name decides
+		{"hit_gun", "SFX_Impact"},            // whatever the order in the name
+		{"monster_slam_bus", "SFX_Creature"}, // 8 beats 4 and 3, priority 8 over 7 and 6 is moot
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if result := InferCategory(tt.input); result != tt.expected {
+				t.Errorf("InferCategory(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInferCategoryIgnoresRuleOrder(t *testing.T) {
+	names := []string{"scream_male", "gun_shot", "door_wind", "creak_drone", "gun_hit", "whoosh_wind", "rain_gunfire_ambience", "loop_music", "random_sound"}
+	want := make(map[string]string)
+	for _, name := range names {
+		want[name] = InferCategory(name)
+	}
+
+	original := CategoryRules
+	defer func() { CategoryRules = original }()
+	reversed := make([]CategoryRule, len(original))
+	for i, rule := range original {
+		reversed[len(original)-1-i] = rule
+	}
+	CategoryRules = reversed
+
+	for _, name := range names {
+		if got := InferCategory(name); got != want[name] {
+			t.Errorf("InferCategory(%q) = %q with the rules reversed, want %q", name, got, want[name])
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	waveFormatExtensible = 0xFFFE
+	speakerLFE           = 0x8 // SPEAKER_LOW_FREQUENCY in dwChannelMask
+)
+
+// layouts with a common name of their own; everything else is counted as
+// full-range channels dot LFE channels, like 5.1
+var namedLayouts = map[uint32]string{
+	0x33:  "quad", // front left/right, back left/right
+	0x603: "quad", // front left/right, side left/right
+}
+
+// parseChannelMask returns dwChannelMask from a WAVE_FORMAT_EXTENSIBLE fmt
+// chunk: the usual 16 bytes, then cbSize, valid bits per sample and the mask
+// at offset 20. Plain PCM has no mask, and neither does a mask of 0.
+func parseChannelMask(data []byte) (uint32, error) {
+	if len(data) < 24 || binary.LittleEndian.Uint16(data[0:2]) != waveFormatExtensible {
+		return 0, fmt.Errorf("fmt chunk isn't WAVE_FORMAT_EXTENSIBLE")
+	}
+	if binary.LittleEndian.Uint16(data[16:18]) < 22 {
+		return 0, fmt.Errorf("fmt extension too short")
+	}
+	mask := binary.LittleEndian.Uint32(data[20:24])
+	if mask == 0 {
+		return 0, fmt.Errorf("no channel mask")
+	}
+	return mask, nil
+}
+
+// channelLayout names the speaker layout of a channel mask: quad, 5.1, 7.1...
+func channelLayout(mask uint32) string {
+	if name, ok := namedLayouts[mask]; ok {
+		return name
+	}
+	lfe := bits.OnesCount32(mask & speakerLFE)
+	return fmt.Sprintf("%d.%d", bits.OnesCount32(mask)-lfe, lfe)
+}
@@ -0,0 +1,86 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// buildTestExtensibleWAV is a 16-bit WAVE_FORMAT_EXTENSIBLE file with the given channel mask
+func buildTestExtensibleWAV(sampleRate, channels int, mask uint32, pcm []byte) []byte {
+	fmtChunk := make([]byte, 40)
+	blockAlign := channels * 2
+	binary.LittleEndian.PutUint16(fmtChunk[0:], waveFormatExtensible)
+	binary.LittleEndian.PutUint16(fmtChunk[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(fmtChunk[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(fmtChunk[8:], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(fmtChunk[12:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(fmtChunk[14:], 16)
+	binary.LittleEndian.PutUint16(fmtChunk[16:], 22)
+	binary.LittleEndian.PutUint16(fmtChunk[18:], 16)
+	binary.LittleEndian.PutUint32(fmtChunk[20:], mask)
+	binary.LittleEndian.PutUint16(fmtChunk[24:], 1) // PCM sub-format GUID starts with the format tag
+	copy(fmtChunk[26:], "\x00\x00\x00\x00\x10\x00\x80\x00\x00\xAA\x00\x38\x9B\x71")
+
+	body := appendRIFFChunk([]byte("WAVE"), "fmt ", fmtChunk)
+	body = appendRIFFChunk(body, "data", pcm)
+	out := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body)))
+	return append(out, body...)
+}
+
+func TestChannelLayout(t *testing.T) {
+	tests := []struct {
+		mask uint32
+		want string
+	}{
+		{0x3, "2.0"},
+		{0x33, "quad"},
+		{0x603, "quad"},
+		{0x37, "5.0"},
+		{0x3F, "5.1"},
+		{0x60F, "5.1"}, // side surrounds
+		{0x63F, "7.1"},
+		{0x2D63F, "11.1"}, // 7.1.4, height channels count as full range
+	}
+
+	for _, tt := range tests {
+		if got := channelLayout(tt.mask); got != tt.want {
+			t.Errorf("channelLayout(%#x) = %q, want %q", tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestWAVChannelLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		layout   string
+		lfe      bool
+		wantTags []string
+	}{
+		{"5.1", buildTestExtensibleWAV(48000, 6, 0x3F, make([]byte, 6*2*480)), "5.1", true, []string{"multichannel", "6ch", "5.1", "lfe"}},
+		{"quad", buildTestExtensibleWAV(48000, 4, 0x33, make([]byte, 4*2*480)), "quad", false, []string{"multichannel", "4ch", "quad"}},
+		{"plain PCM", buildTestWAV(48000, 6, 16, make([]byte, 6*2*480)), "", false, []string{"multichannel", "6ch"}},
+	}
+
+	aa := NewAudioAnalyzer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := aa.AnalyzeFile(writeTestFile(t, "bed.wav", tt.data))
+			if err != nil {
+				t.Fatalf("AnalyzeFile() error = %v", err)
+			}
+			if meta.ChannelLayout != tt.layout || meta.LFE != tt.lfe {
+				t.Errorf("layout = %q lfe %v, want %q lfe %v", meta.ChannelLayout, meta.LFE, tt.layout, tt.lfe)
+			}
+			tags := aa.GenerateAudioTags(meta)
+			for _, tag := range tt.wantTags {
+				if !containsTag(tags, tag) {
+					t.Errorf("tags %v missing %q", tags, tag)
+				}
+			}
+			if tt.lfe != containsTag(tags, "lfe") {
+				t.Errorf("tags %v, lfe tag should be there only with an LFE channel", tags)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package tidy
+
+import "math"
+
+// NormalizeConfidence maps a raw category score onto 0.3-1.0
+func NormalizeConfidence(score float64) float64 {
+	confidence := math.Min(score/1.5, 1.0) // cap at reasonable max
+	if confidence < 0.3 {
+		confidence = 0.3 // minimum confidence floor
+	}
+	return confidence
+}
+
+// KeywordConfidence is the confidence of a category matched by filename keywords alone
+func KeywordConfidence(name, category string) float64 {
+	return NormalizeConfidence(InferCategoryWithConfidenceScores(name)[category])
+}
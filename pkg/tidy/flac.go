@@ -0,0 +1,98 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const flacStreamInfoType = 0
+
+// analyzeFLAC reads the STREAMINFO metadata block, which every FLAC file is
+// required to start with, for exact sample rate, channels, bit depth and length
+func (aa *AudioAnalyzer) analyzeFLAC(file *os.File, meta *AudioMetadata) error {
+	// some taggers put an ID3v2 tag in front of the stream marker
+	if err := skipID3v2(file); err != nil {
+		return fmt.Errorf("failed to skip ID3 tag: %w", err)
+	}
+
+	marker := make([]byte, 4)
+	if _, err := io.ReadFull(file, marker); err != nil {
+		return fmt.Errorf("failed to read stream marker: %w", err)
+	}
+	if string(marker) != "fLaC" {
+		return fmt.Errorf("invalid FLAC file")
+	}
+
+	info, err := findFLACStreamInfo(file)
+	if err != nil {
+		return err
+	}
+
+	// STREAMINFO layout after the block/frame size fields (10 bytes):
+	// 20 bits sample rate, 3 bits channels-1, 5 bits bits-per-sample-1, 36 bits total samples
+	packed := binary.BigEndian.Uint64(info[10:18])
+	sampleRate := int(packed >> 44)
+	channels := int((packed>>41)&0x07) + 1
+	bitDepth := int((packed>>36)&0x1F) + 1
+	totalSamples := int64(packed & 0xFFFFFFFFF)
+
+	if sampleRate == 0 {
+		return fmt.Errorf("invalid FLAC sample rate")
+	}
+
+	meta.Format = "FLAC"
+	meta.SampleRate = sampleRate
+	meta.Channels = channels
+	meta.BitDepth = bitDepth
+
+	// total samples of 0 means the encoder didn't know the length
+	if totalSamples > 0 {
+		seconds := float64(totalSamples) / float64(sampleRate)
+		meta.Duration = time.Duration(seconds * float64(time.Second))
+
+		// average compressed bitrate is more useful than the raw PCM rate here
+		if fileInfo, err := file.Stat(); err == nil && seconds > 0 {
+			meta.Bitrate = int(float64(fileInfo.Size()*8) / seconds)
+		}
+	}
+
+	meta.Fingerprint = aa.GenerateFingerprint(meta)
+
+	return nil
+}
+
+// findFLACStreamInfo walks the metadata block headers until it finds STREAMINFO
+func findFLACStreamInfo(r io.ReadSeeker) ([]byte, error) {
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("failed to read metadata block: %w", err)
+		}
+
+		isLast := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if blockType == flacStreamInfoType {
+			if length < 34 {
+				return nil, fmt.Errorf("STREAMINFO block too short")
+			}
+			info := make([]byte, length)
+			if _, err := io.ReadFull(r, info); err != nil {
+				return nil, fmt.Errorf("failed to read STREAMINFO: %w", err)
+			}
+			return info, nil
+		}
+
+		if isLast {
+			return nil, fmt.Errorf("no STREAMINFO block found")
+		}
+
+		if _, err := r.Seek(length, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestFLAC builds a FLAC fixture with a padding block ahead of STREAMINFO
+// so the block walker gets exercised, followed by some fake frame data
+func writeTestFLAC(t *testing.T, sampleRate, channels, bitDepth int, totalSamples int64) string {
+	t.Helper()
+
+	data := []byte("fLaC")
+
+	// padding block, not last
+	data = append(data, 0x01, 0x00, 0x00, 0x08)
+	data = append(data, make([]byte, 8)...)
+
+	// STREAMINFO block, last
+	data = append(data, 0x80, 0x00, 0x00, 34)
+	info := make([]byte, 34)
+	binary.BigEndian.PutUint16(info[0:], 4096)
+	binary.BigEndian.PutUint16(info[2:], 4096)
+	packed := uint64(sampleRate)<<44 | uint64(channels-1)<<41 | uint64(bitDepth-1)<<36 | uint64(totalSamples)
+	binary.BigEndian.PutUint64(info[10:], packed)
+	data = append(data, info...)
+
+	data = append(data, make([]byte, 1024)...)
+
+	path := filepath.Join(t.TempDir(), "fixture.flac")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestAnalyzeFLAC(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	path := writeTestFLAC(t, 96000, 2, 24, 96000*90)
+	meta, err := aa.AnalyzeFile(path)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+
+	if meta.Format != "FLAC" {
+		t.Errorf("Format = %q, want FLAC", meta.Format)
+	}
+	if meta.SampleRate != 96000 {
+		t.Errorf("SampleRate = %d, want 96000", meta.SampleRate)
+	}
+	if meta.Channels != 2 {
+		t.Errorf("Channels = %d, want 2", meta.Channels)
+	}
+	if meta.BitDepth != 24 {
+		t.Errorf("BitDepth = %d, want 24", meta.BitDepth)
+	}
+	if meta.Duration != 90*time.Second {
+		t.Errorf("Duration = %v, want 90s", meta.Duration)
+	}
+	if meta.Fingerprint == "" {
+		t.Error("Fingerprint should be set")
+	}
+}
+
+func TestAnalyzeFLACInvalid(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	path := filepath.Join(t.TempDir(), "bad.flac")
+	if err := os.WriteFile(path, []byte("not a flac file at all"), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	if _, err := aa.AnalyzeFile(path); err == nil {
+		t.Error("AnalyzeFile() should fail for a file without a fLaC marker")
+	}
+}
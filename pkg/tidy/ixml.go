@@ -0,0 +1,63 @@
+package tidy
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// IXMLInfo holds the production fields field recorders write into the iXML chunk
+type IXMLInfo struct {
+	Project    string   `json:",omitempty"`
+	Scene      string   `json:",omitempty"`
+	Take       string   `json:",omitempty"`
+	Tape       string   `json:",omitempty"`
+	Note       string   `json:",omitempty"`
+	TrackNames []string `json:",omitempty"`
+}
+
+// ixmlDocument mirrors the parts of the iXML schema we read
+type ixmlDocument struct {
+	XMLName xml.Name `xml:"BWFXML"`
+	Project string   `xml:"PROJECT"`
+	Scene   string   `xml:"SCENE"`
+	Take    string   `xml:"TAKE"`
+	Tape    string   `xml:"TAPE"`
+	Note    string   `xml:"NOTE"`
+	Tracks  []struct {
+		Name string `xml:"NAME"`
+	} `xml:"TRACK_LIST>TRACK"`
+}
+
+// parseIXML decodes an iXML chunk. Returns an error for malformed XML or a
+// document with nothing useful in it, so callers can just ignore it.
+func parseIXML(data []byte) (*IXMLInfo, error) {
+	// chunk is often NUL padded after the closing tag
+	data = bytes.TrimRight(data, "\x00 \r\n\t")
+
+	var doc ixmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid iXML: %w", err)
+	}
+
+	info := &IXMLInfo{
+		Project: strings.TrimSpace(doc.Project),
+		Scene:   strings.TrimSpace(doc.Scene),
+		Take:    strings.TrimSpace(doc.Take),
+		Tape:    strings.TrimSpace(doc.Tape),
+		Note:    strings.TrimSpace(doc.Note),
+	}
+	for _, track := range doc.Tracks {
+		if name := strings.TrimSpace(track.Name); name != "" {
+			info.TrackNames = append(info.TrackNames, name)
+		}
+	}
+
+	if info.Project == "" && info.Scene == "" && info.Take == "" && info.Tape == "" &&
+		info.Note == "" && len(info.TrackNames) == 0 {
+		return nil, fmt.Errorf("iXML has no usable fields")
+	}
+
+	return info, nil
+}
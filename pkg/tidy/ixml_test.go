@@ -0,0 +1,91 @@
+package tidy
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testIXML = `<?xml version="1.0" encoding="UTF-8"?>
+<BWFXML>
+	<IXML_VERSION>1.52</IXML_VERSION>
+	<PROJECT>Night Shoot</PROJECT>
+	<SCENE>12A</SCENE>
+	<TAKE>3</TAKE>
+	<TAPE>Day04</TAPE>
+	<NOTE>dog barking in the distance</NOTE>
+	<TRACK_LIST>
+		<TRACK_COUNT>2</TRACK_COUNT>
+		<TRACK><CHANNEL_INDEX>1</CHANNEL_INDEX><NAME>Boom</NAME></TRACK>
+		<TRACK><CHANNEL_INDEX>2</CHANNEL_INDEX><NAME>Lav</NAME></TRACK>
+	</TRACK_LIST>
+</BWFXML>`
+
+func TestParseIXML(t *testing.T) {
+	info, err := parseIXML(append([]byte(testIXML), 0, 0, 0))
+	if err != nil {
+		t.Fatalf("parseIXML() error = %v", err)
+	}
+
+	expected := &IXMLInfo{
+		Project:    "Night Shoot",
+		Scene:      "12A",
+		Take:       "3",
+		Tape:       "Day04",
+		Note:       "dog barking in the distance",
+		TrackNames: []string{"Boom", "Lav"},
+	}
+	if !reflect.DeepEqual(info, expected) {
+		t.Errorf("parseIXML() = %+v, want %+v", info, expected)
+	}
+}
+
+func TestParseIXMLInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"malformed", "<BWFXML><SCENE>12A</BWF"},
+		{"wrong_root", "<SOMETHING><SCENE>1</SCENE></SOMETHING>"},
+		{"empty_doc", "<BWFXML><IXML_VERSION>1.5</IXML_VERSION></BWFXML>"},
+		{"garbage", "\x00\x01\x02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if info, err := parseIXML([]byte(tt.data)); err == nil {
+				t.Errorf("parseIXML() = %+v, want an error", info)
+			}
+		})
+	}
+}
+
+func TestAnalyzeWAVWithIXML(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	data := buildTestWAV(48000, 2, 16, make([]byte, 48000*4*3), riffChunk{ID: "iXML", Data: []byte(testIXML)})
+	meta, err := aa.AnalyzeFile(writeTestFile(t, "T003.wav", data))
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	if meta.IXML == nil || meta.IXML.Scene != "12A" || meta.IXML.Take != "3" {
+		t.Fatalf("AnalyzeFile() IXML = %+v, want scene 12A take 3", meta.IXML)
+	}
+
+	// "dog barking" in the note should categorize an otherwise anonymous take
+	if result := aa.InferCategoryWithConfidence(meta, "T003.wav"); result.Category != "SFX_Creature" {
+		t.Errorf("InferCategoryWithConfidence() = %q, want SFX_Creature from the iXML note", result.Category)
+	}
+
+	// a broken chunk falls back to normal analysis
+	broken := buildTestWAV(48000, 2, 16, make([]byte, 4800), riffChunk{ID: "iXML", Data: []byte("<BWFXML><SCE")})
+	meta, err = aa.AnalyzeFile(writeTestFile(t, "T004.wav", broken))
+	if err != nil {
+		t.Fatalf("AnalyzeFile() with malformed iXML error = %v", err)
+	}
+	if meta.IXML != nil {
+		t.Errorf("AnalyzeFile() IXML = %+v, want nil for malformed XML", meta.IXML)
+	}
+	if meta.SampleRate != 48000 {
+		t.Errorf("SampleRate = %d, want 48000", meta.SampleRate)
+	}
+}
@@ -0,0 +1,278 @@
+package tidy
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+const (
+	loudnessPeak = "dBFS" // a target in sample peak
+	loudnessLUFS = "LUFS" // a target in integrated loudness
+
+	// files whose suggested gain is bigger than this either way get tagged off-target
+	offTargetDB = 6.0
+
+	// BS.1770 gating: 400 ms blocks overlapping by 75%, so a 100 ms step
+	loudnessStepMs        = 100
+	loudnessBlockSteps    = 4
+	loudnessAbsoluteGate  = -70.0 // LUFS
+	loudnessRelativeGate  = -10.0 // LU below the loudness of the blocks above the absolute gate
+	loudnessSurroundGain  = 1.41  // weight of the surround channels of a 5.1 file
+	loudnessLFEChannel    = 3     // in the usual L R C LFE Ls Rs order
+	loudnessMinSurroundCh = 6
+)
+
+// LoudnessTarget is the reference suggested gains aim at, like -1 dBFS or -23 LUFS
+type LoudnessTarget struct {
+	Level float64
+	Unit  string
+}
+
+// ParseLoudnessTarget reads a level with its unit, like "-1dBFS" or "-23 LUFS"
+func ParseLoudnessTarget(s string) (*LoudnessTarget, error) {
+	s = strings.TrimSpace(s)
+	for _, unit := range []string{loudnessPeak, loudnessLUFS} {
+		if len(s) < len(unit) || !strings.EqualFold(s[len(s)-len(unit):], unit) {
+			continue
+		}
+		level, err := strconv.ParseFloat(strings.TrimSpace(s[:len(s)-len(unit)]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a level like -1dBFS or -23LUFS", s)
+		}
+		if level > 0 {
+			return nil, fmt.Errorf("%q is above full scale", s)
+		}
+		return &LoudnessTarget{Level: level, Unit: unit}, nil
+	}
+	return nil, fmt.Errorf("%q needs a unit, dBFS for peak or LUFS for integrated loudness", s)
+}
+
+// analyzeLoudness measures the sample peak and the integrated loudness
+// (ITU-R BS.1770: K-weighted, gated) over the whole data chunk. Silence has
+// neither and leaves both unset.
+func (aa *AudioAnalyzer) analyzeLoudness(file *os.File, meta *AudioMetadata) error {
+	decoder := wav.NewDecoder(file)
+	if !decoder.IsValidFile() {
+		return fmt.Errorf("invalid WAV file")
+	}
+
+	channels := int(decoder.NumChans)
+	sampleRate := int(decoder.SampleRate)
+	bitDepth := int(decoder.BitDepth)
+	if channels == 0 || sampleRate == 0 || bitDepth == 0 {
+		return fmt.Errorf("missing audio format info")
+	}
+	fullScale := float64(int64(1) << (bitDepth - 1))
+
+	filters := make([]kWeighting, channels)
+	for c := range filters {
+		filters[c] = newKWeighting(float64(sampleRate))
+	}
+
+	// sum of squares of the K-weighted signal per channel and 100 ms step
+	stepFrames := sampleRate * loudnessStepMs / 1000
+	var steps [][]float64
+	current := make([]float64, channels)
+	framesInStep := 0
+
+	buf := &audio.IntBuffer{
+		Format: &audio.Format{NumChannels: channels, SampleRate: sampleRate},
+		Data:   make([]int, 4096*channels),
+	}
+	peak := 0.0
+	for {
+		n, err := decoder.PCMBuffer(buf)
+		if n == 0 || err != nil {
+			break
+		}
+		for i := 0; (i+1)*channels <= n && (i+1)*channels <= len(buf.Data); i++ {
+			for c := 0; c < channels; c++ {
+				v := float64(buf.Data[i*channels+c]) / fullScale
+				peak = math.Max(peak, math.Abs(v))
+				w := filters[c].process(v)
+				current[c] += w * w
+			}
+			framesInStep++
+			if framesInStep == stepFrames {
+				steps = append(steps, current)
+				current = make([]float64, channels)
+				framesInStep = 0
+			}
+		}
+	}
+	if len(steps) == 0 && framesInStep == 0 {
+		return fmt.Errorf("no audio data")
+	}
+	if peak == 0 {
+		return nil // digital silence
+	}
+	meta.PeakDBFS = roundDB(20 * math.Log10(peak))
+
+	weights := make([]float64, channels)
+	for c := range weights {
+		weights[c] = 1
+		if channels >= loudnessMinSurroundCh {
+			if c == loudnessLFEChannel {
+				weights[c] = 0
+			} else if c > loudnessLFEChannel {
+				weights[c] = loudnessSurroundGain
+			}
+		}
+	}
+
+	// mean square per channel of every 400 ms block, or of the whole file
+	// when it's shorter than one block
+	var blocks [][]float64
+	if len(steps) < loudnessBlockSteps {
+		frames := float64(len(steps)*stepFrames + framesInStep)
+		block := make([]float64, channels)
+		for _, step := range append(steps, current) {
+			for c := range block {
+				block[c] += step[c] / frames
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	for start := 0; start+loudnessBlockSteps <= len(steps); start++ {
+		block := make([]float64, channels)
+		for _, step := range steps[start : start+loudnessBlockSteps] {
+			for c := range block {
+				block[c] += step[c] / float64(loudnessBlockSteps*stepFrames)
+			}
+		}
+		blocks = append(blocks, block)
+	}
+
+	if lufs, ok := gatedLoudness(blocks, weights); ok {
+		meta.LoudnessLUFS = roundDB(lufs)
+	}
+	return nil
+}
+
+// gatedLoudness applies the absolute and then the relative gate to the blocks
+// and returns the loudness of what's left
+func gatedLoudness(blocks [][]float64, weights []float64) (float64, bool) {
+	loudness := func(block []float64) float64 {
+		sum := 0.0
+		for c, z := range block {
+			sum += weights[c] * z
+		}
+		return -0.691 + 10*math.Log10(sum)
+	}
+	mean := func(threshold float64) ([]float64, bool) {
+		avg := make([]float64, len(weights))
+		n := 0
+		for _, block := range blocks {
+			if loudness(block) > threshold {
+				for c, z := range block {
+					avg[c] += z
+				}
+				n++
+			}
+		}
+		if n == 0 {
+			return nil, false
+		}
+		for c := range avg {
+			avg[c] /= float64(n)
+		}
+		return avg, true
+	}
+
+	aboveAbsolute, ok := mean(loudnessAbsoluteGate)
+	if !ok {
+		return 0, false
+	}
+	gated, ok := mean(loudness(aboveAbsolute) + loudnessRelativeGate)
+	if !ok {
+		return 0, false
+	}
+	return loudness(gated), true
+}
+
+// kWeighting is the BS.1770 pre-filter, a high shelf then a high pass, as two
+// biquads worked out for the file's sample rate
+type kWeighting struct {
+	stages [2]biquad
+}
+
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+func newKWeighting(sampleRate float64) kWeighting {
+	// high shelf, about +4 dB above 1.7 kHz
+	const shelfFreq, shelfGain, shelfQ = 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * shelfFreq / sampleRate)
+	vh := math.Pow(10, shelfGain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/shelfQ + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/shelfQ + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/shelfQ + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/shelfQ + k*k) / a0,
+	}
+
+	// high pass, rolling off below 38 Hz
+	const passFreq, passQ = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * passFreq / sampleRate)
+	a0 = 1 + k/passQ + k*k
+	pass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/passQ + k*k) / a0,
+	}
+
+	return kWeighting{stages: [2]biquad{shelf, pass}}
+}
+
+func (k *kWeighting) process(x float64) float64 {
+	for i := range k.stages {
+		x = k.stages[i].process(x)
+	}
+	return x
+}
+
+func roundDB(db float64) float64 {
+	return math.Round(db*10) / 10
+}
+
+// SuggestGain works out the gain that would bring a file to aa.LoudnessTarget.
+// It's only a suggestion, the audio is never touched. Cached analysis goes
+// through here too, so a new target applies without analyzing again.
+func (aa *AudioAnalyzer) SuggestGain(meta *AudioMetadata) {
+	meta.SuggestedGainDB = 0
+	target := aa.LoudnessTarget
+	if target == nil {
+		return
+	}
+	switch {
+	case target.Unit == loudnessPeak && (meta.PeakDBFS != 0 || meta.LoudnessLUFS != 0): // a peak right at full scale is 0
+		meta.SuggestedGainDB = roundDB(target.Level - meta.PeakDBFS)
+	case target.Unit == loudnessLUFS && meta.LoudnessLUFS != 0:
+		meta.SuggestedGainDB = roundDB(target.Level - meta.LoudnessLUFS)
+	}
+}
+
+// offTarget reports whether a file is far enough from the loudness target to flag
+func offTarget(meta *AudioMetadata) bool {
+	return math.Abs(meta.SuggestedGainDB) > offTargetDB
+}
@@ -0,0 +1,105 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestParseLoudnessTarget(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LoudnessTarget
+		wantErr bool
+	}{
+		{"-1dBFS", LoudnessTarget{-1, loudnessPeak}, false},
+		{"-23LUFS", LoudnessTarget{-23, loudnessLUFS}, false},
+		{"-16 lufs", LoudnessTarget{-16, loudnessLUFS}, false},
+		{"-23", LoudnessTarget{}, true},
+		{"3dBFS", LoudnessTarget{}, true},
+		{"loudLUFS", LoudnessTarget{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLoudnessTarget(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLoudnessTarget(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && *got != tt.want {
+			t.Errorf("ParseLoudnessTarget(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+}
+
+// sineWAV is three seconds of a 997 Hz mono sine at the given peak level
+func sineWAV(peakDB float64, extra ...riffChunk) []byte {
+	amplitude := 32767 * math.Pow(10, peakDB/20)
+	var pcm []byte
+	for i := 0; i < 48000*3; i++ {
+		v := int16(math.Round(amplitude * math.Sin(2*math.Pi*997*float64(i)/48000)))
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+	}
+	return buildTestWAV(48000, 1, 16, pcm, extra...)
+}
+
+func TestAnalyzeLoudness(t *testing.T) {
+	// a full scale 1 kHz sine is -3.01 LUFS by definition
+	tests := []struct {
+		peakDB   float64
+		wantPeak float64
+		wantLUFS float64
+	}{
+		{-1, -1, -4},
+		{-20, -20, -23},
+	}
+
+	aa := NewAudioAnalyzer()
+	for _, tt := range tests {
+		meta, err := aa.AnalyzeFile(writeTestFile(t, "tone.wav", sineWAV(tt.peakDB)))
+		if err != nil {
+			t.Fatalf("AnalyzeFile() error = %v", err)
+		}
+		if math.Abs(meta.PeakDBFS-tt.wantPeak) > 0.1 || math.Abs(meta.LoudnessLUFS-tt.wantLUFS) > 0.1 {
+			t.Errorf("sine at %v dBFS: peak %v dBFS, loudness %v LUFS, want %v and %v", tt.peakDB, meta.PeakDBFS, meta.LoudnessLUFS, tt.wantPeak, tt.wantLUFS)
+		}
+	}
+
+	meta, err := aa.AnalyzeFile(writeTestFile(t, "silence.wav", buildTestWAV(48000, 1, 16, make([]byte, 96000))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.PeakDBFS != 0 || meta.LoudnessLUFS != 0 {
+		t.Errorf("silence: peak %v loudness %v, want both unset", meta.PeakDBFS, meta.LoudnessLUFS)
+	}
+}
+
+func TestSuggestGain(t *testing.T) {
+	tests := []struct {
+		target    string
+		meta      AudioMetadata
+		wantGain  float64
+		offTarget bool
+	}{
+		{"-1dBFS", AudioMetadata{PeakDBFS: -3.5, LoudnessLUFS: -18}, 2.5, false},
+		{"-23LUFS", AudioMetadata{PeakDBFS: -3.5, LoudnessLUFS: -14.2}, -8.8, true},
+		{"-23LUFS", AudioMetadata{PeakDBFS: -40, LoudnessLUFS: -60}, 37, true},
+		{"-23LUFS", AudioMetadata{}, 0, false}, // nothing measured
+		{"", AudioMetadata{PeakDBFS: -3.5, LoudnessLUFS: -14.2, SuggestedGainDB: 4}, 0, false},
+	}
+
+	for _, tt := range tests {
+		aa := NewAudioAnalyzer()
+		if tt.target != "" {
+			aa.LoudnessTarget, _ = ParseLoudnessTarget(tt.target)
+		}
+		meta := tt.meta
+		aa.SuggestGain(&meta)
+		if meta.SuggestedGainDB != tt.wantGain {
+			t.Errorf("%s %+v: gain = %v, want %v", tt.target, tt.meta, meta.SuggestedGainDB, tt.wantGain)
+		}
+		if got := containsTag(aa.GenerateAudioTags(&meta), "loudness-off-target"); got != tt.offTarget {
+			t.Errorf("%s %+v: loudness-off-target tag = %v, want %v", tt.target, tt.meta, got, tt.offTarget)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// LoopInfo is a loop region in sample frames, from a smpl loop or a cue region
+type LoopInfo struct {
+	Start uint32
+	End   uint32
+}
+
+// parseSmplLoop returns the first loop of a smpl chunk. Layout is 36 bytes of
+// sampler info (loop count at offset 28) followed by 24-byte loop records
+// holding cue id, type, start, end, fraction and play count.
+func parseSmplLoop(data []byte) (*LoopInfo, error) {
+	if len(data) < 36 {
+		return nil, fmt.Errorf("smpl chunk too short")
+	}
+
+	numLoops := binary.LittleEndian.Uint32(data[28:32])
+	if numLoops == 0 || len(data) < 36+24 {
+		return nil, fmt.Errorf("smpl chunk has no loops")
+	}
+
+	loop := data[36:60]
+	start := binary.LittleEndian.Uint32(loop[8:12])
+	end := binary.LittleEndian.Uint32(loop[12:16])
+	if end <= start {
+		return nil, fmt.Errorf("invalid smpl loop %d-%d", start, end)
+	}
+
+	return &LoopInfo{Start: start, End: end}, nil
+}
+
+// parseCuePoints returns cue id -> sample offset from a cue chunk. Each 24-byte
+// record holds id, position, data chunk id, chunk start, block start, sample offset.
+func parseCuePoints(data []byte) (map[uint32]uint32, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("cue chunk too short")
+	}
+
+	// the count is the file's word, a truncated or crafted chunk can't hold
+	// more records than fit in it
+	count := int(binary.LittleEndian.Uint32(data[0:4]))
+	count = min(count, (len(data)-4)/24)
+	cues := make(map[uint32]uint32, count)
+	for i := 0; i < count; i++ {
+		off := 4 + i*24
+		if off+24 > len(data) {
+			break // truncated, keep what we have
+		}
+		id := binary.LittleEndian.Uint32(data[off : off+4])
+		cues[id] = binary.LittleEndian.Uint32(data[off+20 : off+24])
+	}
+
+	return cues, nil
+}
+
+// parseCueRegions reads labeled text ("ltxt") entries from a LIST/adtl chunk,
+// which turn cue points into regions. Returns cue id -> region length in samples.
+func parseCueRegions(data []byte) map[uint32]uint32 {
+	regions := make(map[uint32]uint32)
+	if len(data) < 4 || string(data[0:4]) != "adtl" {
+		return regions
+	}
+
+	for pos := 4; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+		if body+size > len(data) {
+			break
+		}
+		if id == "ltxt" && size >= 8 {
+			cueID := binary.LittleEndian.Uint32(data[body : body+4])
+			regions[cueID] = binary.LittleEndian.Uint32(data[body+4 : body+8])
+		}
+		pos = body + size + size%2
+	}
+
+	return regions
+}
+
+// cueLoop turns the first cue region into a loop, if there is one
+func cueLoop(cues map[uint32]uint32, regions map[uint32]uint32) *LoopInfo {
+	var best *LoopInfo
+	var bestID uint32
+	for id, length := range regions {
+		start, ok := cues[id]
+		if !ok || length == 0 {
+			continue
+		}
+		// lowest cue id wins so the result doesn't depend on map order
+		if best == nil || id < bestID {
+			best = &LoopInfo{Start: start, End: start + length}
+			bestID = id
+		}
+	}
+	return best
+}
@@ -0,0 +1,117 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func buildTestSmpl(start, end uint32) []byte {
+	data := make([]byte, 36+24)
+	binary.LittleEndian.PutUint32(data[28:], 1)
+	binary.LittleEndian.PutUint32(data[36+8:], start)
+	binary.LittleEndian.PutUint32(data[36+12:], end)
+	return data
+}
+
+func buildTestCue(points map[uint32]uint32, order []uint32) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, uint32(len(order)))
+	for _, id := range order {
+		rec := make([]byte, 24)
+		binary.LittleEndian.PutUint32(rec[0:], id)
+		copy(rec[8:], "data")
+		binary.LittleEndian.PutUint32(rec[20:], points[id])
+		data = append(data, rec...)
+	}
+	return data
+}
+
+func buildTestAdtl(cueID, length uint32) []byte {
+	data := []byte("adtl")
+	labl := append(binary.LittleEndian.AppendUint32(nil, cueID), "intro\x00"...)
+	data = appendRIFFChunk(data, "labl", labl)
+	ltxt := binary.LittleEndian.AppendUint32(nil, cueID)
+	ltxt = binary.LittleEndian.AppendUint32(ltxt, length)
+	ltxt = append(ltxt, "rgn "...)
+	ltxt = append(ltxt, make([]byte, 8)...)
+	return appendRIFFChunk(data, "ltxt", ltxt)
+}
+
+func TestParseSmplLoop(t *testing.T) {
+	loop, err := parseSmplLoop(buildTestSmpl(1000, 48000))
+	if err != nil {
+		t.Fatalf("parseSmplLoop() error = %v", err)
+	}
+	if loop.Start != 1000 || loop.End != 48000 {
+		t.Errorf("parseSmplLoop() = %+v, want 1000-48000", loop)
+	}
+
+	if _, err := parseSmplLoop(make([]byte, 36)); err == nil {
+		t.Error("parseSmplLoop() should fail without loops")
+	}
+	if _, err := parseSmplLoop(buildTestSmpl(500, 100)); err == nil {
+		t.Error("parseSmplLoop() should reject end before start")
+	}
+}
+
+func TestParseCuePointsCount(t *testing.T) {
+	data := buildTestCue(map[uint32]uint32{1: 100, 2: 200}, []uint32{1, 2})
+	binary.LittleEndian.PutUint32(data, 0xFFFFFFFF) // claims 4 billion points
+
+	cues, err := parseCuePoints(data)
+	if err != nil {
+		t.Fatalf("parseCuePoints() error = %v", err)
+	}
+	if want := map[uint32]uint32{1: 100, 2: 200}; !reflect.DeepEqual(cues, want) {
+		t.Errorf("parseCuePoints() = %v, want %v", cues, want)
+	}
+}
+
+func TestParseCueRegions(t *testing.T) {
+	cues, err := parseCuePoints(buildTestCue(map[uint32]uint32{1: 0, 2: 24000}, []uint32{1, 2}))
+	if err != nil {
+		t.Fatalf("parseCuePoints() error = %v", err)
+	}
+	if !reflect.DeepEqual(cues, map[uint32]uint32{1: 0, 2: 24000}) {
+		t.Errorf("parseCuePoints() = %v", cues)
+	}
+
+	regions := parseCueRegions(buildTestAdtl(2, 12000))
+	loop := cueLoop(cues, regions)
+	if loop == nil || loop.Start != 24000 || loop.End != 36000 {
+		t.Errorf("cueLoop() = %+v, want 24000-36000", loop)
+	}
+
+	if cueLoop(cues, map[uint32]uint32{}) != nil {
+		t.Error("cueLoop() should be nil when no cue has a region")
+	}
+}
+
+func TestAnalyzeWAVLoopPoints(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	data := buildTestWAV(48000, 2, 16, make([]byte, 48000*4*10),
+		riffChunk{ID: "cue ", Data: buildTestCue(map[uint32]uint32{7: 96000, 3: 4800}, []uint32{7, 3})},
+		riffChunk{ID: "smpl", Data: buildTestSmpl(4800, 96000)},
+	)
+	meta, err := aa.AnalyzeFile(writeTestFile(t, "bed.wav", data))
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+
+	if meta.Loop == nil || meta.Loop.Start != 4800 || meta.Loop.End != 96000 {
+		t.Errorf("Loop = %+v, want 4800-96000 from smpl", meta.Loop)
+	}
+	if !reflect.DeepEqual(meta.CuePoints, []uint32{4800, 96000}) {
+		t.Errorf("CuePoints = %v, want sorted [4800 96000]", meta.CuePoints)
+	}
+	if !containsTag(aa.GenerateAudioTags(meta), "loop") {
+		t.Error("GenerateAudioTags() should include loop")
+	}
+
+	scores := map[string]float64{}
+	ApplyMetadataScoring(scores, &AudioMetadata{Loop: &LoopInfo{Start: 0, End: 100}}, "bed")
+	if scores["Music"] <= 0 || scores["SFX_Drone"] <= 0 {
+		t.Errorf("ApplyMetadataScoring() with loop = %v, want Music and SFX_Drone boosted", scores)
+	}
+}
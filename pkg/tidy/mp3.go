@@ -0,0 +1,256 @@
+package tidy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// bitrate tables in kbps, indexed by [version][layer][bitrate index]
+// version 0 = MPEG1, 1 = MPEG2/2.5; layer 0 = Layer I, 1 = Layer II, 2 = Layer III
+var mp3Bitrates = [2][3][16]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+}
+
+// sample rates indexed by the version bits from the header (MPEG2.5, reserved, MPEG2, MPEG1)
+var mp3SampleRates = [4][3]int{
+	{11025, 12000, 8000},
+	{0, 0, 0},
+	{22050, 24000, 16000},
+	{44100, 48000, 32000},
+}
+
+// mp3Frame holds the fields we care about from a single MPEG audio frame header
+type mp3Frame struct {
+	mpeg1           bool
+	layer           int // 1, 2 or 3
+	bitrate         int // bits per second
+	sampleRate      int
+	channels        int
+	length          int // frame length in bytes, header included
+	samplesPerFrame int
+}
+
+// parseMP3FrameHeader decodes a 4-byte frame header, returning false if it isn't a valid header
+func parseMP3FrameHeader(h []byte) (mp3Frame, bool) {
+	var f mp3Frame
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return f, false
+	}
+
+	versionBits := (h[1] >> 3) & 0x03
+	layerBits := (h[1] >> 1) & 0x03
+	bitrateIdx := (h[2] >> 4) & 0x0F
+	srIdx := (h[2] >> 2) & 0x03
+	padding := int((h[2] >> 1) & 0x01)
+	channelMode := (h[3] >> 6) & 0x03
+
+	if versionBits == 1 || layerBits == 0 || bitrateIdx == 0 || bitrateIdx == 15 || srIdx == 3 {
+		return f, false // reserved values or free-format, which we can't size
+	}
+
+	f.mpeg1 = versionBits == 3
+	f.layer = 4 - int(layerBits)
+	f.sampleRate = mp3SampleRates[versionBits][srIdx]
+
+	tableVersion := 1
+	if f.mpeg1 {
+		tableVersion = 0
+	}
+	f.bitrate = mp3Bitrates[tableVersion][f.layer-1][bitrateIdx] * 1000
+
+	f.channels = 2
+	if channelMode == 3 {
+		f.channels = 1
+	}
+
+	switch {
+	case f.layer == 1:
+		f.samplesPerFrame = 384
+		f.length = (12*f.bitrate/f.sampleRate + padding) * 4
+	case f.layer == 3 && !f.mpeg1:
+		f.samplesPerFrame = 576
+		f.length = 72*f.bitrate/f.sampleRate + padding
+	default:
+		f.samplesPerFrame = 1152
+		f.length = 144*f.bitrate/f.sampleRate + padding
+	}
+
+	if f.length < 4 {
+		return f, false
+	}
+
+	return f, true
+}
+
+// skipID3v2 seeks past an ID3v2 tag at the start of the file, if there is one
+func skipID3v2(file *os.File) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return err
+	}
+
+	if string(header[:3]) != "ID3" {
+		_, err := file.Seek(0, io.SeekStart)
+		return err
+	}
+
+	// tag size is a 28-bit syncsafe integer that excludes the 10-byte header
+	size := int64(header[6]&0x7F)<<21 | int64(header[7]&0x7F)<<14 | int64(header[8]&0x7F)<<7 | int64(header[9]&0x7F)
+	size += 10
+	if header[5]&0x10 != 0 {
+		size += 10 // footer present
+	}
+
+	_, err := file.Seek(size, io.SeekStart)
+	return err
+}
+
+// analyzeMP3 walks MPEG frame headers to get real duration and average bitrate.
+// VBR files with a Xing/Info or VBRI header are read from that header directly,
+// anything else gets every frame counted.
+func (aa *AudioAnalyzer) analyzeMP3(file *os.File, meta *AudioMetadata) error {
+	if err := skipID3v2(file); err != nil {
+		return fmt.Errorf("failed to skip ID3 tag: %w", err)
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+
+	// find the first frame, checking the next header too so a stray 0xFF byte doesn't fool us
+	var first mp3Frame
+	for {
+		h, err := reader.Peek(4)
+		if err != nil {
+			return fmt.Errorf("no MPEG audio frames found")
+		}
+		if f, ok := parseMP3FrameHeader(h); ok {
+			if next, err := reader.Peek(f.length + 4); err == nil {
+				if _, ok := parseMP3FrameHeader(next[f.length:]); ok {
+					first = f
+					break
+				}
+			} else if err == io.EOF || err == bufio.ErrBufferFull {
+				// single-frame file or frame bigger than our buffer, take it as-is
+				first = f
+				break
+			}
+		}
+		reader.Discard(1)
+	}
+
+	meta.Format = "MP3"
+	meta.SampleRate = first.sampleRate
+	meta.Channels = first.channels
+
+	// Xing/Info and VBRI headers live inside the first frame
+	if frameData, err := reader.Peek(first.length); err == nil {
+		if frames, audioBytes, ok := parseMP3VBRHeader(frameData, first); ok && frames > 0 {
+			seconds := float64(frames) * float64(first.samplesPerFrame) / float64(first.sampleRate)
+			meta.Duration = time.Duration(seconds * float64(time.Second))
+			if audioBytes > 0 && seconds > 0 {
+				meta.Bitrate = int(float64(audioBytes) * 8 / seconds)
+			} else {
+				meta.Bitrate = first.bitrate
+			}
+			return nil
+		}
+	}
+
+	// no VBR header, count every frame
+	frames := 0
+	totalBytes := int64(0)
+	for {
+		h, err := reader.Peek(4)
+		if err != nil {
+			break
+		}
+		if string(h[:3]) == "TAG" {
+			break // ID3v1 tag at the end of the file
+		}
+
+		f, ok := parseMP3FrameHeader(h)
+		if !ok {
+			// lost sync, step forward and keep looking
+			if _, err := reader.Discard(1); err != nil {
+				break
+			}
+			continue
+		}
+
+		discarded, _ := reader.Discard(f.length)
+		frames++
+		totalBytes += int64(discarded)
+		if discarded < f.length {
+			break // truncated last frame
+		}
+	}
+
+	if frames == 0 {
+		return fmt.Errorf("no MPEG audio frames found")
+	}
+
+	seconds := float64(frames) * float64(first.samplesPerFrame) / float64(first.sampleRate)
+	meta.Duration = time.Duration(seconds * float64(time.Second))
+	if seconds > 0 {
+		meta.Bitrate = int(float64(totalBytes) * 8 / seconds)
+	}
+
+	return nil
+}
+
+// parseMP3VBRHeader looks for a Xing/Info or VBRI header in the first frame and
+// returns the frame count and audio byte count it declares
+func parseMP3VBRHeader(frame []byte, f mp3Frame) (frames int, audioBytes int64, ok bool) {
+	// Xing sits right after the side info, whose size depends on version and channels
+	sideInfo := 17
+	switch {
+	case f.mpeg1 && f.channels == 2:
+		sideInfo = 32
+	case !f.mpeg1 && f.channels == 1:
+		sideInfo = 9
+	}
+
+	xingOffset := 4 + sideInfo
+	if len(frame) >= xingOffset+8 {
+		tag := frame[xingOffset : xingOffset+4]
+		if bytes.Equal(tag, []byte("Xing")) || bytes.Equal(tag, []byte("Info")) {
+			flags := binary.BigEndian.Uint32(frame[xingOffset+4:])
+			pos := xingOffset + 8
+			if flags&0x1 != 0 && len(frame) >= pos+4 {
+				frames = int(binary.BigEndian.Uint32(frame[pos:]))
+				pos += 4
+			}
+			if flags&0x2 != 0 && len(frame) >= pos+4 {
+				audioBytes = int64(binary.BigEndian.Uint32(frame[pos:]))
+			}
+			return frames, audioBytes, frames > 0
+		}
+	}
+
+	// VBRI is always 32 bytes after the header
+	const vbriOffset = 4 + 32
+	if len(frame) >= vbriOffset+18 && bytes.Equal(frame[vbriOffset:vbriOffset+4], []byte("VBRI")) {
+		audioBytes = int64(binary.BigEndian.Uint32(frame[vbriOffset+10:]))
+		frames = int(binary.BigEndian.Uint32(frame[vbriOffset+14:]))
+		return frames, audioBytes, frames > 0
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,115 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 128kbps, 48kHz, MPEG1 Layer III, stereo, no CRC - frames are exactly 384 bytes
+var testMP3Header = []byte{0xFF, 0xFB, 0x94, 0x00}
+
+const testMP3FrameLen = 384
+
+// writeTestMP3 builds a fixture of CBR frames with an optional ID3v2 tag in front
+// and an optional Xing header in the first frame
+func writeTestMP3(t *testing.T, frames int, withID3 bool, xingFrames int) string {
+	t.Helper()
+
+	var data []byte
+	if withID3 {
+		// 20 bytes of tag body, syncsafe size
+		data = append(data, 'I', 'D', '3', 3, 0, 0, 0, 0, 0, 20)
+		data = append(data, make([]byte, 20)...)
+	}
+
+	for i := 0; i < frames; i++ {
+		frame := make([]byte, testMP3FrameLen)
+		copy(frame, testMP3Header)
+		if i == 0 && xingFrames > 0 {
+			off := 4 + 32 // stereo MPEG1 side info
+			copy(frame[off:], "Xing")
+			binary.BigEndian.PutUint32(frame[off+4:], 0x1) // frames field only
+			binary.BigEndian.PutUint32(frame[off+8:], uint32(xingFrames))
+		}
+		data = append(data, frame...)
+	}
+
+	path := filepath.Join(t.TempDir(), "fixture.mp3")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestParseMP3FrameHeader(t *testing.T) {
+	f, ok := parseMP3FrameHeader(testMP3Header)
+	if !ok {
+		t.Fatal("parseMP3FrameHeader() rejected a valid header")
+	}
+	if f.bitrate != 128000 || f.sampleRate != 48000 || f.channels != 2 {
+		t.Errorf("parseMP3FrameHeader() = %+v, want 128kbps 48kHz stereo", f)
+	}
+	if f.length != testMP3FrameLen {
+		t.Errorf("parseMP3FrameHeader() length = %d, want %d", f.length, testMP3FrameLen)
+	}
+	if f.samplesPerFrame != 1152 {
+		t.Errorf("parseMP3FrameHeader() samplesPerFrame = %d, want 1152", f.samplesPerFrame)
+	}
+
+	invalid := [][]byte{
+		{0x00, 0x00, 0x00, 0x00},
+		{0xFF, 0xFB, 0xF4, 0x00}, // bad bitrate index
+		{0xFF, 0xFB, 0x9C, 0x00}, // reserved sample rate
+		{0xFF, 0xEB, 0x94, 0x00}, // reserved version
+	}
+	for _, h := range invalid {
+		if _, ok := parseMP3FrameHeader(h); ok {
+			t.Errorf("parseMP3FrameHeader(% X) should be invalid", h)
+		}
+	}
+}
+
+func TestAnalyzeMP3Duration(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	tests := []struct {
+		name       string
+		frames     int
+		withID3    bool
+		xingFrames int
+		expected   time.Duration
+	}{
+		{name: "cbr_frame_scan", frames: 250, expected: 6 * time.Second}, // 250 * 1152 / 48000
+		{name: "with_id3v2", frames: 125, withID3: true, expected: 3 * time.Second},
+		{name: "xing_header", frames: 10, xingFrames: 1000, expected: 24 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestMP3(t, tt.frames, tt.withID3, tt.xingFrames)
+			meta, err := aa.AnalyzeFile(path)
+			if err != nil {
+				t.Fatalf("AnalyzeFile() error = %v", err)
+			}
+
+			if diff := meta.Duration - tt.expected; diff > time.Millisecond || diff < -time.Millisecond {
+				t.Errorf("Duration = %v, want %v", meta.Duration, tt.expected)
+			}
+			if meta.SampleRate != 48000 {
+				t.Errorf("SampleRate = %d, want 48000", meta.SampleRate)
+			}
+			if meta.Channels != 2 {
+				t.Errorf("Channels = %d, want 2", meta.Channels)
+			}
+			if meta.Format != "MP3" {
+				t.Errorf("Format = %q, want MP3", meta.Format)
+			}
+			if tt.xingFrames == 0 && meta.Bitrate != 128000 {
+				t.Errorf("Bitrate = %d, want 128000", meta.Bitrate)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// moov is normally well under a megabyte, anything this big isn't worth reading
+const mp4MaxMoovSize = 64 << 20
+
+// analyzeMP4 reads the moov box of an M4A file: mvhd for the length, and the
+// sample description of the sound track for sample rate and channels
+func (aa *AudioAnalyzer) analyzeMP4(file *os.File, meta *AudioMetadata) error {
+	moov, err := findMP4Moov(file)
+	if err != nil {
+		return err
+	}
+
+	mvhd, ok := mp4Child(moov, "mvhd")
+	if !ok {
+		return fmt.Errorf("no mvhd box")
+	}
+	timescale, duration, err := parseMP4Header(mvhd)
+	if err != nil {
+		return fmt.Errorf("mvhd: %w", err)
+	}
+
+	track, ok := mp4SoundTrack(moov)
+	if !ok {
+		return fmt.Errorf("no sound track")
+	}
+
+	meta.Format = "M4A"
+	meta.SampleRate = track.sampleRate
+	meta.Channels = track.channels
+	if track.lossless {
+		meta.BitDepth = track.sampleSize // for AAC it's a nominal 16, not a real bit depth
+	}
+
+	if timescale > 0 && duration > 0 {
+		seconds := float64(duration) / float64(timescale)
+		meta.Duration = time.Duration(seconds * float64(time.Second))
+		if fileInfo, err := file.Stat(); err == nil && seconds > 0 {
+			meta.Bitrate = int(float64(fileInfo.Size()*8) / seconds)
+		}
+	}
+
+	meta.Fingerprint = aa.GenerateFingerprint(meta)
+
+	return nil
+}
+
+// findMP4Moov walks the top-level boxes and returns the contents of moov,
+// which comes before or after the media data depending on the encoder
+func findMP4Moov(r io.ReadSeeker) ([]byte, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 8)
+	for first := true; ; first = false {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("no moov box found: %w", err)
+		}
+		size := int64(binary.BigEndian.Uint32(header[0:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+
+		if first && boxType != "ftyp" {
+			return nil, fmt.Errorf("invalid MP4 file")
+		}
+
+		switch size {
+		case 0:
+			if boxType != "moov" {
+				return nil, fmt.Errorf("no moov box found") // last box runs to the end of the file
+			}
+		case 1:
+			large := make([]byte, 8)
+			if _, err := io.ReadFull(r, large); err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(large))
+			headerSize = 16
+		}
+		if size != 0 && size < headerSize {
+			return nil, fmt.Errorf("invalid %q box size %d", boxType, size)
+		}
+
+		if boxType == "moov" {
+			if size == 0 || size-headerSize > mp4MaxMoovSize {
+				return nil, fmt.Errorf("unsupported moov box size %d", size)
+			}
+			moov := make([]byte, size-headerSize)
+			if _, err := io.ReadFull(r, moov); err != nil {
+				return nil, fmt.Errorf("failed to read moov: %w", err)
+			}
+			return moov, nil
+		}
+
+		if _, err := r.Seek(size-headerSize, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type mp4Box struct {
+	boxType string
+	data    []byte // contents after the header
+}
+
+// mp4Boxes splits data into the boxes it holds, stopping at the first one
+// that doesn't fit
+func mp4Boxes(data []byte) []mp4Box {
+	var boxes []mp4Box
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		headerSize := uint64(8)
+		switch {
+		case size == 1 && len(data) >= 16:
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		case size == 0:
+			size = uint64(len(data)) // runs to the end of its parent
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			break
+		}
+		boxes = append(boxes, mp4Box{boxType: string(data[4:8]), data: data[headerSize:size]})
+		data = data[size:]
+	}
+	return boxes
+}
+
+// mp4Child returns the contents of the first box of the given type in data
+func mp4Child(data []byte, boxType string) ([]byte, bool) {
+	for _, box := range mp4Boxes(data) {
+		if box.boxType == boxType {
+			return box.data, true
+		}
+	}
+	return nil, false
+}
+
+// mp4Path follows a chain of nested boxes, like "mdia", "minf", "stbl"
+func mp4Path(data []byte, path ...string) ([]byte, bool) {
+	for _, boxType := range path {
+		var ok bool
+		if data, ok = mp4Child(data, boxType); !ok {
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+// parseMP4Header reads the timescale and duration of an mvhd or mdhd box,
+// which share the same layout up to the duration
+func parseMP4Header(box []byte) (timescale uint32, duration uint64, err error) {
+	if len(box) < 4 {
+		return 0, 0, fmt.Errorf("box too short")
+	}
+	if box[0] == 1 {
+		// version 1: 64-bit creation and modification times and duration
+		if len(box) < 32 {
+			return 0, 0, fmt.Errorf("box too short")
+		}
+		return binary.BigEndian.Uint32(box[20:24]), binary.BigEndian.Uint64(box[24:32]), nil
+	}
+	if len(box) < 20 {
+		return 0, 0, fmt.Errorf("box too short")
+	}
+	return binary.BigEndian.Uint32(box[12:16]), uint64(binary.BigEndian.Uint32(box[16:20])), nil
+}
+
+type mp4Track struct {
+	sampleRate int
+	channels   int
+	sampleSize int
+	lossless   bool
+}
+
+// mp4SoundTrack finds the first track with a sound handler and reads its
+// audio sample entry
+func mp4SoundTrack(moov []byte) (mp4Track, bool) {
+	for _, trak := range mp4Boxes(moov) {
+		if trak.boxType != "trak" {
+			continue
+		}
+		mdia, ok := mp4Child(trak.data, "mdia")
+		if !ok {
+			continue
+		}
+		// hdlr: version/flags(4), pre_defined(4), handler type(4)
+		if hdlr, ok := mp4Child(mdia, "hdlr"); !ok || len(hdlr) < 12 || string(hdlr[8:12]) != "soun" {
+			continue
+		}
+
+		// stsd: version/flags(4), entry count(4), then the first sample entry
+		stsd, ok := mp4Path(mdia, "minf", "stbl", "stsd")
+		if !ok || len(stsd) < 8+36 {
+			continue
+		}
+		entry := stsd[8:]
+		// sample entry: size(4), format(4), reserved(6), data ref index(2),
+		// reserved(8), channels(2), sample size(2), pre_defined(2), reserved(2),
+		// sample rate as 16.16 fixed point(4)
+		track := mp4Track{
+			channels:   int(binary.BigEndian.Uint16(entry[24:26])),
+			sampleSize: int(binary.BigEndian.Uint16(entry[26:28])),
+			sampleRate: int(binary.BigEndian.Uint32(entry[32:36]) >> 16),
+			lossless:   string(entry[4:8]) == "alac",
+		}
+
+		// rates above 65535 Hz don't fit the fixed point field, the media
+		// timescale is the sample rate for audio tracks
+		if mdhd, ok := mp4Child(mdia, "mdhd"); ok {
+			if timescale, _, err := parseMP4Header(mdhd); err == nil && (track.sampleRate == 0 || timescale > 0xFFFF) {
+				track.sampleRate = int(timescale)
+			}
+		}
+
+		if track.sampleRate == 0 || track.channels == 0 {
+			continue
+		}
+		return track, true
+	}
+	return mp4Track{}, false
+}
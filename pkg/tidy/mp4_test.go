@@ -0,0 +1,114 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func mp4TestBox(boxType string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	box := binary.BigEndian.AppendUint32(nil, uint32(size))
+	box = append(box, boxType...)
+	for _, p := range payload {
+		box = append(box, p...)
+	}
+	return box
+}
+
+// writeTestM4A builds an M4A fixture with the moov box after mdat, the way
+// most encoders write it
+func writeTestM4A(t *testing.T, format string, sampleRate, channels int, seconds float64) string {
+	t.Helper()
+
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], uint32(seconds*1000))
+
+	mdhd := make([]byte, 24)
+	binary.BigEndian.PutUint32(mdhd[12:], uint32(sampleRate))
+	binary.BigEndian.PutUint32(mdhd[16:], uint32(seconds*float64(sampleRate)))
+
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:], "soun")
+
+	entry := make([]byte, 28)
+	binary.BigEndian.PutUint16(entry[6:], 1) // data reference index
+	binary.BigEndian.PutUint16(entry[16:], uint16(channels))
+	binary.BigEndian.PutUint16(entry[18:], 24)
+	if sampleRate <= 0xFFFF {
+		binary.BigEndian.PutUint32(entry[24:], uint32(sampleRate)<<16)
+	}
+	stsd := append(make([]byte, 4), binary.BigEndian.AppendUint32(nil, 1)...)
+	stsd = append(stsd, mp4TestBox(format, entry)...)
+
+	stbl := mp4TestBox("stbl", mp4TestBox("stsd", stsd))
+	mdia := mp4TestBox("mdia", mp4TestBox("mdhd", mdhd), mp4TestBox("hdlr", hdlr), mp4TestBox("minf", stbl))
+	moov := mp4TestBox("moov", mp4TestBox("mvhd", mvhd), mp4TestBox("trak", mp4TestBox("tkhd", make([]byte, 84)), mdia))
+
+	data := mp4TestBox("ftyp", []byte("M4A \x00\x00\x00\x00M4A isom"))
+	data = append(data, mp4TestBox("mdat", make([]byte, 4096))...)
+	data = append(data, moov...)
+
+	path := filepath.Join(t.TempDir(), "fixture.m4a")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestAnalyzeM4A(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		sampleRate   int
+		channels     int
+		seconds      float64
+		wantBitDepth int
+	}{
+		{"AAC stereo", "mp4a", 44100, 2, 65, 0},
+		{"AAC mono one-shot", "mp4a", 48000, 1, 0.8, 0},
+		{"ALAC above 65535 Hz", "alac", 96000, 2, 12, 24},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := NewAudioAnalyzer().AnalyzeFile(writeTestM4A(t, tt.format, tt.sampleRate, tt.channels, tt.seconds))
+			if err != nil {
+				t.Fatalf("AnalyzeFile() error = %v", err)
+			}
+			if meta.Format != "M4A" {
+				t.Errorf("Format = %q, want M4A", meta.Format)
+			}
+			if meta.SampleRate != tt.sampleRate || meta.Channels != tt.channels || meta.BitDepth != tt.wantBitDepth {
+				t.Errorf("got %d Hz, %d ch, %d bit, want %d Hz, %d ch, %d bit", meta.SampleRate, meta.Channels, meta.BitDepth, tt.sampleRate, tt.channels, tt.wantBitDepth)
+			}
+			if want := time.Duration(tt.seconds * float64(time.Second)); meta.Duration != want {
+				t.Errorf("Duration = %v, want %v", meta.Duration, want)
+			}
+			if meta.Bitrate == 0 || meta.Fingerprint == "" {
+				t.Errorf("Bitrate = %d, Fingerprint = %q, want both set", meta.Bitrate, meta.Fingerprint)
+			}
+		})
+	}
+}
+
+func TestAnalyzeM4ANotMP4(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.m4a")
+	if err := os.WriteFile(path, []byte("definitely not an mp4 file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v, should fall back", err)
+	}
+	if meta.Format != "m4a" || meta.Duration != 0 {
+		t.Errorf("Format = %q, Duration = %v, want the m4a fallback with no duration", meta.Format, meta.Duration)
+	}
+}
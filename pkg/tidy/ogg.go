@@ -0,0 +1,117 @@
+package tidy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	oggPageHeaderSize = 27
+
+	// how far back from the end of the file we look for the last page
+	oggTailScanSize = 64 * 1024
+)
+
+// analyzeOGG reads the Vorbis identification header from the first Ogg page
+// for sample rate and channels, and the granule position of the last page for
+// the exact sample count
+func (aa *AudioAnalyzer) analyzeOGG(file *os.File, meta *AudioMetadata) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	header := make([]byte, oggPageHeaderSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return fmt.Errorf("failed to read Ogg page: %w", err)
+	}
+	if string(header[0:4]) != "OggS" {
+		return fmt.Errorf("invalid Ogg file")
+	}
+	serial := binary.LittleEndian.Uint32(header[14:18])
+
+	// the identification packet is the whole payload of the first page
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(file, segments); err != nil {
+		return fmt.Errorf("failed to read Ogg segment table: %w", err)
+	}
+	payloadSize := 0
+	for _, s := range segments {
+		payloadSize += int(s)
+	}
+	packet := make([]byte, payloadSize)
+	if _, err := io.ReadFull(file, packet); err != nil {
+		return fmt.Errorf("failed to read Ogg packet: %w", err)
+	}
+
+	// identification header: type 1, "vorbis", version(4), channels(1), rate(4),
+	// bitrate max(4), nominal(4), min(4)
+	if len(packet) < 28 || packet[0] != 1 || string(packet[1:7]) != "vorbis" {
+		return fmt.Errorf("not an Ogg Vorbis stream")
+	}
+	channels := int(packet[11])
+	sampleRate := int(binary.LittleEndian.Uint32(packet[12:16]))
+	nominalBitrate := int(int32(binary.LittleEndian.Uint32(packet[20:24])))
+
+	if sampleRate == 0 || channels == 0 {
+		return fmt.Errorf("invalid Vorbis identification header")
+	}
+
+	meta.Format = "OGG"
+	meta.SampleRate = sampleRate
+	meta.Channels = channels
+
+	if granule, err := lastOggGranule(file, serial); err == nil && granule > 0 {
+		seconds := float64(granule) / float64(sampleRate)
+		meta.Duration = time.Duration(seconds * float64(time.Second))
+		if fileInfo, err := file.Stat(); err == nil && seconds > 0 {
+			meta.Bitrate = int(float64(fileInfo.Size()*8) / seconds)
+		}
+	}
+	if meta.Bitrate == 0 && nominalBitrate > 0 {
+		meta.Bitrate = nominalBitrate
+	}
+
+	meta.Fingerprint = aa.GenerateFingerprint(meta)
+
+	return nil
+}
+
+// lastOggGranule finds the granule position (total samples) of the last page
+// belonging to the given logical stream
+func lastOggGranule(file *os.File, serial uint32) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	start := info.Size() - oggTailScanSize
+	if start < 0 {
+		start = 0
+	}
+	tail := make([]byte, info.Size()-start)
+	if _, err := file.ReadAt(tail, start); err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	// walk backwards through capture patterns until one is a page of our stream
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		if i+oggPageHeaderSize > len(tail) {
+			continue
+		}
+		page := tail[i:]
+		if binary.LittleEndian.Uint32(page[14:18]) != serial {
+			continue
+		}
+		granule := int64(binary.LittleEndian.Uint64(page[6:14]))
+		if granule < 0 {
+			continue // -1 means no packet finishes on this page
+		}
+		return granule, nil
+	}
+
+	return 0, fmt.Errorf("no final Ogg page found")
+}
@@ -0,0 +1,89 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// buildOggPage assembles one Ogg page with a single packet (CRC left at zero, we don't check it)
+func buildOggPage(headerType byte, granule int64, serial, sequence uint32, packet []byte) []byte {
+	page := []byte("OggS")
+	page = append(page, 0, headerType)
+	page = binary.LittleEndian.AppendUint64(page, uint64(granule))
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = binary.LittleEndian.AppendUint32(page, sequence)
+	page = binary.LittleEndian.AppendUint32(page, 0)
+
+	var segments []byte
+	remaining := len(packet)
+	for remaining >= 255 {
+		segments = append(segments, 255)
+		remaining -= 255
+	}
+	segments = append(segments, byte(remaining))
+
+	page = append(page, byte(len(segments)))
+	page = append(page, segments...)
+	return append(page, packet...)
+}
+
+func buildTestOGG(sampleRate, channels int, totalSamples int64) []byte {
+	ident := []byte{1}
+	ident = append(ident, "vorbis"...)
+	ident = binary.LittleEndian.AppendUint32(ident, 0)
+	ident = append(ident, byte(channels))
+	ident = binary.LittleEndian.AppendUint32(ident, uint32(sampleRate))
+	ident = binary.LittleEndian.AppendUint32(ident, 0)
+	ident = binary.LittleEndian.AppendUint32(ident, 160000)
+	ident = binary.LittleEndian.AppendUint32(ident, 0)
+	ident = append(ident, 0xB8, 1)
+
+	const serial = 0x1234
+	data := buildOggPage(0x02, 0, serial, 0, ident)
+	data = append(data, buildOggPage(0, totalSamples/2, serial, 1, make([]byte, 4000))...)
+	// a page from another logical stream after ours, which must be ignored
+	data = append(data, buildOggPage(0x04, totalSamples, serial, 2, make([]byte, 4000))...)
+	data = append(data, buildOggPage(0x04, 999999999, serial+1, 0, make([]byte, 100))...)
+	return data
+}
+
+func TestAnalyzeOGG(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	path := writeTestFile(t, "rain.ogg", buildTestOGG(44100, 2, 44100*75/2))
+	meta, err := aa.AnalyzeFile(path)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+
+	if meta.Format != "OGG" {
+		t.Errorf("Format = %q, want OGG", meta.Format)
+	}
+	if meta.SampleRate != 44100 {
+		t.Errorf("SampleRate = %d, want 44100", meta.SampleRate)
+	}
+	if meta.Channels != 2 {
+		t.Errorf("Channels = %d, want 2", meta.Channels)
+	}
+	if meta.Duration != 37500*time.Millisecond {
+		t.Errorf("Duration = %v, want 37.5s", meta.Duration)
+	}
+	if meta.Fingerprint == "" {
+		t.Error("Fingerprint should be set")
+	}
+}
+
+func TestAnalyzeOGGNotVorbis(t *testing.T) {
+	aa := NewAudioAnalyzer()
+
+	// an Opus stream isn't Vorbis, analysis should fall back rather than fail
+	opus := buildOggPage(0x02, 0, 1, 0, append([]byte("OpusHead"), make([]byte, 11)...))
+	meta, err := aa.AnalyzeFile(writeTestFile(t, "voice.ogg", opus))
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	if meta.SampleRate != 0 || meta.Duration != 0 {
+		t.Errorf("non-Vorbis stream should not get audio properties, got %+v", meta)
+	}
+}
@@ -0,0 +1,184 @@
+package tidy
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"os"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+const (
+	// enough audio to tell a one-shot from a sustained texture
+	onsetMaxDuration = 10 * time.Second
+
+	onsetFrameSize = 1024 // FFT size, a power of two
+	onsetHop       = 512
+
+	// onsets closer together than this are one transient ringing out
+	onsetMinGap = 50 * time.Millisecond
+
+	// spectral flux a frame needs above its neighbourhood to count as an onset.
+	// Flux is measured on log magnitudes, so this doesn't depend on the level.
+	onsetMinFlux = 0.05
+	// and it has to stand out from the frames around it by this factor
+	onsetFluxRatio = 1.5
+	onsetAvgFrames = 8 // frames either side for the local average
+)
+
+// analyzeOnsets counts the transients in the first seconds of a WAV file, and
+// hashes them for near-duplicate detection while they're decoded anyway
+func (aa *AudioAnalyzer) analyzeOnsets(file *os.File, meta *AudioMetadata) error {
+	samples, sampleRate, err := decodeMonoWAV(file, onsetMaxDuration)
+	if err != nil {
+		return err
+	}
+	meta.OnsetCount = countOnsets(samples, sampleRate)
+	meta.PerceptualHash = perceptualHash(samples, sampleRate)
+	return nil
+}
+
+// decodeMonoWAV decodes up to maxDuration of a WAV file, mixed down to mono and
+// scaled to -1..1
+func decodeMonoWAV(file *os.File, maxDuration time.Duration) ([]float64, int, error) {
+	decoder := wav.NewDecoder(file)
+	if !decoder.IsValidFile() {
+		return nil, 0, fmt.Errorf("invalid WAV file")
+	}
+
+	channels := int(decoder.NumChans)
+	sampleRate := int(decoder.SampleRate)
+	bitDepth := int(decoder.BitDepth)
+	if bitDepth == 0 {
+		return nil, 0, fmt.Errorf("missing audio format info")
+	}
+	if err := checkFormat(float64(sampleRate), channels); err != nil {
+		return nil, 0, err
+	}
+
+	fullScale := float64(int64(1) << (bitDepth - 1))
+	maxFrames := int(maxDuration.Seconds() * float64(sampleRate))
+
+	buf := &audio.IntBuffer{
+		Format: &audio.Format{NumChannels: channels, SampleRate: sampleRate},
+		Data:   make([]int, 4096*channels),
+	}
+
+	// mix down to mono as we go, growing with what's actually read rather
+	// than what the header says maxDuration comes to
+	var samples []float64
+	for len(samples) < maxFrames {
+		n, err := decoder.PCMBuffer(buf)
+		if n == 0 || err != nil {
+			break
+		}
+		for i := 0; i+channels <= n && i+channels <= len(buf.Data); i += channels {
+			sum := 0
+			for ch := 0; ch < channels; ch++ {
+				sum += buf.Data[i+ch]
+			}
+			samples = append(samples, float64(sum)/float64(channels)/fullScale)
+		}
+	}
+	if len(samples) > maxFrames {
+		samples = samples[:maxFrames]
+	}
+	return samples, sampleRate, nil
+}
+
+// countOnsets finds transients with spectral flux: how much the (log) magnitude
+// spectrum grows from one frame to the next. A frame is an onset when its flux
+// peaks well above the frames around it. The start is compared against
+// silence, so a sound that starts right away counts as one onset.
+func countOnsets(samples []float64, sampleRate int) int {
+	if len(samples) < onsetFrameSize || sampleRate == 0 {
+		return 0
+	}
+	// frames are centered on their hop, so the first one is half silence and a
+	// hit right at the start isn't lost to the window fading in
+	pad := onsetFrameSize / 2
+	frames := (len(samples)-pad)/onsetHop + 1
+
+	window := make([]float64, onsetFrameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(onsetFrameSize-1))
+	}
+
+	bins := onsetFrameSize/2 + 1
+	prev := make([]float64, bins)
+	flux := make([]float64, frames)
+	spectrum := make([]complex128, onsetFrameSize)
+	for f := range flux {
+		for i := range spectrum {
+			spectrum[i] = 0
+			if idx := f*onsetHop + i - pad; idx >= 0 {
+				spectrum[i] = complex(samples[idx]*window[i], 0)
+			}
+		}
+		fft(spectrum)
+
+		sum := 0.0
+		for k := 0; k < bins; k++ {
+			// a Hann window halves the gain, so this is roughly the amplitude
+			mag := math.Log1p(100 * cmplx.Abs(spectrum[k]) * 4 / onsetFrameSize)
+			if mag > prev[k] {
+				sum += mag - prev[k]
+			}
+			prev[k] = mag
+		}
+		flux[f] = sum / float64(bins)
+	}
+
+	minGap := int(onsetMinGap.Seconds() * float64(sampleRate) / onsetHop)
+	count, last := 0, -minGap-1
+	for f := range flux {
+		if f > 0 && flux[f] < flux[f-1] || f+1 < frames && flux[f] <= flux[f+1] {
+			continue // not a peak
+		}
+
+		lo, hi := max(0, f-onsetAvgFrames), min(frames, f+onsetAvgFrames+1)
+		avg := 0.0
+		for _, v := range flux[lo:hi] {
+			avg += v
+		}
+		avg /= float64(hi - lo)
+
+		if flux[f] >= onsetMinFlux && flux[f] >= onsetFluxRatio*avg && f-last > minGap {
+			count++
+			last = f
+		}
+	}
+	return count
+}
+
+// fft is an in-place iterative radix-2 FFT, len(x) must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+
+	// bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], w*x[start+k+size/2]
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
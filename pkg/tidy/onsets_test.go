@@ -0,0 +1,140 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func buildSine(freq float64, sampleRate int, seconds float64) []float64 {
+	samples := make([]float64, int(seconds*float64(sampleRate)))
+	for i := range samples {
+		samples[i] = 0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+	}
+	return samples
+}
+
+func TestCountOnsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []float64
+		min     int
+		max     int
+	}{
+		{"click train, 10 clicks", buildClickTrack(120, 48000, 5), 10, 10},
+		{"fast click train, 40 clicks", buildClickTrack(480, 44100, 5), 40, 40},
+		{"steady sine", buildSine(440, 48000, 10), 0, 1},
+		{"silence", make([]float64, 48000*5), 0, 0},
+		{"too short", make([]float64, 100), 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := countOnsets(tt.samples, 48000); got < tt.min || got > tt.max {
+			t.Errorf("%s: countOnsets() = %d, want %d-%d", tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestFFT(t *testing.T) {
+	// a cosine at bin 3 puts all its energy in bins 3 and n-3
+	n := 16
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*3*float64(i)/float64(n)), 0)
+	}
+	fft(x)
+
+	for k, v := range x {
+		want := 0.0
+		if k == 3 || k == n-3 {
+			want = float64(n) / 2
+		}
+		if math.Abs(cmplx.Abs(v)-want) > 1e-9 {
+			t.Errorf("bin %d = %.3f, want %.3f", k, cmplx.Abs(v), want)
+		}
+	}
+}
+
+func TestAnalyzeOnsetsWAV(t *testing.T) {
+	clicks := buildClickTrack(120, 48000, 3)
+	pcm := make([]byte, 2*len(clicks))
+	for i, s := range clicks {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(s*32767)))
+	}
+
+	path := filepath.Join(t.TempDir(), "drum_loop.wav")
+	if err := os.WriteFile(path, buildTestWAV(48000, 1, 16, pcm), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.OnsetCount != 6 {
+		t.Errorf("OnsetCount = %d, want 6", meta.OnsetCount)
+	}
+}
+
+func TestApplyOnsetScoring(t *testing.T) {
+	tests := []struct {
+		name     string
+		meta     *AudioMetadata
+		category string
+		want     float64
+	}{
+		{"short and busy", &AudioMetadata{Duration: 2 * time.Second, OnsetCount: 8}, "SFX_Impact", 0.4},
+		{"short and busy", &AudioMetadata{Duration: 2 * time.Second, OnsetCount: 8}, "SFX_Percussion", 0.3},
+		{"single hit", &AudioMetadata{Duration: time.Second, OnsetCount: 1}, "SFX_Impact", 0},
+		{"long and still", &AudioMetadata{Duration: time.Minute, OnsetCount: 0}, "SFX_Drone", 0.3},
+		{"long and still", &AudioMetadata{Duration: time.Minute, OnsetCount: 1}, "Ambient", 0.3},
+		{"long and busy", &AudioMetadata{Duration: time.Minute, OnsetCount: 30}, "Ambient", 0},
+		{"no duration", &AudioMetadata{OnsetCount: 8}, "SFX_Impact", 0},
+	}
+
+	for _, tt := range tests {
+		scores := make(map[string]float64)
+		ApplyOnsetScoring(scores, tt.meta)
+		if got := scores[tt.category]; math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: %s score = %.2f, want %.2f", tt.name, tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeFileCraftedWAVFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int // into the fmt chunk data, which starts at 20
+		value  []byte
+	}{
+		{"rate.wav", 24, []byte{0xFF, 0xFF, 0xFF, 0xFF}},
+		{"channels.wav", 22, []byte{0xFF, 0xFF}},
+	}
+
+	for _, tt := range tests {
+		data := buildTestWAV(48000, 1, 16, make([]byte, 48000*2))
+		copy(data[tt.offset:], tt.value)
+		path := writeTestFile(t, tt.name, data)
+
+		meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+		if err != nil {
+			t.Fatalf("%s: AnalyzeFile() error = %v", tt.name, err)
+		}
+		if meta.SpectralFeatures != nil || meta.PerceptualHash != "" {
+			t.Errorf("%s: decoded the audio of an implausible header", tt.name)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := decodeMonoWAV(file, time.Second); err == nil {
+			t.Errorf("%s: decodeMonoWAV() accepted an implausible header", tt.name)
+		}
+		file.Close()
+	}
+}
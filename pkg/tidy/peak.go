@@ -0,0 +1,63 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// an embedded peak further than this from the one measured from the samples
+// means the file was edited without its PEAK chunk being updated
+const peakMismatchDB = 1.0
+
+// PeakInfo is the loudest channel of a WAV's PEAK chunk, which some editors
+// write so players can draw overviews without scanning the audio
+type PeakInfo struct {
+	DBFS     float64 `json:"dbfs"`
+	Position uint32  `json:"position"` // sample frame of the peak
+}
+
+// parsePeakChunk reads a PEAK chunk: version and timestamp, then a value
+// (1.0 is full scale) and a sample frame position for each channel. It
+// returns the loudest channel.
+func parsePeakChunk(data []byte, channels int) (*PeakInfo, error) {
+	if len(data) < 8+8 {
+		return nil, fmt.Errorf("PEAK chunk too short")
+	}
+	if version := binary.LittleEndian.Uint32(data[0:4]); version != 1 {
+		return nil, fmt.Errorf("unknown PEAK version %d", version)
+	}
+
+	count := (len(data) - 8) / 8
+	if channels > 0 && channels < count {
+		count = channels
+	}
+
+	var loudest float64
+	var position uint32
+	for i := 0; i < count; i++ {
+		off := 8 + i*8
+		value := float64(math.Float32frombits(binary.LittleEndian.Uint32(data[off : off+4])))
+		if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+			return nil, fmt.Errorf("invalid PEAK value for channel %d", i)
+		}
+		if value > loudest || i == 0 {
+			loudest = value
+			position = binary.LittleEndian.Uint32(data[off+4 : off+8])
+		}
+	}
+	if loudest == 0 {
+		return nil, fmt.Errorf("PEAK chunk of a silent file")
+	}
+
+	return &PeakInfo{DBFS: roundDB(20 * math.Log10(loudest)), Position: position}, nil
+}
+
+// checkEmbeddedPeak compares the PEAK chunk with the peak measured from the
+// samples. The measured one stays in PeakDBFS either way, a mismatch only
+// flags the chunk as stale.
+func checkEmbeddedPeak(meta *AudioMetadata) {
+	measured := meta.PeakDBFS != 0 || meta.LoudnessLUFS != 0 // a peak right at full scale is 0
+	meta.PeakMismatch = meta.EmbeddedPeak != nil && measured &&
+		math.Abs(meta.EmbeddedPeak.DBFS-meta.PeakDBFS) > peakMismatchDB
+}
@@ -0,0 +1,85 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// buildTestPeak assembles a version 1 PEAK chunk with a value and position per channel
+func buildTestPeak(values []float32, positions []uint32) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, 1)
+	data = binary.LittleEndian.AppendUint32(data, 0) // timestamp
+	for i, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+		data = binary.LittleEndian.AppendUint32(data, positions[i])
+	}
+	return data
+}
+
+func TestParsePeakChunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		channels int
+		want     *PeakInfo
+	}{
+		{"mono", buildTestPeak([]float32{0.5}, []uint32{1200}), 1, &PeakInfo{DBFS: -6, Position: 1200}},
+		{"loudest channel", buildTestPeak([]float32{0.25, 1}, []uint32{10, 20}), 2, &PeakInfo{DBFS: 0, Position: 20}},
+		{"extra entries ignored", buildTestPeak([]float32{0.5, 1}, []uint32{10, 20}), 1, &PeakInfo{DBFS: -6, Position: 10}},
+		{"silent", buildTestPeak([]float32{0}, []uint32{0}), 1, nil},
+		{"negative", buildTestPeak([]float32{-0.5}, []uint32{0}), 1, nil},
+		{"too short", []byte{1, 0, 0, 0}, 1, nil},
+		{"unknown version", append([]byte{2, 0, 0, 0}, buildTestPeak([]float32{0.5}, []uint32{0})[4:]...), 1, nil},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePeakChunk(tt.data, tt.channels)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("%s: parsePeakChunk() = %+v, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || *got != *tt.want {
+			t.Errorf("%s: parsePeakChunk() = %+v, %v, want %+v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeWAVEmbeddedPeak(t *testing.T) {
+	tests := []struct {
+		name     string
+		embedded float32 // 0 for no PEAK chunk
+		mismatch bool
+	}{
+		{"no chunk", 0, false},
+		{"up to date", float32(math.Pow(10, -6.0/20)), false},
+		{"stale", 1, true}, // edited down from full scale
+	}
+
+	aa := NewAudioAnalyzer()
+	for _, tt := range tests {
+		var extra []riffChunk
+		if tt.embedded > 0 {
+			extra = append(extra, riffChunk{ID: "PEAK", Data: buildTestPeak([]float32{tt.embedded}, []uint32{12})})
+		}
+		meta, err := aa.AnalyzeFile(writeTestFile(t, "tone.wav", sineWAV(-6, extra...)))
+		if err != nil {
+			t.Fatalf("%s: AnalyzeFile() error = %v", tt.name, err)
+		}
+
+		if (meta.EmbeddedPeak != nil) != (tt.embedded > 0) {
+			t.Errorf("%s: EmbeddedPeak = %+v", tt.name, meta.EmbeddedPeak)
+		}
+		if math.Abs(meta.PeakDBFS+6) > 0.1 {
+			t.Errorf("%s: PeakDBFS = %v, want the measured -6", tt.name, meta.PeakDBFS)
+		}
+		if meta.PeakMismatch != tt.mismatch {
+			t.Errorf("%s: PeakMismatch = %v, want %v", tt.name, meta.PeakMismatch, tt.mismatch)
+		}
+		if got := containsTag(aa.GenerateAudioTags(meta), "peak-mismatch"); got != tt.mismatch {
+			t.Errorf("%s: peak-mismatch tag = %v, want %v", tt.name, got, tt.mismatch)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package tidy
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"strconv"
+)
+
+const (
+	phashSegments  = 8    // stretches of time the clip is cut into
+	phashBands     = 9    // frequency bands per stretch, 8 comparisons give 8 bits
+	phashFrameSize = 2048 // FFT size, a power of two
+	phashMinFreq   = 150.0
+	phashMaxFreq   = 8000.0
+	phashFloor     = 0.05 // fraction of a stretch's loudest band below which a band counts as empty
+)
+
+// perceptualHash reduces a clip to a coarse spectrogram (phashSegments
+// stretches of time by phashBands log-spaced bands) and keeps one bit per
+// neighbouring pair of bands: whether the higher one is louder. That survives
+// re-encoding, resampling and level changes, so similar sounds end up a few
+// bits apart. Returns "" when there's too little audio to say anything.
+func perceptualHash(samples []float64, sampleRate int) string {
+	if sampleRate == 0 || len(samples) < phashFrameSize*phashSegments {
+		return ""
+	}
+
+	// band edges in FFT bins, the top edge capped at Nyquist for low sample rates
+	maxFreq := math.Min(phashMaxFreq, float64(sampleRate)/2)
+	edges := make([]int, phashBands+1)
+	for b := range edges {
+		freq := phashMinFreq * math.Pow(maxFreq/phashMinFreq, float64(b)/phashBands)
+		edges[b] = int(freq * phashFrameSize / float64(sampleRate))
+	}
+
+	window := make([]float64, phashFrameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(phashFrameSize-1))
+	}
+
+	var hash uint64
+	var heard bool
+	segmentLen := len(samples) / phashSegments
+	spectrum := make([]complex128, phashFrameSize)
+	for s := 0; s < phashSegments; s++ {
+		segment := samples[s*segmentLen : (s+1)*segmentLen]
+
+		energy := make([]float64, phashBands)
+		for start := 0; start+phashFrameSize <= len(segment); start += phashFrameSize {
+			for i := range spectrum {
+				spectrum[i] = complex(segment[start+i]*window[i], 0)
+			}
+			fft(spectrum)
+			// average per bin, so the wide high bands don't win on width alone
+			for b := 0; b < phashBands; b++ {
+				hi := max(edges[b+1], edges[b]+1)
+				for k := edges[b]; k < hi; k++ {
+					energy[b] += cmplx.Abs(spectrum[k]) / float64(hi-edges[b])
+				}
+			}
+		}
+
+		// bands with next to nothing in them would compare on noise and
+		// leakage, count them as empty so they compare equal
+		loudest := 0.0
+		for _, e := range energy {
+			loudest = math.Max(loudest, e)
+		}
+		for b := range energy {
+			if energy[b] < loudest*phashFloor {
+				energy[b] = 0
+			}
+		}
+
+		for b := 0; b+1 < phashBands; b++ {
+			hash <<= 1
+			if energy[b+1] > energy[b] {
+				hash |= 1
+				heard = true
+			}
+		}
+	}
+
+	if !heard {
+		return "" // silence hashes to all zeros and would match every other silent file
+	}
+	return fmt.Sprintf("%016x", hash)
+}
+
+// HashDistance counts the bits two perceptual hashes differ in, or returns
+// false when either isn't a valid hash
+func HashDistance(a, b string) (int, bool) {
+	x, okA := ParseHash(a)
+	y, okB := ParseHash(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return bits.OnesCount64(x ^ y), true
+}
+
+// ParseHash returns the bits of a perceptual hash, or false when it isn't a
+// valid one. Comparing many hashes is cheaper on the parsed values.
+func ParseHash(hash string) (uint64, bool) {
+	x, err := strconv.ParseUint(hash, 16, 64)
+	return x, err == nil
+}
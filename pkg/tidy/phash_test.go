@@ -0,0 +1,51 @@
+package tidy
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// melody renders a few seconds of tones that change every half second, at
+// the given rate and gain with a little noise on top
+func melody(notes []float64, sampleRate int, gain, noise float64, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	perNote := sampleRate / 2
+	samples := make([]float64, len(notes)*perNote)
+	for i := range samples {
+		t := float64(i) / float64(sampleRate)
+		f := notes[i/perNote]
+		samples[i] = gain*(0.6*math.Sin(2*math.Pi*f*t)+0.3*math.Sin(2*math.Pi*f*2.5*t)) + noise*(rng.Float64()*2-1)
+	}
+	return samples
+}
+
+func TestPerceptualHash(t *testing.T) {
+	tune := []float64{220, 330, 440, 660, 880, 1320, 1760, 2640}
+	other := []float64{3000, 200, 2500, 180, 1900, 260, 4000, 300}
+
+	original := perceptualHash(melody(tune, 44100, 0.8, 0, 1), 44100)
+	if len(original) != 16 {
+		t.Fatalf("perceptualHash() = %q, want 16 hex digits", original)
+	}
+
+	// resampled, quieter and a bit noisy is still the same sound
+	processed := perceptualHash(melody(tune, 48000, 0.4, 0.01, 2), 48000)
+	near, ok := HashDistance(original, processed)
+	if !ok || near > 6 {
+		t.Errorf("distance to the processed copy = %d, want at most 6", near)
+	}
+
+	different := perceptualHash(melody(other, 44100, 0.8, 0, 3), 44100)
+	far, _ := HashDistance(original, different)
+	if far <= near+10 {
+		t.Errorf("distance to a different sound = %d, want well above %d", far, near)
+	}
+
+	if h := perceptualHash(make([]float64, 44100*4), 44100); h != "" {
+		t.Errorf("perceptualHash(silence) = %q, want empty", h)
+	}
+	if h := perceptualHash(make([]float64, 1000), 44100); h != "" {
+		t.Errorf("perceptualHash(too short) = %q, want empty", h)
+	}
+}
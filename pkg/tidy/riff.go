@@ -0,0 +1,187 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxRIFFChunkSize caps how much of a metadata chunk we're willing to load.
+// Metadata chunks are small, anything bigger is almost certainly corrupt.
+const maxRIFFChunkSize = 16 * 1024 * 1024
+
+// riffChunk is a top-level chunk from a RIFF/WAVE file
+type riffChunk struct {
+	ID   string
+	Data []byte
+}
+
+// readRIFFChunks walks the top-level chunks of a RIFF/WAVE file and returns the
+// ones whose IDs are in want. Everything else (including the audio data) is
+// skipped without being read.
+func readRIFFChunks(r io.ReadSeeker, want ...string) ([]riffChunk, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read RIFF header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a RIFF/WAVE file")
+	}
+
+	wanted := make(map[string]bool, len(want))
+	for _, id := range want {
+		wanted[id] = true
+	}
+
+	var chunks []riffChunk
+	chunkHeader := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunkHeader); err != nil {
+			break // end of file (or trailing junk), we're done
+		}
+
+		id := string(chunkHeader[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+		padded := size + size%2 // chunks are word aligned
+
+		if wanted[id] && size <= maxRIFFChunkSize {
+			data := make([]byte, size)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return chunks, fmt.Errorf("failed to read %q chunk: %w", id, err)
+			}
+			chunks = append(chunks, riffChunk{ID: id, Data: data})
+			if padded > size {
+				if _, err := r.Seek(1, io.SeekCurrent); err != nil {
+					break
+				}
+			}
+			continue
+		}
+
+		if _, err := r.Seek(padded, io.SeekCurrent); err != nil {
+			break
+		}
+	}
+
+	return chunks, nil
+}
+
+// fixedString reads a NUL-padded ASCII field, trimming padding and whitespace
+func fixedString(b []byte) string {
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// parseInfoList reads the text fields of a LIST/INFO chunk, like INAM (name),
+// ICMT (comment) and IGNR (genre), keyed by their IDs. Returns nil for other
+// kinds of LIST chunk.
+func parseInfoList(data []byte) map[string]string {
+	if len(data) < 4 || string(data[0:4]) != "INFO" {
+		return nil
+	}
+
+	fields := make(map[string]string)
+	for pos := 4; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+		if body+size > len(data) {
+			break
+		}
+		if value := fixedString(data[body : body+size]); value != "" {
+			fields[id] = value
+		}
+		pos = body + size + size%2
+	}
+	return fields
+}
+
+// applyInfoList fills in the tag fields an ID3 tag didn't already set from a
+// WAV's INFO fields
+func applyInfoList(fields map[string]string, meta *AudioMetadata) {
+	for id, field := range map[string]*string{
+		"INAM": &meta.Title,
+		"IART": &meta.Artist,
+		"IPRD": &meta.Album,
+		"IGNR": &meta.Genre,
+		"ICMT": &meta.Comment,
+	} {
+		if value, ok := fields[id]; ok {
+			meta.HasEmbeddedTags = true
+			if *field == "" {
+				*field = value
+			}
+		}
+	}
+}
+
+// readWAVChunks pulls the metadata chunks we understand out of a WAV file.
+// Missing or malformed chunks are skipped, they never fail the analysis.
+func (aa *AudioAnalyzer) readWAVChunks(file *os.File, meta *AudioMetadata) error {
+	chunks, err := readRIFFChunks(file, "fmt ", "bext", "iXML", "smpl", "cue ", "LIST", "PEAK")
+	if err != nil && len(chunks) == 0 {
+		return err
+	}
+
+	var cues map[uint32]uint32
+	regions := make(map[uint32]uint32)
+
+	for _, chunk := range chunks {
+		switch chunk.ID {
+		case "fmt ":
+			if mask, err := parseChannelMask(chunk.Data); err == nil {
+				meta.ChannelLayout = channelLayout(mask)
+				meta.LFE = mask&speakerLFE != 0
+			}
+		case "bext":
+			if info, err := parseBext(chunk.Data); err == nil {
+				meta.BWF = info
+			}
+		case "iXML":
+			if info, err := parseIXML(chunk.Data); err == nil {
+				meta.IXML = info
+			}
+		case "smpl":
+			if loop, err := parseSmplLoop(chunk.Data); err == nil {
+				meta.Loop = loop
+			}
+		case "cue ":
+			if parsed, err := parseCuePoints(chunk.Data); err == nil {
+				cues = parsed
+			}
+		case "LIST":
+			for id, length := range parseCueRegions(chunk.Data) {
+				regions[id] = length
+			}
+			applyInfoList(parseInfoList(chunk.Data), meta)
+		case "PEAK":
+			if peak, err := parsePeakChunk(chunk.Data, meta.Channels); err == nil {
+				meta.EmbeddedPeak = peak
+			}
+		}
+	}
+	checkEmbeddedPeak(meta)
+
+	if len(cues) > 0 {
+		for _, offset := range cues {
+			meta.CuePoints = append(meta.CuePoints, offset)
+		}
+		sort.Slice(meta.CuePoints, func(i, j int) bool { return meta.CuePoints[i] < meta.CuePoints[j] })
+
+		// smpl loops are explicit, only fall back to cue regions without one
+		if meta.Loop == nil {
+			meta.Loop = cueLoop(cues, regions)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildTestWAV assembles a PCM WAV file with any extra chunks placed before the data chunk
+func buildTestWAV(sampleRate, channels, bitDepth int, pcm []byte, extra ...riffChunk) []byte {
+	var body []byte
+	body = append(body, "WAVE"...)
+
+	fmtChunk := make([]byte, 16)
+	blockAlign := channels * bitDepth / 8
+	binary.LittleEndian.PutUint16(fmtChunk[0:], 1) // PCM
+	binary.LittleEndian.PutUint16(fmtChunk[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(fmtChunk[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(fmtChunk[8:], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(fmtChunk[12:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(fmtChunk[14:], uint16(bitDepth))
+	body = appendRIFFChunk(body, "fmt ", fmtChunk)
+
+	for _, c := range extra {
+		body = appendRIFFChunk(body, c.ID, c.Data)
+	}
+	body = appendRIFFChunk(body, "data", pcm)
+
+	out := []byte("RIFF")
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(body)))
+	return append(out, body...)
+}
+
+func appendRIFFChunk(b []byte, id string, data []byte) []byte {
+	b = append(b, id...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	b = append(b, data...)
+	if len(data)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestReadRIFFChunks(t *testing.T) {
+	data := buildTestWAV(48000, 1, 16, make([]byte, 96),
+		riffChunk{ID: "junk", Data: []byte("odd")}, // odd size exercises padding
+		riffChunk{ID: "bext", Data: make([]byte, bextMinSize)},
+	)
+	path := writeTestFile(t, "chunks.wav", data)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	chunks, err := readRIFFChunks(file, "bext", "data")
+	if err != nil {
+		t.Fatalf("readRIFFChunks() error = %v", err)
+	}
+	if len(chunks) != 2 || chunks[0].ID != "bext" || chunks[1].ID != "data" {
+		t.Fatalf("readRIFFChunks() = %v, want bext then data", chunks)
+	}
+	if len(chunks[1].Data) != 96 {
+		t.Errorf("data chunk length = %d, want 96", len(chunks[1].Data))
+	}
+
+	notWAV := writeTestFile(t, "nope.wav", []byte("definitely not riff"))
+	f2, err := os.Open(notWAV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f2.Close()
+	if _, err := readRIFFChunks(f2, "bext"); err == nil {
+		t.Error("readRIFFChunks() should fail on a non-RIFF file")
+	}
+}
+
+func TestFixedString(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected string
+	}{
+		{[]byte("hello\x00\x00\x00"), "hello"},
+		{[]byte("  padded  "), "padded"},
+		{[]byte{0, 'x'}, ""},
+		{[]byte("full"), "full"},
+	}
+
+	for _, tt := range tests {
+		if result := fixedString(tt.input); result != tt.expected {
+			t.Errorf("fixedString(%q) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestWAVInfoList(t *testing.T) {
+	info := []byte("INFO")
+	info = appendInfoChunk(info, "INAM", []byte("Rusty Door Creak\x00"))
+	info = appendInfoChunk(info, "IGNR", []byte("Foley\x00"))
+	info = appendInfoChunk(info, "ICMT", []byte("recorded in a barn\x00\x00"))
+	info = appendInfoChunk(info, "ISFT", []byte("\x00"))
+
+	fields := parseInfoList(info)
+	want := map[string]string{"INAM": "Rusty Door Creak", "IGNR": "Foley", "ICMT": "recorded in a barn"}
+	for id, value := range want {
+		if fields[id] != value {
+			t.Errorf("parseInfoList()[%s] = %q, want %q", id, fields[id], value)
+		}
+	}
+	if _, ok := fields["ISFT"]; ok {
+		t.Error("parseInfoList() should leave out empty fields")
+	}
+	if parseInfoList([]byte("adtl")) != nil {
+		t.Error("parseInfoList() should ignore lists other than INFO")
+	}
+
+	path := writeTestFile(t, "take_07.wav", buildTestWAV(48000, 1, 16, make([]byte, 9600), riffChunk{ID: "LIST", Data: info}))
+	meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	if !meta.HasEmbeddedTags {
+		t.Error("HasEmbeddedTags = false, want true")
+	}
+	if meta.Title != "Rusty Door Creak" || meta.Genre != "Foley" || meta.Comment != "recorded in a barn" {
+		t.Errorf("AnalyzeFile() title %q, genre %q, comment %q, want the INFO fields", meta.Title, meta.Genre, meta.Comment)
+	}
+
+	// an ID3 tag, read first, wins over INFO
+	tagged := &AudioMetadata{Title: "From ID3"}
+	applyInfoList(fields, tagged)
+	if tagged.Title != "From ID3" || tagged.Genre != "Foley" {
+		t.Errorf("applyInfoList() title %q, genre %q, want ID3 title kept and genre filled in", tagged.Title, tagged.Genre)
+	}
+}
@@ -0,0 +1,83 @@
+package tidy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ScoringConfig holds the thresholds analysis uses to tag and categorize files.
+// The defaults are tuned for typical game audio libraries; LoadScoringConfig
+// reads a JSON file to adjust them for a library that doesn't fit.
+type ScoringConfig struct {
+	// quality tags
+	HQSampleRate int `json:"hq_sample_rate"` // Hz, at or above is tagged hq
+	HQBitDepth   int `json:"hq_bit_depth"`   // bits, at or above is tagged hq
+	HighBitrate  int `json:"high_bitrate"`   // bits/s of compressed files, at or above is tagged hq and high-bitrate
+
+	// spectral features, see SpectralFeatures
+	NoisyZeroCrossing float64 `json:"noisy_zero_crossing"` // rate above which a sound is noisy or percussive (impacts, weapons)
+	LowEnergy         float64 `json:"low_energy"`          // low band energy above which a bass-heavy sound is an impact
+	HighEnergy        float64 `json:"high_energy"`         // high band energy above which a bright sound is UI or a sharp impact
+	BandEnergyFloor   float64 `json:"band_energy_floor"`   // every band needs this much before balance is checked
+	BalancedBands     float64 `json:"balanced_bands"`      // quietest band over loudest above which a sound is ambience or music
+	DarkCentroid      float64 `json:"dark_centroid"`       // Hz, a centroid below this is dark (ambience)
+	BrightCentroid    float64 `json:"bright_centroid"`     // Hz, a centroid above this is bright (UI)
+}
+
+// DefaultScoringConfig returns the thresholds tidy-rename has always used
+func DefaultScoringConfig() ScoringConfig {
+	return ScoringConfig{
+		HQSampleRate: 48000,
+		HQBitDepth:   24,
+		HighBitrate:  320000,
+
+		NoisyZeroCrossing: 0.15,
+		LowEnergy:         0.1,
+		HighEnergy:        0.05,
+		BandEnergyFloor:   0.01,
+		BalancedBands:     0.3,
+		DarkCentroid:      500,
+		BrightCentroid:    2000,
+	}
+}
+
+// LoadScoringConfig reads a JSON file of thresholds. Fields the file leaves out
+// keep their defaults, so it only needs the ones being tuned.
+func LoadScoringConfig(path string) (ScoringConfig, error) {
+	scoring := DefaultScoringConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scoring, err
+	}
+
+	// a misspelled field would otherwise be ignored without a word
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&scoring); err != nil {
+		return scoring, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if err := scoring.validate(); err != nil {
+		return scoring, fmt.Errorf("%s: %w", path, err)
+	}
+	return scoring, nil
+}
+
+func (sc ScoringConfig) validate() error {
+	if sc.HQSampleRate <= 0 || sc.HQBitDepth <= 0 || sc.HighBitrate <= 0 {
+		return fmt.Errorf("hq_sample_rate, hq_bit_depth and high_bitrate must be positive")
+	}
+	if sc.NoisyZeroCrossing < 0 || sc.LowEnergy < 0 || sc.HighEnergy < 0 || sc.BandEnergyFloor < 0 {
+		return fmt.Errorf("energy and zero crossing thresholds can't be negative")
+	}
+	if sc.BalancedBands < 0 || sc.BalancedBands > 1 {
+		return fmt.Errorf("balanced_bands must be between 0 and 1")
+	}
+	if sc.DarkCentroid >= sc.BrightCentroid {
+		return fmt.Errorf("dark_centroid must be below bright_centroid")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package tidy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadScoringConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+		check   func(ScoringConfig) bool
+	}{
+		{"partial file keeps defaults", `{"hq_sample_rate": 44100}`, false, func(sc ScoringConfig) bool {
+			want := DefaultScoringConfig()
+			want.HQSampleRate = 44100
+			return sc == want
+		}},
+		{"empty object", `{}`, false, func(sc ScoringConfig) bool { return sc == DefaultScoringConfig() }},
+		{"misspelled field", `{"hq_samplerate": 44100}`, true, nil},
+		{"not json", `hq_sample_rate=44100`, true, nil},
+		{"centroids crossed", `{"dark_centroid": 3000}`, true, nil},
+		{"balance out of range", `{"balanced_bands": 1.5}`, true, nil},
+		{"zero bit depth", `{"hq_bit_depth": 0}`, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scoring.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			sc, err := LoadScoringConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadScoringConfig() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.check != nil && !tt.check(sc) {
+				t.Errorf("LoadScoringConfig() = %+v", sc)
+			}
+		})
+	}
+
+	if _, err := LoadScoringConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadScoringConfig() on a missing file should fail")
+	}
+}
+
+func TestScoringConfigTuning(t *testing.T) {
+	meta := &AudioMetadata{
+		SampleRate: 44100,
+		Channels:   2,
+		BitDepth:   16,
+		SpectralFeatures: &SpectralFeatures{
+			LowEnergy: 0.02, MidEnergy: 0.05, HighEnergy: 0.03,
+			ZeroCrossing: 0.12, Centroid: 1000,
+		},
+	}
+
+	aa := NewAudioAnalyzer()
+	if containsTag(aa.GenerateAudioTags(meta), "hq") {
+		t.Error("44.1kHz/16bit should not be hq with the defaults")
+	}
+	if got := aa.InferCategoryWithConfidence(meta, "take_07.wav").Category; got == "SFX_Impact" {
+		t.Errorf("default scoring picked %s, zero crossing 0.12 shouldn't count as noisy", got)
+	}
+
+	tuned := NewAudioAnalyzer()
+	tuned.Scoring = ScoringConfig{
+		HQSampleRate: 44100, HQBitDepth: 24, HighBitrate: 320000,
+		NoisyZeroCrossing: 0.1, LowEnergy: 0.1, HighEnergy: 0.05,
+		BandEnergyFloor: 0.01, BalancedBands: 0.9, DarkCentroid: 500, BrightCentroid: 2000,
+	}
+
+	if !containsTag(tuned.GenerateAudioTags(meta), "hq") {
+		t.Error("44.1kHz should be hq with hq_sample_rate 44100")
+	}
+	if got := tuned.InferCategoryWithConfidence(meta, "take_07.wav").Category; got != "SFX_Impact" {
+		t.Errorf("tuned scoring picked %s, want SFX_Impact", got)
+	}
+}
@@ -0,0 +1,84 @@
+package tidy
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+const (
+	DefaultSilenceThreshold = -60.0 // dBFS
+
+	// lead or tail silence at least this long gets the needs-trim tag
+	trimMinMs = 500
+)
+
+// analyzeSilence scans the whole data chunk for the first and last frame with a
+// sample above the silence threshold and records how much dead air is before and
+// after them. A file that never crosses the threshold is all lead.
+func (aa *AudioAnalyzer) analyzeSilence(file *os.File, meta *AudioMetadata) error {
+	decoder := wav.NewDecoder(file)
+	if !decoder.IsValidFile() {
+		return fmt.Errorf("invalid WAV file")
+	}
+
+	channels := int(decoder.NumChans)
+	sampleRate := int(decoder.SampleRate)
+	bitDepth := int(decoder.BitDepth)
+	if channels == 0 || sampleRate == 0 || bitDepth == 0 {
+		return fmt.Errorf("missing audio format info")
+	}
+
+	// threshold as an absolute sample value at this bit depth
+	fullScale := float64(int64(1) << (bitDepth - 1))
+	limit := int(fullScale * math.Pow(10, aa.SilenceThreshold/20))
+
+	buf := &audio.IntBuffer{
+		Format: &audio.Format{NumChannels: channels, SampleRate: sampleRate},
+		Data:   make([]int, 4096*channels),
+	}
+
+	first, last := int64(-1), int64(-1)
+	var sampleIdx int64
+	for {
+		n, err := decoder.PCMBuffer(buf)
+		if n == 0 || err != nil {
+			break
+		}
+		for i := 0; i < n && i < len(buf.Data); i++ {
+			v := buf.Data[i]
+			if v > limit || -v > limit {
+				frame := (sampleIdx + int64(i)) / int64(channels)
+				if first < 0 {
+					first = frame
+				}
+				last = frame
+			}
+		}
+		sampleIdx += int64(n)
+	}
+
+	totalFrames := sampleIdx / int64(channels)
+	if totalFrames == 0 {
+		return fmt.Errorf("no audio data")
+	}
+
+	toMs := func(frames int64) int64 { return frames * 1000 / int64(sampleRate) }
+	if first < 0 {
+		meta.SilenceLeadMs = toMs(totalFrames)
+		meta.SilenceTailMs = 0
+		return nil
+	}
+	meta.SilenceLeadMs = toMs(first)
+	meta.SilenceTailMs = toMs(totalFrames - 1 - last)
+
+	return nil
+}
+
+// NeedsTrim reports whether the file has enough dead air to be worth trimming
+func NeedsTrim(meta *AudioMetadata) bool {
+	return meta.SilenceLeadMs >= trimMinMs || meta.SilenceTailMs >= trimMinMs
+}
@@ -0,0 +1,88 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// buildTestPCM makes 16-bit mono PCM at 8kHz: lead frames of silence, body
+// frames at the given amplitude, then tail frames of silence
+func buildTestPCM(lead, body, tail int, amplitude int16) []byte {
+	var pcm []byte
+	for i := 0; i < lead+body+tail; i++ {
+		var v int16
+		if i >= lead && i < lead+body {
+			v = amplitude
+			if i%2 == 1 {
+				v = -amplitude
+			}
+		}
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+	}
+	return pcm
+}
+
+func TestAnalyzeSilence(t *testing.T) {
+	tests := []struct {
+		name      string
+		pcm       []byte
+		threshold float64
+		wantLead  int64
+		wantTail  int64
+		wantTrim  bool
+	}{
+		{
+			name:      "dead air both ends",
+			pcm:       buildTestPCM(8000, 8000, 4000, 10000),
+			threshold: -60,
+			wantLead:  1000,
+			wantTail:  500,
+			wantTrim:  true,
+		},
+		{
+			name:      "tight edit",
+			pcm:       buildTestPCM(80, 16000, 80, 10000),
+			threshold: -60,
+			wantLead:  10,
+			wantTail:  10,
+			wantTrim:  false,
+		},
+		{
+			name:      "quiet body below threshold",
+			pcm:       buildTestPCM(0, 8000, 0, 10), // about -70 dBFS
+			threshold: -60,
+			wantLead:  1000,
+			wantTail:  0,
+			wantTrim:  true,
+		},
+		{
+			name:      "quiet body above lower threshold",
+			pcm:       buildTestPCM(0, 8000, 0, 10),
+			threshold: -80,
+			wantLead:  0,
+			wantTail:  0,
+			wantTrim:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aa := NewAudioAnalyzer()
+			aa.SilenceThreshold = tt.threshold
+
+			path := writeTestFile(t, "take.wav", buildTestWAV(8000, 1, 16, tt.pcm))
+			meta, err := aa.AnalyzeFile(path)
+			if err != nil {
+				t.Fatalf("AnalyzeFile() error = %v", err)
+			}
+
+			if meta.SilenceLeadMs != tt.wantLead || meta.SilenceTailMs != tt.wantTail {
+				t.Errorf("silence = %dms lead, %dms tail, want %dms, %dms",
+					meta.SilenceLeadMs, meta.SilenceTailMs, tt.wantLead, tt.wantTail)
+			}
+			if got := containsTag(aa.GenerateAudioTags(meta), "needs-trim"); got != tt.wantTrim {
+				t.Errorf("needs-trim tag = %v, want %v", got, tt.wantTrim)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package tidy
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// sniffFormat looks at the first bytes of a file and returns the extension its
+// content calls for, or "" when it doesn't recognize it. Exported SFX often
+// come without an extension, or with the wrong one.
+func sniffFormat(r io.ReaderAt) string {
+	head := make([]byte, 16)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+
+	// an ID3v2 tag can sit in front of MP3, AAC and (rarely) FLAC data
+	offset := int64(0)
+	if len(head) >= 10 && string(head[0:3]) == "ID3" {
+		size := int64(head[6]&0x7F)<<21 | int64(head[7]&0x7F)<<14 | int64(head[8]&0x7F)<<7 | int64(head[9]&0x7F)
+		offset = 10 + size
+		if head[5]&0x10 != 0 {
+			offset += 10 // footer
+		}
+		head = make([]byte, 4)
+		if n, _ := r.ReadAt(head, offset); n < len(head) {
+			return ".mp3" // a tag and nothing after it, most likely an MP3 all the same
+		}
+	}
+
+	switch {
+	case len(head) >= 12 && (string(head[0:4]) == "RIFF" || string(head[0:4]) == "RF64") && string(head[8:12]) == "WAVE":
+		return ".wav"
+	case len(head) >= 12 && string(head[0:4]) == "FORM" && (string(head[8:12]) == "AIFF" || string(head[8:12]) == "AIFC"):
+		return ".aiff"
+	case len(head) >= 4 && string(head[0:4]) == "OggS":
+		return ".ogg"
+	case len(head) >= 4 && string(head[0:4]) == "fLaC":
+		return ".flac"
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return ".m4a"
+	case len(head) >= 16 && bytes.Equal(head[0:16], asfHeaderObject):
+		return ".wma"
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xF6 == 0xF0:
+		return ".aac" // ADTS: MPEG sync with layer 0
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0 && head[1]&0x06 != 0:
+		return ".mp3" // MPEG audio frame sync with a valid layer
+	case offset > 0:
+		return ".mp3"
+	}
+	return ""
+}
+
+// sameFormat reports whether two extensions stand for the same kind of file
+func sameFormat(a, b string) bool {
+	aliases := map[string]string{".aif": ".aiff", ".aifc": ".aiff", ".mp4": ".m4a"}
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if alias, ok := aliases[a]; ok {
+		a = alias
+	}
+	if alias, ok := aliases[b]; ok {
+		b = alias
+	}
+	return a == b
+}
+
+// SniffFile sniffs the file at path, for scanning files without an extension
+func SniffFile(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	return sniffFormat(f)
+}
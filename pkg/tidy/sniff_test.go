@@ -0,0 +1,69 @@
+package tidy
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSniffFormat(t *testing.T) {
+	id3 := append([]byte("ID3\x04\x00\x00\x00\x00\x00\x04"), make([]byte, 4)...) // 4 byte tag
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"wav", buildTestWAV(44100, 1, 16, make([]byte, 64)), ".wav"},
+		{"aiff", []byte("FORM\x00\x00\x00\x10AIFFCOMM"), ".aiff"},
+		{"ogg", []byte("OggS\x00\x02\x00\x00"), ".ogg"},
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), ".flac"},
+		{"m4a", []byte("\x00\x00\x00\x20ftypM4A "), ".m4a"},
+		{"wma", append(append([]byte{}, asfHeaderObject...), 0, 0), ".wma"},
+		{"mp3 frame", []byte{0xFF, 0xFB, 0x90, 0x64}, ".mp3"},
+		{"adts", []byte{0xFF, 0xF1, 0x50, 0x80}, ".aac"},
+		{"id3 then mp3", append(append([]byte{}, id3...), 0xFF, 0xFB, 0x90, 0x64), ".mp3"},
+		{"id3 then flac", append(append([]byte{}, id3...), []byte("fLaC")...), ".flac"},
+		{"text", []byte("just some notes"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := sniffFormat(bytes.NewReader(tt.data)); got != tt.want {
+			t.Errorf("sniffFormat(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSameFormat(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{".wav", ".WAV", true},
+		{".aiff", ".aif", true},
+		{".m4a", ".mp4", true},
+		{".wav", ".mp3", false},
+		{".wav", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := sameFormat(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameFormat(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeFileUnknownContent(t *testing.T) {
+	path := writeTestFile(t, "README", []byte("# not audio at all\n"))
+	meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("AnalyzeFile() = %+v, %v, want ErrUnsupportedFormat", meta, err)
+	}
+
+	// sniffed content is fine without an extension
+	wav := writeTestFile(t, "take_12", buildTestWAV(48000, 1, 16, make([]byte, 4800)))
+	if meta, err := NewAudioAnalyzer().AnalyzeFile(wav); err != nil || meta.Format != "WAV" {
+		t.Errorf("AnalyzeFile() on an extensionless WAV = %+v, %v", meta, err)
+	}
+}
@@ -0,0 +1,93 @@
+package tidy
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// raw tag names that hold the tempo and the musical key: ID3v2.3/2.4 frames,
+// their ID3v2.2 three letter versions, Vorbis comments (lowercased by the tag
+// library) and the MP4 tempo atom
+var (
+	tempoTagNames = []string{"TBPM", "TBP", "bpm", "tempo", "tmpo"}
+	keyTagNames   = []string{"TKEY", "TKE", "initialkey", "key"}
+)
+
+var (
+	// a note with an optional sharp or flat and a mode, like A, F#m, Bb minor
+	musicalKey = regexp.MustCompile(`^([A-Ga-g])([#b♯♭]?)\s*([A-Za-z]*)$`)
+	// Camelot wheel notation, like 8A, as written by DJ tools
+	camelotKey = regexp.MustCompile(`^(\d{1,2})([ABab])$`)
+)
+
+// readTagTempo sets meta.BPM and meta.Key from the tempo and key tags loop
+// libraries embed, when they hold something usable
+func readTagTempo(raw map[string]interface{}, meta *AudioMetadata) {
+	for _, name := range tempoTagNames {
+		if bpm := parseTagBPM(raw[name]); bpm > 0 {
+			meta.BPM = bpm
+			break
+		}
+	}
+	for _, name := range keyTagNames {
+		if value, ok := raw[name].(string); ok {
+			if key := NormalizeKey(value); key != "" {
+				meta.Key = key
+				break
+			}
+		}
+	}
+}
+
+// parseTagBPM reads a tempo tag, a number as text ("120", "92.5", "128 BPM")
+// or an integer from an MP4 atom. Returns 0 for anything else.
+func parseTagBPM(value interface{}) float64 {
+	var bpm float64
+	switch v := value.(type) {
+	case int:
+		bpm = float64(v)
+	case string:
+		fields := strings.Fields(strings.ReplaceAll(v, ",", "."))
+		if len(fields) == 0 {
+			return 0
+		}
+		n, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0
+		}
+		bpm = n
+	}
+	if bpm <= 0 || bpm >= 1000 || math.IsNaN(bpm) {
+		return 0
+	}
+	return math.Round(bpm*10) / 10
+}
+
+// NormalizeKey writes a musical key tag the same way whatever tool wrote it:
+// "a minor", "Amin" and "Am" are all Am, "F# major" is F#, and Camelot keys
+// like 8a are 8A. Returns "" for the ID3 off-key marker "o" and anything that
+// isn't a key.
+func NormalizeKey(value string) string {
+	value = strings.TrimSpace(value)
+	if m := camelotKey.FindStringSubmatch(value); m != nil {
+		return m[1] + strings.ToUpper(m[2])
+	}
+	m := musicalKey.FindStringSubmatch(value)
+	if m == nil {
+		return ""
+	}
+
+	accidental := strings.NewReplacer("♯", "#", "♭", "b").Replace(m[2])
+	var mode string
+	switch strings.ToLower(m[3]) {
+	case "", "maj", "major":
+	case "m", "min", "minor":
+		mode = "m"
+	default:
+		return ""
+	}
+	return fmt.Sprintf("%s%s%s", strings.ToUpper(m[1]), accidental, mode)
+}
@@ -0,0 +1,89 @@
+package tidy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	tests := map[string]string{
+		"Am":       "Am",
+		"a minor":  "Am",
+		"Amin":     "Am",
+		"F#":       "F#",
+		"F# major": "F#",
+		"f♯m":      "F#m",
+		"Bbm":      "Bbm",
+		"bb":       "Bb",
+		" C ":      "C",
+		"8a":       "8A",
+		"12B":      "12B",
+		"o":        "", // ID3 for off-key
+		"H":        "",
+		"Am7":      "",
+		"":         "",
+	}
+
+	for value, want := range tests {
+		if got := NormalizeKey(value); got != want {
+			t.Errorf("NormalizeKey(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestReadTagTempo(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  map[string]interface{}
+		bpm  float64
+		key  string
+	}{
+		{"id3", map[string]interface{}{"TBPM": "128", "TKEY": "Am"}, 128, "Am"},
+		{"id3v2.2", map[string]interface{}{"TBP": "92.5", "TKE": "C#m"}, 92.5, "C#m"},
+		{"vorbis", map[string]interface{}{"bpm": "120 BPM", "initialkey": "8A"}, 120, "8A"},
+		{"mp4", map[string]interface{}{"tmpo": 140}, 140, ""},
+		{"unusable", map[string]interface{}{"TBPM": "fast", "TKEY": "o"}, 0, ""},
+		{"out of range", map[string]interface{}{"TBPM": "0", "bpm": "12000"}, 0, ""},
+		{"none", map[string]interface{}{"TIT2": "Drum Loop"}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		meta := &AudioMetadata{}
+		readTagTempo(tt.raw, meta)
+		if meta.BPM != tt.bpm || meta.Key != tt.key {
+			t.Errorf("%s: BPM, Key = %v, %q, want %v, %q", tt.name, meta.BPM, meta.Key, tt.bpm, tt.key)
+		}
+	}
+}
+
+func TestAnalyzeFileTagTempo(t *testing.T) {
+	var frames []byte
+	frames = append(frames, id3Frame(3, "TBPM", id3Text(3, "95"))...)
+	frames = append(frames, id3Frame(3, "TKEY", id3Text(3, "F#m"))...)
+	data := append([]byte{'I', 'D', '3', 3, 0, 0}, toSyncsafe(len(frames))...)
+	data = append(data, frames...)
+
+	mp3, err := os.ReadFile(writeTestMP3(t, 100, false, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "drum_loop.mp3")
+	if err := os.WriteFile(path, append(data, mp3...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	aa := NewAudioAnalyzer()
+	meta, err := aa.AnalyzeFile(path)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	if meta.BPM != 95 || meta.Key != "F#m" {
+		t.Errorf("BPM, Key = %v, %q, want 95, F#m", meta.BPM, meta.Key)
+	}
+
+	// the tag wins over an estimate, EstimateTempo doesn't even open the file
+	if err := aa.EstimateTempo(filepath.Join(t.TempDir(), "missing.wav"), meta); err != nil || meta.BPM != 95 {
+		t.Errorf("EstimateTempo() = %v, BPM %v, want the tagged 95 kept", err, meta.BPM)
+	}
+}
@@ -0,0 +1,349 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrTagWriteUnsupported means we don't know how to safely write tags into this file
+var ErrTagWriteUnsupported = errors.New("writing tags is not supported for this format")
+
+// WriteTags stores genre and comment in a WAV or MP3 file's own metadata.
+// Only metadata is rewritten, the audio bytes are copied through untouched,
+// and the file is replaced atomically.
+func WriteTags(path, genre, comment string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		return rewriteFile(path, func(src *os.File, dst *os.File) error {
+			return writeWAVInfo(src, dst, map[string]string{"IGNR": genre, "ICMT": comment})
+		})
+	case ".mp3":
+		return rewriteFile(path, func(src *os.File, dst *os.File) error {
+			return writeID3Tags(src, dst, genre, comment)
+		})
+	}
+	return ErrTagWriteUnsupported
+}
+
+// rewriteFile runs write from path into a temp file next to it and swaps the
+// temp file in only if write succeeded, so a failure never leaves a half-written file
+func rewriteFile(path string, write func(src, dst *os.File) error) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tidy-rename-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if err := write(src, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeWAVInfo copies a RIFF/WAVE file chunk by chunk, merging fields into the
+// LIST/INFO chunk (or appending one at the end if there isn't one). Every other
+// chunk, including data, is copied byte for byte. A few trailing bytes too
+// short to be a chunk are kept at the very end, after any appended chunk and
+// outside the RIFF size, so nothing of the file is lost.
+func writeWAVInfo(src io.Reader, dst io.WriteSeeker, fields map[string]string) error {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return fmt.Errorf("failed to read RIFF header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return fmt.Errorf("not a RIFF/WAVE file")
+	}
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	written := int64(4) // "WAVE"
+	wroteInfo := false
+	chunkHeader := make([]byte, 8)
+	var tail []byte
+	for {
+		if n, err := io.ReadFull(src, chunkHeader); err != nil {
+			tail = chunkHeader[:n] // end of file, maybe with a fragment to keep
+			break
+		}
+
+		id := string(chunkHeader[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+		padded := size + size%2
+
+		if id == "LIST" && !wroteInfo && size >= 4 && size <= maxRIFFChunkSize {
+			data := make([]byte, padded)
+			if _, err := io.ReadFull(src, data[:size]); err != nil {
+				return fmt.Errorf("failed to read LIST chunk: %w", err)
+			}
+			if padded > size {
+				io.ReadFull(src, data[size:]) // pad byte may be missing at EOF
+			}
+			if string(data[0:4]) == "INFO" {
+				n, err := writeRIFFChunk(dst, "LIST", buildInfoList(data[4:size], fields))
+				if err != nil {
+					return err
+				}
+				written += n
+				wroteInfo = true
+				continue
+			}
+			n, err := writeRIFFChunk(dst, id, data[:size])
+			if err != nil {
+				return err
+			}
+			written += n
+			continue
+		}
+
+		if _, err := dst.Write(chunkHeader); err != nil {
+			return err
+		}
+		// a short final chunk is copied as is rather than padded out
+		n, err := io.CopyN(dst, src, padded)
+		written += 8 + n
+		if err != nil && !(err == io.EOF && n >= size) {
+			return fmt.Errorf("failed to copy %q chunk: %w", id, err)
+		}
+	}
+
+	if !wroteInfo {
+		n, err := writeRIFFChunk(dst, "LIST", buildInfoList(nil, fields))
+		if err != nil {
+			return err
+		}
+		written += n
+	}
+	if _, err := dst.Write(tail); err != nil {
+		return err
+	}
+
+	if written > 0xFFFFFFFF {
+		return fmt.Errorf("file too large for RIFF")
+	}
+	if _, err := dst.Seek(4, io.SeekStart); err != nil {
+		return err
+	}
+	return binary.Write(dst, binary.LittleEndian, uint32(written))
+}
+
+// buildInfoList returns an INFO list body with the existing sub-chunks in their
+// original order, values replaced from fields, and new fields appended
+func buildInfoList(existing []byte, fields map[string]string) []byte {
+	out := []byte("INFO")
+	seen := make(map[string]bool)
+
+	for pos := 0; pos+8 <= len(existing); {
+		id := string(existing[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(existing[pos+4 : pos+8]))
+		body := pos + 8
+		if body+size > len(existing) {
+			break
+		}
+		value := existing[body : body+size]
+		if v, ok := fields[id]; ok {
+			value = append([]byte(v), 0)
+			seen[id] = true
+		}
+		out = appendInfoChunk(out, id, value)
+		pos = body + size + size%2
+	}
+
+	// fixed order so the output doesn't depend on map iteration
+	for _, id := range []string{"IGNR", "ICMT"} {
+		if v, ok := fields[id]; ok && !seen[id] {
+			out = appendInfoChunk(out, id, append([]byte(v), 0))
+		}
+	}
+	return out
+}
+
+func appendInfoChunk(b []byte, id string, data []byte) []byte {
+	b = append(b, id...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	b = append(b, data...)
+	if len(data)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// writeRIFFChunk writes a chunk with its pad byte and returns the bytes written
+func writeRIFFChunk(w io.Writer, id string, data []byte) (int64, error) {
+	chunk := appendInfoChunk(nil, id, data)
+	n, err := w.Write(chunk)
+	return int64(n), err
+}
+
+// writeID3Tags writes an ID3v2 tag with the genre (TCON) and comment (COMM)
+// frames replaced, keeping every other frame of an existing tag. Tags using
+// unsynchronisation, extended headers or footers are left alone since we can't
+// rewrite them without decoding the whole tag.
+func writeID3Tags(src io.Reader, dst io.Writer, genre, comment string) error {
+	var version byte = 3
+	var frames []byte
+
+	header := make([]byte, 10)
+	n, err := io.ReadFull(src, header)
+	if err != nil && n == 0 {
+		return fmt.Errorf("empty file")
+	}
+	header = header[:n]
+	var rest io.Reader = src
+
+	if n == 10 && string(header[:3]) == "ID3" {
+		version = header[3]
+		if version != 3 && version != 4 {
+			return fmt.Errorf("unsupported ID3v2.%d tag", version)
+		}
+		if header[5]&0xD0 != 0 { // unsync, extended header, footer
+			return fmt.Errorf("unsupported ID3v2 tag flags %#x", header[5])
+		}
+		size := syncsafe(header[6:10])
+		body := make([]byte, size)
+		if _, err := io.ReadFull(src, body); err != nil {
+			return fmt.Errorf("failed to read ID3v2 tag: %w", err)
+		}
+		frames, err = keepID3Frames(body, version, "TCON", "COMM")
+		if err != nil {
+			return err
+		}
+	} else {
+		// no tag, the bytes we peeked at are audio
+		rest = io.MultiReader(strings.NewReader(string(header)), src)
+	}
+
+	frames = append(frames, id3Frame(version, "TCON", id3Text(version, genre))...)
+	// COMM is encoding, language, description, text; the empty description has
+	// to be in the same encoding as the text
+	text := id3Text(version, comment)
+	comm := append([]byte{text[0]}, "eng"...)
+	if text[0] == 1 {
+		comm = append(comm, 0xFF, 0xFE, 0, 0)
+	} else {
+		comm = append(comm, 0)
+	}
+	comm = append(comm, text[1:]...)
+	frames = append(frames, id3Frame(version, "COMM", comm)...)
+
+	if len(frames) >= 1<<28 {
+		return fmt.Errorf("ID3v2 tag too large")
+	}
+	out := []byte{'I', 'D', '3', version, 0, 0}
+	out = append(out, toSyncsafe(len(frames))...)
+	out = append(out, frames...)
+	if _, err := dst.Write(out); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, rest)
+	return err
+}
+
+// keepID3Frames returns the raw frames of a tag body minus the ones named in drop
+func keepID3Frames(body []byte, version byte, drop ...string) ([]byte, error) {
+	var kept []byte
+	for pos := 0; pos+10 <= len(body); {
+		if body[pos] == 0 {
+			break // padding
+		}
+		id := string(body[pos : pos+4])
+		var size int
+		if version == 4 {
+			size = syncsafe(body[pos+4 : pos+8])
+		} else {
+			size = int(binary.BigEndian.Uint32(body[pos+4 : pos+8]))
+		}
+		end := pos + 10 + size
+		if size < 0 || end > len(body) {
+			return nil, fmt.Errorf("corrupt ID3v2 frame %q", id)
+		}
+
+		dropped := false
+		for _, d := range drop {
+			if id == d {
+				dropped = true
+			}
+		}
+		if !dropped {
+			kept = append(kept, body[pos:end]...)
+		}
+		pos = end
+	}
+	return kept, nil
+}
+
+// id3Text encodes s as an encoding byte followed by the text and its terminator.
+// Latin-1 when possible, otherwise UTF-8 (v2.4) or UTF-16 with BOM (v2.3).
+func id3Text(version byte, s string) []byte {
+	latin1 := true
+	for _, r := range s {
+		if r > 0xFF {
+			latin1 = false
+			break
+		}
+	}
+
+	switch {
+	case latin1:
+		out := []byte{0}
+		for _, r := range s {
+			out = append(out, byte(r))
+		}
+		return append(out, 0)
+	case version == 4:
+		return append(append([]byte{3}, s...), 0)
+	default:
+		out := []byte{1, 0xFF, 0xFE}
+		for _, r := range s {
+			if r > 0xFFFF {
+				r = '?' // outside the BMP, not worth surrogate pairs for a comment
+			}
+			out = binary.LittleEndian.AppendUint16(out, uint16(r))
+		}
+		return append(out, 0, 0)
+	}
+}
+
+func id3Frame(version byte, id string, data []byte) []byte {
+	out := []byte(id)
+	if version == 4 {
+		out = append(out, toSyncsafe(len(data))...)
+	} else {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+	}
+	out = append(out, 0, 0) // flags
+	return append(out, data...)
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+func toSyncsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
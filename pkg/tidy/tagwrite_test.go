@@ -0,0 +1,165 @@
+package tidy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+func TestWriteTagsWAV(t *testing.T) {
+	pcm := buildTestPCM(100, 1000, 100, 5000)
+	// an existing INFO list plus an odd-sized chunk before data
+	path := writeTestFile(t, "door.wav", buildTestWAV(8000, 1, 16, pcm,
+		riffChunk{ID: "LIST", Data: appendInfoChunk([]byte("INFO"), "INAM", []byte("Door Slam\x00"))},
+		riffChunk{ID: "junk", Data: []byte{1, 2, 3}},
+	))
+
+	for i := 0; i < 2; i++ { // a second write replaces, doesn't add
+		if err := WriteTags(path, "SFX_Object", "door, slam"); err != nil {
+			t.Fatalf("WriteTags() error = %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	chunks, err := readRIFFChunks(file, "LIST", "data", "junk")
+	if err != nil {
+		t.Fatalf("readRIFFChunks() error = %v", err)
+	}
+
+	var lists int
+	for _, c := range chunks {
+		switch c.ID {
+		case "data":
+			if !bytes.Equal(c.Data, pcm) {
+				t.Error("audio data changed")
+			}
+		case "junk":
+			if !bytes.Equal(c.Data, []byte{1, 2, 3}) {
+				t.Errorf("junk chunk = %v, want it copied as is", c.Data)
+			}
+		case "LIST":
+			lists++
+			want := []byte("INFO")
+			want = appendInfoChunk(want, "INAM", []byte("Door Slam\x00"))
+			want = appendInfoChunk(want, "IGNR", []byte("SFX_Object\x00"))
+			want = appendInfoChunk(want, "ICMT", []byte("door, slam\x00"))
+			if !bytes.Equal(c.Data, want) {
+				t.Errorf("INFO list = %q, want %q", c.Data, want)
+			}
+		}
+	}
+	if lists != 1 {
+		t.Errorf("found %d LIST chunks, want 1", lists)
+	}
+
+	// the RIFF size has to match so other tools don't see trailing junk
+	stat, _ := file.Stat()
+	header := make([]byte, 8)
+	file.ReadAt(header, 0)
+	if size := int64(binary.LittleEndian.Uint32(header[4:8])); size != stat.Size()-8 {
+		t.Errorf("RIFF size = %d, want %d", size, stat.Size()-8)
+	}
+
+	meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+	if err != nil || meta.SampleRate != 8000 {
+		t.Errorf("tagged WAV no longer analyzes: %v", err)
+	}
+}
+
+func TestWriteTagsMP3(t *testing.T) {
+	for _, withID3 := range []bool{false, true} {
+		path := writeTestMP3(t, 50, withID3, 0)
+		before, _ := os.ReadFile(path)
+		audio := before
+		if withID3 {
+			audio = before[30:] // 10 byte header + 20 bytes of padding
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := WriteTags(path, "SFX_Impact", "boom, énorme"); err != nil {
+				t.Fatalf("WriteTags() error = %v", err)
+			}
+		}
+
+		after, _ := os.ReadFile(path)
+		if string(after[:3]) != "ID3" {
+			t.Fatal("no ID3v2 tag written")
+		}
+		size := syncsafe(after[6:10])
+		if !bytes.Equal(after[10+size:], audio) {
+			t.Error("audio frames changed")
+		}
+
+		frames, err := keepID3Frames(after[10:10+size], after[3])
+		if err != nil {
+			t.Fatalf("keepID3Frames() error = %v", err)
+		}
+		wantFrames := append(id3Frame(3, "TCON", []byte("\x00SFX_Impact\x00")),
+			id3Frame(3, "COMM", []byte("\x00eng\x00boom, \xe9norme\x00"))...)
+		if !bytes.Equal(frames, wantFrames) {
+			t.Errorf("frames = %q, want %q", frames, wantFrames)
+		}
+
+		meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+		if err != nil || meta.Format != "MP3" || meta.SampleRate != 48000 {
+			t.Errorf("tagged MP3 no longer analyzes: %+v, %v", meta, err)
+		}
+	}
+}
+
+func TestWriteTagsUnsupported(t *testing.T) {
+	path := writeTestFile(t, "pad.flac", []byte("fLaC"))
+	if err := WriteTags(path, "Ambient", ""); err != ErrTagWriteUnsupported {
+		t.Errorf("WriteTags() error = %v, want ErrTagWriteUnsupported", err)
+	}
+}
+
+func TestID3Text(t *testing.T) {
+	tests := []struct {
+		version byte
+		input   string
+		want    []byte
+	}{
+		{3, "rain", []byte("\x00rain\x00")},
+		{3, "雨", []byte{1, 0xFF, 0xFE, 0xE8, 0x96, 0, 0}},
+		{4, "雨", append([]byte{3}, "雨\x00"...)},
+	}
+
+	for _, tt := range tests {
+		if got := id3Text(tt.version, tt.input); !bytes.Equal(got, tt.want) {
+			t.Errorf("id3Text(%d, %q) = %v, want %v", tt.version, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTagsWAVTrailingFragment(t *testing.T) {
+	pcm := buildTestPCM(100, 1000, 100, 5000)
+	data := append(buildTestWAV(8000, 1, 16, pcm), 'x', 'y', 'z') // too short to be a chunk
+	path := writeTestFile(t, "door.wav", data)
+
+	if err := WriteTags(path, "SFX_Object", "door"); err != nil {
+		t.Fatalf("WriteTags() error = %v", err)
+	}
+
+	tagged, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(tagged, []byte("xyz")) {
+		t.Errorf("tagged file ends in %q, want the trailing fragment kept", tagged[len(tagged)-8:])
+	}
+	if size := int64(binary.LittleEndian.Uint32(tagged[4:8])); size != int64(len(tagged))-8-3 {
+		t.Errorf("RIFF size = %d, want %d, the fragment stays outside", size, len(tagged)-8-3)
+	}
+
+	meta, err := NewAudioAnalyzer().AnalyzeFile(path)
+	if err != nil || meta.Genre != "SFX_Object" {
+		t.Errorf("AnalyzeFile() genre = %v, %v, want the written tag", meta, err)
+	}
+}
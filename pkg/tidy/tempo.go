@@ -0,0 +1,159 @@
+package tidy
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// shorter files are one-shots, not worth decoding for a tempo
+	tempoMinDuration = 4 * time.Second
+	// enough audio for a stable estimate without decoding whole songs
+	tempoMaxDuration = 30 * time.Second
+
+	tempoMinBPM = 60.0
+	tempoMaxBPM = 200.0
+
+	// how strongly the onset envelope has to repeat at the beat period, 0-1
+	tempoMinConfidence = 0.3
+
+	tempoHop = 512 // samples between onset envelope frames
+)
+
+// WantsTempo reports whether a file is worth a tempo estimate: music or loops
+// long enough to have a few bars in them
+func WantsTempo(meta *AudioMetadata, category, filename string) bool {
+	if meta == nil || meta.Duration < tempoMinDuration {
+		return false
+	}
+	if category == "Music" || meta.Loop != nil {
+		return true
+	}
+	return strings.Contains(strings.ToLower(filename), "loop")
+}
+
+// EstimateTempo decodes the start of a WAV file and sets meta.BPM when a
+// confident tempo is found. Other formats are left alone, and so is a tempo
+// that came from the file's tags, the library knows better than an estimate.
+func (aa *AudioAnalyzer) EstimateTempo(filePath string, meta *AudioMetadata) error {
+	if meta.BPM > 0 {
+		return nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if meta.Extension != "" {
+		ext = meta.Extension // the content is WAV whatever the name says
+	}
+	if ext != ".wav" {
+		return nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	samples, sampleRate, err := decodeMonoWAV(file, tempoMaxDuration)
+	if err != nil {
+		return err
+	}
+
+	if bpm, confidence := estimateTempo(samples, sampleRate); confidence >= tempoMinConfidence {
+		meta.BPM = bpm
+	}
+	return nil
+}
+
+// estimateTempo finds the beat period by autocorrelating an onset envelope (the
+// rise in energy from frame to frame). Lags are weighted towards 120 BPM so a
+// beat doesn't come out at half or double speed. Returns the tempo rounded to
+// 0.1 BPM and how strongly the envelope repeats at that period.
+func estimateTempo(samples []float64, sampleRate int) (float64, float64) {
+	frames := len(samples) / tempoHop
+	if frames < 4 || sampleRate == 0 {
+		return 0, 0
+	}
+
+	// log energy per frame, then keep only the rises
+	energy := make([]float64, frames)
+	for f := range energy {
+		sum := 0.0
+		for _, s := range samples[f*tempoHop : (f+1)*tempoHop] {
+			sum += s * s
+		}
+		energy[f] = math.Log1p(1000 * sum / tempoHop)
+	}
+	onset := make([]float64, frames)
+	mean := 0.0
+	for f := 1; f < frames; f++ {
+		if rise := energy[f] - energy[f-1]; rise > 0 {
+			onset[f] = rise
+		}
+		mean += onset[f]
+	}
+	mean /= float64(frames)
+	for f := range onset {
+		onset[f] -= mean
+	}
+
+	autocorr := func(lag int) float64 {
+		sum := 0.0
+		for f := lag; f < frames; f++ {
+			sum += onset[f] * onset[f-lag]
+		}
+		return sum
+	}
+
+	zero := autocorr(0)
+	if zero <= 0 {
+		return 0, 0 // flat envelope, nothing to go on
+	}
+
+	fps := float64(sampleRate) / tempoHop
+	minLag := int(math.Floor(60 * fps / tempoMaxBPM))
+	maxLag := int(math.Ceil(60 * fps / tempoMinBPM))
+	if minLag < 1 {
+		minLag = 1
+	}
+	if maxLag >= frames-1 {
+		maxLag = frames - 2
+	}
+	if minLag > maxLag {
+		return 0, 0
+	}
+
+	corr := make([]float64, maxLag+2)
+	for lag := minLag - 1; lag <= maxLag+1; lag++ {
+		if lag > 0 {
+			corr[lag] = autocorr(lag) / zero
+		}
+	}
+
+	bestLag, bestScore := 0, 0.0
+	for lag := minLag; lag <= maxLag; lag++ {
+		bpm := 60 * fps / float64(lag)
+		weight := math.Exp(-0.5 * math.Pow(math.Log2(bpm/120), 2))
+		if score := corr[lag] * weight; score > bestScore {
+			bestLag, bestScore = lag, score
+		}
+	}
+	if bestLag == 0 {
+		return 0, 0
+	}
+
+	// the beat rarely falls on a whole frame, fit a parabola through the peak
+	lag := float64(bestLag)
+	if prev, next := corr[bestLag-1], corr[bestLag+1]; bestLag > 1 {
+		if denom := prev - 2*corr[bestLag] + next; denom < 0 {
+			lag += 0.5 * (prev - next) / denom
+		}
+	}
+
+	bpm := math.Round(600*fps/lag) / 10
+	return bpm, corr[bestLag]
+}
@@ -0,0 +1,126 @@
+package tidy
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// buildClickTrack returns mono samples with a short decaying 1kHz click on every beat
+func buildClickTrack(bpm float64, sampleRate int, seconds float64) []float64 {
+	samples := make([]float64, int(seconds*float64(sampleRate)))
+	beat := 60 / bpm * float64(sampleRate)
+	clickLen := sampleRate / 50 // 20ms
+	for start := 0.0; int(start) < len(samples); start += beat {
+		for i := 0; i < clickLen && int(start)+i < len(samples); i++ {
+			t := float64(i) / float64(sampleRate)
+			samples[int(start)+i] = 0.8 * math.Sin(2*math.Pi*1000*t) * math.Exp(-float64(i)/float64(clickLen)*5)
+		}
+	}
+	return samples
+}
+
+func TestEstimateTempo(t *testing.T) {
+	tests := []struct {
+		bpm        float64
+		sampleRate int
+	}{
+		{90, 44100},
+		{120, 48000},
+		{128, 44100},
+		{140, 48000},
+		{174, 44100},
+	}
+
+	for _, tt := range tests {
+		bpm, confidence := estimateTempo(buildClickTrack(tt.bpm, tt.sampleRate, 20), tt.sampleRate)
+		if math.Abs(bpm-tt.bpm) > 1.5 {
+			t.Errorf("estimateTempo(%v BPM at %dHz) = %v", tt.bpm, tt.sampleRate, bpm)
+		}
+		if confidence < tempoMinConfidence {
+			t.Errorf("estimateTempo(%v BPM) confidence = %.2f, want at least %.2f", tt.bpm, confidence, tempoMinConfidence)
+		}
+	}
+}
+
+func TestEstimateTempoNoBeat(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	noise := make([]float64, 44100*10)
+	for i := range noise {
+		noise[i] = rng.Float64()*0.6 - 0.3
+	}
+
+	tests := []struct {
+		name    string
+		samples []float64
+	}{
+		{"silence", make([]float64, 44100*10)},
+		{"noise", noise},
+		{"too short", make([]float64, 100)},
+	}
+
+	for _, tt := range tests {
+		if bpm, confidence := estimateTempo(tt.samples, 44100); confidence >= tempoMinConfidence {
+			t.Errorf("%s: estimateTempo() = %v BPM with confidence %.2f, want no confident tempo", tt.name, bpm, confidence)
+		}
+	}
+}
+
+func TestEstimateTempoWAV(t *testing.T) {
+	var pcm []byte
+	for _, s := range buildClickTrack(120, 44100, 10) {
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(int16(s*32767)))
+	}
+	path := writeTestFile(t, "drum_loop.wav", buildTestWAV(44100, 1, 16, pcm))
+
+	meta := &AudioMetadata{}
+	if err := NewAudioAnalyzer().EstimateTempo(path, meta); err != nil {
+		t.Fatalf("EstimateTempo() error = %v", err)
+	}
+	if math.Abs(meta.BPM-120) > 1.5 {
+		t.Errorf("BPM = %v, want about 120", meta.BPM)
+	}
+
+	tags := NewAudioAnalyzer().GenerateAudioTags(meta)
+	found := false
+	for _, tag := range tags {
+		if tag == "bpm:120" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("GenerateAudioTags() = %v, want bpm:120", tags)
+	}
+}
+
+func TestWantsTempo(t *testing.T) {
+	long := &AudioMetadata{Duration: 10 * time.Second}
+	short := &AudioMetadata{Duration: 2 * time.Second}
+	looped := &AudioMetadata{Duration: 10 * time.Second, Loop: &LoopInfo{Start: 0, End: 1000}}
+
+	tests := []struct {
+		name     string
+		meta     *AudioMetadata
+		category string
+		filename string
+		want     bool
+	}{
+		{"music", long, "Music", "theme.wav", true},
+		{"loop in name", long, "SFX", "Drum_Loop_01.wav", true},
+		{"loop points", looped, "SFX_Drone", "hum.wav", true},
+		{"short music", short, "Music", "sting.wav", false},
+		{"short loop", short, "SFX", "beat_loop.wav", false},
+		{"plain sfx", long, "SFX_Impact", "door_slam.wav", false},
+		{"no metadata", nil, "Music", "theme.wav", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WantsTempo(tt.meta, tt.category, tt.filename); got != tt.want {
+				t.Errorf("WantsTempo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "strings"
+
+// tagTitle returns what -prefer-tags names a file after: its embedded title,
+// with the artist in front for -tag-artist. Returns "" when the file has no
+// title tag, so the filename is used as usual.
+func (ap *AudioProcessor) tagTitle(af *AudioFile) string {
+	meta := af.AudioMeta
+	if !ap.config.PreferTags || meta == nil || !meta.HasEmbeddedTags {
+		return ""
+	}
+
+	title := strings.TrimSpace(meta.Title)
+	if title == "" {
+		return ""
+	}
+	if artist := strings.TrimSpace(meta.Artist); ap.config.TagArtist && artist != "" {
+		return artist + " " + title
+	}
+	return title
+}
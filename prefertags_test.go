@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestPreferTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       Config
+		originalName string
+		meta         *tidy.AudioMetadata
+		expectedCat  string
+		expectedName string
+	}{
+		{
+			name:         "title_names_the_file",
+			config:       Config{PackName: "Pack", PreferTags: true},
+			originalName: "audio_0042.wav",
+			meta:         &tidy.AudioMetadata{HasEmbeddedTags: true, Title: "Epic Battle Music", Artist: "Some Composer"},
+			expectedCat:  "Music",
+			expectedName: "A_Pack_Music_Epic_Battle_Music.wav",
+		},
+		{
+			name:         "with_artist",
+			config:       Config{PackName: "Pack", PreferTags: true, TagArtist: true},
+			originalName: "audio_0042.wav",
+			meta:         &tidy.AudioMetadata{HasEmbeddedTags: true, Title: "Door Creak", Artist: "Foley Co"},
+			expectedCat:  "SFX_Object",
+			expectedName: "A_Pack_Object_Foley_Co_Door_Creak.wav",
+		},
+		{
+			name:         "name_keyword_wins",
+			config:       Config{PackName: "Pack", PreferTags: true},
+			originalName: "gun_shot_01.wav",
+			meta:         &tidy.AudioMetadata{HasEmbeddedTags: true, Title: "Take 3"},
+			expectedCat:  "SFX_Weapon",
+			expectedName: "A_Pack_Weapon_Take_3.wav",
+		},
+		{
+			name:         "no_title",
+			config:       Config{PackName: "Pack", PreferTags: true},
+			originalName: "door_creak.wav",
+			meta:         &tidy.AudioMetadata{HasEmbeddedTags: true, Artist: "Foley Co"},
+			expectedCat:  "SFX_Object",
+			expectedName: "A_Pack_Object_Door_Creak.wav",
+		},
+		{
+			name:         "off",
+			config:       Config{PackName: "Pack"},
+			originalName: "door_creak.wav",
+			meta:         &tidy.AudioMetadata{HasEmbeddedTags: true, Title: "Something Else"},
+			expectedCat:  "SFX_Object",
+			expectedName: "A_Pack_Object_Door_Creak.wav",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(tt.config)
+			af := AudioFile{OriginalName: tt.originalName, AudioMeta: tt.meta}
+			ap.parseFile(&af)
+
+			if af.Category != tt.expectedCat {
+				t.Errorf("Category = %q, want %q", af.Category, tt.expectedCat)
+			}
+			if got := ap.generateUE5Name(&af); got != tt.expectedName {
+				t.Errorf("generateUE5Name() = %q, want %q", got, tt.expectedName)
+			}
+		})
+	}
+}
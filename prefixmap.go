@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// what UE5 names sound waves, and every category without its own prefix
+// unless -prefix says otherwise
+const defaultAssetPrefix = "A"
+
+var assetPrefixPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// parsePrefixMap reads a category -> asset prefix mapping (-prefix-map), like
+// "SFX_Voice=DLG,Music=MUS", in the same formats as -folder-map. A trailing
+// underscore on a prefix is dropped, it's added when the name is built.
+func parsePrefixMap(value string) (map[string]string, error) {
+	prefixMap, err := parseCategoryMap(value, "Prefix")
+	if err != nil {
+		return nil, err
+	}
+	for category, prefix := range prefixMap {
+		prefix = strings.TrimSuffix(prefix, "_")
+		if !assetPrefixPattern.MatchString(prefix) {
+			return nil, fmt.Errorf("invalid prefix %q for %s, use letters and digits starting with a letter", prefix, category)
+		}
+		prefixMap[category] = prefix
+	}
+	return prefixMap, nil
+}
+
+// defaultPrefix returns the prefix of every category -prefix-map doesn't
+// name: -prefix, A unless it's set, nothing with -prefix ""
+func (ap *AudioProcessor) defaultPrefix() string {
+	if ap.config.NoPrefix {
+		return ""
+	}
+	if ap.config.AssetPrefix != "" {
+		return ap.config.AssetPrefix
+	}
+	return defaultAssetPrefix
+}
+
+// assetPrefix returns the prefix names in a category start with, the default
+// one unless the prefix map says otherwise
+func (ap *AudioProcessor) assetPrefix(category string) string {
+	if prefix, ok := ap.config.PrefixMap[strings.ToUpper(category)]; ok {
+		return prefix
+	}
+	return ap.defaultPrefix()
+}
+
+// assetPrefixes returns every prefix a generated name can start with, the
+// default first
+func (ap *AudioProcessor) assetPrefixes() []string {
+	seen := map[string]bool{ap.defaultPrefix(): true}
+	var mapped []string
+	for _, prefix := range ap.config.PrefixMap {
+		if !seen[prefix] {
+			seen[prefix] = true
+			mapped = append(mapped, prefix)
+		}
+	}
+	sort.Strings(mapped)
+	return append([]string{ap.defaultPrefix()}, mapped...)
+}
+
+// withAssetPrefix puts prefix and an underscore in front of a rendered name,
+// or leaves it alone when there's no prefix
+func withAssetPrefix(name, prefix string) string {
+	if prefix == "" {
+		return name
+	}
+	if name == "" {
+		return prefix
+	}
+	return prefix + "_" + name
+}
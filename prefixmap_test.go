@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePrefixMap(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{"", map[string]string{}, false},
+		{"SFX_Voice=DLG, Music=MUS_", map[string]string{"SFX_VOICE": "DLG", "MUSIC": "MUS"}, false},
+		{"Music=", nil, true},
+		{"Music=2MUS", nil, true},
+		{"Music=MU-S", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePrefixMap(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePrefixMap(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parsePrefixMap(%q) = %v, want %v", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestGenerateUE5NamePrefix(t *testing.T) {
+	prefixMap := map[string]string{"SFX_VOICE": "DLG", "MUSIC": "MUS"}
+	tests := []struct {
+		template string
+		category string
+		want     string
+	}{
+		{"", "SFX_Voice", "DLG_HorrorPack_Voice_Scream.wav"},
+		{"", "Music", "MUS_HorrorPack_Music_Scream.wav"},
+		{"", "SFX_Impact", "A_HorrorPack_Impact_Scream.wav"},
+		{"{pack}_{category}_{subcategory}", "SFX_Voice", "DLG_HorrorPack_Voice_Scream.wav"},
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(Config{PackName: "HorrorPack", Template: tt.template, PrefixMap: prefixMap})
+		af := &AudioFile{OriginalName: "scream.wav", Category: tt.category, SubCategory: "Scream"}
+		if got := ap.generateUE5Name(af); got != tt.want {
+			t.Errorf("generateUE5Name() in %s with %q = %q, want %q", tt.category, tt.template, got, tt.want)
+		}
+
+		// a later run has to recognize the name as its own
+		if _, ok := ap.parseTidied(tt.want[:len(tt.want)-len(".wav")]); !ok {
+			t.Errorf("parseTidied(%q) didn't recognize the name", tt.want)
+		}
+	}
+}
+
+func TestGenerateUE5NameDefaultPrefix(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		noPrefix bool
+		pack     string
+		template string
+		category string
+		want     string
+	}{
+		{"SW", false, "HorrorPack", "", "SFX_Impact", "SW_HorrorPack_Impact_Scream.wav"},
+		{"SW", false, "HorrorPack", "", "SFX_Voice", "DLG_HorrorPack_Voice_Scream.wav"},
+		{"", true, "HorrorPack", "", "SFX_Impact", "HorrorPack_Impact_Scream.wav"},
+		{"", true, "HorrorPack", "{pack}_{category}_{subcategory}", "SFX_Impact", "HorrorPack_Impact_Scream.wav"},
+		{"", true, "HorrorPack", "{category}_{subcategory}", "SFX_Impact", "Impact_Scream.wav"},
+		{"", true, "HorrorPack", "", "SFX_Voice", "DLG_HorrorPack_Voice_Scream.wav"}, // -prefix-map still applies
+		{"", true, "Alpha", "{pack}_{category}_{subcategory}", "SFX_Impact", "Alpha_Impact_Scream.wav"},
+		{"", true, "2024 Pack", "", "SFX_Impact", "2024Pack_Impact_Scream.wav"}, // a leading digit gets no A_ either
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(Config{PackName: tt.pack, Template: tt.template, AssetPrefix: tt.prefix, NoPrefix: tt.noPrefix, PrefixMap: map[string]string{"SFX_VOICE": "DLG"}})
+		ap.log.out = io.Discard
+		af := &AudioFile{OriginalName: "scream.wav", Category: tt.category, SubCategory: "Scream"}
+		values := ap.templateValues(af)
+		if got := ap.safeName(af, ap.generateUE5Name(af), values); got != tt.want {
+			t.Errorf("prefix %q, template %q, pack %q: name = %q, want %q", tt.prefix, tt.template, tt.pack, got, tt.want)
+		}
+		if tt.noPrefix && tt.category != "SFX_Voice" && strings.HasPrefix(ap.generateUE5Name(af), "A_") {
+			t.Errorf("template %q: A_ leaked in without a prefix", tt.template)
+		}
+
+		// a later run has to recognize the name as its own, when there's anything to go by
+		if tt.template != "{category}_{subcategory}" {
+			if _, ok := ap.parseTidied(strings.TrimSuffix(tt.want, ".wav")); !ok {
+				t.Errorf("parseTidied(%q) didn't recognize the name", tt.want)
+			}
+		}
+	}
+}
@@ -1,64 +1,271 @@
 package main
 
 import (
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/schollz/progressbar/v3"
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
 )
 
 type AudioProcessor struct {
 	config        Config
 	audioFiles    []AudioFile
 	extensions    map[string]bool
-	audioAnalyzer *AudioAnalyzer
+	audioAnalyzer *tidy.AudioAnalyzer
 	fingerprints  map[string][]int // fingerprint -> list of file indices (for duplicate detection)
+	input         io.Reader        // where interactive answers are read from
+	log           *logger          // human-readable output, stderr when stdout carries JSON lines
+	json          *json.Encoder    // JSON lines on stdout (-format json)
+	statusCounts  map[string]int   // files per status, for the JSON summary
+	profile       *ParseProfile    // vendor filename schema (-profile), nil for the default heuristic
+	sourcePattern *regexp.Regexp   // extra source codes to recognize (-source-pattern)
+	idPatterns    []*regexp.Regexp // trailing catalog IDs, -id-pattern ones first
+	priorState    *runState        // analysis loaded from a previous run (-resume)
+	state         *runState        // analysis of this run, checkpointed to the state file
+	stateSaved    bool             // the state file holds this run's analysis, so far
+	runDate       time.Time        // when the run started, for the {date} token
+
+	duplicateGroups     [][]int          // file indices of each group of duplicates found
+	nearDuplicateGroups [][]int          // file indices of each group of similar sounding files
+	roundGroups         [][]int          // file indices of each round of numbered variations
+	duplicateReport     []DuplicateGroup // suggested keeper of each duplicate group, for the manifest
+	priorFiles          []AudioFile      // files from an existing manifest (-incremental)
+	existingAssets      map[string]bool  // assets already in the output directory, by assetKey (-merge-output)
+	fileErrors          []fileError      // files that could not be analyzed
+	skippedSymlinks     int              // symlinks left out of the scan without -follow-symlinks
+	sandbox             bool             // applying into a temp directory with copies (-sandbox)
 }
 
 func NewAudioProcessor(config Config) *AudioProcessor {
+	analyzer := tidy.NewAudioAnalyzer()
+	if config.SilenceThreshold != 0 { // zero means unset, 0 dBFS isn't a usable threshold
+		analyzer.SilenceThreshold = config.SilenceThreshold
+	}
+	if config.Scoring != nil {
+		analyzer.Scoring = *config.Scoring
+	}
+	if config.FingerprintPrecision > 0 {
+		analyzer.FingerprintPrecision = config.FingerprintPrecision
+	}
+	if config.AnalysisWindow > 0 {
+		analyzer.AnalysisWindow = config.AnalysisWindow
+	}
+	if config.LoudnessTarget != "" {
+		analyzer.LoudnessTarget, _ = tidy.ParseLoudnessTarget(config.LoudnessTarget) // validated in main
+	}
+
+	var out io.Writer = os.Stdout
+	if config.Format == formatJSON {
+		out = os.Stderr // keep stdout clean for the JSON stream
+	}
+
+	var profile *ParseProfile
+	if config.Profile != "" {
+		profile, _ = lookupProfile(config.Profile) // validated in main
+	}
+
+	var sourcePattern *regexp.Regexp
+	if config.SourcePattern != "" {
+		sourcePattern, _ = regexp.Compile(config.SourcePattern) // validated in main
+	}
+	idPatterns, _ := compileIDPatterns(config.IDPatterns) // validated in main
+
+	log := newLogger(out, logLevelFor(config))
+	if log.enabled(levelVerbose) {
+		analyzer.Verbosef = log.Verbosef
+	}
+
 	return &AudioProcessor{
 		config:        config,
+		profile:       profile,
+		sourcePattern: sourcePattern,
+		idPatterns:    idPatterns,
 		audioFiles:    make([]AudioFile, 0),
-		audioAnalyzer: NewAudioAnalyzer(),
+		audioAnalyzer: analyzer,
 		fingerprints:  make(map[string][]int),
-		extensions: map[string]bool{
-			".wav": true, ".mp3": true, ".ogg": true, ".flac": true,
-			".aac": true, ".m4a": true, ".wma": true, // common formats
-		},
+		input:         os.Stdin,
+		log:           log,
+		json:          json.NewEncoder(os.Stdout),
+		statusCounts:  make(map[string]int),
+		state:         &runState{Files: make(map[string]stateEntry), FingerprintPrecision: analyzer.FingerprintPrecision, AnalysisWindow: analyzer.AnalysisWindow},
+		runDate:       time.Now(),
+		extensions:    extensionSet(config.Extensions),
+	}
+}
+
+// extensionSet turns a list of extensions into a lookup, using the defaults for an empty list
+func extensionSet(extensions []string) map[string]bool {
+	if len(extensions) == 0 {
+		extensions = defaultExtensions
+	}
+	set := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		set[strings.ToLower(ext)] = true
 	}
+	return set
 }
 
-func (ap *AudioProcessor) Process() error {
-	fmt.Printf("Scanning directory: %s\n", ap.config.SourceDir)
+// Process runs the whole thing. Cancelling ctx (Ctrl-C) stops it between
+// files: analysis keeps what it has for -resume, and a run that's moving
+// files finishes the one in progress and writes a manifest of those it moved.
+func (ap *AudioProcessor) Process(ctx context.Context) error {
+	if ap.config.ConfigFile != "" {
+		ap.log.Verbosef("Using config file %s", ap.config.ConfigFile)
+	}
+
+	for _, dir := range ap.sourceDirs() {
+		ap.log.Infof("Scanning directory: %s\n", dir)
+	}
 
 	if err := ap.scanFiles(); err != nil {
 		return fmt.Errorf("failed to scan files: %w", err)
 	}
 
-	fmt.Printf("Found %d audio files\n", len(ap.audioFiles))
+	ap.log.Infof("Found %d audio files\n", len(ap.audioFiles))
+	ap.reportEmptyFiles()
+	if ap.skippedSymlinks > 0 {
+		ap.log.Infof("Skipped %d symlinks (use -follow-symlinks to include them)\n", ap.skippedSymlinks)
+	}
 
-	if err := ap.analyzeAudioFiles(); err != nil {
+	if ap.config.Incremental {
+		if err := ap.loadPriorManifest(); err != nil {
+			return fmt.Errorf("failed to load existing manifest: %w", err)
+		}
+		if len(ap.priorFiles) > 0 {
+			skipped := ap.skipKnownByName()
+			ap.log.Infof("Existing manifest has %d files, skipping %d already processed\n", len(ap.priorFiles), skipped)
+		}
+	}
+
+	if ap.config.Resume {
+		if err := ap.loadState(); err != nil {
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+		if ap.priorState != nil {
+			ap.log.Infof("Resuming with %d previously analyzed files\n", len(ap.priorState.Files))
+		}
+	}
+
+	if ap.config.NoAnalyze {
+		ap.log.Infoln("Skipping audio analysis, naming from filenames only")
+		ap.detectRounds()
+	} else if err := ap.analyzeAudioFiles(ctx); err != nil {
+		if ctx.Err() != nil {
+			ap.emitSummary(true)
+			return err
+		}
 		return fmt.Errorf("failed to analyze audio files: %w", err)
 	}
 
 	ap.parseFiles()
-	ap.generateNewNames()
-	ap.displayPreview()
+	if ap.config.Catalog {
+		ap.checkSampleRates()
+		ap.sortFiles()
+		ap.displayPackStats()
+		if ap.config.RulesReport && !ap.jsonOutput() {
+			ap.displayRulesReport()
+		}
+		if ap.config.TagReport && !ap.jsonOutput() {
+			ap.displayTagReport()
+		}
+		return ap.writeCatalog()
+	}
+	if ap.config.RenumberRounds {
+		ap.renumberRounds()
+	}
+	ap.filterCategories()
+	if ap.config.Dedupe {
+		ap.resolveDuplicates()
+	}
+	ap.checkSampleRates()
+	if ap.config.MergeOutput {
+		if err := ap.indexOutput(); err != nil {
+			return fmt.Errorf("failed to scan the output directory: %w", err)
+		}
+	}
+	if err := ap.generateNewNames(); err != nil {
+		return err
+	}
+	ap.sortFiles()
+	if !ap.jsonOutput() {
+		ap.displayPreview()
+		ap.displayRenameCounts()
+	}
+	ap.displayPackStats()
+	if ap.config.RulesReport && !ap.jsonOutput() {
+		ap.displayRulesReport()
+	}
+	if ap.config.TagReport && !ap.jsonOutput() {
+		ap.displayTagReport()
+	}
+
+	// an automated run stops here, dry or not, rather than dump guesses into SFX
+	if ap.config.Strict {
+		if err := ap.checkStrict(); err != nil {
+			return err
+		}
+	}
+
+	// a dry run only warns, the real run stops before touching anything
+	if !ap.config.SkipSpaceCheck {
+		if err := ap.checkDiskSpace(); err != nil {
+			if !ap.config.DryRun && !ap.config.Sandbox {
+				return err
+			}
+			ap.log.Warnf("⚠ %v\n", err)
+		}
+	}
 
 	if ap.config.DryRun {
-		fmt.Println("\n[DRY RUN] No files were modified. Remove -dry-run to apply changes.")
+		for i := range ap.audioFiles {
+			ap.emitFile(&ap.audioFiles[i], ap.fileStatus(&ap.audioFiles[i]))
+		}
+		ap.emitSummary(false)
+		if ap.config.ReportPath != "" {
+			if err := ap.writeReport(ap.config.ReportPath); err != nil {
+				return err
+			}
+			ap.log.Infof("\n✓ Wrote report: %s\n", ap.config.ReportPath)
+		}
+		if err := ap.emitHTMLReport(); err != nil {
+			return err
+		}
+		ap.log.Println("\n[DRY RUN] No files were modified. Remove -dry-run to apply changes.")
 		return nil // bail out early if dry run
 	}
 
-	if err := ap.applyChanges(); err != nil {
+	if ap.config.Sandbox {
+		return ap.runSandbox(ctx)
+	}
+
+	if ap.config.Interactive || ap.config.InteractivePerCategory {
+		if !ap.confirmChanges() {
+			ap.emitSummary(true)
+			ap.log.Println("\nAborted. No files were modified.")
+			return nil
+		}
+	}
+
+	if err := ap.checkOutputWritable(); err != nil {
+		return err
+	}
+	if err := ap.applyChanges(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ap.finishInterrupted(err)
+		}
 		return fmt.Errorf("failed to apply changes: %w", err)
 	}
 
@@ -68,70 +275,223 @@ func (ap *AudioProcessor) Process() error {
 		}
 	}
 
-	fmt.Println("\n✓ Processing complete!")
+	if ap.config.ManifestPerCategory {
+		if err := ap.createCategoryManifests(); err != nil {
+			return fmt.Errorf("failed to create category manifests: %w", err)
+		}
+	}
+
+	if ap.config.DataTable != "" {
+		if err := ap.writeDataTable(ap.config.DataTable); err != nil {
+			return err
+		}
+		ap.log.Infof("\n✓ Wrote data table: %s\n", ap.config.DataTable)
+	}
+
+	if err := ap.emitHTMLReport(); err != nil {
+		return err
+	}
+
+	ap.emitSummary(false)
+	ap.log.Println("\n✓ Processing complete!")
 	return nil
 }
 
 func (ap *AudioProcessor) scanFiles() error {
-	return filepath.WalkDir(ap.config.SourceDir, func(path string, d fs.DirEntry, err error) error {
+	for _, root := range ap.sourceDirs() {
+		if err := ap.scanDir(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanDir walks one source directory and records where each file came from
+func (ap *AudioProcessor) scanDir(root string) error {
+	// a source named on the command line is walked even if it's a link
+	dir := root
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		dir = real
+	}
+	guard := newSymlinkGuard(dir)
+	if err := ap.walkDir(root, root, dir, guard); err != nil {
+		return err
+	}
+	return ap.followLinks(root, guard)
+}
+
+// walkDir walks dir, which sits at path inside the source root. The two only
+// differ when dir is the target of a followed symlink: its files keep paths
+// under the link, so layouts and filters see them where the link is.
+func (ap *AudioProcessor) walkDir(root, path, dir string, guard *symlinkGuard) error {
+	return filepath.WalkDir(dir, func(walked string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
+		path := path
+		if walked != dir {
+			sub, err := filepath.Rel(dir, walked)
+			if err != nil {
+				return err
+			}
+			path = filepath.Join(path, sub)
+		}
+
 		if d.IsDir() {
-			// skip output dir to avoid processing files we just created
-			if ap.config.OutputDir != ap.config.SourceDir && path == ap.config.OutputDir {
+			if walked == dir {
+				return nil
+			}
+			if ap.skipDir(root, path, d.Name()) {
 				return filepath.SkipDir
 			}
+			if ap.config.FollowSymlinks && !guard.enter(walked) {
+				return filepath.SkipDir // reached again through a link
+			}
 			return nil
 		}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		if ap.extensions[ext] {
-			ap.audioFiles = append(ap.audioFiles, AudioFile{
-				OriginalPath: path,
-				OriginalName: filepath.Base(path),
-			})
+		if d.Type()&fs.ModeSymlink != 0 {
+			ap.scanSymlink(path, guard)
+			return nil
 		}
-
+		if ap.config.FollowSymlinks && !guard.first(walked) {
+			return nil // already found through a link
+		}
+		ap.addScannedFile(root, path)
 		return nil
 	})
 }
 
-func (ap *AudioProcessor) analyzeAudioFiles() error {
+// skipDir reports whether a directory inside the source root is left out of the scan
+func (ap *AudioProcessor) skipDir(root, path, name string) bool {
+	// skip an output dir inside the source to avoid processing files we
+	// already renamed
+	if samePath(path, ap.config.OutputDir) {
+		return true
+	}
+	// dropped duplicates from an earlier -dedupe run
+	if samePath(path, filepath.Join(ap.config.OutputDir, duplicatesDir)) {
+		return true
+	}
+	// another source nested in this one gets walked on its own
+	if ap.isSourceDir(path) {
+		return true
+	}
+	if ap.tooDeep(root, path) {
+		return true
+	}
+	if ap.config.SkipHidden && isHidden(name) {
+		return true
+	}
+	return ap.isExcluded(ap.relPath(path))
+}
+
+// addScannedFile records a file found in the source root if it's audio and
+// passes the filters
+func (ap *AudioProcessor) addScannedFile(root, path string) {
+	name := filepath.Base(path)
+
+	// macOS metadata next to the real file, never audio even with a .wav name
+	if isAppleDouble(name) {
+		return
+	}
+	if ap.config.SkipHidden && isHidden(name) {
+		return
+	}
+
+	// excludes win over includes
+	rel := ap.relPath(path)
+	if ap.isExcluded(rel) || !ap.isIncluded(rel) {
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ap.extensions[ext] || ext == "" && ap.config.Sniff && tidy.SniffFile(path) != "" {
+		af := AudioFile{
+			OriginalPath: path,
+			OriginalName: name,
+			SourceDir:    root,
+			RelativePath: rel,
+		}
+		ap.checkSize(&af)
+		ap.audioFiles = append(ap.audioFiles, af)
+	}
+}
+
+func (ap *AudioProcessor) analyzeAudioFiles(ctx context.Context) error {
 	total := len(ap.audioFiles)
 	if total == 0 {
 		return nil
 	}
 
 	// create progress bar
-	bar := progressbar.NewOptions(total,
-		progressbar.OptionSetDescription("Analyzing audio files"),
-		progressbar.OptionSetWidth(50),
-		progressbar.OptionShowCount(),
-		progressbar.OptionShowIts(),
-		progressbar.OptionSetItsString("files"),
-	)
+	bar := ap.newProgress(total, "Analyzing audio files")
 
 	// use worker pool for parallel processing
-	numWorkers := 8
-	if total < numWorkers {
-		numWorkers = total
-	}
+	numWorkers := ap.workerCount(total)
 
 	type job struct {
 		index int
 		file  *AudioFile
 	}
 
-	jobs := make(chan job, total)
-	results := make(chan struct {
+	type result struct {
 		index int
-		meta  *AudioMetadata
+		meta  *tidy.AudioMetadata
 		tags  []string
 		cat   string
+		conf  float64
 		err   error
-	}, total)
+	}
+
+	jobs := make(chan job, total)
+	results := make(chan result, total)
+
+	// analyze runs one job. A parser that panics on a file it can't make
+	// sense of only fails that file, it ends up in fileErrors like any other
+	// unreadable one instead of taking the whole run down.
+	analyze := func(j job) (r result) {
+		r.index = j.index
+		defer func() {
+			if p := recover(); p != nil {
+				r = result{index: j.index, err: fmt.Errorf("panic: %v", p)}
+			}
+		}()
+
+		// nothing in there to analyze, the scan already tagged it
+		if emptyOrCorrupt(j.file) && !ap.config.IncludeEmpty {
+			return r
+		}
+
+		// reuse the analysis from a previous run if the file hasn't changed
+		meta := ap.cachedMeta(j.file.OriginalPath)
+		cached := meta != nil
+		if meta == nil {
+			var err error
+			if meta, err = ap.audioAnalyzer.AnalyzeFile(j.file.OriginalPath); err != nil {
+				r.err = err
+				return r
+			}
+		}
+
+		// use confidence-based categorization
+		catResult := ap.audioAnalyzer.InferCategoryWithConfidence(meta, j.file.OriginalName)
+		r.cat = catResult.Category
+		r.conf = catResult.Confidence
+
+		// tempo needs the category, so it runs after it (cached analysis already has it)
+		if !cached && tidy.WantsTempo(meta, r.cat, j.file.OriginalName) {
+			if err := ap.audioAnalyzer.EstimateTempo(j.file.OriginalPath, meta); err != nil {
+				// no tempo, the rest of the analysis still stands
+				ap.log.Verbosef("%s: no tempo estimate, %v", j.file.OriginalName, err)
+			}
+		}
+		ap.audioAnalyzer.SuggestGain(meta)
+		r.meta = meta
+		r.tags = ap.audioAnalyzer.GenerateAudioTags(meta)
+		return r
+	}
 
 	// start workers
 	var wg sync.WaitGroup
@@ -140,44 +500,25 @@ func (ap *AudioProcessor) analyzeAudioFiles() error {
 		go func() {
 			defer wg.Done()
 			for j := range jobs {
-				meta, err := ap.audioAnalyzer.AnalyzeFile(j.file.OriginalPath)
-				if err != nil {
-					results <- struct {
-						index int
-						meta  *AudioMetadata
-						tags  []string
-						cat   string
-						err   error
-					}{index: j.index, err: err}
-					continue
-				}
-
-				var audioTags []string
-				var audioCat string
-				if meta != nil {
-					audioTags = ap.audioAnalyzer.GenerateAudioTags(meta)
-					// use confidence-based categorization
-					catResult := ap.audioAnalyzer.InferCategoryWithConfidence(meta, j.file.OriginalName)
-					audioCat = catResult.Category
+				// the files in progress finish, nothing new starts after an interrupt
+				if ctx.Err() != nil {
+					return
 				}
-
-				results <- struct {
-					index int
-					meta  *AudioMetadata
-					tags  []string
-					cat   string
-					err   error
-				}{index: j.index, meta: meta, tags: audioTags, cat: audioCat}
+				results <- analyze(j)
 			}
 		}()
 	}
 
 	// send jobs
 	go func() {
+		defer close(jobs)
 		for i := range ap.audioFiles {
-			jobs <- job{index: i, file: &ap.audioFiles[i]}
+			select {
+			case jobs <- job{index: i, file: &ap.audioFiles[i]}:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(jobs)
 	}()
 
 	// collect results with progress
@@ -187,103 +528,323 @@ func (ap *AudioProcessor) analyzeAudioFiles() error {
 	}()
 
 	processed := 0
+	estimate := ap.newAnalysisEstimate()
+	advance := func() {
+		bar.Add(1)
+		processed++
+		if left, ok := estimate.after(processed); ok {
+			bar.Clear()
+			ap.log.Infof("Analysis should take about %s more (timed on the first %d files)\n", formatETA(left), processed)
+		}
+	}
+	lastCheckpoint := time.Now()
 	for result := range results {
 		af := &ap.audioFiles[result.index]
 
 		if result.err != nil {
-			// skip if we can't analyze it
-			bar.Add(1)
-			processed++
+			// keep going, the file still gets a name from its filename
+			ap.fileErrors = append(ap.fileErrors, fileError{Path: af.OriginalPath, Error: result.err.Error()})
+			if ap.config.SkipUnreadable {
+				af.SkipReason = skipUnreadable
+			}
+			advance()
 			continue
 		}
 
 		af.AudioMeta = result.meta
 
-		// track fingerprints for duplicate detection
-		if result.meta != nil && result.meta.Fingerprint != "" {
-			ap.fingerprints[result.meta.Fingerprint] = append(ap.fingerprints[result.meta.Fingerprint], result.index)
-		}
-
 		// use audio properties to help categorize if filename didn't give us much
 		if result.cat != "" {
 			if af.Category == "" || af.Category == "SFX" {
 				af.Category = result.cat
+				af.Confidence = result.conf
 			}
 		}
 
 		af.Tags = append(af.Tags, result.tags...)
 
-		bar.Add(1)
-		processed++
+		// checkpoint now and then so an interrupted run can be resumed
+		if result.meta != nil {
+			ap.recordState(af.OriginalPath, result.meta)
+		}
+		if (processed+1)%checkpointEvery == 0 || time.Since(lastCheckpoint) > checkpointInterval {
+			if err := ap.saveState(); err != nil {
+				ap.log.Warnf("⚠ Could not checkpoint analysis state: %v\n", err)
+			}
+			lastCheckpoint = time.Now()
+		}
+
+		advance()
 	}
 
 	bar.Finish()
-	fmt.Println()
+	ap.log.Infoln()
+
+	ap.reportFileErrors()
+
+	if err := ap.saveState(); err != nil {
+		ap.log.Warnf("⚠ Could not save analysis state: %v\n", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		if ap.stateSaved {
+			ap.log.Warnf("Interrupted after analyzing %d of %d files, nothing was moved. Run again with -resume to reuse the analysis.\n", processed, total)
+		} else {
+			ap.log.Warnf("Interrupted after analyzing %d of %d files, nothing was moved. The analysis couldn't be saved, so a new run starts over.\n", processed, total)
+		}
+		return err
+	}
+
+	// files a previous run already handled are only recognizable once we have fingerprints
+	if ap.config.Incremental {
+		if skipped := ap.skipKnownByFingerprint(); skipped > 0 {
+			ap.log.Infof("Skipping %d files already in the manifest (same audio content)\n", skipped)
+		}
+	}
 
 	// detect and report duplicates
+	ap.indexFingerprints()
+	ap.detectRounds()
 	ap.detectDuplicates()
+	ap.detectNearDuplicates()
 
 	return nil
 }
 
-// detectDuplicates finds files with matching fingerprints and tags them
+// workerCount picks how many analysis workers to run for total files.
+// Spectral analysis is CPU-bound, so this defaults to one worker per core.
+func (ap *AudioProcessor) workerCount(total int) int {
+	numWorkers := ap.config.Workers
+	if numWorkers < 1 {
+		numWorkers = runtime.NumCPU()
+	}
+	if total < numWorkers {
+		numWorkers = total
+	}
+	return numWorkers
+}
+
+// forEachFile runs fn on every file with a pool of workers, so fn must only
+// touch the file it's given. With -verbose it goes one file at a time, keeping
+// each file's log lines together and in order.
+func (ap *AudioProcessor) forEachFile(fn func(af *AudioFile)) {
+	numWorkers := ap.workerCount(len(ap.audioFiles))
+	if numWorkers <= 1 || ap.log.enabled(levelVerbose) {
+		for i := range ap.audioFiles {
+			fn(&ap.audioFiles[i])
+		}
+		return
+	}
+
+	indices := make(chan int, len(ap.audioFiles))
+	for i := range ap.audioFiles {
+		indices <- i
+	}
+	close(indices)
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(&ap.audioFiles[i])
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// indexFingerprints rebuilds the fingerprint -> file indices map used for duplicate detection
+func (ap *AudioProcessor) indexFingerprints() {
+	ap.fingerprints = make(map[string][]int)
+	for i, af := range ap.audioFiles {
+		if af.AudioMeta != nil && af.AudioMeta.Fingerprint != "" {
+			ap.fingerprints[af.AudioMeta.Fingerprint] = append(ap.fingerprints[af.AudioMeta.Fingerprint], i)
+		}
+	}
+}
+
+// detectDuplicates finds files with matching fingerprints and tags them.
+// Fingerprints are visited in sorted order so group numbers are the same every run.
 func (ap *AudioProcessor) detectDuplicates() {
-	duplicateCount := 0
-	for _, indices := range ap.fingerprints {
-		if len(indices) > 1 {
+	fingerprints := make([]string, 0, len(ap.fingerprints))
+	for fp := range ap.fingerprints {
+		fingerprints = append(fingerprints, fp)
+	}
+	sort.Strings(fingerprints)
+
+	duplicateCount, possibleCount := 0, 0
+	for _, fp := range fingerprints {
+		candidates := ap.withoutRounds(ap.fingerprints[fp])
+		if len(candidates) < 2 {
+			continue
+		}
+
+		// the fingerprint only covers the format, length and title, so
+		// different sounds can share one; only identical content is a duplicate
+		groups, unconfirmed := groupByContent(ap.audioFiles, candidates)
+		for _, idx := range unconfirmed {
+			ap.audioFiles[idx].Tags = append(ap.audioFiles[idx].Tags, "possible-duplicate")
+			possibleCount++
+		}
+
+		for _, indices := range groups {
 			duplicateCount++
+			ap.duplicateGroups = append(ap.duplicateGroups, indices)
+
+			// suggest a keeper even without -dedupe, for cleaning up by hand
+			keeper := ap.pickKeeper(indices)
+			group := DuplicateGroup{Keep: ap.audioFiles[keeper].OriginalPath}
+
 			// tag all duplicates
 			for _, idx := range indices {
 				ap.audioFiles[idx].Tags = append(ap.audioFiles[idx].Tags, "duplicate")
 				if len(indices) > 1 {
 					ap.audioFiles[idx].Tags = append(ap.audioFiles[idx].Tags, fmt.Sprintf("duplicate-group-%d", duplicateCount))
 				}
+				if idx == keeper {
+					ap.audioFiles[idx].Tags = append(ap.audioFiles[idx].Tags, "duplicate-keep")
+				} else {
+					ap.audioFiles[idx].Tags = append(ap.audioFiles[idx].Tags, "duplicate-redundant")
+					group.Redundant = append(group.Redundant, ap.audioFiles[idx].OriginalPath)
+				}
 			}
+			ap.duplicateReport = append(ap.duplicateReport, group)
 		}
 	}
 	if duplicateCount > 0 {
-		fmt.Printf("⚠ Found %d duplicate file groups (same audio content)\n", duplicateCount)
+		ap.log.Warnf("⚠ Found %d duplicate file groups (same audio content)\n", duplicateCount)
+	}
+	if possibleCount > 0 {
+		ap.log.Infof("%d files have the format and length of another file but different content, tagged possible-duplicate\n", possibleCount)
 	}
 }
 
 func (ap *AudioProcessor) parseFiles() {
-	for i := range ap.audioFiles {
-		ap.parseFile(&ap.audioFiles[i])
-	}
+	ap.forEachFile(ap.parseFile)
 }
 
 func (ap *AudioProcessor) parseFile(af *AudioFile) {
 	name := strings.TrimSuffix(af.OriginalName, filepath.Ext(af.OriginalName))
 
-	// grab the ID (usually at the end like .12345)
-	idPattern := regexp.MustCompile(`\.(\d+)$`)
-	if matches := idPattern.FindStringSubmatch(name); len(matches) > 1 {
-		af.ID = matches[1]
-		name = strings.TrimSuffix(name, "."+af.ID)
+	// analysis runs first, remember what it made of the file
+	analyzedCategory, analyzedConfidence := af.Category, af.Confidence
+
+	// a vendor profile knows the schema, fall back to guessing when it doesn't match
+	parsed, ok := parsedName{}, false
+	if parsed, ok = ap.parseTidied(name); ok {
+		af.AlreadyNamed = true
+		ap.log.Verbosef("%s: already named by an earlier run, keeping the name", af.OriginalName)
+	} else if ap.profile != nil {
+		parsed, ok = ap.profile.Parse(name)
+		if !ok {
+			ap.log.Verbosef("%s: doesn't match the %s profile, guessing from the name", af.OriginalName, ap.profile.Name)
+		}
+	}
+	if !ok {
+		parsed = parseDefaultName(name, ap.idPatterns, ap.sourcePattern)
 	}
 
-	// last underscore segment is usually the source/library code
-	parts := strings.Split(name, "_")
-	if len(parts) > 1 {
-		af.Source = parts[len(parts)-1]
-		name = strings.Join(parts[:len(parts)-1], "_")
+	af.ID = parsed.ID
+	af.Source = parsed.Source
+	af.Category = parsed.Category
+	af.SubCategory = parsed.SubCategory
+	explicit := parsed.Explicit
+
+	// names like track01.wav say nothing, a title tag usually does. A category
+	// the name spells out or a keyword found in it still counts.
+	if title := ap.tagTitle(af); title != "" && !af.AlreadyNamed {
+		ap.log.Verbosef("%s: naming after its title tag %q", af.OriginalName, title)
+		af.SubCategory = title
+		if !explicit && af.Category == "SFX" {
+			af.Category = tidy.InferCategory(title)
+		}
 	}
 
-	// check for dash-separated category (e.g., "FX-Impact")
-	if strings.Contains(name, "-") {
-		catParts := strings.SplitN(name, "-", 2)
-		af.Category = catParts[0]
-		if len(catParts) > 1 {
-			af.SubCategory = catParts[1]
+	af.Category = tidy.NormalizeCategory(af.Category)
+
+	// the folders a pack is sorted into say more than a name without keywords,
+	// and a parent folder that didn't name the category adds to the subcategory
+	folder := ""
+	if ap.config.UseFolders && af.Category == "SFX" {
+		var category string
+		if folder, category = ap.folderCategory(af); folder != "" {
+			af.Category = category
+			if parent := ap.parentFolder(af); parent != folder {
+				af.SubCategory = parent + "_" + af.SubCategory
+			}
 		}
-	} else {
-		// no dash, try to guess from the name
-		af.Category = InferCategory(name)
-		af.SubCategory = name
 	}
 
-	af.Category = NormalizeCategory(af.Category)
-	af.Tags = ap.generateTags(af)
+	override := ap.overrideFor(af)
+	if override != nil {
+		af.Override = override.Pattern
+	}
+
+	// an in-house categorizer knows the library better than our keywords, but
+	// a category set by hand still wins
+	var external categorizerResult
+	useExternal := false
+	if ap.config.CategorizerCmd != "" && (override == nil || override.Category == "") {
+		external, useExternal = ap.externalCategory(af, analyzedCategory, analyzedConfidence)
+	}
+
+	// the filename wins, unless all it gave us is the generic fallback
+	var reason string
+	switch {
+	case override != nil && override.Category != "":
+		af.Category = override.Category
+		af.Confidence = 1 // set by hand
+		reason = fmt.Sprintf("set by override %q", override.Pattern)
+	case useExternal:
+		af.Category = external.Category
+		af.Confidence = external.Confidence
+		reason = "from -categorizer-cmd"
+	case folder != "":
+		af.Confidence = tidy.KeywordConfidence(folder, af.Category)
+		reason = fmt.Sprintf("keyword %q in folder %q", tidy.MatchedKeyword(folder), folder)
+	case af.Category == "SFX" && analyzedCategory != "":
+		af.Category = analyzedCategory
+		af.Confidence = analyzedConfidence
+		reason = "no keyword in the name, using audio analysis"
+	case af.Category == analyzedCategory:
+		af.Confidence = analyzedConfidence
+		reason = "name and audio analysis agree"
+	case explicit:
+		af.Confidence = explicitCategoryConfidence
+		reason = fmt.Sprintf("category %q spelled out in the name", parsed.Category)
+	case af.Category == "SFX":
+		af.Confidence = 0 // nothing to go on at all
+		reason = "nothing to go on, using the fallback"
+	default:
+		af.Confidence = tidy.KeywordConfidence(name, af.Category)
+		reason = fmt.Sprintf("keyword %q in the name", tidy.MatchedKeyword(name))
+	}
+	ap.log.Verbosef("%s: %s (%.2f), %s", af.OriginalName, af.Category, af.Confidence, reason)
+
+	// a weak guess is worse than none, park the file for sorting by hand
+	if ap.config.MinConfidence > 0 && af.Confidence < ap.config.MinConfidence {
+		ap.log.Verbosef("%s: %.2f is below -min-confidence, using %s", af.OriginalName, af.Confidence, ap.config.FallbackCategory)
+		af.GuessedCategory = af.Category
+		af.Category = ap.config.FallbackCategory
+		af.Unresolved = true
+	}
+	if af.Source != "" || af.ID != "" {
+		ap.log.Verbosef("%s: source %q, id %q", af.OriginalName, af.Source, af.ID)
+	}
+	if af.AudioMeta != nil && af.AudioMeta.Extension != "" {
+		ap.log.Verbosef("%s: content is %s, the new name gets %s", af.OriginalName, strings.TrimPrefix(af.AudioMeta.Extension, "."), af.AudioMeta.Extension)
+	}
+
+	// keep tags from analysis and duplicate detection
+	af.Tags = mergeTags(ap.generateTags(af), af.Tags)
+	if ap.config.ConfidenceThreshold > 0 && af.Confidence < ap.config.ConfidenceThreshold {
+		af.Tags = mergeTags(af.Tags, []string{"low-confidence"})
+	}
+	if af.Unresolved {
+		af.Tags = mergeTags(af.Tags, []string{"unresolved"})
+	}
 }
 
 func (ap *AudioProcessor) generateTags(af *AudioFile) []string {
@@ -321,67 +882,78 @@ func (ap *AudioProcessor) generateTags(af *AudioFile) []string {
 	return tags
 }
 
-func (ap *AudioProcessor) generateNewNames() {
+func (ap *AudioProcessor) generateNewNames() error {
 	nameCounts := make(map[string]int)
 
-	// first pass: generate all the base names
-	for i := range ap.audioFiles {
-		af := &ap.audioFiles[i]
-		af.NewName = ap.generateUE5Name(af)
+	// names handed out by a previous run are taken, number new files after them
+	for _, prior := range ap.priorFiles {
+		baseName := strings.TrimSuffix(prior.NewName, filepath.Ext(prior.NewName))
+		nameCounts[baseName]++
 	}
 
-	// second pass: handle duplicates by adding numbers
+	// first pass: generate all the base names, each file on its own
+	ap.forEachFile(func(af *AudioFile) {
+		if af.DuplicateStatus == duplicateDropped {
+			af.NewName = af.OriginalName // dropped copies keep their name in _duplicates/
+			return
+		}
+		if override := ap.overrideFor(af); override != nil && override.NewName != "" {
+			af.NewName = ap.safeName(af, overrideName(override, af), nil)
+			return
+		}
+		if af.AlreadyNamed {
+			af.NewName = ap.safeName(af, ap.generateUE5Name(af), nil)
+			return
+		}
+		af.NewName = ap.safeName(af, ap.generateUE5Name(af), ap.templateValues(af))
+	})
+
+	// second pass: resolve names taken earlier in the batch or already on disk
 	for i := range ap.audioFiles {
 		af := &ap.audioFiles[i]
+		if af.SkipReason != "" {
+			continue // staying where it is, doesn't need the name
+		}
 		baseName := strings.TrimSuffix(af.NewName, filepath.Ext(af.NewName))
-		count := nameCounts[baseName]
-		nameCounts[baseName]++
-
-		if count > 0 {
-			ext := filepath.Ext(af.NewName)
-			af.NewName = fmt.Sprintf("%s_%02d%s", baseName, count, ext) // _01, _02, etc.
+		key := baseName
+		if af.DuplicateStatus == duplicateDropped {
+			key = duplicatesDir + "/" + baseName // numbered separately, they land in another folder
 		}
-	}
-}
-
-func (ap *AudioProcessor) generateUE5Name(af *AudioFile) string {
-	var parts []string
-
-	parts = append(parts, "A") // UE5 convention
+		count := nameCounts[key]
+		nameCounts[key]++
 
-	if ap.config.PackName != "" {
-		packName := ap.cleanNameWithCase(ap.config.PackName)
-		if packName != "" {
-			parts = append(parts, packName)
+		if count > 0 || ap.destinationTaken(af) {
+			next, err := ap.resolveCollision(af, baseName, count)
+			if err != nil {
+				return err
+			}
+			if next > nameCounts[key] {
+				nameCounts[key] = next
+			}
 		}
 	}
 
-	// strip SFX_ prefix since it's implied
-	category := strings.TrimPrefix(af.Category, "SFX_")
-	if category != "" {
-		category = ap.cleanNamePart(category)
-		parts = append(parts, category)
-	}
+	return nil
+}
 
-	if af.SubCategory != "" {
-		subCat := ap.cleanNamePart(af.SubCategory)
-		if subCat != "" {
-			parts = append(parts, subCat)
-		}
+func (ap *AudioProcessor) generateUE5Name(af *AudioFile) string {
+	ext := fileExt(af)
+	if af.AlreadyNamed {
+		// renaming it again would only stack another prefix on
+		return strings.TrimSuffix(af.OriginalName, filepath.Ext(af.OriginalName)) + ext
 	}
 
-	newName := strings.Join(parts, "_")
-
-	// make sure it starts with A_ (just in case)
-	if !strings.HasPrefix(newName, "A_") {
-		newName = "A_" + strings.TrimPrefix(newName, "A")
-	}
+	return ap.renderName(ap.templateValues(af)) + ext
+}
 
-	ext := filepath.Ext(af.OriginalName)
-	return newName + ext
+// renderName fills in the name template, without an extension, behind the
+// category's prefix
+func (ap *AudioProcessor) renderName(values map[string]string) string {
+	return withAssetPrefix(renderTemplate(ap.nameTemplate(), values), values["prefix"])
 }
 
 func (ap *AudioProcessor) cleanName(name string) string {
+	name = ap.replaceUnreadableWords(ap.normalizeName(name))
 	name = strings.ReplaceAll(name, "-", "_")
 
 	reg := regexp.MustCompile(`[^a-zA-Z0-9_]`)
@@ -403,6 +975,7 @@ func (ap *AudioProcessor) cleanName(name string) string {
 }
 
 func (ap *AudioProcessor) cleanNamePart(name string) string {
+	name = ap.replaceUnreadableWords(ap.normalizeName(name))
 	name = strings.ReplaceAll(name, "-", "_")
 	name = strings.ReplaceAll(name, " ", "_")
 
@@ -415,23 +988,15 @@ func (ap *AudioProcessor) cleanNamePart(name string) string {
 	name = reg.ReplaceAllString(name, "_")
 
 	name = strings.Trim(name, "_")
-
-	words := strings.Split(name, "_")
-	for i, word := range words {
-		if len(word) > 0 {
-			// keep numbers as-is, capitalize words
-			if word[0] >= '0' && word[0] <= '9' {
-				words[i] = word
-			} else {
-				words[i] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
-			}
-		}
+	if name == "" {
+		return ""
 	}
 
-	return strings.Join(words, "_")
+	return formatWords(strings.Split(name, "_"), ap.caseStyle(), "_")
 }
 
 func (ap *AudioProcessor) cleanNameWithCase(name string) string {
+	name = ap.replaceUnreadableWords(ap.normalizeName(name))
 	reg := regexp.MustCompile(`[^a-zA-Z0-9\s\-_]`)
 	name = reg.ReplaceAllString(name, "")
 
@@ -443,6 +1008,10 @@ func (ap *AudioProcessor) cleanNameWithCase(name string) string {
 
 	words := strings.Fields(name)
 
+	if style := ap.caseStyle(); style != caseTitle {
+		return formatWords(words, style, "")
+	}
+
 	for i, word := range words {
 		if len(word) > 0 {
 			words[i] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
@@ -453,118 +1022,266 @@ func (ap *AudioProcessor) cleanNameWithCase(name string) string {
 }
 
 func (ap *AudioProcessor) displayPreview() {
-	fmt.Println("\n=== Preview of Changes ===")
-
-	// Group by category
-	categoryGroups := make(map[string][]*AudioFile)
-	for i := range ap.audioFiles {
-		cat := ap.audioFiles[i].Category
-		if cat == "" {
-			cat = "Uncategorized"
-		}
-		categoryGroups[cat] = append(categoryGroups[cat], &ap.audioFiles[i])
-	}
+	ap.log.Infoln("\n=== Preview of Changes ===")
 
-	// Sort categories
-	categories := make([]string, 0, len(categoryGroups))
-	for cat := range categoryGroups {
-		categories = append(categories, cat)
-	}
-	sort.Strings(categories)
+	categoryGroups, categories := ap.groupByCategory()
 
 	for _, cat := range categories {
 		files := categoryGroups[cat]
-		fmt.Printf("\n[%s] (%d files)\n", cat, len(files))
+		ap.log.Infof("\n[%s] (%d files)\n", cat, len(files))
 		for _, af := range files {
-			fmt.Printf("  %s\n", af.OriginalName)
-			fmt.Printf("  → %s\n", af.NewName)
+			ap.log.Infof("  %s\n", af.OriginalName)
+			switch af.DuplicateStatus {
+			case duplicateDropped:
+				if ap.config.DedupeAction == dedupeDelete {
+					ap.log.Infof("  ✗ delete (duplicate of %s)\n", filepath.Base(af.DuplicateOf))
+				} else {
+					ap.log.Infof("  → %s/%s (duplicate of %s)\n", duplicatesDir, af.NewName, filepath.Base(af.DuplicateOf))
+				}
+			case duplicateKept:
+				ap.log.Infof("  → %s (kept, best of duplicates)\n", af.NewName)
+			default:
+				if af.SkipReason == skipNameTaken {
+					ap.log.Infof("  ✗ skipped, %s is already taken\n", af.NewName)
+					break
+				}
+				if af.SkipReason != "" {
+					ap.log.Infof("  ✗ skipped, %s\n", af.SkipReason)
+					break
+				}
+				ap.log.Infof("  → %s\n", af.NewName)
+			}
+			if af.Unresolved {
+				ap.log.Infof("    Category: %s (unresolved, best guess %s at %.2f)\n", strings.TrimPrefix(af.Category, "SFX_"), strings.TrimPrefix(af.GuessedCategory, "SFX_"), af.Confidence)
+			} else {
+				ap.log.Infof("    Category: %s (%.2f)\n", strings.TrimPrefix(af.Category, "SFX_"), af.Confidence)
+			}
+			if af.Override != "" {
+				ap.log.Infof("    Override: %s\n", af.Override)
+			}
 			if af.AudioMeta != nil {
 				if af.AudioMeta.Duration > 0 {
-					fmt.Printf("    Duration: %v", af.AudioMeta.Duration.Round(time.Millisecond))
+					ap.log.Infof("    Duration: %v", af.AudioMeta.Duration.Round(time.Millisecond))
 				}
 				if af.AudioMeta.SampleRate > 0 {
-					fmt.Printf(" | %dHz", af.AudioMeta.SampleRate)
+					ap.log.Infof(" | %dHz", af.AudioMeta.SampleRate)
 				}
 				if af.AudioMeta.Channels > 0 {
-					fmt.Printf(" | %dch", af.AudioMeta.Channels)
+					ap.log.Infof(" | %dch", af.AudioMeta.Channels)
 				}
 				if af.AudioMeta.BitDepth > 0 {
-					fmt.Printf(" | %dbit", af.AudioMeta.BitDepth)
+					ap.log.Infof(" | %dbit", af.AudioMeta.BitDepth)
+				}
+				if af.AudioMeta.BPM > 0 {
+					ap.log.Infof(" | %.1f BPM", af.AudioMeta.BPM)
+				}
+				if af.AudioMeta.Key != "" {
+					ap.log.Infof(" | %s", af.AudioMeta.Key)
+				}
+				ap.log.Infoln()
+				if tidy.NeedsTrim(af.AudioMeta) {
+					ap.log.Infof("    Trim: %.2fs lead, %.2fs tail\n",
+						float64(af.AudioMeta.SilenceLeadMs)/1000, float64(af.AudioMeta.SilenceTailMs)/1000)
+				}
+				if ix := af.AudioMeta.IXML; ix != nil {
+					var fields []string
+					if ix.Project != "" {
+						fields = append(fields, "Project: "+ix.Project)
+					}
+					if ix.Scene != "" {
+						fields = append(fields, "Scene: "+ix.Scene)
+					}
+					if ix.Take != "" {
+						fields = append(fields, "Take: "+ix.Take)
+					}
+					if len(ix.TrackNames) > 0 {
+						fields = append(fields, "Tracks: "+strings.Join(ix.TrackNames, ", "))
+					}
+					if ix.Note != "" {
+						fields = append(fields, "Note: "+ix.Note)
+					}
+					if len(fields) > 0 {
+						ap.log.Infof("    iXML: %s\n", strings.Join(fields, " | "))
+					}
 				}
-				fmt.Println()
 			}
 			if len(af.Tags) > 0 {
-				fmt.Printf("    Tags: %s\n", strings.Join(af.Tags, ", "))
+				ap.log.Infof("    Tags: %s\n", strings.Join(af.Tags, ", "))
 			}
 		}
 	}
 }
 
-func (ap *AudioProcessor) applyChanges() error {
-	fmt.Println("\n=== Applying Changes ===")
+// groupByCategory groups files by category and returns the group names sorted
+func (ap *AudioProcessor) groupByCategory() (map[string][]*AudioFile, []string) {
+	categoryGroups := make(map[string][]*AudioFile)
+	for i := range ap.audioFiles {
+		cat := ap.audioFiles[i].Category
+		if cat == "" {
+			cat = "Uncategorized"
+		}
+		categoryGroups[cat] = append(categoryGroups[cat], &ap.audioFiles[i])
+	}
+
+	categories := make([]string, 0, len(categoryGroups))
+	for cat := range categoryGroups {
+		categories = append(categories, cat)
+	}
+	sort.Strings(categories)
+
+	return categoryGroups, categories
+}
+
+// applyChanges moves every file into place. Cancelling ctx stops it between
+// two files, never in the middle of a move, and leaves ap.audioFiles with
+// only the files it got to.
+func (ap *AudioProcessor) applyChanges(ctx context.Context) error {
+	ap.log.Infoln("\n=== Applying Changes ===")
 
 	total := len(ap.audioFiles)
 	if total == 0 {
 		return nil
 	}
 
-	bar := progressbar.NewOptions(total,
-		progressbar.OptionSetDescription("Moving files"),
-		progressbar.OptionSetWidth(50),
-		progressbar.OptionShowCount(),
-		progressbar.OptionShowIts(),
-		progressbar.OptionSetItsString("files"),
-	)
+	bar := ap.newProgress(total, "Moving files")
 
+	var tagFailures, sidecarFailures []string
+	unchanged := 0
+	var interrupted error
 	for i := range ap.audioFiles {
+		if interrupted = ctx.Err(); interrupted != nil {
+			ap.log.Warnf("\nInterrupted after %d of %d files, the rest weren't touched\n", i, total)
+			ap.audioFiles = ap.audioFiles[:i]
+			break
+		}
 		af := &ap.audioFiles[i]
 
-		// Determine output path
-		var outputPath string
-		if ap.config.Organize {
-			// Organize by category
-			categoryDir := ap.cleanName(af.Category)
-			if categoryDir == "" {
-				categoryDir = "Uncategorized"
+		if af.DuplicateStatus == duplicateDropped {
+			if err := ap.dropDuplicate(af); err != nil {
+				bar.Finish()
+				return fmt.Errorf("failed to drop duplicate %s: %w", af.OriginalName, err)
 			}
-			outputPath = filepath.Join(ap.config.OutputDir, categoryDir, af.NewName)
-		} else {
-			// Keep in same structure
-			relPath, err := filepath.Rel(ap.config.SourceDir, af.OriginalPath)
-			if err != nil {
-				relPath = af.NewName
+			ap.emitFile(af, statusDropped)
+			bar.Add(1)
+			continue
+		}
+
+		if af.SkipReason != "" {
+			ap.emitFile(af, statusSkipped)
+			bar.Add(1)
+			continue
+		}
+
+		// already has the right name in the right folder, nothing to move
+		if ap.alreadyInPlace(af) {
+			if ap.config.Sidecar {
+				if err := ap.writeSidecar(af.OriginalPath, af); err != nil {
+					sidecarFailures = append(sidecarFailures, fmt.Sprintf("%s: %v", af.NewName, err))
+				}
 			}
-			outputPath = filepath.Join(ap.config.OutputDir, filepath.Dir(relPath), af.NewName)
+			ap.emitFile(af, statusUnchanged)
+			unchanged++
+			bar.Add(1)
+			continue
+		}
+
+		outputPath := ap.outputPath(af)
+
+		// names were checked in generateNewNames, but the disk may have changed since
+		if ap.collisionStrategy() != collisionOverwrite && ap.destinationTaken(af) {
+			if ap.collisionStrategy() == collisionSkip {
+				af.SkipReason = skipNameTaken
+				ap.emitFile(af, statusSkipped)
+				bar.Add(1)
+				continue
+			}
+			bar.Finish()
+			return fmt.Errorf("refusing to overwrite %s", outputPath)
 		}
 
 		// Create directory if needed
-		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		if err := ap.mkdirAll(filepath.Dir(outputPath)); err != nil {
 			bar.Finish()
 			return fmt.Errorf("failed to create directory: %w", err)
 		}
 
-		// Skip if source and destination are the same
-		if af.OriginalPath == outputPath {
-			bar.Add(1)
-			continue
-		}
+		times, timesOK := captureTimes(af.OriginalPath)
 
 		// Rename/move file
-		if err := os.Rename(af.OriginalPath, outputPath); err != nil {
-			// If rename fails (cross-device), try copy + delete
-			if err := ap.moveFile(af.OriginalPath, outputPath); err != nil {
-				bar.Finish()
-				return fmt.Errorf("failed to move file %s: %w", af.OriginalName, err)
+		if err := ap.relocate(af.OriginalPath, outputPath); err != nil {
+			bar.Finish()
+			return fmt.Errorf("failed to move file %s: %w", af.OriginalName, err)
+		}
+		if err := ap.applyFileMode(outputPath); err != nil {
+			ap.log.Warnf("Warning: could not set the permissions of %s: %v\n", af.NewName, err)
+		}
+
+		// writing tags would replace the link with a tagged copy
+		if ap.config.WriteTags && !isSymlink(outputPath) {
+			if err := tidy.WriteTags(outputPath, af.Category, strings.Join(af.Tags, ", ")); err != nil && !errors.Is(err, tidy.ErrTagWriteUnsupported) {
+				// the file is moved and intact, it just doesn't get tags
+				tagFailures = append(tagFailures, fmt.Sprintf("%s: %v", af.NewName, err))
 			}
 		}
 
+		// the manifest's size is what -verify expects, so take it after the tags
+		if info, err := os.Stat(outputPath); err == nil {
+			af.Size = info.Size()
+		}
+
+		if ap.config.Sidecar {
+			if err := ap.writeSidecar(outputPath, af); err != nil {
+				sidecarFailures = append(sidecarFailures, fmt.Sprintf("%s: %v", af.NewName, err))
+			}
+		}
+
+		// last, after anything that rewrote the file; a link has no times of its own to keep
+		if ap.config.PreserveTimes && timesOK && !isSymlink(outputPath) {
+			if err := times.restore(outputPath); err != nil {
+				ap.log.Warnf("Warning: could not keep the modification time of %s: %v\n", af.NewName, err)
+			}
+		}
+
+		ap.emitFile(af, statusRenamed)
+
 		bar.Add(1)
 	}
 
 	bar.Finish()
-	fmt.Println()
+	ap.log.Infoln()
 
-	return nil
+	if unchanged > 0 {
+		ap.log.Infof("%d files already had the right name and place, left untouched\n", unchanged)
+	}
+	for _, failure := range tagFailures {
+		ap.log.Warnf("Warning: could not write tags to %s\n", failure)
+	}
+	for _, failure := range sidecarFailures {
+		ap.log.Warnf("Warning: could not write sidecar for %s\n", failure)
+	}
+
+	return interrupted
+}
+
+// outputPath returns where a file ends up when changes are applied
+func (ap *AudioProcessor) outputPath(af *AudioFile) string {
+	if af.DuplicateStatus == duplicateDropped {
+		return filepath.Join(ap.config.OutputDir, duplicatesDir, af.NewName)
+	}
+
+	return filepath.Join(ap.config.OutputDir, ap.layoutDir(af, ap.config.Layout), af.NewName)
+}
+
+// alreadyInPlace reports whether a file's output path is where it is now.
+// The paths are compared cleaned and absolute, since a source given as
+// ./sfx and the output joined from it spell the same file differently.
+func (ap *AudioProcessor) alreadyInPlace(af *AudioFile) bool {
+	out, err := filepath.Abs(ap.outputPath(af))
+	if err != nil {
+		return false
+	}
+	current, err := filepath.Abs(af.OriginalPath)
+	return err == nil && out == current
 }
 
 func (ap *AudioProcessor) moveFile(src, dst string) error {
@@ -574,7 +1291,7 @@ func (ap *AudioProcessor) moveFile(src, dst string) error {
 		return err
 	}
 
-	if err := os.WriteFile(dst, data, 0644); err != nil {
+	if err := ap.writeFile(dst, data); err != nil {
 		return err
 	}
 
@@ -582,30 +1299,83 @@ func (ap *AudioProcessor) moveFile(src, dst string) error {
 }
 
 func (ap *AudioProcessor) createManifest() error {
-	manifestPath := filepath.Join(ap.config.OutputDir, "manifest.json")
+	manifestPath := ap.manifestPath()
+
+	// incremental runs append to what the previous manifest already had
+	files := ap.audioFiles
+	if len(ap.priorFiles) > 0 {
+		files = append(append([]AudioFile{}, ap.priorFiles...), ap.audioFiles...)
+	}
+
+	if err := ap.writeManifest(manifestPath, files, ap.fileErrors); err != nil {
+		return err
+	}
+
+	ap.log.Infof("\n✓ Created manifest: %s\n", manifestPath)
+	return nil
+}
+
+// writeManifest writes files with their totals and stats as a manifest to path,
+// listing the files that failed analysis if there are any. A path ending in
+// .gz gets a gzip-compressed manifest.
+func (ap *AudioProcessor) writeManifest(path string, files []AudioFile, failed []fileError) error {
+	// groups are matched up by original path, before the paths are made relative
+	groups := duplicateGroupsIn(ap.duplicateReport, files)
+	files, failed = ap.portableFiles(files), ap.portableErrors(failed)
 
 	manifest := map[string]interface{}{
-		"total_files": len(ap.audioFiles),
-		"categories":  ap.getCategoryStats(),
-		"files":       ap.audioFiles,
+		"total_files": len(files),
+		"categories":  getCategoryStats(files),
+	}
+
+	if ap.config.Dedupe {
+		manifest["dedupe_action"] = ap.config.DedupeAction
 	}
 
-	data, err := json.MarshalIndent(manifest, "", "  ")
+	manifest["pack_stats"] = computePackStats(files)
+
+	if groups = ap.portableGroups(groups); len(groups) > 0 {
+		manifest["duplicate_groups"] = groups
+	}
+	if !ap.config.Catalog {
+		manifest["renames"] = countRenames(files) // nothing is renamed in a catalog
+	}
+
+	if ap.config.TagReport {
+		manifest["tag_histogram"] = tagHistogram(files)
+	}
+
+	if len(failed) > 0 {
+		manifest["failed_files"] = failed
+	}
+
+	f, err := ap.createFile(path)
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, gzipExt) {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	if err := encodeManifest(w, manifest, files); err != nil {
+		f.Close()
 		return err
 	}
-
-	fmt.Printf("\n✓ Created manifest: %s\n", manifestPath)
-	return nil
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
 }
 
-func (ap *AudioProcessor) getCategoryStats() map[string]int {
+func getCategoryStats(files []AudioFile) map[string]int {
 	stats := make(map[string]int)
-	for _, af := range ap.audioFiles {
+	for _, af := range files {
 		cat := af.Category
 		if cat == "" {
 			cat = "Uncategorized"
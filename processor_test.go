@@ -1,7 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
 )
 
 func TestCleanName(t *testing.T) {
@@ -61,79 +72,6 @@ func TestCleanNamePart(t *testing.T) {
 	}
 }
 
-func TestInferCategory(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{"scream_male", "SFX_Voice"},
-		{"voice_dialogue", "SFX_Voice"},
-		{"creature_roar", "SFX_Creature"},
-		{"monster_growl", "SFX_Creature"},
-		{"gun_shot", "SFX_Weapon"},
-		{"weapon_fire", "SFX_Weapon"},
-		{"explosion_impact", "SFX_Impact"},
-		{"footstep_walk", "SFX_Footstep"},
-		{"car_engine", "SFX_Vehicle"},
-		{"door_creak", "SFX_Object"},
-		{"button_click", "SFX_UI"},
-		{"wind_ambient", "Ambient"},
-		{"music_track", "Music"}, // music and track keywords now supported
-		{"siren_alarm", "SFX_Alarm"},
-		{"random_sound", "SFX"}, // default fallback
-		{"", "SFX"},
-		{"drone_sustained", "SFX_Drone"},
-		{"loop_music", "Music"},
-		{"riser_tension", "SFX_Riser"},
-		{"whoosh_wind", "SFX_Whoosh"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			result := InferCategory(tt.input)
-			if result != tt.expected {
-				t.Errorf("InferCategory(%q) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
-func TestNormalizeCategory(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{"SFX_Voice", "SFX_Voice"}, // NormalizeCategory uses map lookup, preserves case
-		{"SFX_Creature", "SFX_Creature"},
-		{"SFX_Weapon", "SFX_Weapon"},
-		{"SFX_Impact", "SFX_Impact"},
-		{"SFX_Footstep", "SFX_Footstep"},
-		{"SFX_Vehicle", "SFX_Vehicle"},
-		{"SFX_Mechanical", "SFX_Mechanical"},
-		{"SFX_Object", "SFX_Object"},
-		{"SFX_UI", "SFX_UI"}, // SFX_UI not in map, already has "_", so returned as-is
-		{"UI", "UI"},         // UI maps to "UI" in CategoryNormalization
-		{"SFX_Alarm", "SFX_Alarm"},
-		{"Ambient", "Ambient"},
-		{"Music", "Music"},
-		{"SFX", "SFX"},
-		{"PE", "SFX_Percussion"}, // PE maps to SFX_Percussion
-		{"DRONE", "SFX_Drone"},
-		{"LOOP", "Music"},
-		{"unknown", "SFX_UNKNOWN"}, // unknown gets SFX_ prefix and uppercased
-		{"", "SFX_"},               // empty: "" -> "" -> not in map -> !contains("_") -> "SFX_" + "" = "SFX_"
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			result := NormalizeCategory(tt.input)
-			if result != tt.expected {
-				t.Errorf("NormalizeCategory(%q) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestGenerateUE5Name(t *testing.T) {
 	ap := NewAudioProcessor(Config{PackName: "TestPack"})
 
@@ -208,6 +146,36 @@ func TestGenerateUE5Name(t *testing.T) {
 	}
 }
 
+func TestGenerateUE5NameBPM(t *testing.T) {
+	loop := AudioFile{
+		OriginalName: "beat_loop.wav",
+		Category:     "Music",
+		SubCategory:  "beat_loop",
+		AudioMeta:    &tidy.AudioMetadata{BPM: 127.6},
+	}
+	noTempo := AudioFile{OriginalName: "theme.wav", Category: "Music", SubCategory: "theme"}
+
+	tests := []struct {
+		name      string
+		bpmInName bool
+		file      AudioFile
+		expected  string
+	}{
+		{"off", false, loop, "A_TestPack_Music_Beat_Loop.wav"},
+		{"on", true, loop, "A_TestPack_Music_Beat_Loop_128BPM.wav"},
+		{"on without tempo", true, noTempo, "A_TestPack_Music_Theme.wav"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: "TestPack", BPMInName: tt.bpmInName})
+			if result := ap.generateUE5Name(&tt.file); result != tt.expected {
+				t.Errorf("generateUE5Name() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestParseFile(t *testing.T) {
 	ap := NewAudioProcessor(Config{PackName: "TestPack"})
 
@@ -230,7 +198,7 @@ func TestParseFile(t *testing.T) {
 			originalName:   "Scream_SFXB.1471.wav",
 			expectedID:     "1471",
 			expectedSource: "SFXB",
-			expectedCat:    "SFX_Voice", // NormalizeCategory preserves case
+			expectedCat:    "SFX_Voice", // tidy.NormalizeCategory preserves case
 		},
 		{
 			name:           "dash_category",
@@ -239,11 +207,25 @@ func TestParseFile(t *testing.T) {
 			expectedSource: "",
 			expectedCat:    "SFX_FX", // FX becomes category, Impact becomes subcategory
 		},
+		{
+			name:           "short_alphanumeric_source",
+			originalName:   "door_slam_Sx2.wav",
+			expectedID:     "",
+			expectedSource: "Sx2",
+			expectedCat:    "SFX_Impact",
+		},
+		{
+			name:           "short_mixed_case_word",
+			originalName:   "door_slam_Wood.wav",
+			expectedID:     "",
+			expectedSource: "", // a word, not a library code
+			expectedCat:    "SFX_Impact",
+		},
 		{
 			name:           "no_id_or_source",
 			originalName:   "test_sound.wav",
 			expectedID:     "",
-			expectedSource: "sound", // last underscore segment is treated as source
+			expectedSource: "", // "sound" is a word, not a library code
 			expectedCat:    "SFX",
 		},
 	}
@@ -271,12 +253,19 @@ func TestParseFile(t *testing.T) {
 func TestDetectDuplicates(t *testing.T) {
 	ap := NewAudioProcessor(Config{PackName: "TestPack"})
 
-	// create test files with same fingerprint
+	// create test files with same fingerprint, the first two with the same content
+	dir := t.TempDir()
 	fingerprint := "test_fingerprint_123"
 	ap.audioFiles = []AudioFile{
-		{OriginalName: "file1.wav", AudioMeta: &AudioMetadata{Fingerprint: fingerprint}},
-		{OriginalName: "file2.wav", AudioMeta: &AudioMetadata{Fingerprint: fingerprint}},
-		{OriginalName: "file3.wav", AudioMeta: &AudioMetadata{Fingerprint: "different_fp"}},
+		{OriginalName: "file1.wav", AudioMeta: &tidy.AudioMetadata{Fingerprint: fingerprint}},
+		{OriginalName: "file2.wav", AudioMeta: &tidy.AudioMetadata{Fingerprint: fingerprint}},
+		{OriginalName: "file3.wav", AudioMeta: &tidy.AudioMetadata{Fingerprint: "different_fp"}},
+	}
+	for i := range ap.audioFiles {
+		ap.audioFiles[i].OriginalPath = filepath.Join(dir, ap.audioFiles[i].OriginalName)
+		if err := os.WriteFile(ap.audioFiles[i].OriginalPath, []byte(ap.audioFiles[i].AudioMeta.Fingerprint), 0644); err != nil {
+			t.Fatal(err)
+		}
 	}
 	ap.fingerprints[fingerprint] = []int{0, 1}
 
@@ -294,6 +283,44 @@ func TestDetectDuplicates(t *testing.T) {
 	}
 }
 
+func TestDetectDuplicatesStableGroups(t *testing.T) {
+	dir := t.TempDir()
+	// map order varies between runs, so check a few times
+	for run := 0; run < 20; run++ {
+		ap := NewAudioProcessor(Config{PackName: "TestPack"})
+		ap.log.out = io.Discard
+		for i, fp := range []string{"ccc", "aaa", "ccc", "bbb", "aaa", "bbb", "ddd"} {
+			path := filepath.Join(dir, fmt.Sprintf("file%d.wav", i))
+			if err := os.WriteFile(path, []byte(fp), 0644); err != nil {
+				t.Fatal(err)
+			}
+			ap.audioFiles = append(ap.audioFiles, AudioFile{
+				OriginalPath: path,
+				OriginalName: filepath.Base(path),
+				AudioMeta:    &tidy.AudioMetadata{Fingerprint: fp},
+			})
+		}
+		ap.indexFingerprints()
+		ap.detectDuplicates()
+
+		want := []string{
+			"duplicate-group-3", "duplicate-group-1", "duplicate-group-3",
+			"duplicate-group-2", "duplicate-group-1", "duplicate-group-2", "",
+		}
+		for i, af := range ap.audioFiles {
+			got := ""
+			for _, tag := range af.Tags {
+				if strings.HasPrefix(tag, "duplicate-group-") {
+					got = tag
+				}
+			}
+			if got != want[i] {
+				t.Fatalf("run %d: %s group = %q, want %q", run, af.OriginalName, got, want[i])
+			}
+		}
+	}
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -302,3 +329,184 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
+
+// fakeAudio stands in for a file that doesn't decode as audio but is big
+// enough not to be taken for an empty one
+var fakeAudio = bytes.Repeat([]byte("not audio "), 16)
+
+// buildTestWAV returns a minimal PCM WAV file around pcm
+func buildTestWAV(sampleRate, channels, bitDepth int, pcm []byte) []byte {
+	blockAlign := channels * bitDepth / 8
+	body := []byte("WAVE")
+	body = append(body, "fmt "...)
+	body = binary.LittleEndian.AppendUint32(body, 16)
+	body = binary.LittleEndian.AppendUint16(body, 1) // PCM
+	body = binary.LittleEndian.AppendUint16(body, uint16(channels))
+	body = binary.LittleEndian.AppendUint32(body, uint32(sampleRate))
+	body = binary.LittleEndian.AppendUint32(body, uint32(sampleRate*blockAlign))
+	body = binary.LittleEndian.AppendUint16(body, uint16(blockAlign))
+	body = binary.LittleEndian.AppendUint16(body, uint16(bitDepth))
+	body = append(body, "data"...)
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(pcm)))
+	body = append(body, pcm...)
+	if len(pcm)%2 == 1 {
+		body = append(body, 0)
+	}
+
+	out := []byte("RIFF")
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(body)))
+	return append(out, body...)
+}
+
+// buildTestPCM returns 16-bit mono samples: lead silent samples, body samples
+// alternating between plus and minus amplitude, then tail silent samples
+func buildTestPCM(lead, body, tail int, amplitude int16) []byte {
+	var pcm []byte
+	for i := 0; i < lead+body+tail; i++ {
+		var v int16
+		if i >= lead && i < lead+body {
+			v = amplitude
+			if i%2 == 1 {
+				v = -amplitude
+			}
+		}
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+	}
+	return pcm
+}
+
+func TestWorkerCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int
+		total    int
+		expected int
+	}{
+		{"configured", 4, 100, 4},
+		{"capped_by_files", 16, 3, 3},
+		{"zero_defaults_to_cpus", 0, 100000, runtime.NumCPU()},
+		{"negative_defaults_to_cpus", -2, 100000, runtime.NumCPU()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{Workers: tt.workers})
+			if result := ap.workerCount(tt.total); result != tt.expected {
+				t.Errorf("workerCount(%d) with Workers=%d = %d, want %d", tt.total, tt.workers, result, tt.expected)
+			}
+		})
+	}
+}
+
+// syntheticFiles builds n files with varied names, categories and some
+// duplicates, without touching the disk
+func syntheticFiles(n int) []AudioFile {
+	words := []string{"scream", "door_creak", "footstep_gravel", "wind_howl", "gun_shot", "ui_click", "monster_roar", "rain_loop"}
+	files := make([]AudioFile, n)
+	for i := range files {
+		name := fmt.Sprintf("%s_%d_STUDIO.%d.wav", words[i%len(words)], i%97, i)
+		files[i] = AudioFile{OriginalPath: "/library/" + name, OriginalName: name}
+	}
+	return files
+}
+
+func TestParseAndNameParallelMatchesSequential(t *testing.T) {
+	run := func(workers int) []AudioFile {
+		ap := NewAudioProcessor(Config{PackName: "Pack", Workers: workers, OutputDir: t.TempDir()})
+		ap.log.out = io.Discard
+		ap.audioFiles = syntheticFiles(2000)
+		ap.parseFiles()
+		if err := ap.generateNewNames(); err != nil {
+			t.Fatal(err)
+		}
+		return ap.audioFiles
+	}
+
+	sequential, parallel := run(1), run(8)
+	for i := range sequential {
+		s, p := sequential[i], parallel[i]
+		if s.NewName != p.NewName || s.Category != p.Category || strings.Join(s.Tags, ",") != strings.Join(p.Tags, ",") {
+			t.Fatalf("file %d: parallel %s/%s %v, sequential %s/%s %v", i, p.NewName, p.Category, p.Tags, s.NewName, s.Category, s.Tags)
+		}
+	}
+}
+
+func BenchmarkParseAndName(b *testing.B) {
+	files := syntheticFiles(50000)
+
+	pools := []int{1}
+	if n := runtime.NumCPU(); n > 1 {
+		pools = append(pools, n)
+	}
+
+	for _, workers := range pools {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			ap := NewAudioProcessor(Config{PackName: "Pack", Workers: workers, OutputDir: b.TempDir()})
+			ap.log.out = io.Discard
+			for i := 0; i < b.N; i++ {
+				ap.audioFiles = append(ap.audioFiles[:0], files...)
+				ap.parseFiles()
+				if err := ap.generateNewNames(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFileDashSegments(t *testing.T) {
+	ap := NewAudioProcessor(Config{PackName: "Pack"})
+
+	tests := []struct {
+		originalName string
+		expectedCat  string
+		expectedSub  string
+		expectedName string
+	}{
+		{"FX-Impact.wav", "SFX_FX", "Impact", "A_Pack_Fx_Impact.wav"},
+		{"FX-Impact-Metal.wav", "SFX_FX", "Impact_Metal", "A_Pack_Fx_Impact_Metal.wav"},
+		{"AMB-Forest--Night.wav", "SFX_AMB", "Forest_Night", "A_Pack_Amb_Forest_Night.wav"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.originalName, func(t *testing.T) {
+			af := AudioFile{OriginalName: tt.originalName}
+			ap.parseFile(&af)
+
+			if af.Category != tt.expectedCat {
+				t.Errorf("Category = %q, want %q", af.Category, tt.expectedCat)
+			}
+			if af.SubCategory != tt.expectedSub {
+				t.Errorf("SubCategory = %q, want %q", af.SubCategory, tt.expectedSub)
+			}
+			if got := ap.generateUE5Name(&af); got != tt.expectedName {
+				t.Errorf("generateUE5Name() = %q, want %q", got, tt.expectedName)
+			}
+		})
+	}
+}
+
+func TestProcessNoAnalyze(t *testing.T) {
+	src := t.TempDir()
+	// not audio at all, analysis would fail on it
+	if err := os.WriteFile(filepath.Join(src, "door_creak.wav"), []byte("not audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: src, PackName: "TestPack", Layout: layoutCategory, DryRun: true, NoAnalyze: true, Workers: 1})
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if len(ap.fileErrors) != 0 {
+		t.Errorf("fileErrors = %v, analysis should have been skipped", ap.fileErrors)
+	}
+	af := ap.audioFiles[0]
+	if af.AudioMeta != nil {
+		t.Errorf("AudioMeta = %+v, want none without analysis", af.AudioMeta)
+	}
+	if af.NewName != "A_TestPack_Object_Door_Creak.wav" {
+		t.Errorf("NewName = %q, want A_TestPack_Object_Door_Creak.wav from the filename", af.NewName)
+	}
+}
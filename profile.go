@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+// ParseProfile pulls category, subcategory, source and ID out of one vendor's
+// filename schema. Patterns are tried in order against the filename without its
+// extension and the first match wins. They use the named groups category,
+// subcategory, source and id, any of which may be left out.
+type ParseProfile struct {
+	Name        string
+	Description string
+	Patterns    []*regexp.Regexp
+	Categories  map[string]string // upper-cased vendor category -> our category
+}
+
+// parsedName is what a profile or the default heuristic made of a filename
+type parsedName struct {
+	Category    string
+	SubCategory string
+	Source      string
+	ID          string
+	Explicit    bool // the name spells out its category, it wasn't guessed from keywords
+}
+
+var parseProfiles = map[string]*ParseProfile{}
+
+func registerProfile(p *ParseProfile) {
+	parseProfiles[p.Name] = p
+}
+
+// lookupProfile returns the registered profile called name
+func lookupProfile(name string) (*ParseProfile, error) {
+	p, ok := parseProfiles[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(profileNames(), ", "))
+	}
+	return p, nil
+}
+
+// profileNames lists the registered profiles alphabetically
+func profileNames() []string {
+	names := make([]string, 0, len(parseProfiles))
+	for name := range parseProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse matches name against the profile's patterns. Without a category group,
+// or an empty one, the category is guessed from the subcategory like the
+// default heuristic does.
+func (p *ParseProfile) Parse(name string) (parsedName, bool) {
+	for _, re := range p.Patterns {
+		m := re.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+
+		group := func(g string) string {
+			if i := re.SubexpIndex(g); i >= 0 {
+				return strings.TrimSpace(m[i])
+			}
+			return ""
+		}
+
+		parsed := parsedName{
+			Category:    group("category"),
+			SubCategory: group("subcategory"),
+			Source:      group("source"),
+			ID:          group("id"),
+		}
+		if parsed.Category != "" {
+			parsed.Explicit = true
+			if mapped, ok := p.Categories[strings.ToUpper(parsed.Category)]; ok {
+				parsed.Category = mapped
+			}
+		} else {
+			parsed.Category = tidy.InferCategory(parsed.SubCategory)
+		}
+		return parsed, true
+	}
+	return parsedName{}, false
+}
+
+// parseDefaultName is the schema-less heuristic: a trailing number matching
+// one of idPatterns (.12345, _12345...) is the ID,
+// the last underscore segment the source if it looks like a library code, and
+// anything before a dash the category, with the dash segments after it
+// joined into the subcategory
+func parseDefaultName(name string, idPatterns []*regexp.Regexp, sourcePattern *regexp.Regexp) parsedName {
+	var parsed parsedName
+
+	// grab the ID (usually at the end like .12345)
+	name, parsed.ID = splitID(name, idPatterns)
+
+	// last underscore segment is often the source/library code, but just as often
+	// the last word of the description
+	parts := strings.Split(name, "_")
+	if len(parts) > 1 && looksLikeSource(parts[len(parts)-1], sourcePattern) {
+		parsed.Source = parts[len(parts)-1]
+		name = strings.Join(parts[:len(parts)-1], "_")
+	}
+
+	// check for dash-separated category (e.g., "FX-Impact"), deeper levels
+	// like "FX-Impact-Metal" make an Impact_Metal subcategory
+	if strings.Contains(name, "-") {
+		segments := strings.Split(name, "-")
+		parsed.Category = segments[0]
+		var sub []string
+		for _, segment := range segments[1:] {
+			if segment != "" {
+				sub = append(sub, segment)
+			}
+		}
+		parsed.SubCategory = strings.Join(sub, "_")
+		parsed.Explicit = true
+		return parsed
+	}
+
+	// no dash, try to guess from the name
+	parsed.Category = tidy.InferCategory(name)
+	parsed.SubCategory = name
+	return parsed
+}
+
+// looksLikeSource reports whether a name segment is a library code like "BW",
+// "SFXB" or "Sx2" rather than a word: all caps, at most 4 letters and digits
+// with at least one digit, or matching the -source-pattern regex. Short mixed
+// case words like "Hit" or "Wood" are too common to guess, codes like "PSEx"
+// need the pattern.
+func looksLikeSource(segment string, pattern *regexp.Regexp) bool {
+	if segment == "" {
+		return false
+	}
+	if pattern != nil && pattern.MatchString(segment) {
+		return true
+	}
+
+	hasUpper, hasLower, hasDigit := false, false, false
+	for _, r := range segment {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		default:
+			return false // spaces, dashes, accents: part of a description
+		}
+	}
+
+	if hasUpper && !hasLower {
+		return true
+	}
+	return (hasUpper || hasLower) && hasDigit && len(segment) <= 4
+}
+
+func init() {
+	// Universal Category System, used by Soundly, BOOM and Pro Sound Effects:
+	// CatID_FXName_CreatorID_SourceID, where the CatID is an upper-case category
+	// short name followed by the subcategory, e.g. DOORWood_Creaky Open_JD_Haunted
+	registerProfile(&ParseProfile{
+		Name:        "ucs",
+		Description: "Universal Category System (CatID_FXName_CreatorID_SourceID)",
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^(?P<category>[A-Z]+)[A-Z][a-z][A-Za-z]*_(?P<subcategory>[^_]+?)(?:[ -](?P<id>\d+))?_(?P<source>[^_]+)(?:_[^_]*)?$`),
+		},
+		Categories: map[string]string{
+			"AMB":   "Ambient",
+			"MUSC":  "Music",
+			"VOX":   "SFX_Voice",
+			"CREA":  "SFX_Creature",
+			"GUN":   "SFX_Weapon",
+			"WEAP":  "SFX_Weapon",
+			"EXPL":  "SFX_Impact",
+			"FEET":  "SFX_Footstep",
+			"VEH":   "SFX_Vehicle",
+			"ALRM":  "SFX_Alarm",
+			"MECH":  "SFX_Mechanical",
+			"UI":    "UI",
+			"WHSH":  "SFX_Whoosh",
+			"DRONE": "SFX_Drone",
+		},
+	})
+
+	// older BOOM Library packs: an upper-case category word, a description,
+	// and an optional take number, e.g. "EXPLOSION Distant Rumble 03"
+	registerProfile(&ParseProfile{
+		Name:        "boom",
+		Description: "BOOM Library (CATEGORY Description 01)",
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^(?P<category>[A-Z]{2,})[ _](?P<subcategory>.+?)(?:[ _](?P<id>\d+))?$`),
+		},
+		Categories: map[string]string{
+			"AMBIENCE":   "Ambient",
+			"ATMOS":      "Ambient",
+			"CREATURES":  "SFX_Creature",
+			"EXPLOSION":  "SFX_Impact",
+			"EXPLOSIONS": "SFX_Impact",
+			"FOOTSTEPS":  "SFX_Footstep",
+			"GUNS":       "SFX_Weapon",
+			"WEAPONS":    "SFX_Weapon",
+			"VEHICLES":   "SFX_Vehicle",
+			"VOICES":     "SFX_Voice",
+			"WHOOSHES":   "SFX_Whoosh",
+		},
+	})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParseProfiles(t *testing.T) {
+	tests := []struct {
+		profile  string
+		input    string
+		wantOK   bool
+		expected parsedName
+	}{
+		{
+			profile:  "ucs",
+			input:    "DOORWood_Creaky Open_JD_Haunted",
+			wantOK:   true,
+			expected: parsedName{Category: "DOOR", SubCategory: "Creaky Open", Source: "JD", Explicit: true},
+		},
+		{
+			profile:  "ucs",
+			input:    "AMBForst_Morning Birds 02_KM",
+			wantOK:   true,
+			expected: parsedName{Category: "Ambient", SubCategory: "Morning Birds", Source: "KM", ID: "02", Explicit: true},
+		},
+		{
+			profile:  "ucs",
+			input:    "GUNAuto_Rifle Burst_BOOM_Assault",
+			wantOK:   true,
+			expected: parsedName{Category: "SFX_Weapon", SubCategory: "Rifle Burst", Source: "BOOM", Explicit: true},
+		},
+		{
+			profile: "ucs",
+			input:   "scream_male_SFXB",
+			wantOK:  false,
+		},
+		{
+			profile:  "boom",
+			input:    "EXPLOSION Distant Rumble 03",
+			wantOK:   true,
+			expected: parsedName{Category: "SFX_Impact", SubCategory: "Distant Rumble", ID: "03", Explicit: true},
+		},
+		{
+			profile:  "boom",
+			input:    "DOOR Metal Slam",
+			wantOK:   true,
+			expected: parsedName{Category: "DOOR", SubCategory: "Metal Slam", Explicit: true},
+		},
+		{
+			profile: "boom",
+			input:   "door_slam",
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile+"/"+tt.input, func(t *testing.T) {
+			p, err := lookupProfile(tt.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := p.Parse(tt.input)
+			if ok != tt.wantOK {
+				t.Fatalf("Parse() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.expected {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLookupProfile(t *testing.T) {
+	if _, err := lookupProfile("UCS"); err != nil {
+		t.Errorf("lookupProfile is case-sensitive: %v", err)
+	}
+	if _, err := lookupProfile("nope"); err == nil {
+		t.Error("lookupProfile(\"nope\") should fail")
+	}
+}
+
+func TestParseFileWithProfile(t *testing.T) {
+	tests := []struct {
+		name           string
+		profile        string
+		originalName   string
+		expectedCat    string
+		expectedSubCat string
+		expectedSource string
+	}{
+		{"profile match", "ucs", "VOXScrm_Female Terror_AB_Horror.wav", "SFX_Voice", "Female Terror", "AB"},
+		{"falls back to default", "ucs", "Scream_SFXB.1471.wav", "SFX_Voice", "Scream", "SFXB"},
+		{"no profile", "", "VOXScrm_Female Terror_AB_Horror.wav", "SFX_Voice", "VOXScrm_Female Terror_AB_Horror", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: "TestPack", Profile: tt.profile})
+			af := AudioFile{OriginalName: tt.originalName}
+			ap.parseFile(&af)
+
+			if af.Category != tt.expectedCat {
+				t.Errorf("Category = %q, want %q", af.Category, tt.expectedCat)
+			}
+			if af.SubCategory != tt.expectedSubCat {
+				t.Errorf("SubCategory = %q, want %q", af.SubCategory, tt.expectedSubCat)
+			}
+			if af.Source != tt.expectedSource {
+				t.Errorf("Source = %q, want %q", af.Source, tt.expectedSource)
+			}
+		})
+	}
+}
+
+func TestLooksLikeSource(t *testing.T) {
+	pattern := regexp.MustCompile(`^lib\d+$`)
+
+	tests := []struct {
+		segment string
+		pattern *regexp.Regexp
+		want    bool
+	}{
+		{"BW", nil, true},
+		{"SFXB", nil, true},
+		{"PSE2", nil, true},
+		{"Sx2", nil, true},
+		{"PSEx", nil, false},
+		{"PSEx", regexp.MustCompile(`^PSE`), true},
+		{"SfxB", nil, false},
+		{"sound", nil, false},
+		{"Sound", nil, false},
+		{"male", nil, false},
+		{"Hit", nil, false}, // short mixed case words are left to -source-pattern
+		{"Wood", nil, false},
+		{"123", nil, false},
+		{"Soundly", nil, false},
+		{"lib42", nil, false},
+		{"lib42", pattern, true},
+		{"", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			if got := looksLikeSource(tt.segment, tt.pattern); got != tt.want {
+				t.Errorf("looksLikeSource(%q) = %v, want %v", tt.segment, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/term"
+)
+
+// a plain progress line is printed every tenth of the way, or after this long
+// without one, whichever comes first
+const plainProgressInterval = 30 * time.Second
+
+// analysis of runs with at least estimateMinFiles files, mostly WAV and AIFF
+// (spectral analysis makes them the slow ones), gets a time estimate once the
+// first estimateSample files are done
+const (
+	estimateMinFiles = 100
+	estimateSample   = 20
+)
+
+// progress is what the analysis and move loops report to: a progress bar on a
+// terminal, or plain lines when stdout goes to a log
+type progress interface {
+	Add(n int) error
+	Finish() error
+	Clear() error // blank the bar's line for a log line, it's drawn again on the next Add
+}
+
+// newProgress returns the progress display for a loop over total files. On a
+// terminal it's a progress bar, hidden with -verbose where the per-file lines
+// would tear it apart. When stdout is a pipe or file, like in CI, it's a plain
+// line now and then instead of control characters. Nothing is shown with
+// -no-progress, -quiet, or while JSON lines are being written to stdout.
+func (ap *AudioProcessor) newProgress(total int, description string) progress {
+	if ap.config.NoProgress || ap.jsonOutput() || !ap.log.enabled(levelNormal) {
+		return noProgress{}
+	}
+	if !stdoutIsTerminal() {
+		return newPlainProgress(ap.log, total, description)
+	}
+	return progressbar.NewOptions(total,
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetWidth(50),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetItsString("files"),
+		progressbar.OptionSetPredictTime(true), // [elapsed:left]
+		progressbar.OptionShowElapsedTimeOnFinish(),
+		progressbar.OptionSetVisibility(ap.log.level == levelNormal),
+	)
+}
+
+// stdoutIsTerminal reports whether stdout is a terminal rather than a pipe or file
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+type noProgress struct{}
+
+func (noProgress) Add(int) error { return nil }
+func (noProgress) Finish() error { return nil }
+func (noProgress) Clear() error  { return nil }
+
+// plainProgress prints lines like "Analyzing audio files: 500/2000 (25%,
+// about 3m left)"
+type plainProgress struct {
+	log         *logger
+	description string
+	total       int
+	done        int
+	printed     int // done at the last line
+	start       time.Time
+	lastLine    time.Time
+	now         func() time.Time
+}
+
+func newPlainProgress(log *logger, total int, description string) *plainProgress {
+	now := time.Now()
+	return &plainProgress{log: log, description: description, total: total, start: now, lastLine: now, now: time.Now}
+}
+
+func (p *plainProgress) Add(n int) error {
+	p.done += n
+	step := max((p.total+9)/10, 1) // a tenth, rounded up
+	if p.done-p.printed >= step || p.now().Sub(p.lastLine) >= plainProgressInterval {
+		p.print()
+	}
+	return nil
+}
+
+// Finish prints the last line if it's complete and wasn't printed yet. A loop
+// that stopped early on an error prints nothing more.
+func (p *plainProgress) Finish() error {
+	if p.done >= p.total && p.printed < p.done {
+		p.print()
+	}
+	return nil
+}
+
+// Clear does nothing, plain lines don't get in the way of log lines
+func (p *plainProgress) Clear() error { return nil }
+
+func (p *plainProgress) print() {
+	percent := 100
+	if p.total > 0 {
+		percent = p.done * 100 / p.total
+	}
+	eta := ""
+	if left := timeLeft(p.now().Sub(p.start), p.done, p.total); left >= time.Second {
+		eta = fmt.Sprintf(", about %s left", formatETA(left))
+	}
+	p.log.Infof("%s: %d/%d (%d%%%s)\n", p.description, p.done, p.total, percent, eta)
+	p.printed = p.done
+	p.lastLine = p.now()
+}
+
+// timeLeft extrapolates how long the rest of total takes from how long the
+// first done took
+func timeLeft(elapsed time.Duration, done, total int) time.Duration {
+	if done <= 0 || done >= total {
+		return 0
+	}
+	return elapsed / time.Duration(done) * time.Duration(total-done)
+}
+
+// formatETA rounds an estimate to what's worth reading: 42s, 3m or 1h05m
+func formatETA(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Round(time.Second)/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Round(time.Minute)/time.Minute))
+	}
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+// analysisEstimate says once, early on, how long the analysis of a big
+// batch of WAV and AIFF files is going to take
+type analysisEstimate struct {
+	start time.Time
+	total int
+	given bool
+	now   func() time.Time
+}
+
+// newAnalysisEstimate returns nil for runs that are small or mostly formats
+// without spectral analysis, those are quick enough not to need one
+func (ap *AudioProcessor) newAnalysisEstimate() *analysisEstimate {
+	total := len(ap.audioFiles)
+	if total < estimateMinFiles {
+		return nil
+	}
+	spectral := 0
+	for _, af := range ap.audioFiles {
+		switch strings.ToLower(filepath.Ext(af.OriginalName)) {
+		case ".wav", ".aif", ".aiff":
+			spectral++
+		}
+	}
+	if spectral*2 < total {
+		return nil
+	}
+	return &analysisEstimate{start: time.Now(), total: total, now: time.Now}
+}
+
+// after returns the time left once done reaches estimateSample, and false
+// before and every time after that
+func (e *analysisEstimate) after(done int) (time.Duration, bool) {
+	if e == nil || e.given || done < estimateSample {
+		return 0, false
+	}
+	e.given = true
+	return timeLeft(e.now().Sub(e.start), done, e.total), true
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlainProgress(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int
+		adds  int
+		want  []string
+	}{
+		{"every tenth", 100, 100, []string{"10/100 (10%)", "20/100 (20%)", "30/100 (30%)", "40/100 (40%)", "50/100 (50%)", "60/100 (60%)", "70/100 (70%)", "80/100 (80%)", "90/100 (90%)", "100/100 (100%)"}},
+		{"uneven total ends on the last file", 25, 25, []string{"3/25 (12%)", "6/25 (24%)", "9/25 (36%)", "12/25 (48%)", "15/25 (60%)", "18/25 (72%)", "21/25 (84%)", "24/25 (96%)", "25/25 (100%)"}},
+		{"stopped early", 100, 15, []string{"10/100 (10%)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := newPlainProgress(newLogger(&out, levelNormal), tt.total, "Moving files")
+			for i := 0; i < tt.adds; i++ {
+				p.Add(1)
+			}
+			p.Finish()
+
+			got := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d lines %q, want %d", len(got), got, len(tt.want))
+			}
+			for i, line := range got {
+				if want := "Moving files: " + tt.want[i]; line != want {
+					t.Errorf("line %d = %q, want %q", i, line, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlainProgressInterval(t *testing.T) {
+	var out bytes.Buffer
+	p := newPlainProgress(newLogger(&out, levelNormal), 1000, "Analyzing audio files")
+	clock := time.Now()
+	p.start = clock
+	p.now = func() time.Time { return clock }
+
+	p.Add(1)
+	clock = clock.Add(plainProgressInterval)
+	p.Add(1) // a slow file, well under a tenth but long enough for a line
+
+	if got := strings.TrimSpace(out.String()); got != "Analyzing audio files: 2/1000 (0%, about 4h10m left)" {
+		t.Errorf("output = %q, want one line after the interval", got)
+	}
+}
+
+func TestNewProgress(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		plain  bool
+	}{
+		{"not a terminal", Config{}, true},
+		{"no-progress", Config{NoProgress: true}, false},
+		{"quiet", Config{Quiet: true}, false},
+		{"json", Config{Format: formatJSON}, false},
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(tt.config)
+		_, plain := ap.newProgress(10, "Moving files").(*plainProgress)
+		if plain != tt.plain {
+			t.Errorf("%s: plain progress = %v, want %v", tt.name, plain, tt.plain)
+		}
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	tests := map[time.Duration]string{
+		400 * time.Millisecond:         "0s",
+		42 * time.Second:               "42s",
+		3*time.Minute + 20*time.Second: "3m",
+		65 * time.Minute:               "1h05m",
+		2*time.Hour + 59*time.Minute:   "2h59m",
+	}
+	for d, want := range tests {
+		if got := formatETA(d); got != want {
+			t.Errorf("formatETA(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestAnalysisEstimate(t *testing.T) {
+	ap := NewAudioProcessor(Config{})
+	for i := 0; i < estimateMinFiles; i++ {
+		ap.audioFiles = append(ap.audioFiles, AudioFile{OriginalName: "take.WAV"})
+	}
+	e := ap.newAnalysisEstimate()
+	if e == nil {
+		t.Fatal("no estimate for a big batch of WAVs")
+	}
+	clock := e.start
+	e.now = func() time.Time { return clock }
+
+	clock = clock.Add(10 * time.Second)
+	if _, ok := e.after(estimateSample - 1); ok {
+		t.Errorf("estimated before %d files were done", estimateSample)
+	}
+	left, ok := e.after(estimateSample)
+	if want := 40 * time.Second; !ok || left != want {
+		t.Errorf("after(%d) = %v, %v, want %v", estimateSample, left, ok, want)
+	}
+	if _, ok := e.after(estimateSample + 1); ok {
+		t.Error("estimated twice")
+	}
+
+	ap.audioFiles[0].OriginalName = "take.mp3"
+	ap.audioFiles = ap.audioFiles[:estimateMinFiles-1]
+	if ap.newAnalysisEstimate() != nil {
+		t.Error("estimate for a batch below estimateMinFiles")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renameCounts compares new names with the names files have now, so a re-run
+// after a small rule change shows how much it actually touches
+type renameCounts struct {
+	Total   int `json:"total"`
+	Renamed int `json:"renamed"`
+	Correct int `json:"already_correct"`   // new name is the one it already has
+	Skipped int `json:"skipped,omitempty"` // left where they are
+	Dropped int `json:"dropped,omitempty"` // duplicates moved aside or deleted
+}
+
+// countRenames compares each file's new name with its current base name
+func countRenames(files []AudioFile) renameCounts {
+	counts := renameCounts{Total: len(files)}
+	for _, af := range files {
+		switch {
+		case af.DuplicateStatus == duplicateDropped:
+			counts.Dropped++
+		case af.SkipReason != "":
+			counts.Skipped++
+		case af.NewName == af.OriginalName:
+			counts.Correct++
+		default:
+			counts.Renamed++
+		}
+	}
+	return counts
+}
+
+// describe reads like "1200 files, 340 would be renamed, 860 already correct"
+func (c renameCounts) describe(dryRun bool) string {
+	verb := "will be"
+	if dryRun {
+		verb = "would be"
+	}
+	parts := []string{
+		fmt.Sprintf("%d files", c.Total),
+		fmt.Sprintf("%d %s renamed", c.Renamed, verb),
+		fmt.Sprintf("%d already correct", c.Correct),
+	}
+	if c.Skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", c.Skipped))
+	}
+	if c.Dropped > 0 {
+		parts = append(parts, fmt.Sprintf("%d duplicates dropped", c.Dropped))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (ap *AudioProcessor) displayRenameCounts() {
+	ap.log.Infof("\n%s\n", countRenames(ap.audioFiles).describe(ap.config.DryRun))
+	if skipped := skippedByCategory(ap.audioFiles); len(skipped) > 0 {
+		ap.log.Infof("Skipped by category: %s\n", describeCategoryCounts(skipped))
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestCountRenames(t *testing.T) {
+	files := []AudioFile{
+		{OriginalName: "scream.wav", NewName: "A_Pack_Voice_Scream.wav"},
+		{OriginalName: "A_Pack_Door_Creak.wav", NewName: "A_Pack_Door_Creak.wav"},
+		{OriginalName: "a_pack_wind.wav", NewName: "A_Pack_Wind.wav"}, // case only still counts
+		{OriginalName: "taken.wav", NewName: "A_Pack_Taken.wav", SkipReason: skipNameTaken},
+		{OriginalName: "copy.wav", NewName: "A_Pack_Copy.wav", DuplicateStatus: duplicateDropped},
+	}
+
+	got := countRenames(files)
+	want := renameCounts{Total: 5, Renamed: 2, Correct: 1, Skipped: 1, Dropped: 1}
+	if got != want {
+		t.Fatalf("countRenames() = %+v, want %+v", got, want)
+	}
+
+	if s := got.describe(true); s != "5 files, 2 would be renamed, 1 already correct, 1 skipped, 1 duplicates dropped" {
+		t.Errorf("describe(true) = %q", s)
+	}
+	if s := (renameCounts{Total: 3, Renamed: 3}).describe(false); s != "3 files, 3 will be renamed, 0 already correct" {
+		t.Errorf("describe(false) = %q", s)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// reportEntry is one file in a dry-run report. Paths are relative to the source
+// and output directories so reports from different runs can be diffed.
+type reportEntry struct {
+	Original   string  `json:"original"`
+	New        string  `json:"new"`
+	Status     string  `json:"status"`
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+}
+
+// buildReport lists what would happen to every file, sorted by original path
+func (ap *AudioProcessor) buildReport() []reportEntry {
+	entries := make([]reportEntry, 0, len(ap.audioFiles))
+	for i := range ap.audioFiles {
+		af := &ap.audioFiles[i]
+		status := ap.fileStatus(af)
+
+		newPath := ""
+		switch {
+		case status == statusSkipped || status == statusUnchanged:
+			newPath = ap.relPath(af.OriginalPath)
+		case status == statusDropped && ap.config.DedupeAction == dedupeDelete:
+			// deleted, doesn't go anywhere
+		default:
+			if rel, err := filepath.Rel(ap.config.OutputDir, ap.outputPath(af)); err == nil {
+				newPath = filepath.ToSlash(rel)
+			}
+		}
+
+		entries = append(entries, reportEntry{
+			Original:   ap.relPath(af.OriginalPath),
+			New:        newPath,
+			Status:     status,
+			Category:   af.Category,
+			Confidence: af.Confidence,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Original < entries[j].Original })
+	return entries
+}
+
+// writeReport writes the dry-run report to path, as JSON if it ends in .json and
+// as one line per file otherwise
+func (ap *AudioProcessor) writeReport(path string) error {
+	entries := ap.buildReport()
+
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var err error
+		data, err = json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		data = append(data, '\n')
+	} else {
+		var b strings.Builder
+		for _, e := range entries {
+			newPath := e.New
+			if newPath == "" {
+				newPath = "(deleted)"
+			}
+			fmt.Fprintf(&b, "%s -> %s [%s %.2f]", e.Original, newPath, e.Category, e.Confidence)
+			if e.Status != statusRenamed {
+				fmt.Fprintf(&b, " (%s)", e.Status)
+			}
+			b.WriteString("\n")
+		}
+		data = []byte(b.String())
+	}
+
+	if err := ap.writeFile(path, data); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
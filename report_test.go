@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	src := filepath.Join("lib", "raw")
+	out := filepath.Join("lib", "clean")
+
+	ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, DedupeAction: dedupeMove})
+	ap.audioFiles = []AudioFile{
+		{OriginalPath: filepath.Join(src, "voices", "scream_male.wav"), OriginalName: "scream_male.wav"},
+		{OriginalPath: filepath.Join(src, "creature_roar.wav"), OriginalName: "creature_roar.wav"},
+		{OriginalPath: filepath.Join(src, "creature_roar_copy.wav"), OriginalName: "creature_roar_copy.wav", DuplicateStatus: duplicateDropped},
+	}
+	ap.parseFiles()
+	if err := ap.generateNewNames(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	textPath := filepath.Join(dir, "report.txt")
+	if err := ap.writeReport(textPath); err != nil {
+		t.Fatalf("writeReport() error = %v", err)
+	}
+	text, _ := os.ReadFile(textPath)
+	wantText := "creature_roar.wav -> Sfx_Creature/A_TestPack_Creature_Creature_Roar.wav [SFX_Creature 0.53]\n" +
+		"creature_roar_copy.wav -> _duplicates/creature_roar_copy.wav [SFX_Creature 0.53] (dropped)\n" +
+		"voices/scream_male.wav -> Sfx_Voice/A_TestPack_Voice_Scream_Male.wav [SFX_Voice 0.53]\n"
+	if string(text) != wantText {
+		t.Errorf("text report =\n%s\nwant\n%s", text, wantText)
+	}
+
+	jsonPath := filepath.Join(dir, "report.json")
+	if err := ap.writeReport(jsonPath); err != nil {
+		t.Fatalf("writeReport() error = %v", err)
+	}
+	data, _ := os.ReadFile(jsonPath)
+	var entries []reportEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("JSON report doesn't parse: %v", err)
+	}
+	if !reflect.DeepEqual(entries, ap.buildReport()) {
+		t.Errorf("JSON report = %+v, want %+v", entries, ap.buildReport())
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// a name ending in a number after a word, like step_01, Shot 2 or hit3. The
+// number has to follow a letter or a separator, so IDs like .12345 don't count.
+var roundPattern = regexp.MustCompile(`^(.*[A-Za-z])[ _-]?(\d+)$`)
+
+// roundName splits a filename without its extension into the base name of its
+// round and its number
+func roundName(name string) (base, number string, ok bool) {
+	m := roundPattern.FindStringSubmatch(name)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// roundOf finds the round a file would be part of. A catalog ID comes off
+// the name first, so step_01.12345 is number 01 of step, and a number an
+// -id-pattern matches is never a round number.
+func (ap *AudioProcessor) roundOf(af *AudioFile) (base, number string, ok bool) {
+	name, _ := splitID(strings.TrimSuffix(af.OriginalName, filepath.Ext(af.OriginalName)), ap.idPatterns)
+	return roundName(name)
+}
+
+// detectRounds groups numbered variations of the same sound, like step_01 to
+// step_12 in one folder, and tags them round and round-group-N. They usually
+// have the same format and length, so detectDuplicates would take them for
+// copies of each other. Groups are numbered in file order.
+func (ap *AudioProcessor) detectRounds() {
+	members := make(map[string][]int)
+	var keys []string
+	for i, af := range ap.audioFiles {
+		base, _, ok := ap.roundOf(&af)
+		if !ok {
+			continue
+		}
+		key := filepath.Dir(af.OriginalPath) + "/" + strings.ToLower(base)
+		if members[key] == nil {
+			keys = append(keys, key)
+		}
+		members[key] = append(members[key], i)
+	}
+
+	for _, key := range keys {
+		group := members[key]
+		if len(group) < 2 {
+			continue
+		}
+		ap.roundGroups = append(ap.roundGroups, group)
+		n := len(ap.roundGroups)
+		for _, idx := range group {
+			ap.audioFiles[idx].RoundGroup = n
+			ap.audioFiles[idx].Tags = append(ap.audioFiles[idx].Tags, "round", fmt.Sprintf("round-group-%d", n))
+		}
+	}
+	if len(ap.roundGroups) > 0 {
+		ap.log.Infof("Found %d round groups (numbered variations of the same sound)\n", len(ap.roundGroups))
+	}
+}
+
+// sameRound reports whether two files are variations from one round
+func (ap *AudioProcessor) sameRound(i, j int) bool {
+	round := ap.audioFiles[i].RoundGroup
+	return round != 0 && round == ap.audioFiles[j].RoundGroup
+}
+
+// withoutRounds leaves out of a duplicate group the files that share a round
+// with another file in it. Matching fingerprints within a round only mean the
+// variations have the same format and length, and rather than guess which of
+// them a copy elsewhere matches, none of them count.
+func (ap *AudioProcessor) withoutRounds(indices []int) []int {
+	var kept []int
+	for _, i := range indices {
+		shared := false
+		for _, j := range indices {
+			if i != j && ap.sameRound(i, j) {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// renumberRounds gives the files of each round a gapless _01, _02... sequence
+// in the order of their old numbers, so step_1, step_2 and step_10 become
+// step_01, step_02 and step_03. It rewrites the number at the end of the
+// subcategory, files named from a title tag or by an earlier run keep theirs.
+func (ap *AudioProcessor) renumberRounds() {
+	type numbered struct {
+		af     *AudioFile
+		number int
+	}
+	rounds := make(map[int][]numbered)
+	for i := range ap.audioFiles {
+		af := &ap.audioFiles[i]
+		if af.RoundGroup == 0 {
+			continue
+		}
+		_, number, _ := ap.roundOf(af)
+		n, _ := strconv.Atoi(number)
+		rounds[af.RoundGroup] = append(rounds[af.RoundGroup], numbered{af, n})
+	}
+
+	for _, files := range rounds {
+		sort.SliceStable(files, func(i, j int) bool { return files[i].number < files[j].number })
+		width := max(2, len(strconv.Itoa(len(files))))
+		for i, f := range files {
+			if f.af.AlreadyNamed {
+				continue
+			}
+			base, number, ok := roundName(f.af.SubCategory)
+			if n, _ := strconv.Atoi(number); !ok || n != f.number {
+				continue // the subcategory didn't come from the numbered name
+			}
+			f.af.SubCategory = fmt.Sprintf("%s_%0*d", base, width, i+1)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoundName(t *testing.T) {
+	tests := []struct {
+		name       string
+		base, want string
+		ok         bool
+	}{
+		{"step_01", "step", "01", true},
+		{"Shot 2", "Shot", "2", true},
+		{"hit3", "hit", "3", true},
+		{"footstep_gravel-12", "footstep_gravel", "12", true},
+		{"door_creak", "", "", false},
+		{"impact.12345", "", "", false},
+		{"01", "", "", false},
+	}
+
+	for _, tt := range tests {
+		base, number, ok := roundName(tt.name)
+		if base != tt.base || number != tt.want || ok != tt.ok {
+			t.Errorf("roundName(%q) = %q, %q, %v, want %q, %q, %v", tt.name, base, number, ok, tt.base, tt.want, tt.ok)
+		}
+	}
+}
+
+func writeRound(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		// same format and length, so the fingerprints match
+		if err := os.WriteFile(filepath.Join(dir, name), buildTestWAV(48000, 1, 16, make([]byte, 48000)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRoundsAreNotDuplicates(t *testing.T) {
+	src := t.TempDir()
+	writeRound(t, src, "step_1.wav", "step_2.wav", "step_10.wav", "door_creak.wav", "door_slam.wav")
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: t.TempDir(), PackName: "TestPack", Layout: layoutFlat, DryRun: true, Workers: 1})
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if len(ap.roundGroups) != 1 {
+		t.Fatalf("found %d rounds, want 1", len(ap.roundGroups))
+	}
+	for _, af := range ap.audioFiles {
+		round := strings.HasPrefix(af.OriginalName, "step")
+		if got := contains(af.Tags, "round"); got != round {
+			t.Errorf("%s: round tag = %v, want %v (tags %v)", af.OriginalName, got, round, af.Tags)
+		}
+		if round && (contains(af.Tags, "duplicate") || !contains(af.Tags, "round-group-1")) {
+			t.Errorf("%s: tags %v, want round-group-1 and no duplicate", af.OriginalName, af.Tags)
+		}
+		if !round && !contains(af.Tags, "duplicate") {
+			t.Errorf("%s: tags %v, want duplicate", af.OriginalName, af.Tags)
+		}
+	}
+}
+
+func TestRenumberRounds(t *testing.T) {
+	src := t.TempDir()
+	writeRound(t, src, "step_1.wav", "step_2.wav", "step_10.wav")
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: t.TempDir(), PackName: "TestPack", Layout: layoutFlat, RenumberRounds: true, DryRun: true, Workers: 1})
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	want := map[string]string{"step_1.wav": "_Step_01.wav", "step_2.wav": "_Step_02.wav", "step_10.wav": "_Step_03.wav"}
+	for _, af := range ap.audioFiles {
+		if !strings.HasSuffix(af.NewName, want[af.OriginalName]) {
+			t.Errorf("%s -> %s, want it to end in %s", af.OriginalName, af.NewName, want[af.OriginalName])
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+// ruleCoverage counts which tidy.CategoryRules decide the category of a library's
+// file names, for tuning the keyword lists (-rules-report)
+type ruleCoverage struct {
+	Files    []int            // files matched per rule, by index in tidy.CategoryRules
+	Keywords []map[string]int // files per keyword that matched, by rule
+	Fallback int              // files no rule matched, left as SFX
+}
+
+// computeRuleCoverage runs every file name through the keyword rules. A keyword
+// only counts when it's the one that decided, so a keyword always beaten by an
+// earlier rule shows up as never matching.
+func computeRuleCoverage(files []AudioFile) ruleCoverage {
+	cov := ruleCoverage{
+		Files:    make([]int, len(tidy.CategoryRules)),
+		Keywords: make([]map[string]int, len(tidy.CategoryRules)),
+	}
+	for i := range cov.Keywords {
+		cov.Keywords[i] = make(map[string]int)
+	}
+
+	for _, af := range files {
+		name := strings.TrimSuffix(af.OriginalName, filepath.Ext(af.OriginalName))
+		rule, keyword := tidy.MatchedRule(name)
+		if rule < 0 {
+			cov.Fallback++
+			continue
+		}
+		cov.Files[rule]++
+		cov.Keywords[rule][keyword]++
+	}
+	return cov
+}
+
+// deadKeywords returns the keywords of a rule that never matched
+func (cov ruleCoverage) deadKeywords(rule int) []string {
+	var dead []string
+	for _, keyword := range tidy.CategoryRules[rule].Keywords {
+		if cov.Keywords[rule][keyword] == 0 {
+			dead = append(dead, keyword)
+		}
+	}
+	return dead
+}
+
+// ruleLabel names a rule by its category, numbered when a category has more than one rule
+func ruleLabel(rule int) string {
+	category := tidy.CategoryRules[rule].Category
+	n, total := 0, 0
+	for i, r := range tidy.CategoryRules {
+		if r.Category == category {
+			total++
+			if i <= rule {
+				n++
+			}
+		}
+	}
+	if total > 1 {
+		return fmt.Sprintf("%s #%d", category, n)
+	}
+	return category
+}
+
+func (ap *AudioProcessor) displayRulesReport() {
+	cov := computeRuleCoverage(ap.audioFiles)
+
+	ap.log.Printf("\n=== Rule Coverage (%d files) ===\n", len(ap.audioFiles))
+	for rule, count := range cov.Files {
+		if count == 0 {
+			continue
+		}
+		keywords := make([]string, 0, len(cov.Keywords[rule]))
+		for keyword := range cov.Keywords[rule] {
+			keywords = append(keywords, keyword)
+		}
+		sort.Slice(keywords, func(i, j int) bool {
+			a, b := keywords[i], keywords[j]
+			if cov.Keywords[rule][a] != cov.Keywords[rule][b] {
+				return cov.Keywords[rule][a] > cov.Keywords[rule][b]
+			}
+			return a < b
+		})
+		for i, keyword := range keywords {
+			keywords[i] = fmt.Sprintf("%s %d", keyword, cov.Keywords[rule][keyword])
+		}
+		ap.log.Printf("  %-24s %5d  (%s)\n", ruleLabel(rule), count, strings.Join(keywords, ", "))
+	}
+	ap.log.Printf("  %-24s %5d\n", "SFX (no rule matched)", cov.Fallback)
+
+	var unused []string
+	for rule, count := range cov.Files {
+		if count == 0 {
+			unused = append(unused, ruleLabel(rule))
+		}
+	}
+	if len(unused) > 0 {
+		ap.log.Printf("\nRules that matched nothing: %s\n", strings.Join(unused, ", "))
+	}
+
+	ap.log.Println("\nKeywords that matched nothing:")
+	none := true
+	for rule := range tidy.CategoryRules {
+		if dead := cov.deadKeywords(rule); len(dead) > 0 {
+			ap.log.Printf("  %-24s %s\n", ruleLabel(rule), strings.Join(dead, ", "))
+			none = false
+		}
+	}
+	if none {
+		ap.log.Println("  (none)")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestComputeRuleCoverage(t *testing.T) {
+	files := []AudioFile{
+		{OriginalName: "door_creak.wav"},
+		{OriginalName: "door_open.wav"},
+		{OriginalName: "drone_low.wav"},
+		{OriginalName: "mystery.wav"},
+	}
+	cov := computeRuleCoverage(files)
+
+	if cov.Fallback != 1 {
+		t.Errorf("Fallback = %d, want 1", cov.Fallback)
+	}
+
+	total := cov.Fallback
+	for _, count := range cov.Files {
+		total += count
+	}
+	if total != len(files) {
+		t.Errorf("counted %d files, want %d", total, len(files))
+	}
+
+	for _, tt := range []struct {
+		name    string
+		keyword string
+		count   int
+	}{
+		{"door_creak", "creak", 1}, // the longer keyword is the more specific one
+		{"door_open", "door", 1},
+		{"drone_low", "drone", 1},
+	} {
+		rule, keyword := tidy.MatchedRule(tt.name)
+		if keyword != tt.keyword {
+			t.Fatalf("tidy.MatchedRule(%q) keyword = %q, want %q", tt.name, keyword, tt.keyword)
+		}
+		if got := cov.Keywords[rule][tt.keyword]; got != tt.count {
+			t.Errorf("%s keyword %q matched %d files, want %d", ruleLabel(rule), tt.keyword, got, tt.count)
+		}
+		for _, dead := range cov.deadKeywords(rule) {
+			if dead == tt.keyword {
+				t.Errorf("%s: %q matched files but is listed as dead", ruleLabel(rule), tt.keyword)
+			}
+		}
+	}
+}
+
+func TestRuleLabel(t *testing.T) {
+	seen := make(map[string]bool)
+	for rule := range tidy.CategoryRules {
+		label := ruleLabel(rule)
+		if seen[label] {
+			t.Errorf("label %q used for more than one rule", label)
+		}
+		seen[label] = true
+		if !strings.HasPrefix(label, tidy.CategoryRules[rule].Category) {
+			t.Errorf("ruleLabel(%d) = %q, want it to start with %s", rule, label, tidy.CategoryRules[rule].Category)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// runSandbox applies the changes for real, but into a throwaway temp
+// directory and with copies of the files, so what a dry run can't see
+// (permissions, names too long for the filesystem, clashes on disk) shows up
+// without touching the library or the real output. The temp directory is
+// removed afterwards either way.
+func (ap *AudioProcessor) runSandbox(ctx context.Context) error {
+	dir, err := os.MkdirTemp("", "tidy-rename-sandbox-")
+	if err != nil {
+		return fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	ap.log.Infof("\nSandbox: applying changes in %s\n", dir)
+
+	outputDir := ap.config.OutputDir
+	ap.config.OutputDir = dir
+	ap.sandbox = true
+	defer func() {
+		ap.config.OutputDir = outputDir
+		ap.sandbox = false
+	}()
+
+	if err := ap.applyChanges(ctx); err != nil {
+		return fmt.Errorf("sandbox run in %s failed: %w", dir, err)
+	}
+	if ap.config.CreateManifest {
+		if err := ap.createManifest(); err != nil {
+			return fmt.Errorf("sandbox run in %s failed to create manifest: %w", dir, err)
+		}
+	}
+	if ap.config.ManifestPerCategory {
+		if err := ap.createCategoryManifests(); err != nil {
+			return fmt.Errorf("sandbox run in %s failed to create category manifests: %w", dir, err)
+		}
+	}
+
+	ap.emitSummary(false)
+	ap.log.Printf("\n[SANDBOX] All changes applied cleanly in %s, which was removed again. No files were modified. Remove -sandbox to apply changes.\n", dir)
+	return nil
+}
+
+// sandboxCopy stands in for a move in the sandbox: the source stays where
+// it is, reading it still fails the way the move would
+func sandboxCopy(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
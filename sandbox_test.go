@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProcessSandbox(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp) // where os.MkdirTemp puts the sandbox
+
+	src := t.TempDir()
+	for _, name := range []string{"door_creak.wav", "wind_howl.wav"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte("not audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: src, PackName: "TestPack", Layout: layoutCategory, Sandbox: true, CreateManifest: true, NoAnalyze: true, SkipSpaceCheck: true, Workers: 1})
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 2 || names[0] != "door_creak.wav" || names[1] != "wind_howl.wav" {
+		t.Errorf("source holds %v, want the two files untouched and no manifest", names)
+	}
+
+	if left, _ := os.ReadDir(tmp); len(left) != 0 {
+		t.Errorf("sandbox left %d entries in the temp directory, want it removed", len(left))
+	}
+	if ap.config.OutputDir != src || ap.sandbox {
+		t.Errorf("OutputDir = %q, sandbox = %v after the run, want %q and false", ap.config.OutputDir, ap.sandbox, src)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "encoding/json"
+
+// sidecarPath returns where -sidecar puts the metadata of the file at path:
+// next to it, with .meta.json after the extension so Door.wav and Door.mp3
+// don't share one
+func sidecarPath(path string) string {
+	return path + ".meta.json"
+}
+
+// writeSidecar writes the file's full record (category, tags, confidence,
+// original path, audio metadata) next to the file at path, so it travels with
+// the file when it's moved on its own
+func (ap *AudioProcessor) writeSidecar(path string, af *AudioFile) error {
+	data, err := json.MarshalIndent(af, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ap.writeFile(sidecarPath(path), data)
+}
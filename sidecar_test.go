@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestSidecarPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/out/SFX_Door/A_Pack_Door_Creak.wav", "/out/SFX_Door/A_Pack_Door_Creak.wav.meta.json"},
+		{"A_Pack_Voice_Scream.flac", "A_Pack_Voice_Scream.flac.meta.json"},
+		{"A_Pack_Voice_Scream.mp3", "A_Pack_Voice_Scream.mp3.meta.json"}, // same name, different format
+		{"no_extension", "no_extension.meta.json"},
+	}
+
+	for _, tt := range tests {
+		if got := sidecarPath(tt.path); got != tt.want {
+			t.Errorf("sidecarPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestApplyChangesSidecar(t *testing.T) {
+	for _, sidecar := range []bool{false, true} {
+		src := t.TempDir()
+		out := t.TempDir()
+
+		original := filepath.Join(src, "door_creak_ABC.wav")
+		if err := os.WriteFile(original, []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, PackName: "TestPack", Layout: layoutFlat, Sidecar: sidecar})
+		ap.log.out = io.Discard
+		ap.audioFiles = []AudioFile{
+			{OriginalPath: original, OriginalName: "door_creak_ABC.wav", AudioMeta: &tidy.AudioMetadata{SampleRate: 48000}},
+		}
+		ap.parseFiles()
+		if err := ap.generateNewNames(); err != nil {
+			t.Fatal(err)
+		}
+		if err := ap.applyChanges(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		path := sidecarPath(filepath.Join(out, ap.audioFiles[0].NewName))
+		data, err := os.ReadFile(path)
+		if !sidecar {
+			if err == nil {
+				t.Errorf("sidecar written to %s without -sidecar", path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("no sidecar at %s: %v", path, err)
+		}
+
+		var got AudioFile
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.OriginalPath != original || got.Category != ap.audioFiles[0].Category || got.AudioMeta == nil || got.AudioMeta.SampleRate != 48000 {
+			t.Errorf("sidecar = %+v, want the file's record", got)
+		}
+	}
+}
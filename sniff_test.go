@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMislabeledFiles(t *testing.T) {
+	wavData := buildTestWAV(44100, 1, 16, make([]byte, 4410))
+
+	tests := []struct {
+		sniff bool
+		want  []string // files scanned, all of them get .wav
+	}{
+		{false, []string{"door_creak.wav", "scream_male.mp3"}},
+		{true, []string{"door_creak.wav", "footstep_gravel", "scream_male.mp3"}},
+	}
+
+	for _, tt := range tests {
+		src := t.TempDir()
+		for _, name := range []string{"scream_male.mp3", "door_creak.wav", "footstep_gravel"} {
+			if err := os.WriteFile(filepath.Join(src, name), wavData, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(src, "notes"), []byte("not audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: src, PackName: "TestPack", Workers: 1, DryRun: true, Sniff: tt.sniff})
+		ap.log.out = io.Discard
+		if err := ap.Process(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		var scanned []string
+		for _, af := range ap.audioFiles {
+			scanned = append(scanned, af.OriginalName)
+			if ext := filepath.Ext(af.NewName); ext != ".wav" {
+				t.Errorf("sniff %v: %s -> %s, want a .wav name", tt.sniff, af.OriginalName, af.NewName)
+			}
+			fixed := contains(af.Tags, "extension-fixed")
+			if want := af.OriginalName != "door_creak.wav"; fixed != want {
+				t.Errorf("sniff %v: %s extension-fixed tag = %v, want %v", tt.sniff, af.OriginalName, fixed, want)
+			}
+		}
+		sort.Strings(scanned)
+		if !reflect.DeepEqual(scanned, tt.want) {
+			t.Errorf("sniff %v: scanned %v, want %v", tt.sniff, scanned, tt.want)
+		}
+	}
+}
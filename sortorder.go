@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// orders for the preview and manifest (-sort)
+const (
+	sortCategory = "category" // by category, then new name
+	sortName     = "name"     // by new name
+	sortDuration = "duration" // shortest first, files without a duration last
+	sortOriginal = "original" // by original path
+)
+
+// validSortOrder reports whether s is one of the -sort values
+func validSortOrder(s string) bool {
+	switch s {
+	case sortCategory, sortName, sortDuration, sortOriginal:
+		return true
+	}
+	return false
+}
+
+// sortOrder returns the configured order, defaulting to category
+func (ap *AudioProcessor) sortOrder() string {
+	if ap.config.Sort == "" {
+		return sortCategory
+	}
+	return ap.config.Sort
+}
+
+// sortFiles puts the files in -sort order. Every order ends in the new name and
+// then the original path, so the result doesn't depend on how the filesystem
+// listed them. Runs after naming: duplicate groups hold indices into the slice.
+func (ap *AudioProcessor) sortFiles() {
+	order := ap.sortOrder()
+	sort.SliceStable(ap.audioFiles, func(i, j int) bool {
+		a, b := &ap.audioFiles[i], &ap.audioFiles[j]
+		switch order {
+		case sortCategory:
+			if a.Category != b.Category {
+				return a.Category < b.Category
+			}
+		case sortDuration:
+			if da, db := fileDuration(a), fileDuration(b); da != db {
+				if da == 0 || db == 0 {
+					return db == 0 // unknown durations go last
+				}
+				return da < db
+			}
+		case sortOriginal:
+			if a.OriginalPath != b.OriginalPath {
+				return a.OriginalPath < b.OriginalPath
+			}
+		}
+		if na, nb := strings.ToLower(a.NewName), strings.ToLower(b.NewName); na != nb {
+			return na < nb
+		}
+		return a.OriginalPath < b.OriginalPath
+	})
+}
+
+func fileDuration(af *AudioFile) int64 {
+	if af.AudioMeta == nil {
+		return 0
+	}
+	return int64(af.AudioMeta.Duration)
+}
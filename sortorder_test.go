@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestSortFiles(t *testing.T) {
+	files := func() []AudioFile {
+		return []AudioFile{
+			{OriginalPath: "src/z/wind.wav", NewName: "A_P_Ambient_Wind.wav", Category: "Ambient", AudioMeta: &tidy.AudioMetadata{Duration: time.Minute}},
+			{OriginalPath: "src/b/click.wav", NewName: "A_P_UI_Click.wav", Category: "SFX_UI", AudioMeta: &tidy.AudioMetadata{Duration: 200 * time.Millisecond}},
+			{OriginalPath: "src/a/broken.wav", NewName: "A_P_Impact_Broken.wav", Category: "SFX_Impact"},
+			{OriginalPath: "src/c/rain.wav", NewName: "A_P_Ambient_Rain.wav", Category: "Ambient", AudioMeta: &tidy.AudioMetadata{Duration: 20 * time.Second}},
+		}
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"", []string{"A_P_Ambient_Rain.wav", "A_P_Ambient_Wind.wav", "A_P_Impact_Broken.wav", "A_P_UI_Click.wav"}},
+		{sortCategory, []string{"A_P_Ambient_Rain.wav", "A_P_Ambient_Wind.wav", "A_P_Impact_Broken.wav", "A_P_UI_Click.wav"}},
+		{sortName, []string{"A_P_Ambient_Rain.wav", "A_P_Ambient_Wind.wav", "A_P_Impact_Broken.wav", "A_P_UI_Click.wav"}},
+		{sortDuration, []string{"A_P_UI_Click.wav", "A_P_Ambient_Rain.wav", "A_P_Ambient_Wind.wav", "A_P_Impact_Broken.wav"}},
+		{sortOriginal, []string{"A_P_Impact_Broken.wav", "A_P_UI_Click.wav", "A_P_Ambient_Rain.wav", "A_P_Ambient_Wind.wav"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{Sort: tt.order})
+			ap.audioFiles = files()
+			ap.sortFiles()
+
+			for i, af := range ap.audioFiles {
+				if af.NewName != tt.want[i] {
+					t.Errorf("position %d = %s, want %s", i, af.NewName, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSortFilesCategoryThenName(t *testing.T) {
+	// same category, listed in reverse: the name decides
+	ap := NewAudioProcessor(Config{})
+	ap.audioFiles = []AudioFile{
+		{OriginalPath: "b.wav", NewName: "A_P_Voice_Scream_02.wav", Category: "SFX_Voice"},
+		{OriginalPath: "a.wav", NewName: "A_P_Voice_Scream_01.wav", Category: "SFX_Voice"},
+		{OriginalPath: "c.wav", NewName: "A_P_Creature_Growl.wav", Category: "SFX_Creature"},
+	}
+	ap.sortFiles()
+
+	want := []string{"c.wav", "a.wav", "b.wav"}
+	for i, af := range ap.audioFiles {
+		if af.OriginalPath != want[i] {
+			t.Errorf("position %d = %s, want %s", i, af.OriginalPath, want[i])
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// sourceList collects -source flags. The flag can be repeated and each value
+// can be a comma-separated list of directories.
+type sourceList []string
+
+func (s *sourceList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *sourceList) Set(value string) error {
+	*s = append(*s, splitList(value)...)
+	return nil
+}
+
+// sourceDirs returns every directory to scan. Configs built without
+// SourceDirs (like most tests) just have SourceDir.
+func (ap *AudioProcessor) sourceDirs() []string {
+	if len(ap.config.SourceDirs) > 0 {
+		return ap.config.SourceDirs
+	}
+	return []string{ap.config.SourceDir}
+}
+
+// sourceRoot returns the source directory p is in. With nested sources the
+// innermost one wins, since that's the one that was walked for it.
+func (ap *AudioProcessor) sourceRoot(p string) string {
+	root := ap.config.SourceDir
+	best := -1
+	for _, dir := range ap.sourceDirs() {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(dir) > best {
+			root, best = dir, len(dir)
+		}
+	}
+	return root
+}
+
+// isSourceDir reports whether p is one of the source directories
+func (ap *AudioProcessor) isSourceDir(p string) bool {
+	for _, dir := range ap.sourceDirs() {
+		if samePath(dir, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// samePath reports whether a and b name the same directory, however they were
+// written: relative or absolute, with or without a trailing slash or "./"
+func samePath(a, b string) bool {
+	return absPath(a) == absPath(b)
+}
+
+// absPath cleans p and makes it absolute, or just cleans it if the working
+// directory can't be found
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return filepath.Clean(p)
+}
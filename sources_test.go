@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSourceListSet(t *testing.T) {
+	var s sourceList
+	s.Set("packs/horror")
+	s.Set("packs/scifi, packs/nature")
+
+	expected := sourceList{"packs/horror", "packs/scifi", "packs/nature"}
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("sourceList = %v, want %v", s, expected)
+	}
+}
+
+func TestScanMultipleSources(t *testing.T) {
+	base := t.TempDir()
+	horror := filepath.Join(base, "horror")
+	scifi := filepath.Join(base, "scifi")
+	nested := filepath.Join(horror, "bonus") // also given as its own source
+	out := filepath.Join(base, "out")
+
+	for _, p := range []string{
+		filepath.Join(horror, "voices", "scream_male.wav"),
+		filepath.Join(scifi, "voices", "scream_male.wav"),
+		filepath.Join(nested, "laser.wav"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, append([]byte(p), fakeAudio...), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap := NewAudioProcessor(Config{
+		SourceDir:   horror,
+		SourceDirs:  []string{horror, scifi, nested},
+		OutputDir:   out,
+		PackName:    "TestPack",
+		Layout:      layoutPreserve,
+		OnCollision: collisionNumber,
+	})
+	ap.log.out = io.Discard
+	if err := ap.scanFiles(); err != nil {
+		t.Fatalf("scanFiles() error = %v", err)
+	}
+
+	var found []string
+	for _, af := range ap.audioFiles {
+		rel, _ := filepath.Rel(base, af.SourceDir)
+		found = append(found, rel+": "+ap.relPath(af.OriginalPath))
+	}
+	sort.Strings(found)
+	expected := []string{"horror/bonus: laser.wav", "horror: voices/scream_male.wav", "scifi: voices/scream_male.wav"}
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("scanFiles() found %v, want %v", found, expected)
+	}
+
+	// same name from two packs, numbered like any other collision
+	ap.parseFiles()
+	if err := ap.generateNewNames(); err != nil {
+		t.Fatal(err)
+	}
+	var outputs []string
+	for i := range ap.audioFiles {
+		rel, _ := filepath.Rel(out, ap.outputPath(&ap.audioFiles[i]))
+		outputs = append(outputs, filepath.ToSlash(rel))
+	}
+	sort.Strings(outputs)
+	expectedOutputs := []string{
+		"A_TestPack_Sfx_Laser.wav",
+		"voices/A_TestPack_Voice_Scream_Male.wav",
+		"voices/A_TestPack_Voice_Scream_Male_01.wav",
+	}
+	if !reflect.DeepEqual(outputs, expectedOutputs) {
+		t.Errorf("output paths = %v, want %v", outputs, expectedOutputs)
+	}
+}
+
+func TestScanSkipsNestedOutput(t *testing.T) {
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "scream_male.wav"), fakeAudio, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// written differently from how the walk sees it, so plain string equality misses it
+	sep := string(filepath.Separator)
+	out := src + sep + "." + sep + "cleaned" + sep
+
+	config := Config{SourceDir: src + sep, OutputDir: out, PackName: "TestPack", Layout: layoutFlat, Workers: 1}
+	for run := 1; run <= 2; run++ {
+		ap := NewAudioProcessor(config)
+		ap.log.out = io.Discard
+		if err := ap.Process(context.Background()); err != nil {
+			t.Fatalf("run %d: Process() error = %v", run, err)
+		}
+		if want := 2 - run; len(ap.audioFiles) != want {
+			t.Fatalf("run %d: processed %d files, want %d", run, len(ap.audioFiles), want)
+		}
+	}
+
+	renamed, err := filepath.Glob(filepath.Join(src, "cleaned", "*.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renamed) != 1 || filepath.Base(renamed[0]) != "A_TestPack_Voice_Scream_Male.wav" {
+		t.Errorf("output = %v, want just A_TestPack_Voice_Scream_Male.wav", renamed)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+const (
+	stateFileName = ".tidy-rename-state.json"
+
+	// how often analysis results get checkpointed to the state file
+	checkpointEvery    = 100
+	checkpointInterval = 10 * time.Second
+)
+
+// stateEntry caches the analysis of one file. Size and mod time are used to tell
+// whether the file changed since it was analyzed.
+type stateEntry struct {
+	Size      int64               `json:"size"`
+	ModTime   time.Time           `json:"mod_time"`
+	AudioMeta *tidy.AudioMetadata `json:"audio_metadata"`
+}
+
+// runState is what gets written to the state file, keyed by original path
+type runState struct {
+	Files map[string]stateEntry `json:"files"`
+
+	// what durations were rounded to in the fingerprints, 0 before -fingerprint-precision
+	FingerprintPrecision time.Duration `json:"fingerprint_precision,omitempty"`
+
+	// how much of each file the spectral features cover, 0 before -analysis-window
+	AnalysisWindow time.Duration `json:"analysis_window,omitempty"`
+}
+
+func newRunState() *runState {
+	return &runState{Files: make(map[string]stateEntry)}
+}
+
+// statePath is where the state of runs into the output directory is kept:
+// in the state directory under a name derived from the output directory's
+// absolute path, or without one in the output directory itself
+func (ap *AudioProcessor) statePath() string {
+	if ap.config.StateDir == "" {
+		return filepath.Join(ap.config.OutputDir, stateFileName)
+	}
+
+	output, err := filepath.Abs(ap.config.OutputDir)
+	if err != nil {
+		output = filepath.Clean(ap.config.OutputDir)
+	}
+	sum := sha256.Sum256([]byte(output))
+	return filepath.Join(ap.config.StateDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadState reads analysis results from a previous run so unchanged files
+// don't have to be analyzed again
+func (ap *AudioProcessor) loadState() error {
+	data, err := os.ReadFile(ap.statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // nothing to resume from, that's fine
+		}
+		return err
+	}
+
+	state := newRunState()
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("invalid state file %s: %w", ap.statePath(), err)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]stateEntry)
+	}
+
+	ap.priorState = state
+	return nil
+}
+
+// cachedMeta returns the analysis from the prior state if the file still has
+// the same size and mod time, nil otherwise. Safe to call from workers since
+// priorState is never written during analysis.
+func (ap *AudioProcessor) cachedMeta(path string) *tidy.AudioMetadata {
+	if ap.priorState == nil {
+		return nil
+	}
+
+	entry, ok := ap.priorState.Files[path]
+	if !ok || entry.AudioMeta == nil {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+		return nil
+	}
+
+	// spectral features from another -analysis-window would categorize differently
+	if entry.AudioMeta.SpectralFeatures != nil && ap.priorState.AnalysisWindow != ap.audioAnalyzer.AnalysisWindow {
+		return nil
+	}
+
+	// fingerprints from a run with another -fingerprint-precision don't compare
+	if entry.AudioMeta.Fingerprint != "" && ap.priorState.FingerprintPrecision != ap.audioAnalyzer.FingerprintPrecision {
+		meta := *entry.AudioMeta
+		meta.Fingerprint = ap.audioAnalyzer.GenerateFingerprint(&meta)
+		return &meta
+	}
+	return entry.AudioMeta
+}
+
+// recordState remembers a finished analysis for the next checkpoint
+func (ap *AudioProcessor) recordState(path string, meta *tidy.AudioMetadata) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	ap.state.Files[path] = stateEntry{
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		AudioMeta: meta,
+	}
+}
+
+// saveState writes the current analysis results to the state file.
+// Written to a temp file first so a Ctrl-C mid-write can't corrupt the old one.
+// In the state directory it's written on dry runs too, so previews can be
+// resumed; in the output directory a dry run writes nothing.
+func (ap *AudioProcessor) saveState() error {
+	data, err := json.Marshal(ap.state)
+	if err != nil {
+		return err
+	}
+	tmpPath := ap.statePath() + ".tmp"
+
+	if ap.config.StateDir != "" {
+		if err := os.MkdirAll(ap.config.StateDir, defaultDirMode); err != nil {
+			return err
+		}
+		if err := os.WriteFile(tmpPath, data, defaultFileMode); err != nil {
+			return err
+		}
+	} else {
+		if ap.config.DryRun {
+			return nil
+		}
+		if err := ap.mkdirAll(ap.config.OutputDir); err != nil {
+			return err
+		}
+		if err := ap.writeFile(tmpPath, data); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpPath, ap.statePath()); err != nil {
+		return err
+	}
+	ap.stateSaved = true
+	return nil
+}
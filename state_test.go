@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := filepath.Join(dir, "boom.wav")
+	if err := os.WriteFile(audioPath, []byte("not really audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := &tidy.AudioMetadata{Duration: 3 * time.Second, SampleRate: 48000, Channels: 2, Fingerprint: "abc"}
+
+	ap := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir})
+	ap.recordState(audioPath, meta)
+	if err := ap.saveState(); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+
+	resumed := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir, Resume: true})
+	if err := resumed.loadState(); err != nil {
+		t.Fatalf("loadState() error = %v", err)
+	}
+
+	cached := resumed.cachedMeta(audioPath)
+	if cached == nil {
+		t.Fatal("cachedMeta() = nil, want the saved metadata")
+	}
+	if cached.Duration != meta.Duration || cached.SampleRate != meta.SampleRate || cached.Fingerprint != meta.Fingerprint {
+		t.Errorf("cachedMeta() = %+v, want %+v", cached, meta)
+	}
+
+	// changing the file invalidates the cached entry
+	if err := os.WriteFile(audioPath, []byte("different content, different size"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.cachedMeta(audioPath) != nil {
+		t.Error("cachedMeta() should return nil after the file changed")
+	}
+}
+
+func TestLoadStateMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	ap := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir, Resume: true})
+
+	if err := ap.loadState(); err != nil {
+		t.Errorf("loadState() with no state file should not error, got %v", err)
+	}
+	if ap.cachedMeta(filepath.Join(dir, "x.wav")) != nil {
+		t.Error("cachedMeta() should return nil without a prior state")
+	}
+}
+
+func TestAnalyzeAudioFilesUsesState(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := filepath.Join(dir, "wind_ambient.wav")
+	// garbage content, so a real analysis would fail
+	if err := os.WriteFile(audioPath, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	first := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir})
+	first.recordState(audioPath, &tidy.AudioMetadata{Duration: 45 * time.Second, Channels: 2})
+	if err := first.saveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir, Resume: true, Workers: 1})
+	if err := ap.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	ap.audioFiles = []AudioFile{{OriginalPath: audioPath, OriginalName: "wind_ambient.wav"}}
+
+	if err := ap.analyzeAudioFiles(context.Background()); err != nil {
+		t.Fatalf("analyzeAudioFiles() error = %v", err)
+	}
+
+	if ap.audioFiles[0].AudioMeta == nil || ap.audioFiles[0].AudioMeta.Duration != 45*time.Second {
+		t.Errorf("analyzeAudioFiles() should reuse the cached metadata, got %+v", ap.audioFiles[0].AudioMeta)
+	}
+	if !contains(ap.audioFiles[0].Tags, "long") {
+		t.Errorf("tags should be generated from cached metadata, got %v", ap.audioFiles[0].Tags)
+	}
+}
+
+func TestStateRefingerprintsOnPrecisionChange(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := filepath.Join(dir, "boom.wav")
+	if err := os.WriteFile(audioPath, []byte("not really audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir, FingerprintPrecision: time.Second})
+	ap.recordState(audioPath, &tidy.AudioMetadata{Duration: 3 * time.Second, SampleRate: 48000, Fingerprint: "abc"})
+	if err := ap.saveState(); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+
+	resumed := NewAudioProcessor(Config{SourceDir: dir, OutputDir: dir, Resume: true})
+	if err := resumed.loadState(); err != nil {
+		t.Fatalf("loadState() error = %v", err)
+	}
+	cached := resumed.cachedMeta(audioPath)
+	if cached == nil {
+		t.Fatal("cachedMeta() = nil, want the saved metadata")
+	}
+	if want := resumed.audioAnalyzer.GenerateFingerprint(cached); cached.Fingerprint != want {
+		t.Errorf("cachedMeta() fingerprint = %q, want it redone at the new precision (%q)", cached.Fingerprint, want)
+	}
+	if resumed.priorState.Files[audioPath].AudioMeta.Fingerprint != "abc" {
+		t.Error("cachedMeta() shouldn't change the loaded state")
+	}
+}
+
+func TestDryRunState(t *testing.T) {
+	src := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out")
+	audioPath := filepath.Join(src, "wind_ambient.wav")
+	if err := os.WriteFile(audioPath, buildTestWAV(48000, 1, 16, make([]byte, 9600)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stateDir := range []string{"", filepath.Join(t.TempDir(), "state")} {
+		ap := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, StateDir: stateDir, DryRun: true, Workers: 1})
+		ap.log.out = io.Discard
+		ap.audioFiles = []AudioFile{{OriginalPath: audioPath, OriginalName: "wind_ambient.wav"}}
+
+		if err := ap.analyzeAudioFiles(context.Background()); err != nil {
+			t.Fatalf("analyzeAudioFiles() error = %v", err)
+		}
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Errorf("state dir %q: a dry run shouldn't create the output directory, Stat() error = %v", stateDir, err)
+		}
+
+		// a preview's analysis is what the next run wants to resume from
+		resumed := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, StateDir: stateDir, Resume: true})
+		if err := resumed.loadState(); err != nil {
+			t.Fatal(err)
+		}
+		if cached := resumed.cachedMeta(audioPath) != nil; cached != (stateDir != "") {
+			t.Errorf("state dir %q: cached after a dry run = %v, want %v", stateDir, cached, stateDir != "")
+		}
+	}
+}
+
+func TestStatePathPerOutput(t *testing.T) {
+	stateDir := t.TempDir()
+	a := NewAudioProcessor(Config{OutputDir: "out", StateDir: stateDir})
+	b := NewAudioProcessor(Config{OutputDir: "./out/", StateDir: stateDir})
+	c := NewAudioProcessor(Config{OutputDir: "other", StateDir: stateDir})
+
+	if a.statePath() != b.statePath() {
+		t.Errorf("statePath() = %q and %q for the same output directory", a.statePath(), b.statePath())
+	}
+	if a.statePath() == c.statePath() {
+		t.Errorf("statePath() = %q for two output directories", a.statePath())
+	}
+	if filepath.Dir(a.statePath()) != stateDir {
+		t.Errorf("statePath() = %q, want it in %s", a.statePath(), stateDir)
+	}
+}
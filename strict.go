@@ -0,0 +1,40 @@
+package main
+
+import "fmt"
+
+// uncertainFiles returns the files -strict counts against the pack: the ones
+// below -min-confidence and the ones left in the generic SFX fallback.
+// Dropped duplicates don't count, they're not going anywhere.
+func (ap *AudioProcessor) uncertainFiles() (uncertain []*AudioFile, total int) {
+	for i := range ap.audioFiles {
+		af := &ap.audioFiles[i]
+		if af.DuplicateStatus == duplicateDropped {
+			continue
+		}
+		total++
+		if af.Unresolved || af.Category == "SFX" {
+			uncertain = append(uncertain, af)
+		}
+	}
+	return uncertain, total
+}
+
+// checkStrict fails the run when more than -strict-ratio of the files
+// couldn't be categorized with confidence, listing them
+func (ap *AudioProcessor) checkStrict() error {
+	uncertain, total := ap.uncertainFiles()
+	if total == 0 || float64(len(uncertain))/float64(total) <= ap.config.StrictRatio {
+		return nil
+	}
+
+	ap.log.Warnf("\n⚠ %d of %d files couldn't be categorized with confidence:\n", len(uncertain), total)
+	for _, af := range uncertain {
+		if af.Unresolved {
+			ap.log.Warnf("  %s (guessed %s at %.2f)\n", af.OriginalPath, af.GuessedCategory, af.Confidence)
+		} else {
+			ap.log.Warnf("  %s (no category found)\n", af.OriginalPath)
+		}
+	}
+	return fmt.Errorf("-strict: %.0f%% of files are uncategorized, more than -strict-ratio allows (%.0f%%)",
+		100*float64(len(uncertain))/float64(total), 100*ap.config.StrictRatio)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestCheckStrict(t *testing.T) {
+	files := []AudioFile{
+		{OriginalPath: "door_creak.wav", Category: "SFX_Object", Confidence: 0.8},
+		{OriginalPath: "gun_shot.wav", Category: "SFX_Weapon", Confidence: 0.8},
+		{OriginalPath: "take_07.wav", Category: "SFX"},
+		{OriginalPath: "thing.wav", Category: "Unsorted", GuessedCategory: "SFX_Impact", Confidence: 0.2, Unresolved: true},
+		{OriginalPath: "copy.wav", Category: "SFX", DuplicateStatus: duplicateDropped},
+	}
+
+	tests := []struct {
+		ratio   float64
+		wantErr bool
+	}{
+		{0, true},
+		{0.4, true}, // 2 of 4
+		{0.5, false},
+		{1, false},
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(Config{PackName: "TestPack", Strict: true, StrictRatio: tt.ratio})
+		ap.log.out = io.Discard
+		ap.audioFiles = append([]AudioFile{}, files...)
+
+		uncertain, total := ap.uncertainFiles()
+		if len(uncertain) != 2 || total != 4 {
+			t.Fatalf("uncertainFiles() = %d of %d, want 2 of 4", len(uncertain), total)
+		}
+		if err := ap.checkStrict(); (err != nil) != tt.wantErr {
+			t.Errorf("ratio %.1f: checkStrict() error = %v, wantErr %v", tt.ratio, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// symlinkGuard remembers the real paths walked with -follow-symlinks, so a
+// link back up the tree doesn't loop forever and a file reachable both
+// directly and through a link is only picked up once. Links wait in pending
+// until the plain walk is done, so a file's real path wins over a link to it.
+type symlinkGuard struct {
+	dirs    map[string]bool
+	files   map[string]bool
+	pending []string // link paths still to follow
+}
+
+func newSymlinkGuard(root string) *symlinkGuard {
+	g := &symlinkGuard{dirs: make(map[string]bool), files: make(map[string]bool)}
+	g.enter(root)
+	return g
+}
+
+// enter reports whether dir hasn't been walked yet, and marks it walked
+func (g *symlinkGuard) enter(dir string) bool {
+	return g.mark(g.dirs, dir)
+}
+
+// first reports whether file hasn't been found yet, and marks it found
+func (g *symlinkGuard) first(file string) bool {
+	return g.mark(g.files, file)
+}
+
+func (g *symlinkGuard) mark(seen map[string]bool, path string) bool {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	if seen[path] {
+		return false
+	}
+	seen[path] = true
+	return true
+}
+
+// scanSymlink handles a symlink found in the source. By default links are
+// skipped and counted, with -follow-symlinks they're queued for followLinks.
+func (ap *AudioProcessor) scanSymlink(path string, guard *symlinkGuard) {
+	if !ap.config.FollowSymlinks {
+		ap.skippedSymlinks++
+		ap.log.Verbosef("%s: symlink, skipping it (see -follow-symlinks)", path)
+		return
+	}
+	guard.pending = append(guard.pending, path)
+}
+
+// followLinks scans the queued links: a linked file as if it were at the
+// link's path, and a linked directory unless it's one we've already been
+// through. Links found in linked directories join the queue.
+func (ap *AudioProcessor) followLinks(root string, guard *symlinkGuard) error {
+	for len(guard.pending) > 0 {
+		path := guard.pending[0]
+		guard.pending = guard.pending[1:]
+		if err := ap.followLink(root, path, guard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ap *AudioProcessor) followLink(root, path string, guard *symlinkGuard) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		ap.log.Warnf("⚠ %s: broken symlink, skipping it\n", path)
+		return nil
+	}
+
+	if info.IsDir() {
+		if ap.skipDir(root, path, filepath.Base(path)) {
+			return nil
+		}
+		if !guard.enter(path) {
+			ap.log.Verbosef("%s: links to a folder already scanned, skipping it", path)
+			return nil
+		}
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return err
+		}
+		return ap.walkDir(root, path, real, guard)
+	}
+
+	if guard.first(path) {
+		ap.addScannedFile(root, path)
+	}
+	return nil
+}
+
+func isSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// relocate moves a file to dst. A symlink (only scanned with -follow-symlinks)
+// is moved as a link: the target stays where it is and a relative link is
+// rewritten to still point at it from its new place.
+func (ap *AudioProcessor) relocate(src, dst string) error {
+	if ap.sandbox {
+		return sandboxCopy(src, dst)
+	}
+	if isSymlink(src) {
+		return moveSymlink(src, dst)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		// cross-device, fall back to copy + delete
+		return ap.moveFile(src, dst)
+	}
+	return nil
+}
+
+func moveSymlink(src, dst string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(target) {
+		absTarget := filepath.Join(filepath.Dir(src), target)
+		if target, err = filepath.Rel(filepath.Dir(dst), absTarget); err != nil {
+			target = absTarget
+		}
+	}
+	if err := os.Symlink(target, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestScanSymlinks(t *testing.T) {
+	root := t.TempDir()
+	elsewhere := t.TempDir()
+	for _, p := range []string{filepath.Join(root, "sfx", "boom.wav"), filepath.Join(elsewhere, "creak.wav")} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(root, "creak.wav"):    filepath.Join(elsewhere, "creak.wav"),  // a file outside the source
+		filepath.Join(root, "boom_alt.wav"): filepath.Join(root, "sfx", "boom.wav"), // the same file twice
+		filepath.Join(root, "sfx", "loop"):  root,                                   // a cycle
+		filepath.Join(root, "more"):         elsewhere,                              // a folder outside the source
+		filepath.Join(root, "gone.wav"):     filepath.Join(root, "missing.wav"),     // broken
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("can't create symlinks here: %v", err)
+		}
+	}
+
+	tests := []struct {
+		follow   bool
+		expected []string
+		skipped  int
+	}{
+		{false, []string{"sfx/boom.wav"}, len(links)},
+		// creak.wav is reached both directly and through "more", whichever comes first wins
+		{true, []string{"creak.wav", "sfx/boom.wav"}, 0},
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(Config{SourceDir: root, SourceDirs: []string{root}, OutputDir: root, FollowSymlinks: tt.follow})
+		ap.log.out = io.Discard
+		if err := ap.scanFiles(); err != nil {
+			t.Fatalf("follow=%v: scanFiles() error = %v", tt.follow, err)
+		}
+
+		var got []string
+		for _, af := range ap.audioFiles {
+			rel, _ := filepath.Rel(root, af.OriginalPath)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		sort.Strings(got)
+		if len(got) != len(tt.expected) || got[0] != tt.expected[0] || got[len(got)-1] != tt.expected[len(tt.expected)-1] {
+			t.Errorf("follow=%v: scanned %v, want %v", tt.follow, got, tt.expected)
+		}
+		if ap.skippedSymlinks != tt.skipped {
+			t.Errorf("follow=%v: skipped %d symlinks, want %d", tt.follow, ap.skippedSymlinks, tt.skipped)
+		}
+	}
+}
+
+func TestMoveSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "library", "boom.wav")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "boom.wav")
+	if err := os.Symlink(filepath.Join("library", "boom.wav"), link); err != nil {
+		t.Skipf("can't create symlinks here: %v", err)
+	}
+
+	dst := filepath.Join(dir, "out", "Sfx_Impact", "A_Pack_Impact_Boom.wav")
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ap := NewAudioProcessor(Config{})
+	if err := ap.relocate(link, dst); err != nil {
+		t.Fatalf("relocate() error = %v", err)
+	}
+
+	if !isSymlink(dst) {
+		t.Fatal("the link should be moved as a link")
+	}
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "audio" {
+		t.Errorf("moved link doesn't reach the target: %q, %v", data, err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Error("the old link should be gone")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("the target should stay where it was: %v", err)
+	}
+}
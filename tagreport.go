@@ -0,0 +1,48 @@
+package main
+
+import "sort"
+
+// tagCount is one line of the -tag-report histogram
+type tagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// tagHistogram counts the files carrying each distinct tag, most used first
+// and alphabetical among equals, so near-duplicate tags are easy to spot
+func tagHistogram(files []AudioFile) []tagCount {
+	counts := make(map[string]int)
+	for _, af := range files {
+		seen := make(map[string]bool, len(af.Tags))
+		for _, tag := range af.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				counts[tag]++
+			}
+		}
+	}
+
+	histogram := make([]tagCount, 0, len(counts))
+	for tag, count := range counts {
+		histogram = append(histogram, tagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(histogram, func(i, j int) bool {
+		if histogram[i].Count != histogram[j].Count {
+			return histogram[i].Count > histogram[j].Count
+		}
+		return histogram[i].Tag < histogram[j].Tag
+	})
+	return histogram
+}
+
+func (ap *AudioProcessor) displayTagReport() {
+	histogram := tagHistogram(ap.audioFiles)
+
+	ap.log.Printf("\n=== Tags (%d distinct across %d files) ===\n", len(histogram), len(ap.audioFiles))
+	for _, tc := range histogram {
+		ap.log.Printf("  %-32s %5d\n", tc.Tag, tc.Count)
+	}
+	if len(histogram) == 0 {
+		ap.log.Println("  (none)")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTagHistogram(t *testing.T) {
+	files := []AudioFile{
+		{Tags: []string{"fx", "combat", "hq"}},
+		{Tags: []string{"combat", "hq", "hq"}}, // a repeated tag counts once
+		{Tags: []string{"hq", "src:BW"}},
+		{},
+	}
+
+	want := []tagCount{{"hq", 3}, {"combat", 2}, {"fx", 1}, {"src:BW", 1}}
+	if got := tagHistogram(files); !reflect.DeepEqual(got, want) {
+		t.Errorf("tagHistogram() = %v, want %v", got, want)
+	}
+	if got := tagHistogram(nil); len(got) != 0 {
+		t.Errorf("tagHistogram(nil) = %v, want none", got)
+	}
+}
+
+func TestManifestTagHistogram(t *testing.T) {
+	files := []AudioFile{{OriginalName: "a.wav", Tags: []string{"fx"}}, {OriginalName: "b.wav", Tags: []string{"fx", "hq"}}}
+
+	for _, report := range []bool{false, true} {
+		ap := NewAudioProcessor(Config{TagReport: report})
+		ap.log.out = io.Discard
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		if err := ap.writeManifest(path, files, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var manifest struct {
+			TagHistogram []tagCount `json:"tag_histogram"`
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatal(err)
+		}
+
+		var want []tagCount
+		if report {
+			want = []tagCount{{"fx", 2}, {"hq", 1}}
+		}
+		if !reflect.DeepEqual(manifest.TagHistogram, want) {
+			t.Errorf("-tag-report %v: tag_histogram = %v, want %v", report, manifest.TagHistogram, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// the naming scheme tidy-rename has always used
+	defaultNameTemplate = "A_{pack}_{category}_{subcategory}"
+
+	defaultDateFormat = "20060102" // Go reference time layout for {date}
+)
+
+var (
+	templateToken   = regexp.MustCompile(`\{([a-z]+)\}`)
+	templateLiteral = regexp.MustCompile(`^[A-Za-z0-9_]*$`)
+)
+
+// tokens a -template can use
+var templateTokens = map[string]bool{
+	"pack":        true,
+	"category":    true, // without SFX_
+	"subcategory": true,
+	"source":      true,
+	"id":          true,
+	"bpm":         true, // like 120BPM
+	"key":         true, // like Am, or Fsm for F#m
+	"scene":       true, // from the iXML chunk
+	"take":        true, // from the iXML chunk
+	"version":     true, // -pack-version
+	"date":        true, // the run's date in -date-format
+}
+
+// validateTemplate checks that a -template only uses known tokens and
+// characters allowed in UE5 asset names
+func validateTemplate(tmpl string) error {
+	for _, m := range templateToken.FindAllStringSubmatch(tmpl, -1) {
+		if !templateTokens[m[1]] {
+			return fmt.Errorf("unknown token {%s}", m[1])
+		}
+	}
+	if literal := templateToken.ReplaceAllString(tmpl, ""); !templateLiteral.MatchString(literal) {
+		return fmt.Errorf("only letters, digits, underscores and {tokens} are allowed")
+	}
+	if !templateToken.MatchString(tmpl) {
+		return fmt.Errorf("no tokens, every file would get the same name")
+	}
+	return nil
+}
+
+// nameTemplate returns the template new names are built from, behind the
+// prefix. A leading A_ in the template stands for the prefix, so it's left
+// out here and renderName puts the actual one (if any) in its place.
+// -bpm-in-name adds the tempo to the end when the template doesn't place it
+// itself.
+func (ap *AudioProcessor) nameTemplate() string {
+	tmpl := ap.config.Template
+	if tmpl == "" {
+		tmpl = defaultNameTemplate
+	}
+	tmpl = strings.TrimPrefix(tmpl, defaultAssetPrefix+"_")
+	if ap.config.BPMInName && !strings.Contains(tmpl, "{bpm}") {
+		tmpl += "_{bpm}"
+	}
+	return tmpl
+}
+
+// templateValues returns the cleaned value of each token for a file
+func (ap *AudioProcessor) templateValues(af *AudioFile) map[string]string {
+	values := map[string]string{
+		"pack":        ap.cleanNameWithCase(ap.config.PackName),
+		"category":    ap.cleanNamePart(strings.TrimPrefix(af.Category, "SFX_")), // SFX_ is implied
+		"subcategory": ap.cleanNamePart(af.SubCategory),
+		"source":      ap.cleanNamePart(af.Source),
+		"id":          ap.cleanNamePart(af.ID),
+		"version":     ap.cleanNamePart(ap.config.PackVersion),
+		"prefix":      ap.assetPrefix(af.Category), // not a token, renderName puts it in front
+	}
+	if af.AudioMeta != nil && af.AudioMeta.BPM > 0 {
+		values["bpm"] = fmt.Sprintf("%.0fBPM", af.AudioMeta.BPM)
+	}
+	if af.AudioMeta != nil && af.AudioMeta.Key != "" {
+		values["key"] = strings.ReplaceAll(af.AudioMeta.Key, "#", "s") // no # in asset names
+	}
+	if af.AudioMeta != nil && af.AudioMeta.IXML != nil {
+		values["scene"] = ap.cleanNamePart(af.AudioMeta.IXML.Scene)
+		values["take"] = ap.cleanNamePart(af.AudioMeta.IXML.Take)
+	}
+
+	dateFormat := ap.config.DateFormat
+	if dateFormat == "" {
+		dateFormat = defaultDateFormat
+	}
+	values["date"] = ap.cleanNamePart(ap.runDate.Format(dateFormat))
+
+	return values
+}
+
+// renderTemplate fills in the tokens of an underscore-separated template.
+// A segment whose tokens are all empty is left out along with its underscore,
+// so "A_{pack}_v{version}_{category}" without a version is just "A_Pack_Door".
+func renderTemplate(tmpl string, values map[string]string) string {
+	var segments []string
+	for _, segment := range strings.Split(tmpl, "_") {
+		hasToken, filled := false, false
+		rendered := templateToken.ReplaceAllStringFunc(segment, func(token string) string {
+			hasToken = true
+			value := values[token[1:len(token)-1]]
+			if value != "" {
+				filled = true
+			}
+			return value
+		})
+		if rendered == "" || hasToken && !filled {
+			continue
+		}
+		segments = append(segments, rendered)
+	}
+	return strings.Join(segments, "_")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	values := map[string]string{"pack": "Horror", "category": "Voice", "subcategory": "Scream_Male", "version": "2"}
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{defaultNameTemplate, "A_Horror_Voice_Scream_Male"},
+		{"A_{pack}_v{version}_{category}", "A_Horror_v2_Voice"},
+		{"A_{pack}_v{date}_{category}", "A_Horror_Voice"}, // empty token takes its literal with it
+		{"A_{pack}_{source}_{id}_{category}", "A_Horror_Voice"},
+		{"A__{pack}", "A_Horror"},
+		{"SFX_{category}{subcategory}", "SFX_VoiceScream_Male"},
+	}
+
+	for _, tt := range tests {
+		if got := renderTemplate(tt.tmpl, values); got != tt.want {
+			t.Errorf("renderTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		wantErr bool
+	}{
+		{defaultNameTemplate, false},
+		{"A_{pack}_v{version}_{category}_{subcategory}_{date}", false},
+		{"A_{pack}_{flavor}", true},
+		{"A-{pack}-{category}", true},
+		{"A_Fixed_Name", true},
+	}
+
+	for _, tt := range tests {
+		if err := validateTemplate(tt.tmpl); (err != nil) != tt.wantErr {
+			t.Errorf("validateTemplate(%q) error = %v, want error %v", tt.tmpl, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGenerateUE5NameTemplate(t *testing.T) {
+	af := &AudioFile{OriginalName: "scream.wav", Category: "SFX_Voice", SubCategory: "Scream"}
+
+	tests := []struct {
+		name       string
+		template   string
+		version    string
+		dateFormat string
+		want       string
+	}{
+		{"version and date", "A_{pack}_v{version}_{category}_{subcategory}_{date}", "2", "", "A_Pack_v2_Voice_Scream_20240115.wav"},
+		{"date separators cleaned", "A_{pack}_{category}_{date}", "", "2006-01-02", "A_Pack_Voice_2024_01_15.wav"},
+		{"no version", "A_{pack}_v{version}_{category}_{subcategory}", "", "", "A_Pack_Voice_Scream.wav"},
+		{"dotted version", "A_{pack}_v{version}_{category}", "1.2", "", "A_Pack_v12_Voice.wav"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: "Pack", Template: tt.template, PackVersion: tt.version, DateFormat: tt.dateFormat})
+			ap.runDate = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+			if got := ap.generateUE5Name(af); got != tt.want {
+				t.Errorf("generateUE5Name() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateUE5NameTempoTags(t *testing.T) {
+	af := &AudioFile{OriginalName: "bass_loop.mp3", Category: "Music", SubCategory: "Bass_Loop", AudioMeta: &tidy.AudioMetadata{BPM: 95, Key: "F#m"}}
+	ap := NewAudioProcessor(Config{PackName: "Pack", Template: "A_{pack}_{category}_{subcategory}_{bpm}_{key}"})
+
+	if got, want := ap.generateUE5Name(af), "A_Pack_Music_Bass_Loop_95BPM_Fsm.mp3"; got != want {
+		t.Errorf("generateUE5Name() = %q, want %q", got, want)
+	}
+
+	af.AudioMeta = nil // no tags, the segments go away
+	if got, want := ap.generateUE5Name(af), "A_Pack_Music_Bass_Loop.mp3"; got != want {
+		t.Errorf("generateUE5Name() without tempo = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateUE5NameSceneTake(t *testing.T) {
+	af := &AudioFile{OriginalName: "T003.wav", Category: "Foley", SubCategory: "Footsteps", AudioMeta: &tidy.AudioMetadata{IXML: &tidy.IXMLInfo{Scene: "12A", Take: "3"}}}
+	ap := NewAudioProcessor(Config{PackName: "Pack", Template: "A_{pack}_{category}_S{scene}_T{take}"})
+
+	if got, want := ap.generateUE5Name(af), "A_Pack_Foley_S12A_T3.wav"; got != want {
+		t.Errorf("generateUE5Name() = %q, want %q", got, want)
+	}
+
+	af.AudioMeta = nil // no iXML, the segments go away
+	if got, want := ap.generateUE5Name(af), "A_Pack_Foley.wav"; got != want {
+		t.Errorf("generateUE5Name() without iXML = %q, want %q", got, want)
+	}
+}
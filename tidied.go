@@ -0,0 +1,103 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kemaswara/tidy-rename/pkg/tidy"
+)
+
+// tokens whose value is the same for every file in a run
+var runTokens = map[string]bool{"pack": true, "version": true}
+
+// tidiedPrefix returns how every name from the current template in a category
+// with the given asset prefix starts: its leading segments that don't depend
+// on the file, like "A_HorrorPack_". A file already starting with it was named
+// by an earlier run. The asset prefix alone says nothing, plenty of vendor
+// files start with A_ too, so without a constant part like {pack} there's no
+// prefix to recognize.
+func (ap *AudioProcessor) tidiedPrefix(assetPrefix string) string {
+	constant := strings.TrimSuffix(templatePrefixSegments(ap.nameTemplate()), "_")
+	prefix := renderTemplate(constant, map[string]string{
+		"pack":    ap.cleanNameWithCase(ap.config.PackName),
+		"version": ap.cleanNamePart(ap.config.PackVersion),
+	})
+	if prefix == "" {
+		return ""
+	}
+
+	// same rule as renderName
+	return withAssetPrefix(prefix, assetPrefix) + "_"
+}
+
+// parseTidied recognizes a name an earlier run produced and takes it apart
+// again, so re-running over renamed files leaves them alone instead of
+// prefixing them a second time. When the template puts the category right
+// after the prefix, it's read back from there; otherwise it's guessed from the
+// rest of the name like any other file.
+func (ap *AudioProcessor) parseTidied(name string) (parsedName, bool) {
+	var rest string
+	for _, assetPrefix := range ap.assetPrefixes() {
+		prefix := ap.tidiedPrefix(assetPrefix)
+		if prefix == "" {
+			continue
+		}
+		if r, ok := strings.CutPrefix(name, prefix); ok && r != "" {
+			rest = r
+			break
+		}
+	}
+	if rest == "" {
+		return parsedName{}, false
+	}
+
+	tmpl := ap.nameTemplate()
+	afterPrefix := strings.TrimPrefix(tmpl, templatePrefixSegments(tmpl))
+	if !strings.HasPrefix(afterPrefix, "{category}_") && afterPrefix != "{category}" {
+		return parsedName{Category: tidy.InferCategory(rest), SubCategory: rest}, true
+	}
+
+	category, subCategory, _ := strings.Cut(rest, "_")
+	return parsedName{
+		Category:    ap.categoryFromName(category),
+		SubCategory: subCategory,
+		Explicit:    true,
+	}, true
+}
+
+// templatePrefixSegments returns the leading segments of tmpl that only use
+// run-wide tokens, with their trailing underscore
+func templatePrefixSegments(tmpl string) string {
+	var b strings.Builder
+	for _, segment := range strings.Split(tmpl, "_") {
+		for _, m := range templateToken.FindAllStringSubmatch(segment, -1) {
+			if !runTokens[m[1]] {
+				return b.String()
+			}
+		}
+		b.WriteString(segment + "_")
+	}
+	return b.String()
+}
+
+// categoryFromName maps the category part of a generated name ("Voice", "Sfx")
+// back onto the category it was made from
+func (ap *AudioProcessor) categoryFromName(part string) string {
+	candidates := []string{"SFX", ap.config.FallbackCategory}
+	for _, rule := range tidy.CategoryRules {
+		candidates = append(candidates, rule.Category)
+	}
+	normalized := make([]string, 0, len(tidy.CategoryNormalization))
+	for _, category := range tidy.CategoryNormalization {
+		normalized = append(normalized, category)
+	}
+	sort.Strings(normalized) // map order would make odd matches random
+	candidates = append(candidates, normalized...)
+
+	for _, category := range candidates {
+		if category != "" && ap.cleanNamePart(strings.TrimPrefix(category, "SFX_")) == part {
+			return category
+		}
+	}
+	return tidy.NormalizeCategory(part)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTidied(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		file     string
+		ok       bool
+		category string
+		sub      string
+	}{
+		{"default template", "", "A_HorrorPack_Voice_Scream_Male", true, "SFX_Voice", "Scream_Male"},
+		{"generic category", "", "A_HorrorPack_Sfx_Thing", true, "SFX", "Thing"},
+		{"fallback category", "", "A_HorrorPack_Uncategorized_Thing", true, "SFX_Uncategorized", "Thing"},
+		{"another pack", "", "A_OtherPack_Voice_Scream", false, "", ""},
+		{"raw file", "", "scream_male", false, "", ""},
+		{"prefix only", "", "A_HorrorPack_", false, "", ""},
+		{"versioned", "A_{pack}_v{version}_{category}_{subcategory}", "A_HorrorPack_v2_Impact_Hit", true, "SFX_Impact", "Hit"},
+		{"category not next", "A_{pack}_{subcategory}_{category}", "A_HorrorPack_Scream_Voice", true, "SFX_Voice", "Scream_Voice"},
+		{"nothing constant", "A_{category}_{pack}", "A_Door_Creak", false, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: "HorrorPack", PackVersion: "2", Template: tt.template, FallbackCategory: defaultFallbackCategory})
+			parsed, ok := ap.parseTidied(tt.file)
+			if ok != tt.ok {
+				t.Fatalf("parseTidied(%q) ok = %v, want %v", tt.file, ok, tt.ok)
+			}
+			if ok && (parsed.Category != tt.category || parsed.SubCategory != tt.sub) {
+				t.Errorf("parseTidied(%q) = %s/%s, want %s/%s", tt.file, parsed.Category, parsed.SubCategory, tt.category, tt.sub)
+			}
+		})
+	}
+}
+
+func TestTidiedPrefix(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"", "A_HorrorPack_"},
+		{"A_{pack}_v{version}_{category}", "A_HorrorPack_v2_"},
+		{"{pack}_{category}", "A_HorrorPack_"},
+		{"A_{category}_{pack}", ""}, // A_ alone would match every vendor file starting with A_
+	}
+
+	for _, tt := range tests {
+		ap := NewAudioProcessor(Config{PackName: "HorrorPack", PackVersion: "2", Template: tt.template})
+		if got := ap.tidiedPrefix(defaultAssetPrefix); got != tt.want {
+			t.Errorf("tidiedPrefix() for %q = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestRerunOnRenamedOutput(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	for _, name := range []string{"scream_male.wav", "door_creak_01.wav", "footstep_gravel.wav", "wind_howl.wav", "misc_thing.wav"} {
+		if err := os.WriteFile(filepath.Join(src, name), fakeAudio, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, Workers: 1})
+	first.log.out = io.Discard
+	if err := first.Process(context.Background()); err != nil {
+		t.Fatalf("first run: Process() error = %v", err)
+	}
+
+	// point it at its own output, nothing should move or be renamed
+	ap := NewAudioProcessor(Config{SourceDir: out, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, Workers: 1, DryRun: true})
+	ap.log.out = io.Discard
+	if err := ap.scanFiles(); err != nil {
+		t.Fatal(err)
+	}
+	ap.analyzeAudioFiles(context.Background())
+	ap.parseFiles()
+	if err := ap.generateNewNames(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ap.audioFiles) != 5 {
+		t.Fatalf("second run found %d files, want 5", len(ap.audioFiles))
+	}
+	for _, af := range ap.audioFiles {
+		if !af.AlreadyNamed {
+			t.Errorf("%s: not recognized as already named", af.OriginalName)
+		}
+		if got := ap.outputPath(&af); got != af.OriginalPath {
+			t.Errorf("%s: would move to %s", af.OriginalPath, got)
+		}
+	}
+}
+
+func TestRerunLeavesFilesInPlace(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	for _, name := range []string{"scream_male.wav", "door_creak_01.wav", "wind_howl.wav"} {
+		if err := os.WriteFile(filepath.Join(src, name), fakeAudio, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first := NewAudioProcessor(Config{SourceDir: src, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, Workers: 1})
+	first.log.out = io.Discard
+	if err := first.Process(context.Background()); err != nil {
+		t.Fatalf("first run: Process() error = %v", err)
+	}
+
+	// the same folder spelled relative as the source and absolute as the output
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(cwd, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	ap := NewAudioProcessor(Config{SourceDir: rel, SourceDirs: []string{rel}, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, Workers: 1})
+	ap.log.out = &log
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("second run: Process() error = %v", err)
+	}
+
+	for _, af := range ap.audioFiles {
+		if status := ap.fileStatus(&af); status != statusUnchanged {
+			t.Errorf("%s: status %q, want %q", af.OriginalPath, status, statusUnchanged)
+		}
+		if _, err := os.Stat(af.OriginalPath); err != nil {
+			t.Errorf("%s: %v", af.OriginalPath, err)
+		}
+	}
+	if !strings.Contains(log.String(), "3 files already had the right name and place") {
+		t.Errorf("second run didn't tally the unchanged files:\n%s", log.String())
+	}
+}
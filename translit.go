@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"hash/crc32"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// letters that don't decompose into an ASCII base plus accents
+var transliterations = map[rune]string{
+	'ß': "ss", 'ẞ': "SS",
+	'æ': "ae", 'Æ': "AE",
+	'œ': "oe", 'Œ': "OE",
+	'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D",
+	'ð': "d", 'Ð': "D",
+	'ł': "l", 'Ł': "L",
+	'þ': "th", 'Þ': "TH",
+	'ı': "i",
+}
+
+// normalizeName puts name in NFC so the same text always cleans up the same way,
+// whether the filesystem stored it composed (macOS doesn't) or not. With
+// transliterate, accented letters are folded to ASCII ("é" -> "e", "ß" -> "ss").
+func (ap *AudioProcessor) normalizeName(name string) string {
+	if !ap.config.Transliterate {
+		return norm.NFC.String(name)
+	}
+
+	var b strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		if unicode.Is(unicode.Mn, r) {
+			continue // accent split off by NFD
+		}
+		if s, ok := transliterations[r]; ok {
+			b.WriteString(s)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+var (
+	nameWordPattern = regexp.MustCompile(`[^\s_\-]+`)
+	nonASCIIPattern = regexp.MustCompile(`[^a-zA-Z0-9]`)
+)
+
+// replaceUnreadableWords swaps each word that would be stripped to nothing by the
+// alphanumeric filter, but does have letters, for a placeholder. Only done when
+// transliterating, otherwise non-ASCII words are dropped like before.
+func (ap *AudioProcessor) replaceUnreadableWords(name string) string {
+	if !ap.config.Transliterate {
+		return name
+	}
+	return nameWordPattern.ReplaceAllStringFunc(name, func(word string) string {
+		if nonASCIIPattern.ReplaceAllString(word, "") == "" && hasLetters(word) {
+			return placeholderName(word)
+		}
+		return word
+	})
+}
+
+// placeholderName stands in for a part that had letters but none we could turn
+// into ASCII, like a CJK word. It's derived from the original text so different
+// words get different placeholders.
+func placeholderName(original string) string {
+	return fmt.Sprintf("U%06x", crc32.ChecksumIEEE([]byte(norm.NFC.String(original)))&0xFFFFFF)
+}
+
+// hasLetters reports whether s has any letters or digits, in any script
+func hasLetters(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
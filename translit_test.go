@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanNamePartTransliterate(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		transliterate bool
+		expected      string
+	}{
+		{"accents dropped by default", "café_crème", false, "Caf_Crme"},
+		{"accents folded", "café_crème", true, "Cafe_Creme"},
+		{"umlaut", "Tür_knarren", true, "Tur_Knarren"},
+		{"decomposed input", "cafe\u0301", true, "Cafe"},
+		{"special letters", "straße_øre", true, "Strasse_Ore"},
+		{"ascii untouched", "door_slam", true, "Door_Slam"},
+		{"cjk dropped by default", "森林_wind", false, "Wind"},
+		{"cjk placeholder", "森林_wind", true, placeholderName("森林") + "_Wind"},
+		{"only punctuation still dropped", "!!_wind", true, "Wind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{Transliterate: tt.transliterate})
+			if got := ap.cleanNamePart(tt.input); got != tt.expected {
+				t.Errorf("cleanNamePart(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPlaceholderName(t *testing.T) {
+	a, b := placeholderName("森林"), placeholderName("雨")
+	if a == b {
+		t.Errorf("different words got the same placeholder %q", a)
+	}
+	if a != placeholderName("森林") {
+		t.Errorf("placeholder isn't stable")
+	}
+	if !strings.HasPrefix(a, "U") || len(a) != 7 {
+		t.Errorf("placeholderName() = %q, want U and 6 hex digits", a)
+	}
+}
+
+func TestGenerateUE5NameCJK(t *testing.T) {
+	af := AudioFile{OriginalName: "雨.wav", Category: "Ambient", SubCategory: "雨"}
+
+	ap := NewAudioProcessor(Config{PackName: "東京", Transliterate: true})
+	got := ap.generateUE5Name(&af)
+	want := "A_" + placeholderName("東京") + "_Ambient_" + placeholderName("雨") + ".wav"
+	if got != want {
+		t.Errorf("generateUE5Name() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var ue5IllegalChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// UE5Validate returns name as a legal UE5 asset name: only letters, digits and
+// underscores, starting with a letter, which prefix is put in front of when
+// it doesn't. Without a prefix (-prefix "", names aren't for UE5) a leading
+// digit stays. An extension is kept as it is. ok is false when the name had
+// to be changed.
+func UE5Validate(name, prefix string) (string, bool) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	fixed := ue5IllegalChars.ReplaceAllString(base, "_")
+	if strings.Trim(fixed, "_") == "" {
+		fixed = withAssetPrefix("Unnamed", prefix)
+	}
+	if c := fixed[0]; !('A' <= c && c <= 'Z' || 'a' <= c && c <= 'z') {
+		fixed = withAssetPrefix(strings.TrimLeft(fixed, "_"), prefix)
+	}
+
+	return fixed + ext, fixed == base
+}
+
+// safeName makes a generated name legal and, with -max-name-length, short
+// enough. For names built from the template (values isn't nil), subcategory
+// words are dropped from the end until it fits, so the pack, category and ID
+// survive. Anything still too long is cut off. Names an earlier run gave are
+// left as long as they are, shortening them would rename a stable library.
+func (ap *AudioProcessor) safeName(af *AudioFile, name string, values map[string]string) string {
+	if fixed, ok := UE5Validate(name, ap.assetPrefix(af.Category)); !ok {
+		ap.log.Verbosef("%s: %s isn't a legal UE5 name, using %s", af.OriginalName, name, fixed)
+		name = fixed
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	maxLength := ap.config.MaxNameLength
+	if maxLength <= 0 || len(base) <= maxLength || af.AlreadyNamed {
+		return name
+	}
+
+	if values != nil {
+		words := strings.Split(values["subcategory"], "_")
+		for len(words) > 0 && len(base) > maxLength {
+			words = words[:len(words)-1]
+			values["subcategory"] = strings.Join(words, "_")
+			base = ap.renderName(values)
+		}
+	}
+
+	ap.markTruncated(af, fmt.Sprintf("over %d characters", maxLength))
+	return truncateName(base, maxLength) + ext
+}
+
+// truncateName cuts name down to maxLength without leaving an underscore at the end
+func truncateName(name string, maxLength int) string {
+	if len(name) <= maxLength {
+		return name
+	}
+	return strings.TrimRight(name[:maxLength], "_")
+}
+
+func (ap *AudioProcessor) markTruncated(af *AudioFile, why string) {
+	ap.log.Verbosef("%s: name shortened, %s", af.OriginalName, why)
+	af.Tags = mergeTags(af.Tags, []string{"truncated"})
+}
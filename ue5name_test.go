@@ -0,0 +1,101 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestUE5Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"A_Pack_Voice_Scream.wav", "A_Pack_Voice_Scream.wav", true},
+		{"A_Pack_Voice_Scream", "A_Pack_Voice_Scream", true},
+		{"808_Kick.wav", "A_808_Kick.wav", false},
+		{"_Hidden.wav", "A_Hidden.wav", false},
+		{"Door Slam (Take 2).wav", "Door_Slam_Take_2_.wav", false},
+		{"Füße.wav", "F_e.wav", false},
+		{"A-B.C.wav", "A_B_C.wav", false},
+		{"!!!.wav", "A_Unnamed.wav", false},
+		{".wav", "A_Unnamed.wav", false},
+		{"Double__Underscore.wav", "Double__Underscore.wav", true},
+	}
+
+	for _, tt := range tests {
+		got, ok := UE5Validate(tt.name, defaultAssetPrefix)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("UE5Validate(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSafeNameLength(t *testing.T) {
+	tests := []struct {
+		name          string
+		pack          string
+		template      string
+		maxLength     int
+		subCategory   string
+		want          string
+		wantTruncated bool
+	}{
+		{"fits", "Pack", "", 64, "Scream_Male", "A_Pack_Voice_Scream_Male.wav", false},
+		{"no limit by default", "Pack", "", 0, "Scream_Male_Distant_Take", "A_Pack_Voice_Scream_Male_Distant_Take.wav", false},
+		{"drops subcategory words", "Pack", "", 23, "Scream_Male_Distant_Take", "A_Pack_Voice_Scream.wav", true},
+		{"keeps the id", "Pack", "A_{pack}_{category}_{subcategory}_{id}", 28, "Scream_Male_Distant", "A_Pack_Voice_Scream_X042.wav", true},
+		{"no subcategory left, cut off", "VeryLongPackName", "A_{pack}_{category}_{subcategory}", 16, "Scream", "A_VeryLongPackNa.wav", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewAudioProcessor(Config{PackName: tt.pack, Template: tt.template, MaxNameLength: tt.maxLength})
+			ap.log.out = io.Discard
+			af := &AudioFile{OriginalName: "x.wav", Category: "SFX_Voice", SubCategory: tt.subCategory, ID: "X042"}
+
+			got := ap.safeName(af, ap.generateUE5Name(af), ap.templateValues(af))
+			if got != tt.want {
+				t.Errorf("safeName() = %q, want %q", got, tt.want)
+			}
+			if contains(af.Tags, "truncated") != tt.wantTruncated {
+				t.Errorf("tags = %v, want truncated %v", af.Tags, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestSafeNameKeepsAlreadyNamed(t *testing.T) {
+	ap := NewAudioProcessor(Config{PackName: "Pack", MaxNameLength: 16})
+	ap.log.out = io.Discard
+	af := &AudioFile{OriginalName: "A_Pack_Voice_Scream_Male.wav", Category: "SFX_Voice", SubCategory: "Scream_Male", AlreadyNamed: true}
+
+	if got, want := ap.safeName(af, af.OriginalName, nil), "A_Pack_Voice_Scream_Male.wav"; got != want {
+		t.Errorf("safeName() = %q, want %q kept as an earlier run named it", got, want)
+	}
+	if contains(af.Tags, "truncated") {
+		t.Errorf("tags = %v, an already named file shouldn't be truncated", af.Tags)
+	}
+}
+
+func TestCollisionSuffixStaysWithinLength(t *testing.T) {
+	ap := NewAudioProcessor(Config{PackName: "Pack", MaxNameLength: 20, OutputDir: t.TempDir()})
+	ap.log.out = io.Discard
+	ap.audioFiles = []AudioFile{
+		{OriginalPath: "a/door.wav", OriginalName: "door.wav", Category: "SFX_Door", SubCategory: "Creaky"},
+		{OriginalPath: "b/door.wav", OriginalName: "door.wav", Category: "SFX_Door", SubCategory: "Creaky"},
+	}
+	if err := ap.generateNewNames(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"A_Pack_Door_Creaky.wav", "A_Pack_Door_Creak_01.wav"}
+	for i, af := range ap.audioFiles {
+		if af.NewName != want[i] {
+			t.Errorf("file %d = %q, want %q", i, af.NewName, want[i])
+		}
+	}
+	if !contains(ap.audioFiles[1].Tags, "truncated") {
+		t.Errorf("numbered file should be tagged truncated, got %v", ap.audioFiles[1].Tags)
+	}
+}
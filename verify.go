@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// kinds of discrepancy -verify reports
+const (
+	verifyMissing = "missing" // not where the manifest says, and not anywhere else either
+	verifyMoved   = "moved"   // not where the manifest says, but a file of that name is elsewhere
+	verifyChanged = "changed" // where the manifest says, but its size or audio fingerprint differs
+	verifyAdded   = "added"   // an audio file the manifest doesn't know about
+)
+
+// discrepancy is one thing -verify found wrong with the library
+type discrepancy struct {
+	Kind    string
+	Path    string // where the file should be, or where an added file is
+	FoundAt string // where a moved file turned up
+}
+
+// Verify checks the output directory against its manifest without changing
+// anything: every recorded file should be at the path its run put it, with
+// the same size and audio fingerprint, and there should be no audio files the
+// manifest doesn't list. Names aren't checked against the template, a file
+// renamed by hand is just missing and added. It reports what it finds and
+// returns the discrepancies.
+func (ap *AudioProcessor) Verify() ([]discrepancy, error) {
+	files, err := readManifestFiles(ap.manifestPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	ap.log.Infof("Verifying %d files from %s\n", len(files), ap.manifestPath())
+
+	onDisk, err := ap.scanLibrary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", ap.config.OutputDir, err)
+	}
+	byName := make(map[string][]string)
+	for path := range onDisk {
+		byName[filepath.Base(path)] = append(byName[filepath.Base(path)], path)
+	}
+
+	var (
+		mu       sync.Mutex
+		problems []discrepancy
+		known    = make(map[string]bool, len(files))
+	)
+	for i := range files {
+		known[filepath.Clean(ap.recordedPath(&files[i]))] = true
+	}
+
+	// fingerprinting means analyzing every file again, spread it over the workers
+	ap.audioFiles = files
+	ap.forEachFile(func(af *AudioFile) {
+		path := filepath.Clean(ap.recordedPath(af))
+		var found *discrepancy
+		switch {
+		case af.DuplicateStatus == duplicateDropped:
+			return // moved aside or deleted, depending on -dedupe-action
+		case !onDisk[path]:
+			found = &discrepancy{Kind: verifyMissing, Path: path}
+			for _, other := range byName[filepath.Base(path)] {
+				if !known[other] {
+					found = &discrepancy{Kind: verifyMoved, Path: path, FoundAt: other}
+					break
+				}
+			}
+		case af.Size > 0 && fileSize(path) != af.Size: // manifests from before sizes were recorded have none
+			found = &discrepancy{Kind: verifyChanged, Path: path}
+		case af.AudioMeta != nil && af.AudioMeta.Fingerprint != "":
+			meta, err := ap.audioAnalyzer.AnalyzeFile(path)
+			if err != nil || meta.Fingerprint != af.AudioMeta.Fingerprint {
+				found = &discrepancy{Kind: verifyChanged, Path: path}
+			}
+		}
+		if found != nil {
+			mu.Lock()
+			problems = append(problems, *found)
+			mu.Unlock()
+		}
+	})
+
+	movedTo := make(map[string]bool)
+	for _, p := range problems {
+		movedTo[p.FoundAt] = true
+	}
+	for path := range onDisk {
+		if !known[path] && !movedTo[path] {
+			problems = append(problems, discrepancy{Kind: verifyAdded, Path: path})
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Kind != problems[j].Kind {
+			return problems[i].Kind < problems[j].Kind
+		}
+		return problems[i].Path < problems[j].Path
+	})
+	ap.displayDiscrepancies(len(files), problems)
+	return problems, nil
+}
+
+// recordedPath is where a manifest's file should be: the path it recorded
+// relative to the output directory, or for manifests from before those its
+// output path, or its original path if the run left it in place
+func (ap *AudioProcessor) recordedPath(af *AudioFile) string {
+	if af.NewRelativePath != "" {
+		return filepath.Join(ap.config.OutputDir, filepath.FromSlash(af.NewRelativePath))
+	}
+	if af.SkipReason != "" || ap.config.Catalog {
+		return af.OriginalPath
+	}
+	return ap.outputPath(af)
+}
+
+// fileSize returns the size of the file at path, or -1 if it can't be read
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+// scanLibrary lists the audio files in the output directory, by cleaned path
+func (ap *AudioProcessor) scanLibrary() (map[string]bool, error) {
+	found := make(map[string]bool)
+	err := filepath.WalkDir(ap.config.OutputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != ap.config.OutputDir && ap.config.SkipHidden && isHidden(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isAppleDouble(d.Name()) || ap.config.SkipHidden && isHidden(d.Name()) {
+			return nil
+		}
+		if ap.extensions[strings.ToLower(filepath.Ext(path))] {
+			found[filepath.Clean(path)] = true
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (ap *AudioProcessor) displayDiscrepancies(total int, problems []discrepancy) {
+	counts := make(map[string]int)
+	for _, p := range problems {
+		counts[p.Kind]++
+		if p.Kind == verifyMoved {
+			ap.log.Printf("  %-8s %s (now at %s)\n", p.Kind, p.Path, p.FoundAt)
+		} else {
+			ap.log.Printf("  %-8s %s\n", p.Kind, p.Path)
+		}
+	}
+
+	if len(problems) == 0 {
+		ap.log.Printf("\n✓ All %d files match the manifest\n", total)
+		return
+	}
+	ap.log.Warnf("\n⚠ %d discrepancies: %d missing, %d moved, %d changed, %d added\n",
+		len(problems), counts[verifyMissing], counts[verifyMoved], counts[verifyChanged], counts[verifyAdded])
+}
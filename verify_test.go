@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	for name, seconds := range map[string]int{"door_creak.wav": 1, "gun_shot.wav": 2, "wind_howl.wav": 3, "rain_loop.wav": 4} {
+		pcm := make([]byte, 48000*2*seconds)
+		if err := os.WriteFile(filepath.Join(src, name), buildTestWAV(48000, 1, 16, pcm), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "TestPack", Layout: layoutCategory, CreateManifest: true, Workers: 2}
+	ap := NewAudioProcessor(config)
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	verify := func() map[string]int {
+		t.Helper()
+		v := NewAudioProcessor(config)
+		v.log.out = io.Discard
+		problems, err := v.Verify()
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		counts := make(map[string]int)
+		for _, p := range problems {
+			counts[p.Kind]++
+		}
+		return counts
+	}
+
+	if counts := verify(); len(counts) != 0 {
+		t.Fatalf("fresh library has discrepancies: %v", counts)
+	}
+
+	paths := make(map[string]string)
+	for _, af := range ap.audioFiles {
+		paths[af.OriginalName] = ap.outputPath(&af)
+	}
+
+	// move one, delete one, replace one with different audio, grow one, add a stranger
+	if err := os.Rename(paths["door_creak.wav"], filepath.Join(out, filepath.Base(paths["door_creak.wav"]))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(paths["gun_shot.wav"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(paths["wind_howl.wav"], buildTestWAV(44100, 2, 16, make([]byte, 44100*4)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// trailing junk leaves the fingerprint alone, but not the size
+	f, err := os.OpenFile(paths["rain_loop.wav"], os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := os.WriteFile(filepath.Join(out, "stray.wav"), buildTestWAV(48000, 1, 16, make([]byte, 960)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := verify()
+	want := map[string]int{verifyMissing: 1, verifyMoved: 1, verifyChanged: 2, verifyAdded: 1}
+	for kind, n := range want {
+		if counts[kind] != n {
+			t.Errorf("%s = %d, want %d (all: %v)", kind, counts[kind], n, counts)
+		}
+	}
+}
+
+func TestVerifyAfterWriteTags(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	for _, name := range []string{"door_creak.wav", "gun_shot.wav"} {
+		if err := os.WriteFile(filepath.Join(src, name), buildTestWAV(48000, 1, 16, make([]byte, 48000*2)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: out, PackName: "Test", Layout: layoutCategory, CreateManifest: true, WriteTags: true, Workers: 1}
+	ap := NewAudioProcessor(config)
+	ap.log.out = io.Discard
+	if err := ap.Process(context.Background()); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	v := NewAudioProcessor(config)
+	v.log.out = io.Discard
+	problems, err := v.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Verify() after -write-tags = %+v, want no discrepancies", problems)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkOutputWritable creates the output directory and a throwaway file in
+// it, so a read-only mount or missing permissions stop the run before the
+// first file is moved rather than halfway through
+func (ap *AudioProcessor) checkOutputWritable() error {
+	dir := ap.config.OutputDir
+	if err := ap.mkdirAll(dir); err != nil {
+		return fmt.Errorf("can't create the output directory, nothing was moved: %w", err)
+	}
+
+	probe, err := os.CreateTemp(dir, ".tidy-rename-write-check-*")
+	if err != nil {
+		return fmt.Errorf("can't write to the output directory %s, nothing was moved: %w", dir, err)
+	}
+	name := probe.Name()
+	probe.Close()
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("can't remove files from the output directory %s, nothing was moved: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckOutputWritable(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "organized", "sfx")
+	ap := NewAudioProcessor(Config{OutputDir: out})
+	if err := ap.checkOutputWritable(); err != nil {
+		t.Fatalf("checkOutputWritable() error = %v", err)
+	}
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatalf("output directory wasn't created: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("check left %d files behind in the output directory", len(entries))
+	}
+}
+
+func TestUnwritableOutputMovesNothing(t *testing.T) {
+	src := t.TempDir()
+	original := filepath.Join(src, "door_creak.wav")
+	if err := os.WriteFile(original, buildTestWAV(48000, 1, 16, make([]byte, 4800)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a file where a folder should be fails the same way for root, unlike permissions
+	blocker := filepath.Join(t.TempDir(), "not_a_folder")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ap := NewAudioProcessor(Config{SourceDir: src, SourceDirs: []string{src}, OutputDir: filepath.Join(blocker, "organized"), PackName: "TestPack", Layout: layoutCategory, CreateManifest: true, SkipSpaceCheck: true, Workers: 1})
+	ap.log.out = io.Discard
+	err := ap.Process(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "nothing was moved") {
+		t.Fatalf("Process() error = %v, want the output directory to be refused", err)
+	}
+	if _, err := os.Stat(original); err != nil {
+		t.Errorf("source file was touched: %v", err)
+	}
+}